@@ -5,17 +5,16 @@
 // Command ng-gengo generates a Go package file from a Neugram script.
 // ng-gengo is a debugging command for testing the ng/gengo package.
 //
-//  Usage: ng-gengo [options] file1.ng
+//	Usage: ng-gengo [options] file1.ng
 //
-//  ex:
-//   $> ng-gengo ./eval/testdata/defer1.ng
-//   $> ng-gengo ./eval/testdata/defer1.ng > defer1.go
-//   $> ng-gengo -pkg=main ./eval/testdata/defer1.ng
-//
-//  options:
-//    -pkg string
-//      	name of the output Go package (default "main")
+//	ex:
+//	 $> ng-gengo ./eval/testdata/defer1.ng
+//	 $> ng-gengo ./eval/testdata/defer1.ng > defer1.go
+//	 $> ng-gengo -pkg=main ./eval/testdata/defer1.ng
 //
+//	options:
+//	  -pkg string
+//	    	name of the output Go package (default "main")
 package main
 
 import (
@@ -0,0 +1,169 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ngfmt formats Neugram source files.
+//
+//	Usage: ngfmt [options] [path ...]
+//
+// Without a path, ngfmt formats its standard input. A directory path
+// formats the .ng files in it, recursively. By default the formatted
+// source is written to standard output.
+//
+//	ex:
+//	 $> ngfmt script.ng
+//	 $> ngfmt -w ./eval/testdata
+//	 $> ngfmt --diff script.ng
+//
+//	options:
+//	  -diff
+//	    	print a diff instead of the formatted source
+//	  -l	list the files whose formatting differs
+//	  -w	write the formatted source back to the file
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"neugram.io/ng/ngfmt"
+)
+
+var (
+	diff  = flag.Bool("diff", false, "print a diff instead of the formatted source")
+	list  = flag.Bool("l", false, "list the files whose formatting differs")
+	write = flag.Bool("w", false, "write the formatted source back to the file")
+)
+
+func main() {
+	log.SetPrefix("ngfmt: ")
+	log.SetFlags(0)
+
+	flag.Usage = func() {
+		fmt.Fprintf(
+			os.Stderr,
+			`Usage: ngfmt [options] [path ...]
+
+ex:
+ $> ngfmt script.ng
+ $> ngfmt -w ./eval/testdata
+ $> ngfmt --diff script.ng
+
+options:
+`,
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			log.Fatal("cannot use -w with standard input")
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := process("<standard input>", src); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	failed := false
+	for _, root := range flag.Args() {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || (path != root && filepath.Ext(path) != ".ng") {
+				return nil // a file named by an argument is always formatted
+			}
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := process(path, src); err != nil {
+				log.Print(err)
+				failed = true
+			}
+			return nil
+		})
+		if err != nil {
+			log.Print(err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(2)
+	}
+}
+
+// process formats the source of the file path as the flags ask.
+func process(path string, src []byte) error {
+	res, err := ngfmt.File(path, src)
+	if err != nil {
+		return err
+	}
+	changed := !bytes.Equal(src, res)
+	if *list && changed {
+		fmt.Println(path)
+	}
+	if *write && changed {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if *diff && changed {
+		d, err := diffSource(path, src, res)
+		if err != nil {
+			return fmt.Errorf("computing diff: %v", err)
+		}
+		os.Stdout.Write(d)
+	}
+	if !*list && !*write && !*diff {
+		os.Stdout.Write(res)
+	}
+	return nil
+}
+
+// diffSource returns a unified diff of the source of the file path
+// before and after formatting, computed by the diff command.
+func diffSource(path string, before, after []byte) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "ngfmt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	orig := filepath.Join(dir, "orig.ng")
+	if err := ioutil.WriteFile(orig, before, 0666); err != nil {
+		return nil, err
+	}
+	formatted := filepath.Join(dir, "formatted.ng")
+	if err := ioutil.WriteFile(formatted, after, 0666); err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command("diff", "-u", orig, formatted).CombinedOutput()
+	if len(out) == 0 {
+		return nil, err // diff exits 1 when the files differ
+	}
+	// Name the file in the header lines, like gofmt -d.
+	lines := strings.SplitN(string(out), "\n", 3)
+	if len(lines) == 3 && strings.HasPrefix(lines[0], "--- ") && strings.HasPrefix(lines[1], "+++ ") {
+		lines[0] = "--- " + path + ".orig"
+		lines[1] = "+++ " + path
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ngls is a language server for Neugram.
+//
+//	Usage: ngls
+//
+// It speaks the Language Server Protocol on its standard input and
+// output, for an editor to run. The server shows types on hover,
+// completes names, finds declarations, reports parse and type errors
+// and formats documents like ngfmt. Package ngls describes it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"neugram.io/ng/ngls"
+)
+
+func main() {
+	log.SetPrefix("ngls: ")
+	log.SetFlags(0)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ngls\n\nngls talks to an editor on its standard input and output.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s := ngls.NewServer(os.Stdin, os.Stdout)
+	if err := s.Serve(); err != nil {
+		log.Fatal(err)
+	}
+}
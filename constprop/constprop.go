@@ -0,0 +1,411 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package constprop folds the constant expressions of a type-checked
+// Neugram package.
+//
+// Each use of a constant, and each operator expression with constant
+// operands, is replaced by a literal of its value. The values are the
+// ones computed by the type checker, which the evaluator also uses,
+// so a folded program computes the same results. An if statement with
+// a constant condition is replaced by the branch taken. Declarations
+// no longer used once this is done, such as the constants themselves
+// or a function only called in a removed branch, are then removed by
+// package dce.
+//
+// Constant declarations are not folded, as the values of those
+// without an initializer repeat the previous one, iota and all.
+package constprop
+
+import (
+	"fmt"
+	"go/constant"
+	"math/big"
+
+	"neugram.io/ng/dce"
+	"neugram.io/ng/internal/bigcplx"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+	"neugram.io/ng/typecheck"
+)
+
+// Propagate returns a copy of the syntax of pkg with its constants
+// folded and its dead declarations removed, and a report of the
+// removed declarations. The checker c must be the one that checked
+// pkg. The syntax of pkg is not modified.
+func Propagate(pkg *typecheck.Package, c *typecheck.Checker, mode dce.Mode) (*syntax.File, *dce.Report) {
+	p := &propagator{c: c}
+	folded := *pkg
+	folded.Syntax = &syntax.File{
+		Filename: pkg.Syntax.Filename,
+		Stmts:    p.stmts(pkg.Syntax.Stmts),
+		Imports:  pkg.Syntax.Imports,
+	}
+	return dce.Eliminate(&folded, mode)
+}
+
+type propagator struct {
+	c *typecheck.Checker
+}
+
+func (p *propagator) stmts(list []stmt.Stmt) []stmt.Stmt {
+	var res []stmt.Stmt
+	for _, s := range list {
+		if s = p.stmt(s); s != nil {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// stmt returns a folded copy of s. It returns nil only for an if
+// statement with a false condition and no else branch.
+func (p *propagator) stmt(s stmt.Stmt) stmt.Stmt {
+	switch s := s.(type) {
+	case nil:
+		return nil
+	case *stmt.Import, *stmt.ImportSet, *stmt.TypeDecl, *stmt.TypeDeclSet,
+		*stmt.Const, *stmt.ConstSet, *stmt.Branch, *stmt.Bad:
+		return s
+	case *stmt.MethodikDecl:
+		res := *s
+		res.Methods = nil
+		for _, m := range s.Methods {
+			res.Methods = append(res.Methods, p.funcLiteral(m))
+		}
+		return &res
+	case *stmt.Var:
+		return p.varStmt(s)
+	case *stmt.VarSet:
+		res := &stmt.VarSet{Position: s.Position}
+		for _, v := range s.Vars {
+			res.Vars = append(res.Vars, p.varStmt(v))
+		}
+		return res
+	case *stmt.Assign:
+		return &stmt.Assign{
+			Position: s.Position,
+			Decl:     s.Decl,
+			Left:     p.exprs(s.Left),
+			Right:    p.exprs(s.Right),
+		}
+	case *stmt.Block:
+		return p.block(s)
+	case *stmt.If:
+		return p.ifStmt(s)
+	case *stmt.For:
+		return &stmt.For{
+			Position: s.Position,
+			Init:     p.stmt(s.Init),
+			Cond:     p.expr(s.Cond),
+			Post:     p.stmt(s.Post),
+			Body:     p.stmt(s.Body),
+		}
+	case *stmt.Switch:
+		res := &stmt.Switch{
+			Position: s.Position,
+			Init:     p.stmt(s.Init),
+			Cond:     p.expr(s.Cond),
+		}
+		for _, cse := range s.Cases {
+			res.Cases = append(res.Cases, stmt.SwitchCase{
+				Position: cse.Position,
+				Conds:    p.exprs(cse.Conds),
+				Default:  cse.Default,
+				Body:     p.block(cse.Body),
+			})
+		}
+		return res
+	case *stmt.TypeSwitch:
+		res := &stmt.TypeSwitch{
+			Position: s.Position,
+			Init:     p.stmt(s.Init),
+			Assign:   p.stmt(s.Assign),
+		}
+		for _, cse := range s.Cases {
+			cse.Body = p.block(cse.Body)
+			res.Cases = append(res.Cases, cse)
+		}
+		return res
+	case *stmt.Go:
+		return &stmt.Go{Position: s.Position, Call: p.expr(s.Call).(*expr.Call)}
+	case *stmt.Range:
+		return &stmt.Range{
+			Position: s.Position,
+			Decl:     s.Decl,
+			Key:      p.expr(s.Key),
+			Val:      p.expr(s.Val),
+			Expr:     p.expr(s.Expr),
+			Body:     p.stmt(s.Body),
+		}
+	case *stmt.Return:
+		return &stmt.Return{Position: s.Position, Exprs: p.exprs(s.Exprs)}
+	case *stmt.Defer:
+		return &stmt.Defer{Position: s.Position, Expr: p.expr(s.Expr)}
+	case *stmt.Simple:
+		return &stmt.Simple{Position: s.Position, Expr: p.expr(s.Expr)}
+	case *stmt.Send:
+		return &stmt.Send{Position: s.Position, Chan: p.expr(s.Chan), Value: p.expr(s.Value)}
+	case *stmt.Labeled:
+		body := p.stmt(s.Stmt)
+		if body == nil {
+			body = &stmt.Block{Position: s.Stmt.Pos()}
+		}
+		return &stmt.Labeled{Position: s.Position, Label: s.Label, Stmt: body}
+	case *stmt.Select:
+		res := &stmt.Select{Position: s.Position}
+		for _, cse := range s.Cases {
+			res.Cases = append(res.Cases, stmt.SelectCase{
+				Position: cse.Position,
+				Default:  cse.Default,
+				Stmt:     p.stmt(cse.Stmt),
+				Body:     p.block(cse.Body),
+			})
+		}
+		return res
+	default:
+		panic(fmt.Sprintf("constprop: unknown stmt %T", s))
+	}
+}
+
+func (p *propagator) varStmt(s *stmt.Var) *stmt.Var {
+	return &stmt.Var{
+		Position: s.Position,
+		NameList: s.NameList,
+		Type:     s.Type,
+		Values:   p.exprs(s.Values),
+	}
+}
+
+func (p *propagator) block(s *stmt.Block) *stmt.Block {
+	if s == nil {
+		return nil
+	}
+	return &stmt.Block{Position: s.Position, Stmts: p.stmts(s.Stmts)}
+}
+
+// ifStmt reduces an if statement with a constant condition to the
+// branch taken. The initialization statement, if any, is kept in a
+// block with the branch, preserving its scope.
+func (p *propagator) ifStmt(s *stmt.If) stmt.Stmt {
+	v := p.c.Value(s.Cond)
+	if v == nil || v.Kind() != constant.Bool {
+		return &stmt.If{
+			Position: s.Position,
+			Init:     p.stmt(s.Init),
+			Cond:     p.expr(s.Cond),
+			Body:     p.stmt(s.Body),
+			Else:     p.stmt(s.Else),
+		}
+	}
+	taken := s.Else
+	if constant.BoolVal(v) {
+		taken = s.Body
+	}
+	taken = p.stmt(taken)
+	if s.Init == nil {
+		return taken
+	}
+	res := &stmt.Block{Position: s.Position, Stmts: []stmt.Stmt{p.stmt(s.Init)}}
+	if taken != nil {
+		res.Stmts = append(res.Stmts, taken)
+	}
+	return res
+}
+
+func (p *propagator) exprs(list []expr.Expr) []expr.Expr {
+	if list == nil {
+		return nil
+	}
+	res := make([]expr.Expr, len(list))
+	for i, e := range list {
+		res[i] = p.expr(e)
+	}
+	return res
+}
+
+// expr returns a folded copy of e.
+func (p *propagator) expr(e expr.Expr) expr.Expr {
+	if lit := p.fold(e); lit != nil {
+		return lit
+	}
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case *expr.Bad, *expr.BasicLiteral, *expr.Ident, *expr.Type, *expr.Shell:
+		return e
+	case *expr.Binary:
+		return &expr.Binary{
+			Position: e.Position,
+			Op:       e.Op,
+			Left:     p.expr(e.Left),
+			Right:    p.expr(e.Right),
+		}
+	case *expr.Unary:
+		return &expr.Unary{Position: e.Position, Op: e.Op, Expr: p.expr(e.Expr)}
+	case *expr.Selector:
+		return &expr.Selector{Position: e.Position, Left: p.expr(e.Left), Right: e.Right}
+	case *expr.Slice:
+		return &expr.Slice{
+			Position: e.Position,
+			Low:      p.expr(e.Low),
+			High:     p.expr(e.High),
+			Max:      p.expr(e.Max),
+		}
+	case *expr.Index:
+		return &expr.Index{Position: e.Position, Left: p.expr(e.Left), Indicies: p.exprs(e.Indicies)}
+	case *expr.TypeAssert:
+		return &expr.TypeAssert{Position: e.Position, Left: p.expr(e.Left), Type: e.Type}
+	case *expr.FuncLiteral:
+		return p.funcLiteral(e)
+	case *expr.CompLiteral:
+		// The keys of a struct literal are field names.
+		res := *e
+		res.Values = p.exprs(e.Values)
+		return &res
+	case *expr.MapLiteral:
+		res := *e
+		res.Keys = p.exprs(e.Keys)
+		res.Values = p.exprs(e.Values)
+		return &res
+	case *expr.ArrayLiteral:
+		res := *e
+		res.Keys = p.exprs(e.Keys)
+		res.Values = p.exprs(e.Values)
+		return &res
+	case *expr.SliceLiteral:
+		res := *e
+		res.Keys = p.exprs(e.Keys)
+		res.Values = p.exprs(e.Values)
+		return &res
+	case *expr.TableLiteral:
+		res := *e
+		res.ColNames = p.exprs(e.ColNames)
+		res.Rows = nil
+		for _, row := range e.Rows {
+			res.Rows = append(res.Rows, p.exprs(row))
+		}
+		return &res
+	case *expr.Call:
+		res := *e
+		res.Func = p.expr(e.Func)
+		res.Args = p.exprs(e.Args)
+		return &res
+	default:
+		panic(fmt.Sprintf("constprop: unknown expr %T", e))
+	}
+}
+
+func (p *propagator) funcLiteral(e *expr.FuncLiteral) *expr.FuncLiteral {
+	res := *e
+	if body, ok := e.Body.(*stmt.Block); ok {
+		res.Body = p.block(body)
+	}
+	return &res
+}
+
+// fold returns the literal value of e if it is a use of a constant or
+// an operator expression with constant operands, or nil. The literal
+// is converted to the type of e if the type of the literal differs.
+func (p *propagator) fold(e expr.Expr) expr.Expr {
+	pos := e.Pos()
+	switch e := e.(type) {
+	case *expr.Binary:
+	case *expr.Unary:
+	case *expr.Ident:
+		// Leave true and false alone.
+		if obj := p.c.Ident(e); obj != nil && obj == typecheck.Universe.Objs[e.Name] {
+			return nil
+		}
+	default:
+		return nil
+	}
+	v := p.c.Value(e)
+	if v == nil || v.Kind() == constant.Unknown {
+		return nil
+	}
+	t := p.c.Type(e)
+	lit, litType := literal(pos, v, t)
+	if lit == nil {
+		return nil
+	}
+	if t == nil || t == litType || isUntyped(t) {
+		return lit
+	}
+	return &expr.Call{
+		Position: pos,
+		Func:     &expr.Type{Position: pos, Type: t},
+		Args:     []expr.Expr{lit},
+	}
+}
+
+func isUntyped(t tipe.Type) bool {
+	switch t {
+	case tipe.UntypedBool, tipe.UntypedString, tipe.UntypedInteger,
+		tipe.UntypedFloat, tipe.UntypedRune, tipe.UntypedComplex:
+		return true
+	}
+	return false
+}
+
+// literal returns an expression for the constant v of type t, and the
+// type of that expression when it is not converted. Values are
+// converted to big numbers the same way the evaluator converts them.
+// A negative number is a literal of its absolute value, negated.
+func literal(pos src.Pos, v constant.Value, t tipe.Type) (e expr.Expr, litType tipe.Type) {
+	lit := &expr.BasicLiteral{Position: pos}
+	neg := false
+	switch v.Kind() {
+	case constant.Bool:
+		lit.Value, litType = constant.BoolVal(v), tipe.Bool
+	case constant.String:
+		lit.Value, litType = constant.StringVal(v), tipe.String
+	case constant.Int:
+		i, _ := new(big.Int).SetString(v.ExactString(), 10)
+		if neg = i.Sign() < 0; neg {
+			i.Neg(i)
+		}
+		lit.Value, litType = i, tipe.Int
+		if t == tipe.UntypedRune && i.IsInt64() {
+			lit.Value, litType = rune(i.Int64()), tipe.Rune
+		}
+	case constant.Float:
+		f := constFloat(v)
+		if neg = f.Sign() < 0; neg {
+			f.Neg(f)
+		}
+		lit.Value, litType = f, tipe.Float64
+	case constant.Complex:
+		lit.Value = &bigcplx.Complex{
+			Real: constFloat(constant.Real(v)),
+			Imag: constFloat(constant.Imag(v)),
+		}
+		litType = tipe.Complex128
+	default:
+		return nil, nil
+	}
+	if neg {
+		return &expr.Unary{Position: pos, Op: token.Sub, Expr: lit}, litType
+	}
+	return lit, litType
+}
+
+// constFloat matches the conversion made by the evaluator.
+func constFloat(v constant.Value) *big.Float {
+	switch x := constant.Val(constant.ToFloat(v)).(type) {
+	case *big.Float:
+		return new(big.Float).Copy(x)
+	case *big.Rat:
+		return new(big.Float).SetRat(x)
+	case int64:
+		return new(big.Float).SetInt64(x)
+	case *big.Int:
+		return new(big.Float).SetInt(x)
+	}
+	panic(fmt.Sprintf("constprop: bad float constant %s", v))
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package constprop_test
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/constprop"
+	"neugram.io/ng/dce"
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/typecheck"
+)
+
+var propagateTests = []struct {
+	name string
+	src  string
+	want string // remaining statements, formatted
+	dead string // eliminated declarations
+}{
+	{
+		name: "square",
+		src: `const x = 2 + 3
+func f() int { return x * x }
+print(f())
+`,
+		want: "func f() int {return 25}\nprint(f())",
+		dead: "const x",
+	},
+	{
+		name: "if",
+		src: `func used() int { return 1 }
+func unused() int { return 2 }
+const debug = false
+if debug {
+	print(unused())
+} else {
+	print(used())
+}
+if !debug {
+	print(1)
+}
+if debug {
+	print(2)
+}
+`,
+		want: "func used() int {return 1}\n{print(used())}\n{print(1)}",
+		dead: "func unused, const debug",
+	},
+	{
+		name: "else if",
+		src: `x := 1
+if x > 0 {
+	print(x)
+} else if 1 < 2 {
+	print(3)
+} else {
+	print(4)
+}
+`,
+		want: "x := 1\nif x > 0 {print(x)} else {print(3)}",
+	},
+	{
+		name: "if init",
+		src: `if y := 1; 2 > 1 {
+	print(y)
+}
+`,
+		want: "{y := 1{print(y)}}",
+	},
+	{
+		name: "kinds",
+		src: `const k int8 = 3
+a := k + 1
+b := 1 - 3
+c := "a" + "b"
+d := 1.5 * 2
+print(a, b, c, d)
+`,
+		want: "a := int8(4)\nb := -2\nc := \"ab\"\nd := 3.0\nprint(a, b, c, d)",
+		dead: "const k",
+	},
+}
+
+func TestPropagate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "constprop-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range propagateTests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Replace(test.name, " ", "_", -1)+".ng")
+			if err := ioutil.WriteFile(path, []byte(test.src), 0666); err != nil {
+				t.Fatal(err)
+			}
+			c := typecheck.New(filepath.Base(path))
+			pkg, err := c.Check(path)
+			if err != nil {
+				t.Fatalf("typecheck: %v", err)
+			}
+
+			f, report := constprop.Propagate(pkg, c, dce.Program)
+
+			var got []string
+			for _, s := range f.Stmts {
+				got = append(got, format.Stmt(s))
+			}
+			if s := strings.Join(got, "\n"); s != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", s, test.want)
+			}
+			var dead []string
+			for _, d := range report.Eliminated {
+				dead = append(dead, d.Kind.String()+" "+d.Name)
+			}
+			if s := strings.Join(dead, ", "); s != test.dead {
+				t.Errorf("eliminated %q, want %q", s, test.dead)
+			}
+		})
+	}
+}
+
+// TestSquare checks the folded tree of const x = 2 + 3; return x * x.
+func TestSquare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "constprop-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "square.ng")
+	if err := ioutil.WriteFile(path, []byte(propagateTests[0].src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c := typecheck.New(filepath.Base(path))
+	pkg, err := c.Check(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := format.Stmt(pkg.Syntax.Stmts[1])
+
+	f, _ := constprop.Propagate(pkg, c, dce.Program)
+
+	fn := f.Stmts[0].(*stmt.Simple).Expr.(*expr.FuncLiteral)
+	ret := fn.Body.(*stmt.Block).Stmts[0].(*stmt.Return)
+	lit, ok := ret.Exprs[0].(*expr.BasicLiteral)
+	if !ok {
+		t.Fatalf("return %s, want a literal", format.Expr(ret.Exprs[0]))
+	}
+	if v, ok := lit.Value.(*big.Int); !ok || v.Int64() != 25 {
+		t.Errorf("return %#v, want 25", lit.Value)
+	}
+	if s := format.Stmt(pkg.Syntax.Stmts[1]); s != orig {
+		t.Errorf("Propagate modified the package syntax: %s, was %s", s, orig)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dce removes unused top-level declarations from a
+// type-checked Neugram package.
+//
+// A Neugram program has no main function: its top-level statements
+// are run in order. These statements are the roots of the program.
+// A function, variable or constant is live if a root, or another
+// live declaration, refers to it by name. All other functions,
+// variables and constants are dead and removed, including those only
+// used by dead code.
+//
+// The analysis is conservative. Types and methodiks are never
+// removed, and the bodies of their methods are roots, as methods can
+// be reached through interfaces. Any use of a name counts as a
+// reference to the top-level declaration of that name, whether or
+// not a local variable shadows it. A variable with an initializer
+// that may have side effects, such as a call, is kept. Constant
+// groups are removed or kept as a whole, as removing a constant
+// changes the value of iota in those that follow it.
+package dce
+
+import (
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/token"
+	"neugram.io/ng/typecheck"
+)
+
+// Mode says what, besides the top-level statements, is a root.
+type Mode int
+
+const (
+	Program Mode = iota // only the top-level statements
+	Library             // exported declarations are also roots
+)
+
+// A Decl is a declaration removed by Eliminate.
+type Decl struct {
+	Name string
+	Kind token.Token // Func, Var, or Const
+	Pos  src.Pos
+}
+
+// A Report lists the declarations removed by Eliminate, in source
+// order.
+type Report struct {
+	Eliminated []Decl
+}
+
+// Eliminate returns a copy of the syntax of pkg with its dead
+// declarations removed. The syntax of pkg is not modified.
+func Eliminate(pkg *typecheck.Package, mode Mode) (*syntax.File, *Report) {
+	e := &eliminator{
+		pkg:   pkg,
+		decls: make(map[string][]*item),
+		live:  make(map[string]bool),
+	}
+	for _, s := range pkg.Syntax.Stmts {
+		e.top = append(e.top, e.classify(s))
+	}
+	if mode == Library {
+		for _, obj := range pkg.Globals {
+			if isExported(obj.Name) {
+				e.markLive(obj.Name)
+			}
+		}
+	}
+	for len(e.work) > 0 {
+		name := e.work[len(e.work)-1]
+		e.work = e.work[:len(e.work)-1]
+		for _, it := range e.decls[name] {
+			e.markRefs(it.deps)
+		}
+	}
+
+	f := &syntax.File{
+		Filename: pkg.Syntax.Filename,
+		Imports:  pkg.Syntax.Imports,
+	}
+	report := new(Report)
+	for _, t := range e.top {
+		if s := e.rebuild(t, report); s != nil {
+			f.Stmts = append(f.Stmts, s)
+		}
+	}
+	return f, report
+}
+
+type eliminator struct {
+	pkg   *typecheck.Package
+	top   []*topStmt
+	decls map[string][]*item // name -> items declaring it
+	live  map[string]bool
+	work  []string // live names whose items are not yet scanned
+}
+
+// A topStmt is a top-level statement and the declarations in it.
+// A statement with no items is a root.
+type topStmt struct {
+	stmt  stmt.Stmt
+	items []*item
+}
+
+// An item is the smallest part of a declaration that can be removed
+// on its own, such as one name and value of a var statement.
+type item struct {
+	names []string
+	kind  token.Token
+	pos   src.Pos
+	deps  []string // global names the item refers to
+
+	// For variables, the index of the Var in a VarSet and the
+	// index of the name in the Var, or -1 for all of its names.
+	index   int
+	sub     int
+	effects bool // the value may have side effects
+}
+
+func (e *eliminator) classify(s stmt.Stmt) *topStmt {
+	t := &topStmt{stmt: s}
+	switch s := s.(type) {
+	case *stmt.Simple:
+		if fn, isFunc := s.Expr.(*expr.FuncLiteral); isFunc && fn.Name != "" && e.isGlobal(fn.Name) {
+			t.items = append(t.items, &item{
+				names: []string{fn.Name},
+				kind:  token.Func,
+				pos:   fn.Position,
+				deps:  e.refs(fn),
+			})
+		}
+	case *stmt.Const:
+		it := &item{names: s.NameList, kind: token.Const, pos: s.Position}
+		for _, v := range s.Values {
+			it.deps = append(it.deps, e.refs(v)...)
+		}
+		t.items = append(t.items, it)
+	case *stmt.ConstSet:
+		it := &item{kind: token.Const, pos: s.Position}
+		for _, c := range s.Consts {
+			it.names = append(it.names, c.NameList...)
+			for _, v := range c.Values {
+				it.deps = append(it.deps, e.refs(v)...)
+			}
+		}
+		t.items = append(t.items, it)
+	case *stmt.Var:
+		t.items = e.varItems(s.Position, s.NameList, s.Values, 0)
+	case *stmt.VarSet:
+		for i, v := range s.Vars {
+			t.items = append(t.items, e.varItems(v.Position, v.NameList, v.Values, i)...)
+		}
+	case *stmt.Assign:
+		if names, ok := identNames(s.Left); s.Decl && ok {
+			t.items = e.varItems(s.Position, names, s.Right, 0)
+		}
+	}
+
+	if t.items == nil {
+		// A root.
+		e.markRefs(e.refs(s))
+		return t
+	}
+	for _, it := range t.items {
+		for _, name := range it.names {
+			e.decls[name] = append(e.decls[name], it)
+		}
+	}
+	return t
+}
+
+func identNames(list []expr.Expr) (names []string, ok bool) {
+	for _, x := range list {
+		id, isIdent := x.(*expr.Ident)
+		if !isIdent {
+			return nil, false
+		}
+		names = append(names, id.Name)
+	}
+	return names, true
+}
+
+// varItems splits a variable declaration into items. If there is a
+// value for each name, each name and value is an item. Otherwise the
+// declaration is a single item.
+func (e *eliminator) varItems(pos src.Pos, names []string, values []expr.Expr, index int) []*item {
+	var items []*item
+	if len(values) == 0 || len(values) == len(names) {
+		for i, name := range names {
+			it := &item{names: []string{name}, kind: token.Var, pos: pos, index: index, sub: i}
+			if len(values) > 0 {
+				it.deps = e.refs(values[i])
+				it.effects = !e.pure(values[i])
+			}
+			items = append(items, it)
+		}
+	} else {
+		it := &item{names: names, kind: token.Var, pos: pos, index: index, sub: -1}
+		for _, v := range values {
+			it.deps = append(it.deps, e.refs(v)...)
+			it.effects = it.effects || !e.pure(v)
+		}
+		items = append(items, it)
+	}
+	for _, it := range items {
+		if it.effects {
+			e.markRefs(it.deps)
+		}
+	}
+	return items
+}
+
+// isLive reports whether it is kept. Besides live names, this
+// includes an item whose value has side effects, and the blank
+// identifier, which is used for checks like var _ I = T{}.
+func (e *eliminator) isLive(it *item) bool {
+	if it.effects {
+		return true
+	}
+	for _, name := range it.names {
+		if name == "_" || e.live[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *eliminator) isGlobal(name string) bool {
+	return e.pkg.GlobalNames[name] != nil
+}
+
+func (e *eliminator) markLive(name string) {
+	if !e.live[name] {
+		e.live[name] = true
+		e.work = append(e.work, name)
+	}
+}
+
+func (e *eliminator) markRefs(names []string) {
+	for _, name := range names {
+		e.markLive(name)
+	}
+}
+
+// refs returns the global names used in node. The selected name in
+// x.y is not a use.
+func (e *eliminator) refs(node syntax.Node) []string {
+	var names []string
+	syntax.Walk(node, func(c *syntax.Cursor) bool {
+		if _, isSel := c.Parent.(*expr.Selector); isSel && c.Name == "Right" {
+			return false
+		}
+		switch n := c.Node.(type) {
+		case *expr.Ident:
+			if e.isGlobal(n.Name) {
+				names = append(names, n.Name)
+			}
+		case *expr.Shell:
+			for _, name := range n.FreeVars {
+				if e.isGlobal(name) {
+					names = append(names, name)
+				}
+			}
+		}
+		return true
+	}, nil)
+	return names
+}
+
+// pure reports whether evaluating x has no side effects and cannot
+// panic.
+func (e *eliminator) pure(x expr.Expr) bool {
+	switch x := x.(type) {
+	case nil, *expr.BasicLiteral, *expr.Ident, *expr.FuncLiteral, *expr.Type:
+		return true
+	case *expr.Unary:
+		// A receive blocks and a nil pointer dereference panics.
+		return x.Op != token.ChanOp && x.Op != token.Mul && e.pure(x.Expr)
+	case *expr.Binary:
+		switch x.Op {
+		case token.Div, token.Rem, token.TwoLess, token.TwoGreater:
+			return false // division by zero, negative shift count
+		}
+		return e.pure(x.Left) && e.pure(x.Right)
+	case *expr.Selector:
+		// A qualified identifier, pkg.Name.
+		id, isIdent := x.Left.(*expr.Ident)
+		if !isIdent {
+			return false
+		}
+		obj := e.pkg.GlobalNames[id.Name]
+		return obj != nil && obj.Kind == typecheck.ObjPkg
+	case *expr.CompLiteral:
+		return e.allPure(x.Keys) && e.allPure(x.Values)
+	case *expr.MapLiteral:
+		return e.allPure(x.Keys) && e.allPure(x.Values)
+	case *expr.ArrayLiteral:
+		return e.allPure(x.Keys) && e.allPure(x.Values)
+	case *expr.SliceLiteral:
+		return e.allPure(x.Keys) && e.allPure(x.Values)
+	case *expr.TableLiteral:
+		if !e.allPure(x.ColNames) {
+			return false
+		}
+		for _, row := range x.Rows {
+			if !e.allPure(row) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (e *eliminator) allPure(list []expr.Expr) bool {
+	for _, x := range list {
+		if !e.pure(x) {
+			return false
+		}
+	}
+	return true
+}
+
+// rebuild returns the statement t with its dead items removed, or
+// nil if it is entirely dead. Removed items are added to report.
+func (e *eliminator) rebuild(t *topStmt, report *Report) stmt.Stmt {
+	if t.items == nil {
+		return t.stmt
+	}
+	var dead []*item
+	for _, it := range t.items {
+		if !e.isLive(it) {
+			dead = append(dead, it)
+		}
+	}
+	if len(dead) == 0 {
+		return t.stmt
+	}
+	for _, it := range dead {
+		for _, name := range it.names {
+			report.Eliminated = append(report.Eliminated, Decl{Name: name, Kind: it.kind, Pos: it.pos})
+		}
+	}
+	if len(dead) == len(t.items) {
+		return nil
+	}
+
+	switch s := t.stmt.(type) {
+	case *stmt.Var:
+		subs := e.liveSubs(t.items, 0)
+		return &stmt.Var{
+			Position: s.Position,
+			NameList: pickNames(s.NameList, subs),
+			Type:     s.Type,
+			Values:   pickExprs(s.Values, subs),
+		}
+	case *stmt.VarSet:
+		res := &stmt.VarSet{Position: s.Position}
+		for i, v := range s.Vars {
+			subs := e.liveSubs(t.items, i)
+			if subs == nil {
+				continue
+			}
+			if subs[0] >= 0 {
+				v = &stmt.Var{
+					Position: v.Position,
+					NameList: pickNames(v.NameList, subs),
+					Type:     v.Type,
+					Values:   pickExprs(v.Values, subs),
+				}
+			}
+			res.Vars = append(res.Vars, v)
+		}
+		return res
+	case *stmt.Assign:
+		subs := e.liveSubs(t.items, 0)
+		return &stmt.Assign{
+			Position: s.Position,
+			Decl:     true,
+			Left:     pickExprs(s.Left, subs),
+			Right:    pickExprs(s.Right, subs),
+		}
+	}
+	// Functions and constants are single items, handled above.
+	return t.stmt
+}
+
+// liveSubs returns the sub index of each live item of the variable
+// declaration at index.
+func (e *eliminator) liveSubs(items []*item, index int) []int {
+	var subs []int
+	for _, it := range items {
+		if it.index == index && e.isLive(it) {
+			subs = append(subs, it.sub)
+		}
+	}
+	return subs
+}
+
+func pickNames(names []string, subs []int) []string {
+	var res []string
+	for _, i := range subs {
+		res = append(res, names[i])
+	}
+	return res
+}
+
+// pickExprs returns the chosen elements of list. An empty list, of
+// variables declared without values, stays empty.
+func pickExprs(list []expr.Expr, subs []int) []expr.Expr {
+	if len(list) == 0 {
+		return nil
+	}
+	var res []expr.Expr
+	for _, i := range subs {
+		res = append(res, list[i])
+	}
+	return res
+}
+
+func isExported(name string) bool {
+	return name != "" && 'A' <= name[0] && name[0] <= 'Z'
+}
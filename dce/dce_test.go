@@ -0,0 +1,188 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dce_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/dce"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/typecheck"
+)
+
+var eliminateTests = []struct {
+	name  string
+	mode  dce.Mode
+	src   string
+	want  string   // eliminated declarations
+	kept  []string // names still declared
+	stmts int      // number of remaining statements, if not 0
+}{
+	{
+		name: "unused",
+		src: `func used() int { return 1 }
+func unused() int { return 2 }
+print(used())
+`,
+		want: "func unused",
+		kept: []string{"used"},
+	},
+	{
+		name: "transitive",
+		src: `func leaf() int { return 1 }
+func mid() int { return leaf() }
+func deadLeaf() int { return 2 }
+func deadMid() int { return deadLeaf() }
+const c = 3
+const d = c
+var v = d
+print(mid())
+`,
+		want: "func deadLeaf, func deadMid, const c, const d, var v",
+		kept: []string{"leaf", "mid"},
+	},
+	{
+		name: "vars",
+		src: `var a, b = 1, 2
+var (
+	x int
+	y = a
+)
+z, w := 3, 4
+func f() int { return 0 }
+var s = f()
+print(y, z)
+`,
+		want: "var b, var x, var w",
+		kept: []string{"a", "y", "z", "f", "s"},
+	},
+	{
+		name: "side effects",
+		src: `func g() int { print("g"); return 1 }
+var unusedCall = g()
+var unusedDiv = 1 / 0.5
+var unusedLit = []int{1, 2}
+`,
+		want: "var unusedLit",
+		kept: []string{"g", "unusedCall", "unusedDiv"},
+	},
+	{
+		name: "const groups",
+		src: `const (
+	A = iota
+	B
+)
+const (
+	C = iota
+	D
+)
+print(D)
+`,
+		want:  "const A, const B",
+		kept:  []string{"C", "D"},
+		stmts: 2,
+	},
+	{
+		name: "library",
+		mode: dce.Library,
+		src: `func helper() int { return 1 }
+func Exported() int { return helper() }
+func unexported() int { return 2 }
+`,
+		want: "func unexported",
+		kept: []string{"helper", "Exported"},
+	},
+	{
+		name: "methodik",
+		src: `func fromMethod() int { return 1 }
+methodik T integer {
+	func (t) M() int { return fromMethod() }
+}
+`,
+		want: "",
+		kept: []string{"fromMethod"},
+	},
+}
+
+func TestEliminate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dce-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, test := range eliminateTests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Replace(test.name, " ", "_", -1)+".ng")
+			if err := ioutil.WriteFile(path, []byte(test.src), 0666); err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := typecheck.New(filepath.Base(path)).Check(path)
+			if err != nil {
+				t.Fatalf("%d: typecheck: %v", i, err)
+			}
+			origLen := len(pkg.Syntax.Stmts)
+
+			f, report := dce.Eliminate(pkg, test.mode)
+
+			var got []string
+			for _, d := range report.Eliminated {
+				got = append(got, d.Kind.String()+" "+d.Name)
+			}
+			if s := strings.Join(got, ", "); s != test.want {
+				t.Errorf("eliminated %q, want %q", s, test.want)
+			}
+
+			names := declared(f)
+			if got := strings.Join(names, " "); got != strings.Join(test.kept, " ") {
+				t.Errorf("declared %q, want %q", got, test.kept)
+			}
+			if test.stmts != 0 && len(f.Stmts) != test.stmts {
+				t.Errorf("%d statements remain, want %d", len(f.Stmts), test.stmts)
+			}
+			if len(pkg.Syntax.Stmts) != origLen {
+				t.Errorf("Eliminate modified the package syntax")
+			}
+		})
+	}
+}
+
+// declared returns the top-level functions, variables and constants
+// declared in f, in source order.
+func declared(f *syntax.File) (names []string) {
+	for _, s := range f.Stmts {
+		switch s := s.(type) {
+		case *stmt.Simple:
+			if fn, isFunc := s.Expr.(*expr.FuncLiteral); isFunc && fn.Name != "" {
+				names = append(names, fn.Name)
+			}
+		case *stmt.Const:
+			names = append(names, s.NameList...)
+		case *stmt.ConstSet:
+			for _, c := range s.Consts {
+				names = append(names, c.NameList...)
+			}
+		case *stmt.Var:
+			names = append(names, s.NameList...)
+		case *stmt.VarSet:
+			for _, v := range s.Vars {
+				names = append(names, v.NameList...)
+			}
+		case *stmt.Assign:
+			if s.Decl {
+				for _, x := range s.Left {
+					names = append(names, x.(*expr.Ident).Name)
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,229 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package embed runs Neugram programs inside a Go program.
+//
+// It is meant for using Neugram as a scripting or formula language,
+// for example to evaluate user-defined computations:
+//
+//	vm := embed.New()
+//	vm.Set("price", 12.5)
+//	vm.Set("qty", 4)
+//	total, err := vm.Eval("price * float64(qty)")
+//
+// Values are passed between Go and Neugram by Set and Get.
+package embed // import "neugram.io/ng/embed"
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/token"
+	"neugram.io/ng/typecheck"
+)
+
+// A VM is a Neugram interpreter. The variables, functions and types
+// defined by one call to Eval are visible to the next.
+//
+// A VM is safe for concurrent use by multiple goroutines. Calls are
+// run one at a time, each seeing the results of the ones before it.
+type VM struct {
+	mu sync.Mutex
+	p  *eval.Program
+}
+
+// New returns a VM with no variables defined.
+func New() *VM {
+	return &VM{p: eval.New("embed.ng", nil)}
+}
+
+// Eval evaluates the statements in src and returns the value of the
+// last one, converted to a Go value as by Get. A statement with no
+// value, such as an assignment, gives nil. A statement with several
+// values gives a []interface{}.
+//
+// Shell commands are not supported.
+func (vm *VM) Eval(src string) (interface{}, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	p := parser.New("embed.ng")
+	defer p.Close()
+
+	var vals []reflect.Value
+	var state parser.ParserState
+	for i, text := range strings.Split(src, "\n") {
+		line := i + 1
+		res := p.ParseLine([]byte(strings.TrimSuffix(text, "\r")))
+		state = res.State
+		if len(res.Errs) > 0 {
+			return nil, fmt.Errorf("embed: %d: %v", line, res.Errs[0])
+		}
+		if len(res.Cmds) > 0 {
+			return nil, fmt.Errorf("embed: %d: shell commands are not supported", line)
+		}
+		for _, s := range res.Stmts {
+			v, err := vm.p.Eval(s, nil)
+			if err != nil {
+				return nil, fmt.Errorf("embed: %d: %v", line, err)
+			}
+			vals = v
+		}
+	}
+	if state == parser.StateStmtPartial {
+		return nil, errors.New("embed: source ends in a partial statement")
+	}
+
+	switch len(vals) {
+	case 0:
+		return nil, nil
+	case 1:
+		return goValue(vals[0]), nil
+	}
+	res := make([]interface{}, len(vals))
+	for i, v := range vals {
+		res[i] = goValue(v)
+	}
+	return res, nil
+}
+
+// Set defines the variable name with the value of v. If name is
+// already a variable, its type must match v and it is assigned v.
+// The name cannot be predeclared, like int or true.
+//
+// The value must be a boolean, number or string, or a slice or map
+// of them. A value of a named Go type, such as time.Duration, is
+// converted to its underlying type; the elements of a slice or map
+// cannot be named types. Slices and maps are shared, not copied.
+func (vm *VM) Set(name string, v interface{}) error {
+	if !isIdent(name) || token.Keyword(name) != token.Unknown {
+		return fmt.Errorf("embed: invalid variable name %q", name)
+	}
+	if typecheck.Universe.Objs[name] != nil {
+		return fmt.Errorf("embed: cannot set predeclared %s", name)
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return fmt.Errorf("embed: cannot set %s to nil", name)
+	}
+	typ, ok := typeName(rv.Type())
+	if !ok {
+		return fmt.Errorf("embed: cannot set %s to a value of type %T", name, v)
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	if dst := vm.p.Cur.Lookup(name); dst.IsValid() {
+		if have, _ := typeName(dst.Type()); have != typ || !dst.CanSet() {
+			return fmt.Errorf("embed: cannot set %s of type %s to a value of type %T", name, dst.Type(), v)
+		}
+		dst.Set(rv.Convert(dst.Type()))
+		return nil
+	}
+	s, err := parser.ParseStmt([]byte("var " + name + " " + typ))
+	if err != nil {
+		return fmt.Errorf("embed: %v", err)
+	}
+	if _, err := vm.p.Eval(s, nil); err != nil {
+		return fmt.Errorf("embed: %v", err)
+	}
+	dst := vm.p.Cur.Lookup(name)
+	dst.Set(rv.Convert(dst.Type()))
+	return nil
+}
+
+// Get returns the value of the variable name.
+//
+// Untyped constants are converted to the default Go type of their
+// kind, so an integer constant is an int, or a *big.Int if it does
+// not fit in one. Other values are returned as they are.
+func (vm *VM) Get(name string) (interface{}, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	v := vm.p.Cur.Lookup(name)
+	if v == (reflect.Value{}) {
+		return nil, fmt.Errorf("embed: undefined: %s", name)
+	}
+	return goValue(v), nil
+}
+
+func goValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch x := v.Interface().(type) {
+	case eval.UntypedInt:
+		if i := x.Int64(); x.IsInt64() && int64(int(i)) == i {
+			return int(i)
+		}
+		return new(big.Int).Set(x.Int)
+	case eval.UntypedFloat:
+		f, _ := x.Float64()
+		return f
+	case eval.UntypedComplex:
+		re, _ := x.Real.Float64()
+		im, _ := x.Imag.Float64()
+		return complex(re, im)
+	case eval.UntypedString:
+		return x.String
+	case eval.UntypedRune:
+		return x.Rune
+	case eval.UntypedBool:
+		return x.Bool
+	default:
+		return x
+	}
+}
+
+// typeName returns the Neugram name of a Go type that Set accepts.
+func typeName(t reflect.Type) (string, bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return t.Kind().String(), true
+	case reflect.Slice:
+		elem, ok := elemName(t.Elem())
+		return "[]" + elem, ok
+	case reflect.Map:
+		key, ok := elemName(t.Key())
+		if !ok {
+			return "", false
+		}
+		elem, ok := elemName(t.Elem())
+		return "map[" + key + "]" + elem, ok
+	}
+	return "", false
+}
+
+// elemName is typeName for the elements of a slice or map, which
+// cannot be converted and so must not be named types.
+func elemName(t reflect.Type) (string, bool) {
+	if t.PkgPath() != "" {
+		return "", false
+	}
+	return typeName(t)
+}
+
+func isIdent(name string) bool {
+	if name == "" || name == "_" {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embed_test
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"neugram.io/ng/embed"
+)
+
+func TestEval(t *testing.T) {
+	vm := embed.New()
+	if err := vm.Set("price", 12.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.Set("qty", 4); err != nil {
+		t.Fatal(err)
+	}
+	got, err := vm.Eval("price * float64(qty)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 50.0 {
+		t.Errorf("Eval = %#v, want 50.0", got)
+	}
+
+	// Definitions persist between calls.
+	if _, err := vm.Eval("func double(x int) int { return 2 * x }\ny := double(qty)"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := vm.Get("y"); err != nil || got != 8 {
+		t.Errorf("Get(y) = %#v, %v, want 8", got, err)
+	}
+
+	// Setting a variable again assigns it a value of the same type.
+	if err := vm.Set("qty", 5); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := vm.Eval("double(qty)"); err != nil || got != 10 {
+		t.Errorf("Eval(double(qty)) = %#v, %v, want 10", got, err)
+	}
+	if err := vm.Set("qty", "five"); err == nil {
+		t.Errorf("Set(qty, \"five\") succeeded, want error")
+	}
+	if err := vm.Set("y", 1.5); err == nil {
+		t.Errorf("Set(y, 1.5) succeeded, want error")
+	}
+}
+
+var evalTests = []struct {
+	src  string
+	want interface{}
+}{
+	{`1 + 2`, 3},
+	{`1 << 70`, new(big.Int).Lsh(big.NewInt(1), 70)},
+	{`1.5 + 1`, 2.5},
+	{`"a" + "b"`, "ab"},
+	{`'x'`, 'x'},
+	{`2 > 1`, true},
+	{`x := 1`, nil},
+	{`int8(3)`, int8(3)},
+	{`[]string{"a"}`, []string{"a"}},
+	{"func f() (int, string) { return 1, \"a\" }\nf()", []interface{}{1, "a"}},
+}
+
+func TestEvalValues(t *testing.T) {
+	vm := embed.New()
+	for _, test := range evalTests {
+		got, err := vm.Eval(test.src)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", test.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Eval(%q) = %#v, want %#v", test.src, got, test.want)
+		}
+	}
+}
+
+var evalErrTests = []string{
+	`x +`,
+	`undefined + 1`,
+	`func f() {`,
+	`$$ ls $$`,
+	`panic("boom")`,
+}
+
+func TestEvalLongLine(t *testing.T) {
+	vm := embed.New()
+	long := strings.Repeat("a", 100000)
+	got, err := vm.Eval("s := \"" + long + "\"\ns")
+	if err != nil || got != long {
+		t.Errorf("Eval of a %d byte line = %.10v, %v, want the line's string", len(long), got, err)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	vm := embed.New()
+	for _, src := range evalErrTests {
+		if got, err := vm.Eval(src); err == nil {
+			t.Errorf("Eval(%q) = %#v, want error", src, got)
+		}
+	}
+	// The VM is still usable.
+	if got, err := vm.Eval("1"); err != nil || got != 1 {
+		t.Errorf("Eval(1) = %#v, %v, want 1", got, err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	vm := embed.New()
+	values := []interface{}{
+		true,
+		int8(-3),
+		uint64(1 << 63),
+		float32(1.5),
+		complex(1, 2),
+		"s",
+		[]int{1, 2},
+		map[string][]float64{"a": {1}},
+	}
+	for i, v := range values {
+		name := fmt.Sprintf("v%d", i)
+		if err := vm.Set(name, v); err != nil {
+			t.Errorf("Set(%s, %#v): %v", name, v, err)
+			continue
+		}
+		if got, err := vm.Get(name); err != nil || !reflect.DeepEqual(got, v) {
+			t.Errorf("Get(%s) = %#v, %v, want %#v", name, got, err, v)
+		}
+	}
+
+	// A named type is converted to its underlying type.
+	if err := vm.Set("d", 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := vm.Eval("d + 1"); err != nil || got != int64(2000000001) {
+		t.Errorf("Eval(d + 1) = %#v, %v, want int64(2000000001)", got, err)
+	}
+}
+
+func TestSetError(t *testing.T) {
+	vm := embed.New()
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"x", nil},
+		{"x", struct{}{}},
+		{"x", []time.Duration{1}},
+		{"x", &struct{}{}},
+		{"", 1},
+		{"_", 1},
+		{"1x", 1},
+		{"x; y", 1},
+		{"func", 1},
+		{"print", 1},
+		{"int", 1},
+		{"true", 1},
+		{"len", 1},
+	}
+	for _, test := range tests {
+		if err := vm.Set(test.name, test.v); err == nil {
+			t.Errorf("Set(%q, %#v) succeeded, want error", test.name, test.v)
+		}
+	}
+	if got, err := vm.Get("x"); err == nil {
+		t.Errorf("Get(x) = %#v, want error", got)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	vm := embed.New()
+	if _, err := vm.Eval("n := 0\nfunc add(x int) { n = n + x }"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("x%d", i)
+			if err := vm.Set(name, i); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := vm.Eval("add(" + name + ")"); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got, err := vm.Get("n"); err != nil || got != 45 {
+		t.Errorf("Get(n) = %#v, %v, want 45", got, err)
+	}
+}
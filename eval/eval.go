@@ -7,6 +7,7 @@ package eval
 import (
 	"bufio"
 	"fmt"
+	"go/constant"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -82,6 +83,11 @@ type Program struct {
 
 	ShellState *shell.State
 
+	// Sandboxed limits imports to the built-in packages that do
+	// not reach the network. Packages that would be built as
+	// plugins cannot be imported.
+	Sandboxed bool
+
 	sigint     <-chan os.Signal
 	sigintSeen bool
 
@@ -89,9 +95,28 @@ type Program struct {
 	branchLabel     string
 	mostRecentLabel string
 
+	iota int64 // value of iota in the current const spec
+
 	typePlugins map[*tipe.Named]string // type to package path TODO lock?
 }
 
+// networkPkgs are the built-in packages a sandboxed Program cannot
+// import.
+var networkPkgs = map[string]bool{
+	"neugram.io/ng/eval/http": true,
+}
+
+// builtinPkgs are the packages registered by wrapbuiltin. Packages
+// loaded as plugins are added to gowrap.Pkgs later, and are not
+// available to a sandboxed Program.
+var builtinPkgs = func() map[string]bool {
+	m := make(map[string]bool, len(gowrap.Pkgs))
+	for path := range gowrap.Pkgs {
+		m[path] = true
+	}
+	return m
+}()
+
 type branchType int
 
 const (
@@ -486,7 +511,7 @@ func (p *Program) Eval(s stmt.Stmt, sigint <-chan os.Signal) (res []reflect.Valu
 		if s, isSimple := s.(*stmt.Simple); isSimple {
 			if e, isIdent := s.Expr.(*expr.Ident); isIdent && (e.Name == "exit" || e.Name == "logout") {
 				if p.Cur.Lookup(e.Name) == (reflect.Value{}) {
-					return nil, fmt.Errorf("use Ctrl-D to exit")
+					return nil, fmt.Errorf(`use Ctrl-D or \quit to exit`)
 				}
 			}
 		}
@@ -518,9 +543,11 @@ func (p *Program) evalStmt(s stmt.Stmt) []reflect.Value {
 	p.mostRecentLabel = ""
 	switch s := s.(type) {
 	case *stmt.Const:
+		p.iota = 0
 		return p.evalConst(s)
 	case *stmt.ConstSet:
-		for _, v := range s.Consts {
+		for i, v := range s.Consts {
+			p.iota = int64(i)
 			p.evalConst(v)
 		}
 		return nil
@@ -545,6 +572,17 @@ func (p *Program) evalStmt(s stmt.Stmt) []reflect.Value {
 			// TODO: insert an implicit interface type conversion here
 			vals = append(vals, v...)
 		}
+		if len(vals) > 1 {
+			// Values read from variables alias their storage.
+			// Copy them so a, b = b, a does not see its own writes.
+			for i, v := range vals {
+				if v.CanAddr() {
+					c := reflect.New(v.Type()).Elem()
+					c.Set(v)
+					vals[i] = c
+				}
+			}
+		}
 
 		vars := make([]reflect.Value, len(s.Left))
 		if s.Decl {
@@ -678,6 +716,9 @@ func (p *Program) evalStmt(s stmt.Stmt) []reflect.Value {
 	case *stmt.Import:
 		var pkg *gowrap.Pkg
 		path := s.Path
+		if p.Sandboxed && (!builtinPkgs[path] || networkPkgs[path]) {
+			panic(Panic{val: fmt.Errorf("package %q is not available in sandboxed mode", path)})
+		}
 		ngPkg := strings.HasSuffix(path, ".ng")
 		if ngPkg {
 			var filename string
@@ -914,6 +955,9 @@ func (p *Program) evalStmt(s stmt.Stmt) []reflect.Value {
 		}
 		return nil
 	case *stmt.TypeDecl:
+		if s.Alias != nil {
+			return nil
+		}
 		if _, isIface := s.Type.Type.(*tipe.Interface); isIface {
 			p.ifaceDecl(s.Type)
 		}
@@ -1254,6 +1298,39 @@ func (uc UntypedComplex) String() string {
 	return "(" + uc.Real.String() + op + uc.Imag.String() + "i)"
 }
 
+// constValue converts a constant computed by the type checker
+// to the matching untyped value.
+func constValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.Bool:
+		return UntypedBool{constant.BoolVal(v)}
+	case constant.String:
+		return UntypedString{constant.StringVal(v)}
+	case constant.Int:
+		i, _ := new(big.Int).SetString(v.ExactString(), 10)
+		return UntypedInt{i}
+	case constant.Float:
+		return UntypedFloat{constFloat(v)}
+	case constant.Complex:
+		return UntypedComplex{constFloat(constant.Real(v)), constFloat(constant.Imag(v))}
+	}
+	panic(fmt.Sprintf("eval: unknown constant %s", v))
+}
+
+func constFloat(v constant.Value) *big.Float {
+	switch x := constant.Val(constant.ToFloat(v)).(type) {
+	case *big.Float:
+		return new(big.Float).Copy(x)
+	case *big.Rat:
+		return new(big.Float).SetRat(x)
+	case int64:
+		return new(big.Float).SetInt64(x)
+	case *big.Int:
+		return new(big.Float).SetInt(x)
+	}
+	panic(fmt.Sprintf("eval: bad float constant %s", v))
+}
+
 func promoteUntyped(x interface{}) interface{} {
 	switch x := x.(type) {
 	case UntypedInt:
@@ -1294,6 +1371,8 @@ func convert(v reflect.Value, t reflect.Type) reflect.Value {
 			res := UntypedComplex{new(big.Float), new(big.Float)}
 			res.Real.SetInt(val.Int)
 			return reflect.ValueOf(res)
+		case reflect.TypeOf(UntypedRune{}):
+			return reflect.ValueOf(UntypedRune{rune(val.Int64())})
 		}
 		ret := reflect.New(t).Elem()
 		switch t.Kind() {
@@ -1349,6 +1428,16 @@ func convert(v reflect.Value, t reflect.Type) reflect.Value {
 		}
 		return ret
 	case UntypedRune:
+		switch t {
+		case reflect.TypeOf(UntypedFloat{}):
+			res := UntypedFloat{new(big.Float)}
+			res.Float.SetInt64(int64(val.Rune))
+			return reflect.ValueOf(res)
+		case reflect.TypeOf(UntypedComplex{}):
+			res := UntypedComplex{new(big.Float), new(big.Float)}
+			res.Real.SetInt64(int64(val.Rune))
+			return reflect.ValueOf(res)
+		}
 		ret := reflect.New(t).Elem()
 		r := val.Rune
 		if t.Kind() == reflect.Interface {
@@ -1415,6 +1504,12 @@ func (p *Program) prepCall(e *expr.Call) (fn reflect.Value, args []reflect.Value
 }
 
 func (p *Program) evalExpr(e expr.Expr) []reflect.Value {
+	// Constant expressions were folded by the type checker.
+	if v := p.Types.Value(e); v != nil && v.Kind() != constant.Unknown {
+		if t := p.Types.Type(e); t != nil {
+			return []reflect.Value{convert(reflect.ValueOf(constValue(v)), p.reflector.ToRType(t))}
+		}
+	}
 	switch e := e.(type) {
 	case *expr.BasicLiteral:
 		var v reflect.Value
@@ -1562,6 +1657,10 @@ func (p *Program) evalExpr(e expr.Expr) []reflect.Value {
 			t := p.reflector.ToRType(p.Types.Type(e))
 			return []reflect.Value{reflect.New(t).Elem()}
 		}
+		if p.Types.Ident(e) == typecheck.Universe.Objs["iota"] {
+			t := p.reflector.ToRType(p.Types.Type(e))
+			return []reflect.Value{convert(reflect.ValueOf(UntypedInt{big.NewInt(p.iota)}), t)}
+		}
 		if v := p.Cur.Lookup(e.Name); v != (reflect.Value{}) {
 			return []reflect.Value{v}
 		}
@@ -1718,12 +1817,14 @@ func (p *Program) evalExpr(e expr.Expr) []reflect.Value {
 			v := p.evalExprOne(e.Expr)
 			return []reflect.Value{v.Elem()}
 		case token.Not:
-			v = p.evalExprOne(e.Expr)
-			b := v.Bool()
-			if !v.CanAddr() {
-				v = reflect.New(reflect.TypeOf(false)).Elem()
+			sub := p.evalExprOne(e.Expr)
+			if ub, ok := sub.Interface().(UntypedBool); ok {
+				v = reflect.ValueOf(UntypedBool{!ub.Bool})
+				break
 			}
-			v.SetBool(!b)
+			// Never modify sub in place, it may be a variable.
+			v = reflect.New(sub.Type()).Elem()
+			v.SetBool(!sub.Bool())
 		case token.Add, token.Sub:
 			rhs := p.evalExprOne(e.Expr)
 			var lhs interface{}
@@ -14,8 +14,10 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"neugram.io/ng/eval/environ"
+	"neugram.io/ng/eval/gowrap"
 	"neugram.io/ng/eval/shell"
 	"neugram.io/ng/format"
 	"neugram.io/ng/gotool"
@@ -135,6 +137,488 @@ func TestExprs(t *testing.T) {
 	}
 }
 
+var evalTests = []struct {
+	expr string
+	want string // fmt.Sprint of the result, or an error substring
+}{
+	{"1 + 2*3", "7"},
+	{"(x + y) * z", "63"},
+	{"7 / 2", "3"},
+	{"7.0 / 2", "3.5"},
+	{"x / 8.0", "0.5"},
+	{"-x % 3", "-1"},
+	{"1 << 100 >> 98", "4"},
+	{"6 &^ 3 | 8", "12"},
+	{"x < y && !(y == z)", "true"},
+	{"big || undef", "true"},
+	{"!big && undef", "false"},
+	{`s + "b" == "ab"`, "true"},
+	{`"a" < "b"`, "true"},
+	{"'a' + 1", "98"},
+	{"nil == nil", "true"},
+	{"s != nil", "true"},
+	{"undef + 1", "undefined: undef"},
+	{"x / 0", "division by zero"},
+	{`s + 1`, "mismatched values"},
+	{"-true", "operator - not defined"},
+	{"true < false", "mismatched values"},
+}
+
+func TestEval(t *testing.T) {
+	env := NewEnv(nil)
+	env.Set("x", big.NewInt(4))
+	env.Set("y", big.NewInt(5))
+	env.Set("s", "a")
+	env = NewEnv(env)
+	env.Set("z", big.NewInt(7))
+	env.Set("big", true)
+
+	for _, test := range evalTests {
+		s, err := parser.ParseStmt([]byte(test.expr))
+		if err != nil {
+			t.Fatalf("ParseStmt(%q): %v", test.expr, err)
+		}
+		v, err := Eval(s.(*stmt.Simple).Expr, env)
+		if err != nil {
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Eval(%s) error: %v, want %q", test.expr, err, test.want)
+			}
+			continue
+		}
+		if got := fmt.Sprint(v); got != test.want {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func mustExec(t *testing.T, env *Env, src string) {
+	if err := Exec(mustParse(src), env); err != nil {
+		t.Fatalf("Exec(%q): %v", src, err)
+	}
+}
+
+func TestEvalCall(t *testing.T) {
+	env := NewEnv(nil)
+	mustExec(t, env, `func fact(n int) int {
+		if n <= 1 {
+			return 1
+		}
+		return n * fact(n-1)
+	}`)
+	mustExec(t, env, `func sum(n int) int {
+		s := 0
+		for i := 1; i <= n; i++ {
+			if i == 3 {
+				continue
+			}
+			s += i
+		}
+		return s
+	}`)
+	mustExec(t, env, `func forever(n int) int {
+		return forever(n+1)
+	}`)
+	mustExec(t, env, `func half(n int) int {
+		return 100 / (n - n)
+	}`)
+	mustExec(t, env, `func twice(n int) int {
+		return half(n) * 2
+	}`)
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{"fact(20)", "2432902008176640000"},
+		{"fact(30) / fact(28)", "870"},
+		{"sum(5)", "12"},
+		{"fact(1, 2)", "wrong number of arguments in call to fact: have 2, want 1"},
+		{"x(1)", "undefined: x"},
+		{"fact(1)(1)", "cannot call non-function fact(1)"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		got := fmt.Sprint(v)
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+
+	defer func(depth int) { MaxCallDepth = depth }(MaxCallDepth)
+	MaxCallDepth = 500
+	_, err := Eval(mustParse("forever(0)").(*stmt.Simple).Expr, env)
+	if e, ok := err.(*Error); !ok {
+		t.Errorf("forever(0): want *Error, got %v", err)
+	} else if len(e.Stack) != MaxCallDepth || !strings.Contains(e.Err.Error(), "stack overflow") {
+		t.Errorf("forever(0): error %v with %d frames, want stack overflow with %d frames", e.Err, len(e.Stack), MaxCallDepth)
+	}
+
+	_, err = Eval(mustParse("twice(3)").(*stmt.Simple).Expr, env)
+	if e, ok := err.(*Error); !ok {
+		t.Errorf("twice(3): want *Error, got %v", err)
+	} else if len(e.Stack) != 2 || e.Stack[0].Func.Func.Name != "twice" || e.Stack[1].Func.Func.Name != "half" {
+		t.Errorf("twice(3): unexpected stack in error: %v", e)
+	} else if v, _ := e.Stack[1].Env.Lookup("n"); fmt.Sprint(v) != "3" {
+		t.Errorf("twice(3): half frame has n=%v, want 3", v)
+	}
+}
+
+func TestEvalClosure(t *testing.T) {
+	env := NewEnv(nil)
+	mustExec(t, env, `func makeCounter() func() int {
+		n := 0
+		return func() int {
+			n++
+			return n
+		}
+	}`)
+	mustExec(t, env, `c1 := makeCounter()`)
+	mustExec(t, env, `c2 := makeCounter()`)
+	mustExec(t, env, `n := 100`) // not seen by the counters
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{"c1()", "1"},
+		{"c1()", "2"},
+		{"c2()", "1"},
+		{"c1() + c2()", "5"},
+		{"n", "100"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		if err != nil {
+			t.Fatalf("Eval(%s): %v", test.expr, err)
+		}
+		if got := fmt.Sprint(v); got != test.want {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+
+	// The callee cannot see the caller's variables.
+	mustExec(t, env, `func get() int {
+		return local
+	}`)
+	mustExec(t, env, `func call() int {
+		local := 1
+		return get()
+	}`)
+	if _, err := Eval(mustParse("call()").(*stmt.Simple).Expr, env); err == nil || !strings.Contains(err.Error(), "undefined: local") {
+		t.Errorf("call(): error %v, want undefined: local", err)
+	}
+}
+
+func TestEvalTable(t *testing.T) {
+	env := NewEnv(nil)
+	mustExec(t, env, `a := [|]int64{{|"x", "y", "z"|}, {1, 2, 3}, {4, 5, 6}}`)
+	mustExec(t, env, `b := [|]int64{{7, 8}}`)
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{`a[0, 1]`, "4"},
+		{`a["z", 0]`, "3"},
+		{`b[1, 0]`, "8"},
+		{`a["y", :]`, "[2 5]"},
+		{`a[:, 1]`, "[4 5 6]"},
+		{`a["y":, 1:]`, "&{[y z] [[5] [6]]}"},
+		{`a[0:2, :]`, "&{[x y] [[1 4] [2 5]]}"},
+		{`a[:, 2]`, "table row index 2 out of range (table has 2 rows)"},
+		{`a[3, 0]`, "table column index 3 out of range (table has 3 columns)"},
+		{`a[:, 1:3]`, "table row range [1:3] out of range (table has 2 rows)"},
+		{`a["w", 0]`, `table has no column "w"`},
+		{`a[0]`, "table index a[0] needs a column and a row index"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		got := fmt.Sprint(v)
+		if err != nil {
+			if _, ok := err.(*Error); !ok {
+				t.Errorf("Eval(%s): error %v is not an *Error", test.expr, err)
+			}
+			got = err.Error()
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+
+	if err := Exec(mustParse(`c := [|]int64{{|"x"|}, {1, 2}}`), env); err == nil || !strings.Contains(err.Error(), "table row 0 has 2 values, want 1") {
+		t.Errorf("bad table literal: error %v", err)
+	}
+}
+
+func TestEvalGoCall(t *testing.T) {
+	env := NewEnv(nil)
+	env.Set("math", gowrap.Pkgs["math"])
+	env.Set("stats", gowrap.Pkgs["neugram.io/ng/eval/stats"])
+	mustExec(t, env, `a := [|]float64{{|"x", "y"|}, {1, 2}, {3, 6}, {8, 7}}`)
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{`math.Sqrt(16)`, "4"},
+		{`math.Pi > 3.14`, "true"},
+		{`math.Inf(1)`, "+Inf"},
+		{`stats.Mean(a["x", :])`, "4"},
+		{`stats.Median(a[1, :])`, "6"},
+		{`stats.Quantile(a["x", :], 0.25)`, "2"},
+		{`stats.Covariance(a[0, :], a[1, :])`, "8"},
+		{`stats.Mean(a[:, 0])`, "1.5"},
+		{`stats.Mean(a)`, "cannot use a (*eval.Table) as []float64 in argument to stats.Mean"},
+		{`stats.Mean(a["x", 0:0])`, "stats.Mean result is NaN"},
+		{`stats.Quantile(a["x", :])`, "wrong number of arguments in call to stats.Quantile: have 1, want 2"},
+		{`stats.Sum(a["x", :])`, "undefined: stats.Sum"},
+		{`a.x`, "cannot select x from a"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		got := fmt.Sprint(v)
+		if err != nil {
+			if _, ok := err.(*Error); !ok {
+				t.Errorf("Eval(%s): error %v is not an *Error", test.expr, err)
+			}
+			got = err.Error()
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestEvalGo(t *testing.T) {
+	env := NewEnv(nil)
+	mustExec(t, env, `a := 0`)
+	mustExec(t, env, `b := 0`)
+	mustExec(t, env, `func sum(n int) int {
+		s := 0
+		for i := 1; i <= n; i++ {
+			s += i
+		}
+		return s
+	}`)
+	mustExec(t, env, `func setA(n int) {
+		a = sum(n)
+	}`)
+	mustExec(t, env, `func setB(n int) {
+		b = sum(n)
+	}`)
+	mustExec(t, env, `func main() {
+		for i := 0; i < 10; i++ {
+			go setA(100)
+			go setB(1000)
+		}
+	}`)
+	mustExec(t, env, `main()`)
+	if a, _ := env.Lookup("a"); fmt.Sprint(a) != "5050" {
+		t.Errorf("a=%v, want 5050", a)
+	}
+	if b, _ := env.Lookup("b"); fmt.Sprint(b) != "500500" {
+		t.Errorf("b=%v, want 500500", b)
+	}
+
+	mustExec(t, env, `func boom() {
+		a = 1 / 0
+	}`)
+	err := Exec(mustParse(`go boom()`), env)
+	if err == nil || !strings.Contains(err.Error(), "division by zero\n\tin boom") {
+		t.Errorf("go boom(): error %v, want division by zero in boom", err)
+	}
+}
+
+func TestEvalChan(t *testing.T) {
+	env := NewEnv(nil)
+	mustExec(t, env, `func producer(ch chan int, n int) {
+		for i := 1; i <= n; i++ {
+			ch <- i
+		}
+		close(ch)
+	}`)
+	mustExec(t, env, `func consumer(ch chan int, done chan int) {
+		s := 0
+		for {
+			v, ok := <-ch
+			if !ok {
+				break
+			}
+			s += v
+		}
+		done <- s
+	}`)
+	mustExec(t, env, `func run(n int) int {
+		ch := make(chan int)
+		done := make(chan int, 1)
+		go producer(ch, n)
+		go consumer(ch, done)
+		return <-done
+	}`)
+	mustExec(t, env, `func pick(first bool) int {
+		a := make(chan int, 1)
+		b := make(chan int, 1)
+		if first {
+			a <- 1
+		} else {
+			b <- 2
+		}
+		select {
+		case v := <-a:
+			return v
+		case v := <-b:
+			return v * 10
+		}
+	}`)
+	mustExec(t, env, `func trySend() string {
+		c := make(chan string)
+		select {
+		case c <- "sent":
+			return "sent"
+		default:
+			return "default"
+		}
+	}`)
+	mustExec(t, env, `func closed() bool {
+		c := make(chan bool, 1)
+		c <- true
+		close(c)
+		v1, ok1 := <-c
+		v2, ok2 := <-c
+		return v1 && ok1 && !v2 && !ok2
+	}`)
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{"run(100)", "5050"},
+		{"pick(true)", "1"},
+		{"pick(false)", "20"},
+		{"trySend()", "default"},
+		{"closed()", "true"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		if err != nil {
+			t.Errorf("Eval(%s): %v", test.expr, err)
+			continue
+		}
+		if got := fmt.Sprint(v); got != test.want {
+			t.Errorf("Eval(%s)=%s, want %s", test.expr, got, test.want)
+		}
+	}
+
+	defer func(d time.Duration) { DeadlockTimeout = d }(DeadlockTimeout)
+	DeadlockTimeout = 10 * time.Millisecond
+	mustExec(t, env, `func stuck() int {
+		c := make(chan int)
+		return <-c
+	}`)
+	_, err := Eval(mustParse("stuck()").(*stmt.Simple).Expr, env)
+	if err == nil || !strings.Contains(err.Error(), "deadlock") {
+		t.Errorf("stuck(): error %v, want deadlock", err)
+	}
+	err = Exec(mustParse(`close(make(chan int, 1))`), env)
+	if err != nil {
+		t.Errorf("close: %v", err)
+	}
+}
+
+func TestEvalDefer(t *testing.T) {
+	env := NewEnv(nil)
+	for _, src := range []string{
+		`closed := false`,
+		`order := 0`,
+		`recovered := ""`,
+		`helped := true`,
+		`func closeResource() {
+			closed = true
+		}`,
+		`func use() {
+			defer closeResource()
+			panic("boom")
+		}`,
+		`func push(d int) {
+			order = order*10 + d
+		}`,
+		`func lifo() int {
+			defer push(1)
+			defer push(2)
+			for i := 3; i < 5; i++ {
+				defer push(i)
+			}
+			return 7
+		}`,
+		`func safeDiv(a int, b int) int {
+			defer func() {
+				r := recover()
+				if r != nil {
+					recovered = r
+				}
+			}()
+			if b == 0 {
+				panic("divide by zero")
+			}
+			return a / b
+		}`,
+		`func replace() {
+			defer func() {
+				panic("second")
+			}()
+			panic("first")
+		}`,
+		`func helper() bool {
+			return recover() != nil
+		}`,
+		`func nested() {
+			defer func() {
+				helped = helper()
+			}()
+			panic("not recovered")
+		}`,
+	} {
+		mustExec(t, env, src)
+	}
+
+	err := Exec(mustParse(`use()`), env)
+	if e, ok := err.(*Error); !ok {
+		t.Errorf("use(): error %v, want *Error", err)
+	} else if p, ok := e.Err.(Panic); !ok || p.val != "boom" {
+		t.Errorf("use(): error %v, want panic boom", err)
+	}
+	if v, _ := env.Lookup("closed"); v != true {
+		t.Error("use(): deferred close did not run")
+	}
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{"lifo()", "7"},
+		{"order", "4321"},
+		{"safeDiv(7, 2)", "3"},
+		{"recovered", ""},
+		{"safeDiv(1, 0)", "<nil>"},
+		{"recovered", "divide by zero"},
+		{"replace()", "eval: neugram panic: second\n\tin func literal\n\tin replace"},
+		{"nested()", "eval: neugram panic: not recovered\n\tin nested"},
+		{"helped", "false"},
+		{"recover()", "<nil>"},
+	} {
+		v, err := Eval(mustParse(test.expr).(*stmt.Simple).Expr, env)
+		got := fmt.Sprint(v)
+		if err != nil {
+			got = err.Error()
+		}
+		if got != test.want {
+			t.Errorf("Eval(%s)=%q, want %q", test.expr, got, test.want)
+		}
+	}
+
+	if err := Exec(mustParse(`defer push(1)`), env); err == nil || !strings.Contains(err.Error(), "defer is not in a function") {
+		t.Errorf("defer outside function: error %v", err)
+	}
+}
+
 func mustParse(src string) stmt.Stmt {
 	expr, err := parser.ParseStmt([]byte(src))
 	if err != nil {
@@ -234,3 +718,35 @@ func TestPrograms(t *testing.T) {
 		})
 	}
 }
+
+func TestSandboxed(t *testing.T) {
+	// A plugin loaded by an earlier Program.
+	gowrap.Pkgs["net/url"] = &gowrap.Pkg{}
+	defer delete(gowrap.Pkgs, "net/url")
+
+	for _, test := range []struct {
+		path string
+		ok   bool
+	}{
+		{"strings", true},
+		{"neugram.io/ng/eval/stats", true},
+		{"neugram.io/ng/eval/http", false},
+		{"net/http", false}, // would be built as a plugin
+		{"net/url", false},
+	} {
+		p := New("sandboxed", nil)
+		p.Sandboxed = true
+		_, err := p.Eval(mustParse(fmt.Sprintf("import %q", test.path)), nil)
+		if test.ok && err != nil {
+			t.Errorf("sandboxed import %q: %v", test.path, err)
+		}
+		if !test.ok && (err == nil || !strings.Contains(err.Error(), "not available in sandboxed mode")) {
+			t.Errorf("sandboxed import %q err=%v, want not available", test.path, err)
+		}
+	}
+
+	p := New("unsandboxed", nil)
+	if _, err := p.Eval(mustParse(`import "neugram.io/ng/eval/http"`), nil); err != nil {
+		t.Errorf("unsandboxed import of http: %v", err)
+	}
+}
@@ -0,0 +1,1415 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	gotoken "go/token"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"neugram.io/ng/eval/gowrap"
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+)
+
+// Value is the result of Eval.
+//
+// It holds a *big.Int, *big.Float, bool, string, []Value, *Table,
+// *Chan or *FuncValue. A *gowrap.Pkg or a Go function selected
+// from one may also be stored in an Env and called.
+type Value interface{}
+
+// Chan is a channel Value.
+type Chan struct {
+	C    chan Value
+	Dir  tipe.ChanDirection
+	Elem tipe.Type
+}
+
+// Table is a table Value.
+type Table struct {
+	ColNames []string  // empty for an unnamed column
+	Cols     [][]Value // Cols[i] holds the rows of column ColNames[i]
+}
+
+// Len returns the number of rows in t.
+func (t *Table) Len() int {
+	if len(t.Cols) == 0 {
+		return 0
+	}
+	return len(t.Cols[0])
+}
+
+// FuncValue is a function Value.
+type FuncValue struct {
+	Func    *expr.FuncLiteral
+	Closure *Env // scope the function was created in
+}
+
+func (f *FuncValue) name() string {
+	if f.Func.Name == "" {
+		return "func literal"
+	}
+	return f.Func.Name
+}
+
+// Env is a scope of named values used by Eval.
+//
+// An Env may be shared by goroutines. Vars must not be accessed
+// directly while goroutines are running.
+type Env struct {
+	Parent *Env
+	Vars   map[string]Value
+
+	mu sync.RWMutex
+}
+
+// NewEnv returns an empty Env nested inside parent.
+func NewEnv(parent *Env) *Env {
+	return &Env{
+		Parent: parent,
+		Vars:   make(map[string]Value),
+	}
+}
+
+// Lookup finds the value of name in env or one of its parents.
+func (env *Env) Lookup(name string) (Value, bool) {
+	for e := env; e != nil; e = e.Parent {
+		e.mu.RLock()
+		v, ok := e.Vars[name]
+		e.mu.RUnlock()
+		if ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets name to v in env.
+func (env *Env) Set(name string, v Value) {
+	env.mu.Lock()
+	env.Vars[name] = v
+	env.mu.Unlock()
+}
+
+// assign sets the innermost existing variable name to v.
+func (env *Env) assign(name string, v Value) bool {
+	for e := env; e != nil; e = e.Parent {
+		e.mu.Lock()
+		_, ok := e.Vars[name]
+		if ok {
+			e.Vars[name] = v
+		}
+		e.mu.Unlock()
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxCallDepth is the maximum number of nested function calls
+// made by Eval and Exec before they report a stack overflow.
+var MaxCallDepth = 10000
+
+// DeadlockTimeout is how long a channel operation or select statement
+// in Eval or Exec may block before it is reported as a deadlock.
+// Zero means operations may block forever.
+var DeadlockTimeout time.Duration
+
+// A Frame is a function call in progress.
+type Frame struct {
+	Func *FuncValue
+	Env  *Env // parameters of the call
+
+	defers    []*deferred
+	unwinding *error // for a deferred call, the error of its caller
+
+	goFunc reflect.Value   // or the Go function called,
+	goArgs []reflect.Value // with these arguments
+	goName string
+}
+
+// Error is an error reported by Eval or Exec.
+type Error struct {
+	Err   error
+	Stack []*Frame // calls in progress, outermost first
+}
+
+func (e *Error) Error() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "eval: %v", e.Err)
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		fmt.Fprintf(buf, "\n\tin %s", e.Stack[i].Func.name())
+	}
+	return buf.String()
+}
+
+// An evaluator runs a single goroutine. It holds the goroutine's
+// call stack.
+type evaluator struct {
+	stack []*Frame
+	sched *scheduler
+}
+
+func newEvaluator() *evaluator {
+	return &evaluator{sched: new(scheduler)}
+}
+
+// scheduler tracks the goroutines started by go statements.
+type scheduler struct {
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+	err error // first error reported by a goroutine
+}
+
+// goroutine runs the call in frame on a new goroutine.
+func (ev *evaluator) goroutine(frame *Frame) {
+	g := &evaluator{sched: ev.sched}
+	ev.sched.wg.Add(1)
+	go func() {
+		defer ev.sched.wg.Done()
+		if _, err := g.call(frame); err != nil {
+			ev.sched.mu.Lock()
+			if ev.sched.err == nil {
+				ev.sched.err = err
+			}
+			ev.sched.mu.Unlock()
+		}
+	}()
+}
+
+// wait waits for all goroutines to finish and reports an error from
+// the main goroutine or, failing that, from any other goroutine.
+func (ev *evaluator) wait(err error) error {
+	ev.sched.wg.Wait()
+	if err != nil {
+		return err
+	}
+	return ev.sched.err
+}
+
+func (ev *evaluator) errorf(format string, args ...interface{}) error {
+	return &Error{
+		Err:   fmt.Errorf(format, args...),
+		Stack: append([]*Frame(nil), ev.stack...),
+	}
+}
+
+// Eval evaluates the expression e in env.
+//
+// Unlike a Program, Eval does not type check e. It works on literals,
+// identifiers, function calls and unary and binary operators, with the
+// arithmetic rules of untyped constants: integer operands are promoted
+// to float when mixed with floats, and integer division truncates.
+//
+// Functions of a *gowrap.Pkg in env can be called. Arguments are
+// converted to the Go parameter types, so a table column can be
+// passed as a []float64. Numeric results become *big.Int or
+// *big.Float Values; a NaN result is reported as an error.
+//
+// Eval returns once every goroutine started by e has finished.
+func Eval(e expr.Expr, env *Env) (Value, error) {
+	ev := newEvaluator()
+	v, err := ev.eval(e, env)
+	if err := ev.wait(err); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Exec executes the statement s in env.
+//
+// It supports the statements needed to write simple functions:
+// assignments, blocks, if, for, break, continue, return, go, defer,
+// select and channel sends.
+// A function declaration binds the function name in env.
+//
+// Exec returns once every goroutine started by s has finished.
+func Exec(s stmt.Stmt, env *Env) error {
+	ev := newEvaluator()
+	ctl, err := ev.exec(s, env)
+	if err == nil && ctl != nil {
+		err = ev.misplaced(ctl)
+	}
+	return ev.wait(err)
+}
+
+// control is a change of control flow caused by a statement.
+type control struct {
+	tok token.Token // Break, Continue or Return
+	ret Value
+}
+
+func (ev *evaluator) misplaced(ctl *control) error {
+	switch ctl.tok {
+	case token.Break:
+		return ev.errorf("break is not in a loop")
+	case token.Continue:
+		return ev.errorf("continue is not in a loop")
+	}
+	return ev.errorf("return is not in a function")
+}
+
+func (ev *evaluator) exec(s stmt.Stmt, env *Env) (*control, error) {
+	switch s := s.(type) {
+	case *stmt.Block:
+		env = NewEnv(env)
+		for _, s := range s.Stmts {
+			ctl, err := ev.exec(s, env)
+			if ctl != nil || err != nil {
+				return ctl, err
+			}
+		}
+		return nil, nil
+	case *stmt.Simple:
+		if fn, ok := s.Expr.(*expr.FuncLiteral); ok && fn.Name != "" {
+			env.Set(fn.Name, &FuncValue{Func: fn, Closure: env})
+			return nil, nil
+		}
+		_, err := ev.eval(s.Expr, env)
+		return nil, err
+	case *stmt.Assign:
+		return nil, ev.execAssign(s, env)
+	case *stmt.Return:
+		ctl := &control{tok: token.Return}
+		switch len(s.Exprs) {
+		case 0:
+		case 1:
+			v, err := ev.eval(s.Exprs[0], env)
+			if err != nil {
+				return nil, err
+			}
+			ctl.ret = v
+		default:
+			return nil, ev.errorf("cannot return multiple values")
+		}
+		return ctl, nil
+	case *stmt.If:
+		env = NewEnv(env)
+		if s.Init != nil {
+			if ctl, err := ev.exec(s.Init, env); ctl != nil || err != nil {
+				return ctl, err
+			}
+		}
+		cond, err := ev.evalBool(s.Cond, env)
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return ev.exec(s.Body, env)
+		} else if s.Else != nil {
+			return ev.exec(s.Else, env)
+		}
+		return nil, nil
+	case *stmt.For:
+		env = NewEnv(env)
+		if s.Init != nil {
+			if ctl, err := ev.exec(s.Init, env); ctl != nil || err != nil {
+				return ctl, err
+			}
+		}
+		for {
+			if s.Cond != nil {
+				cond, err := ev.evalBool(s.Cond, env)
+				if err != nil {
+					return nil, err
+				}
+				if !cond {
+					return nil, nil
+				}
+			}
+			ctl, err := ev.exec(s.Body, env)
+			if err != nil {
+				return nil, err
+			}
+			if ctl != nil {
+				switch ctl.tok {
+				case token.Break:
+					return nil, nil
+				case token.Return:
+					return ctl, nil
+				}
+			}
+			if s.Post != nil {
+				if _, err := ev.exec(s.Post, env); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case *stmt.Send:
+		ch, err := ev.evalChan(s.Chan, env, tipe.ChanSend)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ev.eval(s.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		_, _, _, err = ev.selectCases([]reflect.SelectCase{sendCase(ch, v)}, true)
+		return nil, err
+	case *stmt.Select:
+		return ev.execSelect(s, env)
+	case *stmt.Defer:
+		return nil, ev.execDefer(s, env)
+	case *stmt.Go:
+		frame, err := ev.prepCall(s.Call, env)
+		if err != nil {
+			return nil, err
+		}
+		ev.goroutine(frame)
+		return nil, nil
+	case *stmt.Branch:
+		switch s.Type {
+		case token.Break, token.Continue:
+			if s.Label == "" {
+				return &control{tok: s.Type}, nil
+			}
+		}
+	}
+	return nil, ev.errorf("cannot execute %s", format.Stmt(s))
+}
+
+func (ev *evaluator) execAssign(s *stmt.Assign, env *Env) error {
+	if len(s.Left) == 2 && len(s.Right) == 1 {
+		if u, ok := s.Right[0].(*expr.Unary); ok && u.Op == token.ChanOp {
+			ch, err := ev.evalChan(u.Expr, env, tipe.ChanRecv)
+			if err != nil {
+				return err
+			}
+			_, v, ok, err := ev.selectCases([]reflect.SelectCase{recvCase(ch)}, true)
+			if err != nil {
+				return err
+			}
+			return ev.assign(s, env, []Value{ch.recvValue(v, ok), ok})
+		}
+	}
+	if len(s.Left) != len(s.Right) {
+		return ev.errorf("assignment count mismatch: %d = %d", len(s.Left), len(s.Right))
+	}
+	vals := make([]Value, len(s.Right))
+	for i, e := range s.Right {
+		v, err := ev.eval(e, env)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+	}
+	return ev.assign(s, env, vals)
+}
+
+// assign assigns vals to the left side of s.
+func (ev *evaluator) assign(s *stmt.Assign, env *Env, vals []Value) error {
+	for i, e := range s.Left {
+		ident, ok := e.(*expr.Ident)
+		if !ok {
+			return ev.errorf("cannot assign to %s", format.Expr(e))
+		}
+		switch {
+		case ident.Name == "_":
+		case s.Decl:
+			env.Set(ident.Name, vals[i])
+		case !env.assign(ident.Name, vals[i]):
+			return ev.errorf("undefined: %s", ident.Name)
+		}
+	}
+	return nil
+}
+
+func (ev *evaluator) evalBool(e expr.Expr, env *Env) (bool, error) {
+	v, err := ev.eval(e, env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, ev.errorf("non-bool %s used as condition", format.Expr(e))
+	}
+	return b, nil
+}
+
+func (ev *evaluator) eval(e expr.Expr, env *Env) (Value, error) {
+	switch e := e.(type) {
+	case *expr.BasicLiteral:
+		if r, ok := e.Value.(rune); ok {
+			return big.NewInt(int64(r)), nil
+		}
+		return e.Value, nil
+	case *expr.Ident:
+		if v, ok := env.Lookup(e.Name); ok {
+			return v, nil
+		}
+		switch e.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "nil":
+			return nil, nil
+		}
+		return nil, ev.errorf("undefined: %s", e.Name)
+	case *expr.FuncLiteral:
+		return &FuncValue{Func: e, Closure: env}, nil
+	case *expr.Call:
+		return ev.evalCall(e, env)
+	case *expr.Selector:
+		return ev.evalSelector(e, env)
+	case *expr.TableLiteral:
+		return ev.evalTableLiteral(e, env)
+	case *expr.Index:
+		return ev.evalIndex(e, env)
+	case *expr.Unary:
+		if e.Op != token.ChanOp {
+			v, err := ev.evalConst(e, env)
+			if err != nil {
+				return nil, err
+			}
+			return fromConst(v), nil
+		}
+		ch, err := ev.evalChan(e.Expr, env, tipe.ChanRecv)
+		if err != nil {
+			return nil, err
+		}
+		_, v, ok, err := ev.selectCases([]reflect.SelectCase{recvCase(ch)}, true)
+		if err != nil {
+			return nil, err
+		}
+		return ch.recvValue(v, ok), nil
+	case *expr.Binary:
+		v, err := ev.evalConst(e, env)
+		if err != nil {
+			return nil, err
+		}
+		return fromConst(v), nil
+	}
+	return nil, ev.errorf("cannot evaluate %s", format.Expr(e))
+}
+
+func (ev *evaluator) evalCall(e *expr.Call, env *Env) (Value, error) {
+	if ev.isBuiltin(e, env) {
+		args, err := ev.builtinArgs(e, env)
+		if err != nil {
+			return nil, err
+		}
+		return ev.callBuiltin(e, args)
+	}
+	frame, err := ev.prepCall(e, env)
+	if err != nil {
+		return nil, err
+	}
+	return ev.call(frame)
+}
+
+// prepCall evaluates the function and arguments of a call.
+func (ev *evaluator) prepCall(e *expr.Call, env *Env) (*Frame, error) {
+	v, err := ev.eval(e.Func, env)
+	if err != nil {
+		return nil, err
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Func {
+		return ev.prepGoCall(e, rv, env)
+	}
+	fn, ok := v.(*FuncValue)
+	if !ok {
+		return nil, ev.errorf("cannot call non-function %s", format.Expr(e.Func))
+	}
+	if e.Ellipsis || fn.Func.Type != nil && fn.Func.Type.Variadic {
+		return nil, ev.errorf("%s: variadic calls are not supported", format.Expr(e))
+	}
+	if len(e.Args) != len(fn.Func.ParamNames) {
+		return nil, ev.errorf("wrong number of arguments in call to %s: have %d, want %d", fn.name(), len(e.Args), len(fn.Func.ParamNames))
+	}
+
+	// The parameters are scoped inside the environment the function
+	// was created in, not the caller's, so captured variables are
+	// shared by every call of the closure.
+	frame := &Frame{Func: fn, Env: NewEnv(fn.Closure)}
+	for i, name := range fn.Func.ParamNames {
+		arg, err := ev.eval(e.Args[i], env)
+		if err != nil {
+			return nil, err
+		}
+		if name != "_" {
+			frame.Env.Set(name, arg)
+		}
+	}
+	return frame, nil
+}
+
+// call runs the body of the function in frame, followed by its
+// deferred calls.
+func (ev *evaluator) call(frame *Frame) (ret Value, err error) {
+	if frame.goFunc.IsValid() {
+		return ev.callGo(frame)
+	}
+	if len(ev.stack) >= MaxCallDepth {
+		return nil, ev.errorf("stack overflow: more than %d nested calls", MaxCallDepth)
+	}
+	ev.stack = append(ev.stack, frame)
+	ctl, err := ev.exec(frame.Func.Func.Body.(*stmt.Block), frame.Env)
+	if err == nil && ctl != nil {
+		if ctl.tok == token.Return {
+			ret = ctl.ret
+		} else {
+			err = ev.misplaced(ctl)
+		}
+	}
+	// Deferred calls run last in, first out, even while unwinding
+	// a panic, which they may recover. A panic in a deferred call
+	// replaces the current one.
+	for i := len(frame.defers) - 1; i >= 0; i-- {
+		d := frame.defers[i]
+		var derr error
+		if d.frame != nil {
+			d.frame.unwinding = &err
+			_, derr = ev.call(d.frame)
+		} else {
+			_, derr = ev.callBuiltin(d.builtin, d.args)
+		}
+		if derr != nil {
+			err = derr
+		}
+	}
+	ev.stack = ev.stack[:len(ev.stack)-1]
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// evalSelector evaluates a member of a Go package.
+func (ev *evaluator) evalSelector(e *expr.Selector, env *Env) (Value, error) {
+	v, err := ev.eval(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := v.(*gowrap.Pkg)
+	if !ok {
+		return nil, ev.errorf("cannot select %s from %s", e.Right.Name, format.Expr(e.Left))
+	}
+	x, ok := pkg.Exports[e.Right.Name]
+	if !ok {
+		return nil, ev.errorf("undefined: %s", format.Expr(e))
+	}
+	if x.Kind() == reflect.Func {
+		return x.Interface(), nil
+	}
+	return ev.fromGo(format.Expr(e), x)
+}
+
+// prepGoCall evaluates the arguments of a call to the Go function fn
+// and converts them to its parameter types.
+func (ev *evaluator) prepGoCall(e *expr.Call, fn reflect.Value, env *Env) (*Frame, error) {
+	name := format.Expr(e.Func)
+	t := fn.Type()
+	if e.Ellipsis || t.IsVariadic() {
+		return nil, ev.errorf("%s: variadic calls are not supported", format.Expr(e))
+	}
+	if len(e.Args) != t.NumIn() {
+		return nil, ev.errorf("wrong number of arguments in call to %s: have %d, want %d", name, len(e.Args), t.NumIn())
+	}
+	frame := &Frame{goFunc: fn, goName: name}
+	for i, arg := range e.Args {
+		v, err := ev.eval(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		rv, ok := toGo(v, t.In(i))
+		if !ok {
+			return nil, ev.errorf("cannot use %s (%T) as %s in argument to %s", format.Expr(arg), v, t.In(i), name)
+		}
+		frame.goArgs = append(frame.goArgs, rv)
+	}
+	return frame, nil
+}
+
+// callGo calls the Go function in frame.
+func (ev *evaluator) callGo(frame *Frame) (ret Value, err error) {
+	t := frame.goFunc.Type()
+	if t.NumOut() > 1 {
+		return nil, ev.errorf("%s: multiple results are not supported", frame.goName)
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			err = ev.errorf("%s: %v", frame.goName, x)
+		}
+	}()
+	res := frame.goFunc.Call(frame.goArgs)
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return ev.fromGo(frame.goName+" result", res[0])
+}
+
+// toGo converts v to a Go value of type t.
+func toGo(v Value, t reflect.Type) (reflect.Value, bool) {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := v.(type) {
+		case *big.Int:
+			f, _ = new(big.Float).SetInt(v).Float64()
+		case *big.Float:
+			f, _ = v.Float64()
+		default:
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(f).Convert(t), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.(*big.Int)
+		if !ok || !i.IsInt64() {
+			return reflect.Value{}, false
+		}
+		rv := reflect.New(t).Elem()
+		if rv.OverflowInt(i.Int64()) {
+			return reflect.Value{}, false
+		}
+		rv.SetInt(i.Int64())
+		return rv, true
+	case reflect.Slice:
+		vals, ok := v.([]Value)
+		if !ok {
+			break
+		}
+		rv := reflect.MakeSlice(t, len(vals), len(vals))
+		for i, val := range vals {
+			elem, ok := toGo(val, t.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			rv.Index(i).Set(elem)
+		}
+		return rv, true
+	}
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), true
+		}
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().AssignableTo(t) {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+// fromGo converts the Go value rv, described by what, to a Value.
+func (ev *evaluator) fromGo(what string, rv reflect.Value) (Value, error) {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) {
+			return nil, ev.errorf("%s is NaN", what)
+		}
+		return big.NewFloat(f), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		vals := make([]Value, rv.Len())
+		for i := range vals {
+			v, err := ev.fromGo(what, rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		return vals, nil
+	}
+	return rv.Interface(), nil
+}
+
+// deferred is a call deferred by a function.
+type deferred struct {
+	frame   *Frame     // function call
+	builtin *expr.Call // or builtin call,
+	args    []Value    // with these arguments
+}
+
+func (ev *evaluator) execDefer(s *stmt.Defer, env *Env) error {
+	if len(ev.stack) == 0 {
+		return ev.errorf("defer is not in a function")
+	}
+	e, ok := s.Expr.(*expr.Call)
+	if !ok {
+		return ev.errorf("expression in defer must be function call")
+	}
+	d := new(deferred)
+	var err error
+	if ev.isBuiltin(e, env) {
+		d.builtin = e
+		d.args, err = ev.builtinArgs(e, env)
+	} else {
+		d.frame, err = ev.prepCall(e, env)
+	}
+	if err != nil {
+		return err
+	}
+	f := ev.stack[len(ev.stack)-1]
+	f.defers = append(f.defers, d)
+	return nil
+}
+
+// isBuiltin reports whether e calls a builtin function.
+func (ev *evaluator) isBuiltin(e *expr.Call, env *Env) bool {
+	fn, ok := e.Func.(*expr.Ident)
+	if !ok {
+		return false
+	}
+	switch fn.Name {
+	case "make", "close", "panic", "recover":
+		_, shadowed := env.Lookup(fn.Name)
+		return !shadowed
+	}
+	return false
+}
+
+// builtinArgs evaluates the arguments of a builtin call. Type
+// arguments are left as nil Values.
+func (ev *evaluator) builtinArgs(e *expr.Call, env *Env) ([]Value, error) {
+	args := make([]Value, len(e.Args))
+	for i, arg := range e.Args {
+		if _, isType := arg.(*expr.Type); isType {
+			continue
+		}
+		v, err := ev.eval(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func (ev *evaluator) callBuiltin(e *expr.Call, args []Value) (res Value, err error) {
+	name := e.Func.(*expr.Ident).Name
+	switch name {
+	case "make":
+		if len(args) == 0 || len(args) > 2 {
+			break
+		}
+		return ev.builtinMake(e, args)
+	case "close":
+		if len(args) != 1 {
+			break
+		}
+		ch, err := ev.checkChan(e.Args[0], args[0], tipe.ChanSend)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if x := recover(); x != nil {
+				err = ev.errorf("%v", x) // close of closed channel
+			}
+		}()
+		close(ch.C)
+		return nil, nil
+	case "panic":
+		if len(args) != 1 {
+			break
+		}
+		return nil, &Error{
+			Err:   Panic{val: args[0]},
+			Stack: append([]*Frame(nil), ev.stack...),
+		}
+	case "recover":
+		if len(args) != 0 {
+			break
+		}
+		return ev.recover(), nil
+	}
+	return nil, ev.errorf("wrong number of arguments to %s", name)
+}
+
+func (ev *evaluator) builtinMake(e *expr.Call, args []Value) (Value, error) {
+	t, _ := e.Args[0].(*expr.Type)
+	if t == nil {
+		return nil, ev.errorf("%s is not a type", format.Expr(e.Args[0]))
+	}
+	ct, ok := tipe.Underlying(t.Type).(*tipe.Chan)
+	if !ok {
+		return nil, ev.errorf("cannot make %s", format.Type(t.Type))
+	}
+	size := 0
+	if len(args) == 2 {
+		n, ok := args[1].(*big.Int)
+		if !ok || !n.IsInt64() || n.Sign() < 0 {
+			return nil, ev.errorf("invalid channel size %s", format.Expr(e.Args[1]))
+		}
+		size = int(n.Int64())
+	}
+	return &Chan{
+		C:    make(chan Value, size),
+		Dir:  ct.Direction,
+		Elem: ct.Elem,
+	}, nil
+}
+
+// recover stops the panic, if any, of the function that deferred
+// the current call and returns its value.
+func (ev *evaluator) recover() Value {
+	if len(ev.stack) == 0 {
+		return nil
+	}
+	f := ev.stack[len(ev.stack)-1]
+	if f.unwinding == nil {
+		return nil // not a deferred call
+	}
+	e, ok := (*f.unwinding).(*Error)
+	if !ok {
+		return nil
+	}
+	p, ok := e.Err.(Panic)
+	if !ok {
+		return nil
+	}
+	*f.unwinding = nil
+	return p.val
+}
+
+// evalChan evaluates a channel used for dir, which is ChanSend or
+// ChanRecv.
+func (ev *evaluator) evalChan(e expr.Expr, env *Env, dir tipe.ChanDirection) (*Chan, error) {
+	v, err := ev.eval(e, env)
+	if err != nil {
+		return nil, err
+	}
+	return ev.checkChan(e, v, dir)
+}
+
+// checkChan checks that v, the value of e, is a channel usable for dir.
+func (ev *evaluator) checkChan(e expr.Expr, v Value, dir tipe.ChanDirection) (*Chan, error) {
+	ch, ok := v.(*Chan)
+	if !ok {
+		return nil, ev.errorf("%s is not a channel", format.Expr(e))
+	}
+	if ch.Dir != tipe.ChanBoth && ch.Dir != dir {
+		if dir == tipe.ChanSend {
+			return nil, ev.errorf("cannot send to receive-only channel %s", format.Expr(e))
+		}
+		return nil, ev.errorf("cannot receive from send-only channel %s", format.Expr(e))
+	}
+	return ch, nil
+}
+
+func sendCase(ch *Chan, v Value) reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectSend,
+		Chan: reflect.ValueOf(ch.C),
+		Send: reflect.ValueOf(&v).Elem(),
+	}
+}
+
+func recvCase(ch *Chan) reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ch.C),
+	}
+}
+
+// recvValue returns the Value of a receive from ch, or the zero
+// value of its element type if ch is closed.
+func (ch *Chan) recvValue(v reflect.Value, ok bool) Value {
+	if !ok {
+		return zeroValue(ch.Elem)
+	}
+	return v.Interface()
+}
+
+// selectCases runs reflect.Select on cases. If block is false the
+// select has a default case, reported as chosen == len(cases).
+// A blocking select that waits longer than DeadlockTimeout is
+// reported as an error.
+func (ev *evaluator) selectCases(cases []reflect.SelectCase, block bool) (chosen int, recv reflect.Value, recvOK bool, err error) {
+	n := len(cases)
+	if !block {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	} else if DeadlockTimeout > 0 {
+		timer := time.NewTimer(DeadlockTimeout)
+		defer timer.Stop()
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(timer.C),
+		})
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			err = ev.errorf("%v", x) // send on closed channel
+		}
+	}()
+	chosen, recv, recvOK = reflect.Select(cases)
+	if block && chosen == n {
+		return 0, reflect.Value{}, false, ev.errorf("deadlock: goroutine blocked on channel for %v", DeadlockTimeout)
+	}
+	return chosen, recv, recvOK, nil
+}
+
+func (ev *evaluator) execSelect(s *stmt.Select, env *Env) (*control, error) {
+	var cases []reflect.SelectCase
+	var chans []*Chan
+	var bodies []*stmt.Block
+	var recvs []*stmt.Assign // receive assignment, if any
+	var def *stmt.Block
+	for _, c := range s.Cases {
+		if c.Default {
+			def = c.Body
+			continue
+		}
+		var recvExpr expr.Expr
+		var assign *stmt.Assign
+		switch cs := c.Stmt.(type) {
+		case *stmt.Send:
+			ch, err := ev.evalChan(cs.Chan, env, tipe.ChanSend)
+			if err != nil {
+				return nil, err
+			}
+			v, err := ev.eval(cs.Value, env)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, sendCase(ch, v))
+			chans = append(chans, ch)
+			bodies = append(bodies, c.Body)
+			recvs = append(recvs, nil)
+			continue
+		case *stmt.Simple:
+			recvExpr = cs.Expr
+		case *stmt.Assign:
+			if len(cs.Right) == 1 && len(cs.Left) <= 2 {
+				recvExpr = cs.Right[0]
+				assign = cs
+			}
+		}
+		u, ok := recvExpr.(*expr.Unary)
+		if !ok || u.Op != token.ChanOp {
+			return nil, ev.errorf("select case must be receive or send")
+		}
+		ch, err := ev.evalChan(u.Expr, env, tipe.ChanRecv)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, recvCase(ch))
+		chans = append(chans, ch)
+		bodies = append(bodies, c.Body)
+		recvs = append(recvs, assign)
+	}
+
+	chosen, recv, recvOK, err := ev.selectCases(cases, def == nil)
+	if err != nil {
+		return nil, err
+	}
+	if chosen == len(cases) {
+		return ev.exec(def, env)
+	}
+	env = NewEnv(env)
+	if a := recvs[chosen]; a != nil {
+		vals := []Value{chans[chosen].recvValue(recv, recvOK), recvOK}
+		if err := ev.assign(a, env, vals[:len(a.Left)]); err != nil {
+			return nil, err
+		}
+	}
+	return ev.exec(bodies[chosen], env)
+}
+
+// zeroValue returns the zero Value of t.
+func zeroValue(t tipe.Type) Value {
+	if u, ok := t.(*tipe.Unresolved); ok && u.Package == "" {
+		// Eval does not type check, so builtin types are unresolved.
+		switch u.Name {
+		case "byte":
+			t = tipe.Uint8
+		case "rune":
+			t = tipe.Int32
+		default:
+			t = tipe.Basic(u.Name)
+		}
+	}
+	switch tipe.Underlying(t) {
+	case tipe.Bool:
+		return false
+	case tipe.String:
+		return ""
+	case tipe.Int, tipe.Int8, tipe.Int16, tipe.Int32, tipe.Int64,
+		tipe.Uint, tipe.Uint8, tipe.Uint16, tipe.Uint32, tipe.Uint64, tipe.Uintptr:
+		return new(big.Int)
+	case tipe.Float32, tipe.Float64:
+		return new(big.Float)
+	}
+	return nil
+}
+
+func (ev *evaluator) evalTableLiteral(e *expr.TableLiteral, env *Env) (Value, error) {
+	t := &Table{}
+	for _, c := range e.ColNames {
+		v, err := ev.eval(c, env)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := v.(string)
+		if !ok {
+			return nil, ev.errorf("table column name %s is not a string", format.Expr(c))
+		}
+		t.ColNames = append(t.ColNames, name)
+	}
+	numCols := len(t.ColNames)
+	if numCols == 0 && len(e.Rows) > 0 {
+		numCols = len(e.Rows[0])
+		t.ColNames = make([]string, numCols)
+	}
+	t.Cols = make([][]Value, numCols)
+	for i, row := range e.Rows {
+		if len(row) != numCols {
+			return nil, ev.errorf("table row %d has %d values, want %d", i, len(row), numCols)
+		}
+		for j, x := range row {
+			v, err := ev.eval(x, env)
+			if err != nil {
+				return nil, err
+			}
+			t.Cols[j] = append(t.Cols[j], v)
+		}
+	}
+	return t, nil
+}
+
+// evalIndex evaluates a table index, t[col, row].
+//
+// Either index may be a range. An exact column and row select a
+// single value, one range selects a []Value, and two ranges select
+// a *Table sharing the rows of t.
+func (ev *evaluator) evalIndex(e *expr.Index, env *Env) (Value, error) {
+	v, err := ev.eval(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := v.(*Table)
+	if !ok {
+		return nil, ev.errorf("cannot index %s", format.Expr(e.Left))
+	}
+	if len(e.Indicies) != 2 {
+		return nil, ev.errorf("table index %s needs a column and a row index", format.Expr(e))
+	}
+	c0, c1, colRange, err := ev.tableRange(e.Indicies[0], env, t, true)
+	if err != nil {
+		return nil, err
+	}
+	r0, r1, rowRange, err := ev.tableRange(e.Indicies[1], env, t, false)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !colRange && !rowRange:
+		return t.Cols[c0][r0], nil
+	case !colRange:
+		return t.Cols[c0][r0:r1], nil
+	case !rowRange:
+		var row []Value
+		for _, col := range t.Cols[c0:c1] {
+			row = append(row, col[r0])
+		}
+		return row, nil
+	}
+	sub := &Table{ColNames: t.ColNames[c0:c1]}
+	for _, col := range t.Cols[c0:c1] {
+		sub.Cols = append(sub.Cols, col[r0:r1])
+	}
+	return sub, nil
+}
+
+// tableRange evaluates a table column or row index. An exact index i
+// is reported as the range [i, i+1).
+func (ev *evaluator) tableRange(e expr.Expr, env *Env, t *Table, isCol bool) (start, end int, isRange bool, err error) {
+	n, what := t.Len(), "row"
+	if isCol {
+		n, what = len(t.Cols), "column"
+	}
+	s, isRange := e.(*expr.Slice)
+	if !isRange {
+		i, err := ev.tableIndex(e, env, t, isCol)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if i < 0 || i >= n {
+			return 0, 0, false, ev.errorf("table %s index %d out of range (table has %d %ss)", what, i, n, what)
+		}
+		return i, i + 1, false, nil
+	}
+	if s.Max != nil {
+		return 0, 0, false, ev.errorf("3-index slice of table")
+	}
+	start, end = 0, n
+	if s.Low != nil {
+		if start, err = ev.tableIndex(s.Low, env, t, isCol); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if s.High != nil {
+		if end, err = ev.tableIndex(s.High, env, t, isCol); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if start < 0 || start > end || end > n {
+		return 0, 0, false, ev.errorf("table %s range [%d:%d] out of range (table has %d %ss)", what, start, end, n, what)
+	}
+	return start, end, true, nil
+}
+
+func (ev *evaluator) tableIndex(e expr.Expr, env *Env, t *Table, isCol bool) (int, error) {
+	v, err := ev.eval(e, env)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case *big.Int:
+		if !v.IsInt64() {
+			return 0, ev.errorf("table index %s out of range", v)
+		}
+		return int(v.Int64()), nil
+	case string:
+		if isCol {
+			for i, name := range t.ColNames {
+				if name == v {
+					return i, nil
+				}
+			}
+			return 0, ev.errorf("table has no column %q", v)
+		}
+	}
+	return 0, ev.errorf("invalid table index %s", format.Expr(e))
+}
+
+func (ev *evaluator) evalConst(e expr.Expr, env *Env) (constant.Value, error) {
+	switch e := e.(type) {
+	case *expr.Unary:
+		x, err := ev.evalConst(e.Expr, env)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case token.LeftParen:
+			return x, nil
+		case token.Not:
+			if x.Kind() == constant.Bool {
+				return constant.MakeBool(!constant.BoolVal(x)), nil
+			}
+		case token.Add:
+			if isNumericConst(x) {
+				return x, nil
+			}
+		case token.Sub:
+			if isNumericConst(x) {
+				return constant.UnaryOp(gotoken.SUB, x, 0), nil
+			}
+		}
+		return nil, ev.errorf("invalid operation: %s (operator %s not defined on %s)", format.Expr(e), e.Op, x)
+	case *expr.Binary:
+		return ev.evalBinary(e, env)
+	}
+	v, err := ev.eval(e, env)
+	if err != nil {
+		return nil, err
+	}
+	return ev.toConst(e, v)
+}
+
+func (ev *evaluator) evalBinary(e *expr.Binary, env *Env) (constant.Value, error) {
+	if e.Op == token.Equal || e.Op == token.NotEqual {
+		return ev.evalEqual(e, env)
+	}
+	x, err := ev.evalConst(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	invalid := func(y constant.Value) error {
+		return ev.errorf("invalid operation: %s (mismatched values %s and %s)", format.Expr(e), x, y)
+	}
+
+	switch e.Op {
+	case token.LogicalAnd, token.LogicalOr:
+		if x.Kind() != constant.Bool {
+			return nil, ev.errorf("invalid operation: %s (operator %s not defined on %s)", format.Expr(e), e.Op, x)
+		}
+		if constant.BoolVal(x) == (e.Op == token.LogicalOr) {
+			return x, nil // short circuit
+		}
+		y, err := ev.evalConst(e.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		if y.Kind() != constant.Bool {
+			return nil, invalid(y)
+		}
+		return y, nil
+	}
+
+	y, err := ev.evalConst(e.Right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case token.Less, token.LessEqual, token.Greater, token.GreaterEqual:
+		if !sameKind(x, y) || x.Kind() == constant.Bool {
+			return nil, invalid(y)
+		}
+		return constant.MakeBool(constant.Compare(x, goOps[e.Op], y)), nil
+	case token.TwoLess, token.TwoGreater:
+		x = constant.ToInt(x)
+		y = constant.ToInt(y)
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil, invalid(y)
+		}
+		n, exact := constant.Uint64Val(y)
+		if !exact || n > maxShift {
+			return nil, ev.errorf("invalid shift count %s", y)
+		}
+		return constant.Shift(x, goOps[e.Op], uint(n)), nil
+	case token.Add:
+		if !sameKind(x, y) || x.Kind() == constant.Bool {
+			return nil, invalid(y)
+		}
+	case token.Sub, token.Mul, token.Div:
+		if !sameKind(x, y) || !isNumericConst(x) {
+			return nil, invalid(y)
+		}
+	case token.Rem, token.Pipe, token.Ref, token.Pow, token.RefPow:
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil, invalid(y)
+		}
+	default:
+		return nil, ev.errorf("unknown operator %s", e.Op)
+	}
+	if (e.Op == token.Div || e.Op == token.Rem) && constant.Sign(y) == 0 {
+		return nil, ev.errorf("%s: division by zero", format.Expr(e))
+	}
+	op := goOps[e.Op]
+	if e.Op == token.Div && x.Kind() == constant.Int && y.Kind() == constant.Int {
+		op = gotoken.QUO_ASSIGN // integer division
+	}
+	return constant.BinaryOp(x, op, y), nil
+}
+
+// evalEqual evaluates == and !=. Besides basic values it compares
+// channels, functions and tables by identity, and with nil.
+func (ev *evaluator) evalEqual(e *expr.Binary, env *Env) (constant.Value, error) {
+	x, err := ev.eval(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := ev.eval(e.Right, env)
+	if err != nil {
+		return nil, err
+	}
+	if isReference(x) || isReference(y) {
+		return constant.MakeBool((x == y) == (e.Op == token.Equal)), nil
+	}
+	cx, err := ev.toConst(e.Left, x)
+	if err != nil {
+		return nil, err
+	}
+	cy, err := ev.toConst(e.Right, y)
+	if err != nil {
+		return nil, err
+	}
+	if !sameKind(cx, cy) {
+		return nil, ev.errorf("invalid operation: %s (mismatched values %s and %s)", format.Expr(e), cx, cy)
+	}
+	return constant.MakeBool(constant.Compare(cx, goOps[e.Op], cy)), nil
+}
+
+func isReference(v Value) bool {
+	switch v.(type) {
+	case nil, *Chan, *FuncValue, *Table:
+		return true
+	}
+	return false
+}
+
+// maxShift limits the size of integers produced by shifts.
+const maxShift = 1 << 16
+
+var goOps = map[token.Token]gotoken.Token{
+	token.Add:          gotoken.ADD,
+	token.Sub:          gotoken.SUB,
+	token.Mul:          gotoken.MUL,
+	token.Div:          gotoken.QUO,
+	token.Rem:          gotoken.REM,
+	token.Pipe:         gotoken.OR,
+	token.Ref:          gotoken.AND,
+	token.Pow:          gotoken.XOR,
+	token.RefPow:       gotoken.AND_NOT,
+	token.TwoLess:      gotoken.SHL,
+	token.TwoGreater:   gotoken.SHR,
+	token.Equal:        gotoken.EQL,
+	token.NotEqual:     gotoken.NEQ,
+	token.Less:         gotoken.LSS,
+	token.LessEqual:    gotoken.LEQ,
+	token.Greater:      gotoken.GTR,
+	token.GreaterEqual: gotoken.GEQ,
+}
+
+func (ev *evaluator) toConst(e expr.Expr, v Value) (constant.Value, error) {
+	switch v := v.(type) {
+	case *big.Int:
+		return constant.Make(new(big.Int).Set(v)), nil
+	case *big.Float:
+		if v.IsInf() {
+			break
+		}
+		r, _ := v.Rat(nil)
+		return constant.Make(r), nil
+	case bool:
+		return constant.MakeBool(v), nil
+	case string:
+		return constant.MakeString(v), nil
+	}
+	return nil, ev.errorf("%s: unsupported value %v (type %T)", format.Expr(e), v, v)
+}
+
+func fromConst(v constant.Value) Value {
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Int:
+		i, _ := new(big.Int).SetString(v.ExactString(), 10)
+		return i
+	case constant.Float:
+		return constFloat(v)
+	}
+	panic(fmt.Sprintf("eval: unexpected constant %s", v))
+}
+
+func isNumericConst(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float:
+		return true
+	}
+	return false
+}
+
+func sameKind(x, y constant.Value) bool {
+	return x.Kind() == y.Kind() || isNumericConst(x) && isNumericConst(y)
+}
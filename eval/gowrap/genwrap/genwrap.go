@@ -34,6 +34,11 @@ func buildDataPkg(pkgPath string, pkg *types.Package) DataPkg {
 			continue
 		}
 		obj := scope.Lookup(name)
+		if isGeneric(obj) {
+			// A generic function or type has no value
+			// until it is instantiated.
+			continue
+		}
 		switch obj.(type) {
 		case *types.TypeName:
 			if _, ok := obj.Type().Underlying().(*types.Interface); ok {
@@ -118,6 +123,17 @@ importsLoop:
 	return res, nil
 }
 
+func isGeneric(obj types.Object) bool {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Type().(*types.Signature).TypeParams().Len() > 0
+	case *types.TypeName:
+		named, ok := obj.Type().(*types.Named)
+		return ok && named.TypeParams().Len() > 0
+	}
+	return false
+}
+
 func nilexpr(t types.Type) string {
 	t = t.Underlying()
 	switch t := t.(type) {
@@ -18,6 +18,7 @@
 // TODO do we want bufio? maybe write our own version.
 //go:generate go run genwrap.go bytes
 //go:generate go run genwrap.go path
+//go:generate go run genwrap.go sort
 //go:generate go run genwrap.go strings
 //go:generate go run genwrap.go unicode
 
@@ -33,6 +34,12 @@
 //go:generate go run genwrap.go fmt
 //go:generate go run genwrap.go time
 
+// Neugram:
+//go:generate go run genwrap.go neugram.io/ng/eval/http
+//go:generate go run genwrap.go neugram.io/ng/eval/io
+//go:generate go run genwrap.go neugram.io/ng/eval/re
+//go:generate go run genwrap.go neugram.io/ng/eval/stats
+
 package gowrap // import "neugram.io/ng/eval/gowrap"
 
 import "reflect"
@@ -0,0 +1,31 @@
+// Generated file, do not edit.
+
+package wrapbuiltin
+
+import (
+	"reflect"
+
+	"neugram.io/ng/eval/gowrap"
+
+	wrap_neugram_io_ng_eval_http "neugram.io/ng/eval/http"
+)
+
+var pkg_wrap_neugram_io_ng_eval_http = &gowrap.Pkg{
+	Exports: map[string]reflect.Value{
+
+		"DefaultMaxSize": reflect.ValueOf(wrap_neugram_io_ng_eval_http.DefaultMaxSize),
+		"Get":            reflect.ValueOf(wrap_neugram_io_ng_eval_http.Get),
+		"GetJSON":        reflect.ValueOf(wrap_neugram_io_ng_eval_http.GetJSON),
+		"Headers":        reflect.ValueOf(wrap_neugram_io_ng_eval_http.Headers),
+		"MaxRedirects":   reflect.ValueOf(wrap_neugram_io_ng_eval_http.MaxRedirects),
+		"Post":           reflect.ValueOf(wrap_neugram_io_ng_eval_http.Post),
+		"SetMaxSize":     reflect.ValueOf(wrap_neugram_io_ng_eval_http.SetMaxSize),
+		"SetTimeout":     reflect.ValueOf(wrap_neugram_io_ng_eval_http.SetTimeout),
+	},
+}
+
+func init() {
+	if gowrap.Pkgs["neugram.io/ng/eval/http"] == nil {
+		gowrap.Pkgs["neugram.io/ng/eval/http"] = pkg_wrap_neugram_io_ng_eval_http
+	}
+}
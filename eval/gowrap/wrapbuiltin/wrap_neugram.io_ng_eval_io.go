@@ -0,0 +1,33 @@
+// Generated file, do not edit.
+
+package wrapbuiltin
+
+import (
+	"reflect"
+
+	"neugram.io/ng/eval/gowrap"
+
+	wrap_neugram_io_ng_eval_io "neugram.io/ng/eval/io"
+)
+
+var pkg_wrap_neugram_io_ng_eval_io = &gowrap.Pkg{
+	Exports: map[string]reflect.Value{
+
+		"AppendFile": reflect.ValueOf(wrap_neugram_io_ng_eval_io.AppendFile),
+		"ErrSandbox": reflect.ValueOf(&wrap_neugram_io_ng_eval_io.ErrSandbox).Elem(),
+		"Exists":     reflect.ValueOf(wrap_neugram_io_ng_eval_io.Exists),
+		"ListDir":    reflect.ValueOf(wrap_neugram_io_ng_eval_io.ListDir),
+		"ReadFile":   reflect.ValueOf(wrap_neugram_io_ng_eval_io.ReadFile),
+		"ReadLines":  reflect.ValueOf(wrap_neugram_io_ng_eval_io.ReadLines),
+		"Remove":     reflect.ValueOf(wrap_neugram_io_ng_eval_io.Remove),
+		"Sandbox":    reflect.ValueOf(wrap_neugram_io_ng_eval_io.Sandbox),
+		"SetSandbox": reflect.ValueOf(wrap_neugram_io_ng_eval_io.SetSandbox),
+		"WriteFile":  reflect.ValueOf(wrap_neugram_io_ng_eval_io.WriteFile),
+	},
+}
+
+func init() {
+	if gowrap.Pkgs["neugram.io/ng/eval/io"] == nil {
+		gowrap.Pkgs["neugram.io/ng/eval/io"] = pkg_wrap_neugram_io_ng_eval_io
+	}
+}
@@ -0,0 +1,25 @@
+// Generated file, do not edit.
+
+package wrapbuiltin
+
+import (
+	"reflect"
+
+	"neugram.io/ng/eval/gowrap"
+
+	wrap_neugram_io_ng_eval_re "neugram.io/ng/eval/re"
+)
+
+var pkg_wrap_neugram_io_ng_eval_re = &gowrap.Pkg{
+	Exports: map[string]reflect.Value{
+
+		"Compile": reflect.ValueOf(wrap_neugram_io_ng_eval_re.Compile),
+		"Regexp":  reflect.ValueOf(reflect.TypeOf(wrap_neugram_io_ng_eval_re.Regexp{})),
+	},
+}
+
+func init() {
+	if gowrap.Pkgs["neugram.io/ng/eval/re"] == nil {
+		gowrap.Pkgs["neugram.io/ng/eval/re"] = pkg_wrap_neugram_io_ng_eval_re
+	}
+}
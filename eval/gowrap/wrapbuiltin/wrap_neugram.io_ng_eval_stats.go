@@ -0,0 +1,29 @@
+// Generated file, do not edit.
+
+package wrapbuiltin
+
+import (
+	"reflect"
+
+	"neugram.io/ng/eval/gowrap"
+
+	wrap_neugram_io_ng_eval_stats "neugram.io/ng/eval/stats"
+)
+
+var pkg_wrap_neugram_io_ng_eval_stats = &gowrap.Pkg{
+	Exports: map[string]reflect.Value{
+
+		"Covariance": reflect.ValueOf(wrap_neugram_io_ng_eval_stats.Covariance),
+		"Mean":       reflect.ValueOf(wrap_neugram_io_ng_eval_stats.Mean),
+		"Median":     reflect.ValueOf(wrap_neugram_io_ng_eval_stats.Median),
+		"Quantile":   reflect.ValueOf(wrap_neugram_io_ng_eval_stats.Quantile),
+		"StdDev":     reflect.ValueOf(wrap_neugram_io_ng_eval_stats.StdDev),
+		"Variance":   reflect.ValueOf(wrap_neugram_io_ng_eval_stats.Variance),
+	},
+}
+
+func init() {
+	if gowrap.Pkgs["neugram.io/ng/eval/stats"] == nil {
+		gowrap.Pkgs["neugram.io/ng/eval/stats"] = pkg_wrap_neugram_io_ng_eval_stats
+	}
+}
@@ -0,0 +1,45 @@
+// Generated file, do not edit.
+
+package wrapbuiltin
+
+import (
+	"reflect"
+
+	"neugram.io/ng/eval/gowrap"
+
+	wrap_sort "sort"
+)
+
+var pkg_wrap_sort = &gowrap.Pkg{
+	Exports: map[string]reflect.Value{
+
+		"Find":              reflect.ValueOf(wrap_sort.Find),
+		"Float64Slice":      reflect.ValueOf(reflect.TypeOf(wrap_sort.Float64Slice(nil))),
+		"Float64s":          reflect.ValueOf(wrap_sort.Float64s),
+		"Float64sAreSorted": reflect.ValueOf(wrap_sort.Float64sAreSorted),
+		"IntSlice":          reflect.ValueOf(reflect.TypeOf(wrap_sort.IntSlice(nil))),
+		"Interface":         reflect.ValueOf(reflect.TypeOf((*wrap_sort.Interface)(nil)).Elem()),
+		"Ints":              reflect.ValueOf(wrap_sort.Ints),
+		"IntsAreSorted":     reflect.ValueOf(wrap_sort.IntsAreSorted),
+		"IsSorted":          reflect.ValueOf(wrap_sort.IsSorted),
+		"Reverse":           reflect.ValueOf(wrap_sort.Reverse),
+		"Search":            reflect.ValueOf(wrap_sort.Search),
+		"SearchFloat64s":    reflect.ValueOf(wrap_sort.SearchFloat64s),
+		"SearchInts":        reflect.ValueOf(wrap_sort.SearchInts),
+		"SearchStrings":     reflect.ValueOf(wrap_sort.SearchStrings),
+		"Slice":             reflect.ValueOf(wrap_sort.Slice),
+		"SliceIsSorted":     reflect.ValueOf(wrap_sort.SliceIsSorted),
+		"SliceStable":       reflect.ValueOf(wrap_sort.SliceStable),
+		"Sort":              reflect.ValueOf(wrap_sort.Sort),
+		"Stable":            reflect.ValueOf(wrap_sort.Stable),
+		"StringSlice":       reflect.ValueOf(reflect.TypeOf(wrap_sort.StringSlice(nil))),
+		"Strings":           reflect.ValueOf(wrap_sort.Strings),
+		"StringsAreSorted":  reflect.ValueOf(wrap_sort.StringsAreSorted),
+	},
+}
+
+func init() {
+	if gowrap.Pkgs["sort"] == nil {
+		gowrap.Pkgs["sort"] = pkg_wrap_sort
+	}
+}
@@ -13,158 +13,158 @@ import (
 var pkg_wrap_unicode = &gowrap.Pkg{
 	Exports: map[string]reflect.Value{
 
-		"ASCII_Hex_Digit":       reflect.ValueOf(&wrap_unicode.ASCII_Hex_Digit).Elem(),
-		"Adlam":                 reflect.ValueOf(&wrap_unicode.Adlam).Elem(),
-		"Ahom":                  reflect.ValueOf(&wrap_unicode.Ahom).Elem(),
-		"Anatolian_Hieroglyphs": reflect.ValueOf(&wrap_unicode.Anatolian_Hieroglyphs).Elem(),
-		"Arabic":                reflect.ValueOf(&wrap_unicode.Arabic).Elem(),
-		"Armenian":              reflect.ValueOf(&wrap_unicode.Armenian).Elem(),
-		"Avestan":               reflect.ValueOf(&wrap_unicode.Avestan).Elem(),
-		"AzeriCase":             reflect.ValueOf(&wrap_unicode.AzeriCase).Elem(),
-		"Balinese":              reflect.ValueOf(&wrap_unicode.Balinese).Elem(),
-		"Bamum":                 reflect.ValueOf(&wrap_unicode.Bamum).Elem(),
-		"Bassa_Vah":             reflect.ValueOf(&wrap_unicode.Bassa_Vah).Elem(),
-		"Batak":                 reflect.ValueOf(&wrap_unicode.Batak).Elem(),
-		"Bengali":               reflect.ValueOf(&wrap_unicode.Bengali).Elem(),
-		"Bhaiksuki":             reflect.ValueOf(&wrap_unicode.Bhaiksuki).Elem(),
-		"Bidi_Control":          reflect.ValueOf(&wrap_unicode.Bidi_Control).Elem(),
-		"Bopomofo":              reflect.ValueOf(&wrap_unicode.Bopomofo).Elem(),
-		"Brahmi":                reflect.ValueOf(&wrap_unicode.Brahmi).Elem(),
-		"Braille":               reflect.ValueOf(&wrap_unicode.Braille).Elem(),
-		"Buginese":              reflect.ValueOf(&wrap_unicode.Buginese).Elem(),
-		"Buhid":                 reflect.ValueOf(&wrap_unicode.Buhid).Elem(),
-		"C":                     reflect.ValueOf(&wrap_unicode.C).Elem(),
-		"Canadian_Aboriginal":    reflect.ValueOf(&wrap_unicode.Canadian_Aboriginal).Elem(),
-		"Carian":                 reflect.ValueOf(&wrap_unicode.Carian).Elem(),
-		"CaseRange":              reflect.ValueOf(reflect.TypeOf(wrap_unicode.CaseRange{})),
-		"CaseRanges":             reflect.ValueOf(&wrap_unicode.CaseRanges).Elem(),
-		"Categories":             reflect.ValueOf(&wrap_unicode.Categories).Elem(),
-		"Caucasian_Albanian":     reflect.ValueOf(&wrap_unicode.Caucasian_Albanian).Elem(),
-		"Cc":                     reflect.ValueOf(&wrap_unicode.Cc).Elem(),
-		"Cf":                     reflect.ValueOf(&wrap_unicode.Cf).Elem(),
-		"Chakma":                 reflect.ValueOf(&wrap_unicode.Chakma).Elem(),
-		"Cham":                   reflect.ValueOf(&wrap_unicode.Cham).Elem(),
-		"Cherokee":               reflect.ValueOf(&wrap_unicode.Cherokee).Elem(),
-		"Co":                     reflect.ValueOf(&wrap_unicode.Co).Elem(),
-		"Common":                 reflect.ValueOf(&wrap_unicode.Common).Elem(),
-		"Coptic":                 reflect.ValueOf(&wrap_unicode.Coptic).Elem(),
-		"Cs":                     reflect.ValueOf(&wrap_unicode.Cs).Elem(),
-		"Cuneiform":              reflect.ValueOf(&wrap_unicode.Cuneiform).Elem(),
-		"Cypriot":                reflect.ValueOf(&wrap_unicode.Cypriot).Elem(),
-		"Cyrillic":               reflect.ValueOf(&wrap_unicode.Cyrillic).Elem(),
-		"Dash":                   reflect.ValueOf(&wrap_unicode.Dash).Elem(),
-		"Deprecated":             reflect.ValueOf(&wrap_unicode.Deprecated).Elem(),
-		"Deseret":                reflect.ValueOf(&wrap_unicode.Deseret).Elem(),
-		"Devanagari":             reflect.ValueOf(&wrap_unicode.Devanagari).Elem(),
-		"Diacritic":              reflect.ValueOf(&wrap_unicode.Diacritic).Elem(),
-		"Digit":                  reflect.ValueOf(&wrap_unicode.Digit).Elem(),
-		"Duployan":               reflect.ValueOf(&wrap_unicode.Duployan).Elem(),
-		"Egyptian_Hieroglyphs":   reflect.ValueOf(&wrap_unicode.Egyptian_Hieroglyphs).Elem(),
-		"Elbasan":                reflect.ValueOf(&wrap_unicode.Elbasan).Elem(),
-		"Ethiopic":               reflect.ValueOf(&wrap_unicode.Ethiopic).Elem(),
-		"Extender":               reflect.ValueOf(&wrap_unicode.Extender).Elem(),
-		"FoldCategory":           reflect.ValueOf(&wrap_unicode.FoldCategory).Elem(),
-		"FoldScript":             reflect.ValueOf(&wrap_unicode.FoldScript).Elem(),
-		"Georgian":               reflect.ValueOf(&wrap_unicode.Georgian).Elem(),
-		"Glagolitic":             reflect.ValueOf(&wrap_unicode.Glagolitic).Elem(),
-		"Gothic":                 reflect.ValueOf(&wrap_unicode.Gothic).Elem(),
-		"Grantha":                reflect.ValueOf(&wrap_unicode.Grantha).Elem(),
-		"GraphicRanges":          reflect.ValueOf(&wrap_unicode.GraphicRanges).Elem(),
-		"Greek":                  reflect.ValueOf(&wrap_unicode.Greek).Elem(),
-		"Gujarati":               reflect.ValueOf(&wrap_unicode.Gujarati).Elem(),
-		"Gurmukhi":               reflect.ValueOf(&wrap_unicode.Gurmukhi).Elem(),
-		"Han":                    reflect.ValueOf(&wrap_unicode.Han).Elem(),
-		"Hangul":                 reflect.ValueOf(&wrap_unicode.Hangul).Elem(),
-		"Hanunoo":                reflect.ValueOf(&wrap_unicode.Hanunoo).Elem(),
-		"Hatran":                 reflect.ValueOf(&wrap_unicode.Hatran).Elem(),
-		"Hebrew":                 reflect.ValueOf(&wrap_unicode.Hebrew).Elem(),
-		"Hex_Digit":              reflect.ValueOf(&wrap_unicode.Hex_Digit).Elem(),
-		"Hiragana":               reflect.ValueOf(&wrap_unicode.Hiragana).Elem(),
-		"Hyphen":                 reflect.ValueOf(&wrap_unicode.Hyphen).Elem(),
-		"IDS_Binary_Operator":    reflect.ValueOf(&wrap_unicode.IDS_Binary_Operator).Elem(),
-		"IDS_Trinary_Operator":   reflect.ValueOf(&wrap_unicode.IDS_Trinary_Operator).Elem(),
-		"Ideographic":            reflect.ValueOf(&wrap_unicode.Ideographic).Elem(),
-		"Imperial_Aramaic":       reflect.ValueOf(&wrap_unicode.Imperial_Aramaic).Elem(),
-		"In":                     reflect.ValueOf(wrap_unicode.In),
-		"Inherited":              reflect.ValueOf(&wrap_unicode.Inherited).Elem(),
-		"Inscriptional_Pahlavi":  reflect.ValueOf(&wrap_unicode.Inscriptional_Pahlavi).Elem(),
-		"Inscriptional_Parthian": reflect.ValueOf(&wrap_unicode.Inscriptional_Parthian).Elem(),
-		"Is":           reflect.ValueOf(wrap_unicode.Is),
-		"IsControl":    reflect.ValueOf(wrap_unicode.IsControl),
-		"IsDigit":      reflect.ValueOf(wrap_unicode.IsDigit),
-		"IsGraphic":    reflect.ValueOf(wrap_unicode.IsGraphic),
-		"IsLetter":     reflect.ValueOf(wrap_unicode.IsLetter),
-		"IsLower":      reflect.ValueOf(wrap_unicode.IsLower),
-		"IsMark":       reflect.ValueOf(wrap_unicode.IsMark),
-		"IsNumber":     reflect.ValueOf(wrap_unicode.IsNumber),
-		"IsOneOf":      reflect.ValueOf(wrap_unicode.IsOneOf),
-		"IsPrint":      reflect.ValueOf(wrap_unicode.IsPrint),
-		"IsPunct":      reflect.ValueOf(wrap_unicode.IsPunct),
-		"IsSpace":      reflect.ValueOf(wrap_unicode.IsSpace),
-		"IsSymbol":     reflect.ValueOf(wrap_unicode.IsSymbol),
-		"IsTitle":      reflect.ValueOf(wrap_unicode.IsTitle),
-		"IsUpper":      reflect.ValueOf(wrap_unicode.IsUpper),
-		"Javanese":     reflect.ValueOf(&wrap_unicode.Javanese).Elem(),
-		"Join_Control": reflect.ValueOf(&wrap_unicode.Join_Control).Elem(),
-		"Kaithi":       reflect.ValueOf(&wrap_unicode.Kaithi).Elem(),
-		"Kannada":      reflect.ValueOf(&wrap_unicode.Kannada).Elem(),
-		"Katakana":     reflect.ValueOf(&wrap_unicode.Katakana).Elem(),
-		"Kayah_Li":     reflect.ValueOf(&wrap_unicode.Kayah_Li).Elem(),
-		"Kharoshthi":   reflect.ValueOf(&wrap_unicode.Kharoshthi).Elem(),
-		"Khmer":        reflect.ValueOf(&wrap_unicode.Khmer).Elem(),
-		"Khojki":       reflect.ValueOf(&wrap_unicode.Khojki).Elem(),
-		"Khudawadi":    reflect.ValueOf(&wrap_unicode.Khudawadi).Elem(),
-		"L":            reflect.ValueOf(&wrap_unicode.L).Elem(),
-		"Lao":          reflect.ValueOf(&wrap_unicode.Lao).Elem(),
-		"Latin":        reflect.ValueOf(&wrap_unicode.Latin).Elem(),
-		"Lepcha":       reflect.ValueOf(&wrap_unicode.Lepcha).Elem(),
-		"Letter":       reflect.ValueOf(&wrap_unicode.Letter).Elem(),
-		"Limbu":        reflect.ValueOf(&wrap_unicode.Limbu).Elem(),
-		"Linear_A":     reflect.ValueOf(&wrap_unicode.Linear_A).Elem(),
-		"Linear_B":     reflect.ValueOf(&wrap_unicode.Linear_B).Elem(),
-		"Lisu":         reflect.ValueOf(&wrap_unicode.Lisu).Elem(),
-		"Ll":           reflect.ValueOf(&wrap_unicode.Ll).Elem(),
-		"Lm":           reflect.ValueOf(&wrap_unicode.Lm).Elem(),
-		"Lo":           reflect.ValueOf(&wrap_unicode.Lo).Elem(),
-		"Logical_Order_Exception": reflect.ValueOf(&wrap_unicode.Logical_Order_Exception).Elem(),
-		"Lower":                   reflect.ValueOf(&wrap_unicode.Lower).Elem(),
-		"LowerCase":               reflect.ValueOf(wrap_unicode.LowerCase),
-		"Lt":                      reflect.ValueOf(&wrap_unicode.Lt).Elem(),
-		"Lu":                      reflect.ValueOf(&wrap_unicode.Lu).Elem(),
-		"Lycian":                  reflect.ValueOf(&wrap_unicode.Lycian).Elem(),
-		"Lydian":                  reflect.ValueOf(&wrap_unicode.Lydian).Elem(),
-		"M":                       reflect.ValueOf(&wrap_unicode.M).Elem(),
-		"Mahajani":                reflect.ValueOf(&wrap_unicode.Mahajani).Elem(),
-		"Malayalam":               reflect.ValueOf(&wrap_unicode.Malayalam).Elem(),
-		"Mandaic":                 reflect.ValueOf(&wrap_unicode.Mandaic).Elem(),
-		"Manichaean":              reflect.ValueOf(&wrap_unicode.Manichaean).Elem(),
-		"Marchen":                 reflect.ValueOf(&wrap_unicode.Marchen).Elem(),
-		"Mark":                    reflect.ValueOf(&wrap_unicode.Mark).Elem(),
-		"MaxASCII":                reflect.ValueOf(wrap_unicode.MaxASCII),
-		"MaxCase":                 reflect.ValueOf(wrap_unicode.MaxCase),
-		"MaxLatin1":               reflect.ValueOf(wrap_unicode.MaxLatin1),
-		"MaxRune":                 reflect.ValueOf(wrap_unicode.MaxRune),
-		"Mc":                      reflect.ValueOf(&wrap_unicode.Mc).Elem(),
-		"Me":                      reflect.ValueOf(&wrap_unicode.Me).Elem(),
-		"Meetei_Mayek":            reflect.ValueOf(&wrap_unicode.Meetei_Mayek).Elem(),
-		"Mende_Kikakui":           reflect.ValueOf(&wrap_unicode.Mende_Kikakui).Elem(),
-		"Meroitic_Cursive":        reflect.ValueOf(&wrap_unicode.Meroitic_Cursive).Elem(),
-		"Meroitic_Hieroglyphs":    reflect.ValueOf(&wrap_unicode.Meroitic_Hieroglyphs).Elem(),
-		"Miao":                    reflect.ValueOf(&wrap_unicode.Miao).Elem(),
-		"Mn":                      reflect.ValueOf(&wrap_unicode.Mn).Elem(),
-		"Modi":                    reflect.ValueOf(&wrap_unicode.Modi).Elem(),
-		"Mongolian":               reflect.ValueOf(&wrap_unicode.Mongolian).Elem(),
-		"Mro":                     reflect.ValueOf(&wrap_unicode.Mro).Elem(),
-		"Multani":                 reflect.ValueOf(&wrap_unicode.Multani).Elem(),
-		"Myanmar":                 reflect.ValueOf(&wrap_unicode.Myanmar).Elem(),
-		"N":                       reflect.ValueOf(&wrap_unicode.N).Elem(),
-		"Nabataean":               reflect.ValueOf(&wrap_unicode.Nabataean).Elem(),
-		"Nd":                      reflect.ValueOf(&wrap_unicode.Nd).Elem(),
-		"New_Tai_Lue":             reflect.ValueOf(&wrap_unicode.New_Tai_Lue).Elem(),
-		"Newa":                    reflect.ValueOf(&wrap_unicode.Newa).Elem(),
-		"Nko":                     reflect.ValueOf(&wrap_unicode.Nko).Elem(),
-		"Nl":                      reflect.ValueOf(&wrap_unicode.Nl).Elem(),
-		"No":                      reflect.ValueOf(&wrap_unicode.No).Elem(),
+		"ASCII_Hex_Digit":                    reflect.ValueOf(&wrap_unicode.ASCII_Hex_Digit).Elem(),
+		"Adlam":                              reflect.ValueOf(&wrap_unicode.Adlam).Elem(),
+		"Ahom":                               reflect.ValueOf(&wrap_unicode.Ahom).Elem(),
+		"Anatolian_Hieroglyphs":              reflect.ValueOf(&wrap_unicode.Anatolian_Hieroglyphs).Elem(),
+		"Arabic":                             reflect.ValueOf(&wrap_unicode.Arabic).Elem(),
+		"Armenian":                           reflect.ValueOf(&wrap_unicode.Armenian).Elem(),
+		"Avestan":                            reflect.ValueOf(&wrap_unicode.Avestan).Elem(),
+		"AzeriCase":                          reflect.ValueOf(&wrap_unicode.AzeriCase).Elem(),
+		"Balinese":                           reflect.ValueOf(&wrap_unicode.Balinese).Elem(),
+		"Bamum":                              reflect.ValueOf(&wrap_unicode.Bamum).Elem(),
+		"Bassa_Vah":                          reflect.ValueOf(&wrap_unicode.Bassa_Vah).Elem(),
+		"Batak":                              reflect.ValueOf(&wrap_unicode.Batak).Elem(),
+		"Bengali":                            reflect.ValueOf(&wrap_unicode.Bengali).Elem(),
+		"Bhaiksuki":                          reflect.ValueOf(&wrap_unicode.Bhaiksuki).Elem(),
+		"Bidi_Control":                       reflect.ValueOf(&wrap_unicode.Bidi_Control).Elem(),
+		"Bopomofo":                           reflect.ValueOf(&wrap_unicode.Bopomofo).Elem(),
+		"Brahmi":                             reflect.ValueOf(&wrap_unicode.Brahmi).Elem(),
+		"Braille":                            reflect.ValueOf(&wrap_unicode.Braille).Elem(),
+		"Buginese":                           reflect.ValueOf(&wrap_unicode.Buginese).Elem(),
+		"Buhid":                              reflect.ValueOf(&wrap_unicode.Buhid).Elem(),
+		"C":                                  reflect.ValueOf(&wrap_unicode.C).Elem(),
+		"Canadian_Aboriginal":                reflect.ValueOf(&wrap_unicode.Canadian_Aboriginal).Elem(),
+		"Carian":                             reflect.ValueOf(&wrap_unicode.Carian).Elem(),
+		"CaseRange":                          reflect.ValueOf(reflect.TypeOf(wrap_unicode.CaseRange{})),
+		"CaseRanges":                         reflect.ValueOf(&wrap_unicode.CaseRanges).Elem(),
+		"Categories":                         reflect.ValueOf(&wrap_unicode.Categories).Elem(),
+		"Caucasian_Albanian":                 reflect.ValueOf(&wrap_unicode.Caucasian_Albanian).Elem(),
+		"Cc":                                 reflect.ValueOf(&wrap_unicode.Cc).Elem(),
+		"Cf":                                 reflect.ValueOf(&wrap_unicode.Cf).Elem(),
+		"Chakma":                             reflect.ValueOf(&wrap_unicode.Chakma).Elem(),
+		"Cham":                               reflect.ValueOf(&wrap_unicode.Cham).Elem(),
+		"Cherokee":                           reflect.ValueOf(&wrap_unicode.Cherokee).Elem(),
+		"Co":                                 reflect.ValueOf(&wrap_unicode.Co).Elem(),
+		"Common":                             reflect.ValueOf(&wrap_unicode.Common).Elem(),
+		"Coptic":                             reflect.ValueOf(&wrap_unicode.Coptic).Elem(),
+		"Cs":                                 reflect.ValueOf(&wrap_unicode.Cs).Elem(),
+		"Cuneiform":                          reflect.ValueOf(&wrap_unicode.Cuneiform).Elem(),
+		"Cypriot":                            reflect.ValueOf(&wrap_unicode.Cypriot).Elem(),
+		"Cyrillic":                           reflect.ValueOf(&wrap_unicode.Cyrillic).Elem(),
+		"Dash":                               reflect.ValueOf(&wrap_unicode.Dash).Elem(),
+		"Deprecated":                         reflect.ValueOf(&wrap_unicode.Deprecated).Elem(),
+		"Deseret":                            reflect.ValueOf(&wrap_unicode.Deseret).Elem(),
+		"Devanagari":                         reflect.ValueOf(&wrap_unicode.Devanagari).Elem(),
+		"Diacritic":                          reflect.ValueOf(&wrap_unicode.Diacritic).Elem(),
+		"Digit":                              reflect.ValueOf(&wrap_unicode.Digit).Elem(),
+		"Duployan":                           reflect.ValueOf(&wrap_unicode.Duployan).Elem(),
+		"Egyptian_Hieroglyphs":               reflect.ValueOf(&wrap_unicode.Egyptian_Hieroglyphs).Elem(),
+		"Elbasan":                            reflect.ValueOf(&wrap_unicode.Elbasan).Elem(),
+		"Ethiopic":                           reflect.ValueOf(&wrap_unicode.Ethiopic).Elem(),
+		"Extender":                           reflect.ValueOf(&wrap_unicode.Extender).Elem(),
+		"FoldCategory":                       reflect.ValueOf(&wrap_unicode.FoldCategory).Elem(),
+		"FoldScript":                         reflect.ValueOf(&wrap_unicode.FoldScript).Elem(),
+		"Georgian":                           reflect.ValueOf(&wrap_unicode.Georgian).Elem(),
+		"Glagolitic":                         reflect.ValueOf(&wrap_unicode.Glagolitic).Elem(),
+		"Gothic":                             reflect.ValueOf(&wrap_unicode.Gothic).Elem(),
+		"Grantha":                            reflect.ValueOf(&wrap_unicode.Grantha).Elem(),
+		"GraphicRanges":                      reflect.ValueOf(&wrap_unicode.GraphicRanges).Elem(),
+		"Greek":                              reflect.ValueOf(&wrap_unicode.Greek).Elem(),
+		"Gujarati":                           reflect.ValueOf(&wrap_unicode.Gujarati).Elem(),
+		"Gurmukhi":                           reflect.ValueOf(&wrap_unicode.Gurmukhi).Elem(),
+		"Han":                                reflect.ValueOf(&wrap_unicode.Han).Elem(),
+		"Hangul":                             reflect.ValueOf(&wrap_unicode.Hangul).Elem(),
+		"Hanunoo":                            reflect.ValueOf(&wrap_unicode.Hanunoo).Elem(),
+		"Hatran":                             reflect.ValueOf(&wrap_unicode.Hatran).Elem(),
+		"Hebrew":                             reflect.ValueOf(&wrap_unicode.Hebrew).Elem(),
+		"Hex_Digit":                          reflect.ValueOf(&wrap_unicode.Hex_Digit).Elem(),
+		"Hiragana":                           reflect.ValueOf(&wrap_unicode.Hiragana).Elem(),
+		"Hyphen":                             reflect.ValueOf(&wrap_unicode.Hyphen).Elem(),
+		"IDS_Binary_Operator":                reflect.ValueOf(&wrap_unicode.IDS_Binary_Operator).Elem(),
+		"IDS_Trinary_Operator":               reflect.ValueOf(&wrap_unicode.IDS_Trinary_Operator).Elem(),
+		"Ideographic":                        reflect.ValueOf(&wrap_unicode.Ideographic).Elem(),
+		"Imperial_Aramaic":                   reflect.ValueOf(&wrap_unicode.Imperial_Aramaic).Elem(),
+		"In":                                 reflect.ValueOf(wrap_unicode.In),
+		"Inherited":                          reflect.ValueOf(&wrap_unicode.Inherited).Elem(),
+		"Inscriptional_Pahlavi":              reflect.ValueOf(&wrap_unicode.Inscriptional_Pahlavi).Elem(),
+		"Inscriptional_Parthian":             reflect.ValueOf(&wrap_unicode.Inscriptional_Parthian).Elem(),
+		"Is":                                 reflect.ValueOf(wrap_unicode.Is),
+		"IsControl":                          reflect.ValueOf(wrap_unicode.IsControl),
+		"IsDigit":                            reflect.ValueOf(wrap_unicode.IsDigit),
+		"IsGraphic":                          reflect.ValueOf(wrap_unicode.IsGraphic),
+		"IsLetter":                           reflect.ValueOf(wrap_unicode.IsLetter),
+		"IsLower":                            reflect.ValueOf(wrap_unicode.IsLower),
+		"IsMark":                             reflect.ValueOf(wrap_unicode.IsMark),
+		"IsNumber":                           reflect.ValueOf(wrap_unicode.IsNumber),
+		"IsOneOf":                            reflect.ValueOf(wrap_unicode.IsOneOf),
+		"IsPrint":                            reflect.ValueOf(wrap_unicode.IsPrint),
+		"IsPunct":                            reflect.ValueOf(wrap_unicode.IsPunct),
+		"IsSpace":                            reflect.ValueOf(wrap_unicode.IsSpace),
+		"IsSymbol":                           reflect.ValueOf(wrap_unicode.IsSymbol),
+		"IsTitle":                            reflect.ValueOf(wrap_unicode.IsTitle),
+		"IsUpper":                            reflect.ValueOf(wrap_unicode.IsUpper),
+		"Javanese":                           reflect.ValueOf(&wrap_unicode.Javanese).Elem(),
+		"Join_Control":                       reflect.ValueOf(&wrap_unicode.Join_Control).Elem(),
+		"Kaithi":                             reflect.ValueOf(&wrap_unicode.Kaithi).Elem(),
+		"Kannada":                            reflect.ValueOf(&wrap_unicode.Kannada).Elem(),
+		"Katakana":                           reflect.ValueOf(&wrap_unicode.Katakana).Elem(),
+		"Kayah_Li":                           reflect.ValueOf(&wrap_unicode.Kayah_Li).Elem(),
+		"Kharoshthi":                         reflect.ValueOf(&wrap_unicode.Kharoshthi).Elem(),
+		"Khmer":                              reflect.ValueOf(&wrap_unicode.Khmer).Elem(),
+		"Khojki":                             reflect.ValueOf(&wrap_unicode.Khojki).Elem(),
+		"Khudawadi":                          reflect.ValueOf(&wrap_unicode.Khudawadi).Elem(),
+		"L":                                  reflect.ValueOf(&wrap_unicode.L).Elem(),
+		"Lao":                                reflect.ValueOf(&wrap_unicode.Lao).Elem(),
+		"Latin":                              reflect.ValueOf(&wrap_unicode.Latin).Elem(),
+		"Lepcha":                             reflect.ValueOf(&wrap_unicode.Lepcha).Elem(),
+		"Letter":                             reflect.ValueOf(&wrap_unicode.Letter).Elem(),
+		"Limbu":                              reflect.ValueOf(&wrap_unicode.Limbu).Elem(),
+		"Linear_A":                           reflect.ValueOf(&wrap_unicode.Linear_A).Elem(),
+		"Linear_B":                           reflect.ValueOf(&wrap_unicode.Linear_B).Elem(),
+		"Lisu":                               reflect.ValueOf(&wrap_unicode.Lisu).Elem(),
+		"Ll":                                 reflect.ValueOf(&wrap_unicode.Ll).Elem(),
+		"Lm":                                 reflect.ValueOf(&wrap_unicode.Lm).Elem(),
+		"Lo":                                 reflect.ValueOf(&wrap_unicode.Lo).Elem(),
+		"Logical_Order_Exception":            reflect.ValueOf(&wrap_unicode.Logical_Order_Exception).Elem(),
+		"Lower":                              reflect.ValueOf(&wrap_unicode.Lower).Elem(),
+		"LowerCase":                          reflect.ValueOf(wrap_unicode.LowerCase),
+		"Lt":                                 reflect.ValueOf(&wrap_unicode.Lt).Elem(),
+		"Lu":                                 reflect.ValueOf(&wrap_unicode.Lu).Elem(),
+		"Lycian":                             reflect.ValueOf(&wrap_unicode.Lycian).Elem(),
+		"Lydian":                             reflect.ValueOf(&wrap_unicode.Lydian).Elem(),
+		"M":                                  reflect.ValueOf(&wrap_unicode.M).Elem(),
+		"Mahajani":                           reflect.ValueOf(&wrap_unicode.Mahajani).Elem(),
+		"Malayalam":                          reflect.ValueOf(&wrap_unicode.Malayalam).Elem(),
+		"Mandaic":                            reflect.ValueOf(&wrap_unicode.Mandaic).Elem(),
+		"Manichaean":                         reflect.ValueOf(&wrap_unicode.Manichaean).Elem(),
+		"Marchen":                            reflect.ValueOf(&wrap_unicode.Marchen).Elem(),
+		"Mark":                               reflect.ValueOf(&wrap_unicode.Mark).Elem(),
+		"MaxASCII":                           reflect.ValueOf(wrap_unicode.MaxASCII),
+		"MaxCase":                            reflect.ValueOf(wrap_unicode.MaxCase),
+		"MaxLatin1":                          reflect.ValueOf(wrap_unicode.MaxLatin1),
+		"MaxRune":                            reflect.ValueOf(wrap_unicode.MaxRune),
+		"Mc":                                 reflect.ValueOf(&wrap_unicode.Mc).Elem(),
+		"Me":                                 reflect.ValueOf(&wrap_unicode.Me).Elem(),
+		"Meetei_Mayek":                       reflect.ValueOf(&wrap_unicode.Meetei_Mayek).Elem(),
+		"Mende_Kikakui":                      reflect.ValueOf(&wrap_unicode.Mende_Kikakui).Elem(),
+		"Meroitic_Cursive":                   reflect.ValueOf(&wrap_unicode.Meroitic_Cursive).Elem(),
+		"Meroitic_Hieroglyphs":               reflect.ValueOf(&wrap_unicode.Meroitic_Hieroglyphs).Elem(),
+		"Miao":                               reflect.ValueOf(&wrap_unicode.Miao).Elem(),
+		"Mn":                                 reflect.ValueOf(&wrap_unicode.Mn).Elem(),
+		"Modi":                               reflect.ValueOf(&wrap_unicode.Modi).Elem(),
+		"Mongolian":                          reflect.ValueOf(&wrap_unicode.Mongolian).Elem(),
+		"Mro":                                reflect.ValueOf(&wrap_unicode.Mro).Elem(),
+		"Multani":                            reflect.ValueOf(&wrap_unicode.Multani).Elem(),
+		"Myanmar":                            reflect.ValueOf(&wrap_unicode.Myanmar).Elem(),
+		"N":                                  reflect.ValueOf(&wrap_unicode.N).Elem(),
+		"Nabataean":                          reflect.ValueOf(&wrap_unicode.Nabataean).Elem(),
+		"Nd":                                 reflect.ValueOf(&wrap_unicode.Nd).Elem(),
+		"New_Tai_Lue":                        reflect.ValueOf(&wrap_unicode.New_Tai_Lue).Elem(),
+		"Newa":                               reflect.ValueOf(&wrap_unicode.Newa).Elem(),
+		"Nko":                                reflect.ValueOf(&wrap_unicode.Nko).Elem(),
+		"Nl":                                 reflect.ValueOf(&wrap_unicode.Nl).Elem(),
+		"No":                                 reflect.ValueOf(&wrap_unicode.No).Elem(),
 		"Noncharacter_Code_Point":            reflect.ValueOf(&wrap_unicode.Noncharacter_Code_Point).Elem(),
 		"Number":                             reflect.ValueOf(&wrap_unicode.Number).Elem(),
 		"Ogham":                              reflect.ValueOf(&wrap_unicode.Ogham).Elem(),
@@ -202,81 +202,81 @@ var pkg_wrap_unicode = &gowrap.Pkg{
 		"Phoenician":                         reflect.ValueOf(&wrap_unicode.Phoenician).Elem(),
 		"Pi":                                 reflect.ValueOf(&wrap_unicode.Pi).Elem(),
 		"Po":                                 reflect.ValueOf(&wrap_unicode.Po).Elem(),
-		"Prepended_Concatenation_Mark": reflect.ValueOf(&wrap_unicode.Prepended_Concatenation_Mark).Elem(),
-		"PrintRanges":                  reflect.ValueOf(&wrap_unicode.PrintRanges).Elem(),
-		"Properties":                   reflect.ValueOf(&wrap_unicode.Properties).Elem(),
-		"Ps":                           reflect.ValueOf(&wrap_unicode.Ps).Elem(),
-		"Psalter_Pahlavi":              reflect.ValueOf(&wrap_unicode.Psalter_Pahlavi).Elem(),
-		"Punct":                        reflect.ValueOf(&wrap_unicode.Punct).Elem(),
-		"Quotation_Mark":               reflect.ValueOf(&wrap_unicode.Quotation_Mark).Elem(),
-		"Radical":                      reflect.ValueOf(&wrap_unicode.Radical).Elem(),
-		"Range16":                      reflect.ValueOf(reflect.TypeOf(wrap_unicode.Range16{})),
-		"Range32":                      reflect.ValueOf(reflect.TypeOf(wrap_unicode.Range32{})),
-		"RangeTable":                   reflect.ValueOf(reflect.TypeOf(wrap_unicode.RangeTable{})),
-		"Rejang":                       reflect.ValueOf(&wrap_unicode.Rejang).Elem(),
-		"ReplacementChar":              reflect.ValueOf(wrap_unicode.ReplacementChar),
-		"Runic":                        reflect.ValueOf(&wrap_unicode.Runic).Elem(),
-		"S":                            reflect.ValueOf(&wrap_unicode.S).Elem(),
-		"STerm":                        reflect.ValueOf(&wrap_unicode.STerm).Elem(),
-		"Samaritan":                    reflect.ValueOf(&wrap_unicode.Samaritan).Elem(),
-		"Saurashtra":                   reflect.ValueOf(&wrap_unicode.Saurashtra).Elem(),
-		"Sc":                           reflect.ValueOf(&wrap_unicode.Sc).Elem(),
-		"Scripts":                      reflect.ValueOf(&wrap_unicode.Scripts).Elem(),
-		"Sentence_Terminal":            reflect.ValueOf(&wrap_unicode.Sentence_Terminal).Elem(),
-		"Sharada":                      reflect.ValueOf(&wrap_unicode.Sharada).Elem(),
-		"Shavian":                      reflect.ValueOf(&wrap_unicode.Shavian).Elem(),
-		"Siddham":                      reflect.ValueOf(&wrap_unicode.Siddham).Elem(),
-		"SignWriting":                  reflect.ValueOf(&wrap_unicode.SignWriting).Elem(),
-		"SimpleFold":                   reflect.ValueOf(wrap_unicode.SimpleFold),
-		"Sinhala":                      reflect.ValueOf(&wrap_unicode.Sinhala).Elem(),
-		"Sk":                           reflect.ValueOf(&wrap_unicode.Sk).Elem(),
-		"Sm":                           reflect.ValueOf(&wrap_unicode.Sm).Elem(),
-		"So":                           reflect.ValueOf(&wrap_unicode.So).Elem(),
-		"Soft_Dotted":                  reflect.ValueOf(&wrap_unicode.Soft_Dotted).Elem(),
-		"Sora_Sompeng":                 reflect.ValueOf(&wrap_unicode.Sora_Sompeng).Elem(),
-		"Space":                        reflect.ValueOf(&wrap_unicode.Space).Elem(),
-		"SpecialCase":                  reflect.ValueOf(reflect.TypeOf(wrap_unicode.SpecialCase(nil))),
-		"Sundanese":                    reflect.ValueOf(&wrap_unicode.Sundanese).Elem(),
-		"Syloti_Nagri":                 reflect.ValueOf(&wrap_unicode.Syloti_Nagri).Elem(),
-		"Symbol":                       reflect.ValueOf(&wrap_unicode.Symbol).Elem(),
-		"Syriac":                       reflect.ValueOf(&wrap_unicode.Syriac).Elem(),
-		"Tagalog":                      reflect.ValueOf(&wrap_unicode.Tagalog).Elem(),
-		"Tagbanwa":                     reflect.ValueOf(&wrap_unicode.Tagbanwa).Elem(),
-		"Tai_Le":                       reflect.ValueOf(&wrap_unicode.Tai_Le).Elem(),
-		"Tai_Tham":                     reflect.ValueOf(&wrap_unicode.Tai_Tham).Elem(),
-		"Tai_Viet":                     reflect.ValueOf(&wrap_unicode.Tai_Viet).Elem(),
-		"Takri":                        reflect.ValueOf(&wrap_unicode.Takri).Elem(),
-		"Tamil":                        reflect.ValueOf(&wrap_unicode.Tamil).Elem(),
-		"Tangut":                       reflect.ValueOf(&wrap_unicode.Tangut).Elem(),
-		"Telugu":                       reflect.ValueOf(&wrap_unicode.Telugu).Elem(),
-		"Terminal_Punctuation":         reflect.ValueOf(&wrap_unicode.Terminal_Punctuation).Elem(),
-		"Thaana":                       reflect.ValueOf(&wrap_unicode.Thaana).Elem(),
-		"Thai":                         reflect.ValueOf(&wrap_unicode.Thai).Elem(),
-		"Tibetan":                      reflect.ValueOf(&wrap_unicode.Tibetan).Elem(),
-		"Tifinagh":                     reflect.ValueOf(&wrap_unicode.Tifinagh).Elem(),
-		"Tirhuta":                      reflect.ValueOf(&wrap_unicode.Tirhuta).Elem(),
-		"Title":                        reflect.ValueOf(&wrap_unicode.Title).Elem(),
-		"TitleCase":                    reflect.ValueOf(wrap_unicode.TitleCase),
-		"To":                           reflect.ValueOf(wrap_unicode.To),
-		"ToLower":                      reflect.ValueOf(wrap_unicode.ToLower),
-		"ToTitle":                      reflect.ValueOf(wrap_unicode.ToTitle),
-		"ToUpper":                      reflect.ValueOf(wrap_unicode.ToUpper),
-		"TurkishCase":                  reflect.ValueOf(&wrap_unicode.TurkishCase).Elem(),
-		"Ugaritic":                     reflect.ValueOf(&wrap_unicode.Ugaritic).Elem(),
-		"Unified_Ideograph":            reflect.ValueOf(&wrap_unicode.Unified_Ideograph).Elem(),
-		"Upper":                        reflect.ValueOf(&wrap_unicode.Upper).Elem(),
-		"UpperCase":                    reflect.ValueOf(wrap_unicode.UpperCase),
-		"UpperLower":                   reflect.ValueOf(wrap_unicode.UpperLower),
-		"Vai":                          reflect.ValueOf(&wrap_unicode.Vai).Elem(),
-		"Variation_Selector":           reflect.ValueOf(&wrap_unicode.Variation_Selector).Elem(),
-		"Version":                      reflect.ValueOf(wrap_unicode.Version),
-		"Warang_Citi":                  reflect.ValueOf(&wrap_unicode.Warang_Citi).Elem(),
-		"White_Space":                  reflect.ValueOf(&wrap_unicode.White_Space).Elem(),
-		"Yi":                           reflect.ValueOf(&wrap_unicode.Yi).Elem(),
-		"Z":                            reflect.ValueOf(&wrap_unicode.Z).Elem(),
-		"Zl":                           reflect.ValueOf(&wrap_unicode.Zl).Elem(),
-		"Zp":                           reflect.ValueOf(&wrap_unicode.Zp).Elem(),
-		"Zs":                           reflect.ValueOf(&wrap_unicode.Zs).Elem(),
+		"Prepended_Concatenation_Mark":       reflect.ValueOf(&wrap_unicode.Prepended_Concatenation_Mark).Elem(),
+		"PrintRanges":                        reflect.ValueOf(&wrap_unicode.PrintRanges).Elem(),
+		"Properties":                         reflect.ValueOf(&wrap_unicode.Properties).Elem(),
+		"Ps":                                 reflect.ValueOf(&wrap_unicode.Ps).Elem(),
+		"Psalter_Pahlavi":                    reflect.ValueOf(&wrap_unicode.Psalter_Pahlavi).Elem(),
+		"Punct":                              reflect.ValueOf(&wrap_unicode.Punct).Elem(),
+		"Quotation_Mark":                     reflect.ValueOf(&wrap_unicode.Quotation_Mark).Elem(),
+		"Radical":                            reflect.ValueOf(&wrap_unicode.Radical).Elem(),
+		"Range16":                            reflect.ValueOf(reflect.TypeOf(wrap_unicode.Range16{})),
+		"Range32":                            reflect.ValueOf(reflect.TypeOf(wrap_unicode.Range32{})),
+		"RangeTable":                         reflect.ValueOf(reflect.TypeOf(wrap_unicode.RangeTable{})),
+		"Rejang":                             reflect.ValueOf(&wrap_unicode.Rejang).Elem(),
+		"ReplacementChar":                    reflect.ValueOf(wrap_unicode.ReplacementChar),
+		"Runic":                              reflect.ValueOf(&wrap_unicode.Runic).Elem(),
+		"S":                                  reflect.ValueOf(&wrap_unicode.S).Elem(),
+		"STerm":                              reflect.ValueOf(&wrap_unicode.STerm).Elem(),
+		"Samaritan":                          reflect.ValueOf(&wrap_unicode.Samaritan).Elem(),
+		"Saurashtra":                         reflect.ValueOf(&wrap_unicode.Saurashtra).Elem(),
+		"Sc":                                 reflect.ValueOf(&wrap_unicode.Sc).Elem(),
+		"Scripts":                            reflect.ValueOf(&wrap_unicode.Scripts).Elem(),
+		"Sentence_Terminal":                  reflect.ValueOf(&wrap_unicode.Sentence_Terminal).Elem(),
+		"Sharada":                            reflect.ValueOf(&wrap_unicode.Sharada).Elem(),
+		"Shavian":                            reflect.ValueOf(&wrap_unicode.Shavian).Elem(),
+		"Siddham":                            reflect.ValueOf(&wrap_unicode.Siddham).Elem(),
+		"SignWriting":                        reflect.ValueOf(&wrap_unicode.SignWriting).Elem(),
+		"SimpleFold":                         reflect.ValueOf(wrap_unicode.SimpleFold),
+		"Sinhala":                            reflect.ValueOf(&wrap_unicode.Sinhala).Elem(),
+		"Sk":                                 reflect.ValueOf(&wrap_unicode.Sk).Elem(),
+		"Sm":                                 reflect.ValueOf(&wrap_unicode.Sm).Elem(),
+		"So":                                 reflect.ValueOf(&wrap_unicode.So).Elem(),
+		"Soft_Dotted":                        reflect.ValueOf(&wrap_unicode.Soft_Dotted).Elem(),
+		"Sora_Sompeng":                       reflect.ValueOf(&wrap_unicode.Sora_Sompeng).Elem(),
+		"Space":                              reflect.ValueOf(&wrap_unicode.Space).Elem(),
+		"SpecialCase":                        reflect.ValueOf(reflect.TypeOf(wrap_unicode.SpecialCase(nil))),
+		"Sundanese":                          reflect.ValueOf(&wrap_unicode.Sundanese).Elem(),
+		"Syloti_Nagri":                       reflect.ValueOf(&wrap_unicode.Syloti_Nagri).Elem(),
+		"Symbol":                             reflect.ValueOf(&wrap_unicode.Symbol).Elem(),
+		"Syriac":                             reflect.ValueOf(&wrap_unicode.Syriac).Elem(),
+		"Tagalog":                            reflect.ValueOf(&wrap_unicode.Tagalog).Elem(),
+		"Tagbanwa":                           reflect.ValueOf(&wrap_unicode.Tagbanwa).Elem(),
+		"Tai_Le":                             reflect.ValueOf(&wrap_unicode.Tai_Le).Elem(),
+		"Tai_Tham":                           reflect.ValueOf(&wrap_unicode.Tai_Tham).Elem(),
+		"Tai_Viet":                           reflect.ValueOf(&wrap_unicode.Tai_Viet).Elem(),
+		"Takri":                              reflect.ValueOf(&wrap_unicode.Takri).Elem(),
+		"Tamil":                              reflect.ValueOf(&wrap_unicode.Tamil).Elem(),
+		"Tangut":                             reflect.ValueOf(&wrap_unicode.Tangut).Elem(),
+		"Telugu":                             reflect.ValueOf(&wrap_unicode.Telugu).Elem(),
+		"Terminal_Punctuation":               reflect.ValueOf(&wrap_unicode.Terminal_Punctuation).Elem(),
+		"Thaana":                             reflect.ValueOf(&wrap_unicode.Thaana).Elem(),
+		"Thai":                               reflect.ValueOf(&wrap_unicode.Thai).Elem(),
+		"Tibetan":                            reflect.ValueOf(&wrap_unicode.Tibetan).Elem(),
+		"Tifinagh":                           reflect.ValueOf(&wrap_unicode.Tifinagh).Elem(),
+		"Tirhuta":                            reflect.ValueOf(&wrap_unicode.Tirhuta).Elem(),
+		"Title":                              reflect.ValueOf(&wrap_unicode.Title).Elem(),
+		"TitleCase":                          reflect.ValueOf(wrap_unicode.TitleCase),
+		"To":                                 reflect.ValueOf(wrap_unicode.To),
+		"ToLower":                            reflect.ValueOf(wrap_unicode.ToLower),
+		"ToTitle":                            reflect.ValueOf(wrap_unicode.ToTitle),
+		"ToUpper":                            reflect.ValueOf(wrap_unicode.ToUpper),
+		"TurkishCase":                        reflect.ValueOf(&wrap_unicode.TurkishCase).Elem(),
+		"Ugaritic":                           reflect.ValueOf(&wrap_unicode.Ugaritic).Elem(),
+		"Unified_Ideograph":                  reflect.ValueOf(&wrap_unicode.Unified_Ideograph).Elem(),
+		"Upper":                              reflect.ValueOf(&wrap_unicode.Upper).Elem(),
+		"UpperCase":                          reflect.ValueOf(wrap_unicode.UpperCase),
+		"UpperLower":                         reflect.ValueOf(wrap_unicode.UpperLower),
+		"Vai":                                reflect.ValueOf(&wrap_unicode.Vai).Elem(),
+		"Variation_Selector":                 reflect.ValueOf(&wrap_unicode.Variation_Selector).Elem(),
+		"Version":                            reflect.ValueOf(wrap_unicode.Version),
+		"Warang_Citi":                        reflect.ValueOf(&wrap_unicode.Warang_Citi).Elem(),
+		"White_Space":                        reflect.ValueOf(&wrap_unicode.White_Space).Elem(),
+		"Yi":                                 reflect.ValueOf(&wrap_unicode.Yi).Elem(),
+		"Z":                                  reflect.ValueOf(&wrap_unicode.Z).Elem(),
+		"Zl":                                 reflect.ValueOf(&wrap_unicode.Zl).Elem(),
+		"Zp":                                 reflect.ValueOf(&wrap_unicode.Zp).Elem(),
+		"Zs":                                 reflect.ValueOf(&wrap_unicode.Zs).Elem(),
 	},
 }
 
@@ -0,0 +1,149 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package http fetches data over HTTP for Neugram programs.
+//
+// Responses are read whole, up to a maximum size that defaults to
+// DefaultMaxSize and can be changed with SetMaxSize. Up to
+// MaxRedirects redirects are followed, and TLS certificates are
+// verified. By default requests have no time limit; SetTimeout
+// sets one. A response with a status other than 2xx is an error.
+//
+// The package is not available to programs run in sandboxed mode.
+package http // import "neugram.io/ng/eval/http"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the default limit on the size of a
+	// response body, in bytes.
+	DefaultMaxSize = 10 << 20
+
+	// MaxRedirects is the number of redirects followed by a
+	// request.
+	MaxRedirects = 10
+)
+
+var config = struct {
+	sync.Mutex
+	maxSize int64
+	timeout time.Duration
+}{maxSize: DefaultMaxSize}
+
+// SetMaxSize sets the largest response body read, in bytes.
+func SetMaxSize(n int64) {
+	config.Lock()
+	config.maxSize = n
+	config.Unlock()
+}
+
+// SetTimeout sets the time limit for each request, including
+// redirects and reading the response. Zero means no limit.
+func SetTimeout(d time.Duration) {
+	config.Lock()
+	config.timeout = d
+	config.Unlock()
+}
+
+func limits() (maxSize int64, timeout time.Duration) {
+	config.Lock()
+	defer config.Unlock()
+	return config.maxSize, config.timeout
+}
+
+func do(method, url, body, contentType string) (*http.Response, []byte, error) {
+	maxSize, timeout := limits()
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", MaxRedirects)
+			}
+			return nil
+		},
+	}
+	var r io.Reader
+	if method == "POST" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %v", method, url, err)
+	}
+	if int64(len(b)) > maxSize {
+		return nil, nil, fmt.Errorf("%s %s: response is larger than %d bytes", method, url, maxSize)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, nil, fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	return resp, b, nil
+}
+
+// Get fetches url and returns the response body.
+func Get(url string) (string, error) {
+	_, b, err := do("GET", url, "", "")
+	return string(b), err
+}
+
+// GetJSON fetches url and decodes the response body as JSON.
+// Objects become map[string]interface{} values, arrays
+// []interface{} and numbers float64.
+func GetJSON(url string) (interface{}, error) {
+	_, b, err := do("GET", url, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("GET %s: %v", url, err)
+	}
+	return v, nil
+}
+
+// Post sends body to url with the given content type and returns
+// the response body.
+func Post(url, body, contentType string) (string, error) {
+	if contentType == "" {
+		return "", errors.New("http: Post needs a content type")
+	}
+	_, b, err := do("POST", url, body, contentType)
+	return string(b), err
+}
+
+// Headers makes a HEAD request to url and returns the response
+// headers. Header names are in canonical form, such as
+// "Content-Type", and repeated headers are joined with ", ".
+func Headers(url string) (map[string]string, error) {
+	resp, _, err := do("HEAD", url, "", "")
+	if err != nil {
+		return nil, err
+	}
+	h := make(map[string]string, len(resp.Header))
+	for name, vals := range resp.Header {
+		h[name] = strings.Join(vals, ", ")
+	}
+	return h, nil
+}
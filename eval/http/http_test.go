@@ -0,0 +1,178 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	nghttp "neugram.io/ng/eval/http"
+)
+
+func newServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Tag", "a")
+		w.Header().Add("X-Tag", "b")
+		fmt.Fprint(w, "hello")
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "ng", "n": [1, 2.5], "ok": true}`)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("Content-Type"), b)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		fmt.Fprint(w, strings.Repeat("x", n))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n == 0 {
+			fmt.Fprint(w, "arrived")
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect?n=%d", n-1), http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, "late")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGet(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	got, err := nghttp.Get(srv.URL + "/text")
+	if err != nil || got != "hello" {
+		t.Errorf("Get = %q, %v; want hello", got, err)
+	}
+	if _, err := nghttp.Get(srv.URL + "/missing"); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("Get of a missing page err=%v, want 404", err)
+	}
+	if _, err := nghttp.Get("not a url"); err == nil {
+		t.Error("Get of a bad URL succeeded")
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	got, err := nghttp.GetJSON(srv.URL + "/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name": "ng",
+		"n":    []interface{}{1.0, 2.5},
+		"ok":   true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetJSON = %#v, want %#v", got, want)
+	}
+	if _, err := nghttp.GetJSON(srv.URL + "/text"); err == nil {
+		t.Error("GetJSON of text succeeded")
+	}
+}
+
+func TestPost(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	got, err := nghttp.Post(srv.URL+"/echo", "a=1", "application/x-www-form-urlencoded")
+	if want := "POST application/x-www-form-urlencoded a=1"; err != nil || got != want {
+		t.Errorf("Post = %q, %v; want %q", got, err, want)
+	}
+	if _, err := nghttp.Post(srv.URL+"/echo", "a=1", ""); err == nil {
+		t.Error("Post without a content type succeeded")
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	h, err := nghttp.Headers(srv.URL + "/text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h["X-Tag"] != "a, b" || !strings.HasPrefix(h["Content-Type"], "text/plain") {
+		t.Errorf("Headers = %q", h)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	defer nghttp.SetMaxSize(nghttp.DefaultMaxSize)
+
+	if got, err := nghttp.Get(srv.URL + "/big?n=1000"); err != nil || len(got) != 1000 {
+		t.Errorf("Get of 1000 bytes = %d bytes, %v", len(got), err)
+	}
+	nghttp.SetMaxSize(100)
+	if got, err := nghttp.Get(srv.URL + "/big?n=100"); err != nil || len(got) != 100 {
+		t.Errorf("Get of 100 bytes with a limit of 100 = %d bytes, %v", len(got), err)
+	}
+	if _, err := nghttp.Get(srv.URL + "/big?n=101"); err == nil || !strings.Contains(err.Error(), "larger than 100 bytes") {
+		t.Errorf("Get over the limit err=%v", err)
+	}
+	nghttp.SetMaxSize(nghttp.DefaultMaxSize)
+	if _, err := nghttp.Get(srv.URL + fmt.Sprintf("/big?n=%d", nghttp.DefaultMaxSize+1)); err == nil {
+		t.Error("Get over the default limit succeeded")
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	got, err := nghttp.Get(srv.URL + fmt.Sprintf("/redirect?n=%d", nghttp.MaxRedirects))
+	if err != nil || got != "arrived" {
+		t.Errorf("Get after %d redirects = %q, %v", nghttp.MaxRedirects, got, err)
+	}
+	_, err = nghttp.Get(srv.URL + fmt.Sprintf("/redirect?n=%d", nghttp.MaxRedirects+1))
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("Get after %d redirects err=%v", nghttp.MaxRedirects+1, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	defer nghttp.SetTimeout(0)
+
+	nghttp.SetTimeout(50 * time.Millisecond)
+	if _, err := nghttp.Get(srv.URL + "/slow"); err == nil {
+		t.Error("Get of a slow page beat the timeout")
+	}
+	nghttp.SetTimeout(0)
+	if got, err := nghttp.Get(srv.URL + "/slow"); err != nil || got != "late" {
+		t.Errorf("Get without a timeout = %q, %v", got, err)
+	}
+}
+
+func TestTLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secret")
+	}))
+	defer srv.Close()
+
+	// The test server's certificate is self-signed.
+	if _, err := nghttp.Get(srv.URL); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("Get from a server with an unknown certificate err=%v, want a certificate error", err)
+	}
+}
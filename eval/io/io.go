@@ -0,0 +1,174 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package io implements file input and output for Neugram programs.
+//
+// Every path is checked against a sandbox, a list of directories the
+// files must be in. It defaults to the working directory and is set
+// with SetSandbox. The sandbox catches mistakes, such as writing to
+// a path built from the wrong variable; it is not a security
+// boundary, as paths are compared lexically without following
+// symbolic links.
+//
+// Errors are returned, never panicked, so a program can test them.
+// Paths outside the sandbox give an *os.PathError holding
+// ErrSandbox.
+package io // import "neugram.io/ng/eval/io"
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrSandbox is the error for a path outside the sandbox.
+var ErrSandbox = errors.New("path is outside the sandbox")
+
+var sandbox struct {
+	sync.Mutex
+	dirs []string // absolute; nil means the working directory
+}
+
+// SetSandbox sets the directories that files may be in, to dirs and
+// everything below them. With no dirs, the sandbox is the working
+// directory at the time of each call.
+func SetSandbox(dirs ...string) error {
+	var abs []string
+	for _, dir := range dirs {
+		a, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		abs = append(abs, a)
+	}
+	sandbox.Lock()
+	sandbox.dirs = abs
+	sandbox.Unlock()
+	return nil
+}
+
+// Sandbox returns the absolute paths of the sandbox directories.
+func Sandbox() []string {
+	sandbox.Lock()
+	dirs := append([]string(nil), sandbox.dirs...)
+	sandbox.Unlock()
+	if len(dirs) == 0 {
+		if wd, err := os.Getwd(); err == nil {
+			dirs = []string{wd}
+		}
+	}
+	return dirs
+}
+
+// check returns the absolute form of path, or an error if it is
+// outside the sandbox.
+func check(op, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", &os.PathError{Op: op, Path: path, Err: err}
+	}
+	for _, dir := range Sandbox() {
+		rel, err := filepath.Rel(dir, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return abs, nil
+		}
+	}
+	return "", &os.PathError{Op: op, Path: path, Err: ErrSandbox}
+}
+
+// ReadFile returns the contents of the file at path.
+func ReadFile(path string) (string, error) {
+	abs, err := check("read", path)
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(abs)
+	return string(b), err
+}
+
+// WriteFile writes content to the file at path, creating it or
+// replacing its contents.
+func WriteFile(path, content string) error {
+	abs, err := check("write", path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(abs, []byte(content), 0666)
+}
+
+// AppendFile adds content to the end of the file at path, creating
+// it if it does not exist.
+func AppendFile(path, content string) error {
+	abs, err := check("append", path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(abs, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// ReadLines returns the lines of the file at path, without their
+// "\n" or "\r\n" endings. A final line need not end in a newline.
+func ReadLines(path string) ([]string, error) {
+	content, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return []string{}, nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+// Exists reports whether there is a file or directory at path.
+// It is false for paths outside the sandbox.
+func Exists(path string) bool {
+	abs, err := check("stat", path)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(abs)
+	return err == nil
+}
+
+// Remove removes the file or empty directory at path.
+func Remove(path string) error {
+	abs, err := check("remove", path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(abs)
+}
+
+// ListDir returns the names of the entries of the directory at
+// path, sorted.
+func ListDir(path string) ([]string, error) {
+	abs, err := check("list", path)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package io_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"neugram.io/ng/eval/io"
+)
+
+// tempSandbox makes a new directory under os.TempDir and makes it
+// the sandbox until the returned func is called.
+func tempSandbox(t *testing.T) (dir string, done func()) {
+	dir, err := ioutil.TempDir(os.TempDir(), "ng-io-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := io.SetSandbox(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() {
+		io.SetSandbox()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	dir, done := tempSandbox(t)
+	defer done()
+	path := filepath.Join(dir, "f.txt")
+
+	if io.Exists(path) {
+		t.Fatalf("%s exists before it is written", path)
+	}
+	if err := io.WriteFile(path, "one\r\ntwo\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := io.AppendFile(path, "three"); err != nil {
+		t.Fatal(err)
+	}
+	if !io.Exists(path) {
+		t.Fatalf("%s does not exist after it is written", path)
+	}
+	got, err := io.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\r\ntwo\nthree"; got != want {
+		t.Errorf("ReadFile = %q, want %q", got, want)
+	}
+	lines, err := io.ReadLines(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("ReadLines = %q, want %q", lines, want)
+	}
+
+	// AppendFile creates missing files.
+	path2 := filepath.Join(dir, "g.txt")
+	if err := io.AppendFile(path2, "x\n"); err != nil {
+		t.Fatal(err)
+	}
+	if lines, err := io.ReadLines(path2); err != nil || !reflect.DeepEqual(lines, []string{"x"}) {
+		t.Errorf("ReadLines after AppendFile = %q, %v", lines, err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	names, err := io.ListDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"f.txt", "g.txt", "sub"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListDir = %q, want %q", names, want)
+	}
+
+	if err := io.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if io.Exists(path) {
+		t.Errorf("%s exists after Remove", path)
+	}
+	if _, err := io.ReadFile(path); !os.IsNotExist(err) {
+		t.Errorf("ReadFile of a removed file err=%v, want not exist", err)
+	}
+	if err := io.Remove(path); !os.IsNotExist(err) {
+		t.Errorf("second Remove err=%v, want not exist", err)
+	}
+}
+
+func TestEmptyFile(t *testing.T) {
+	dir, done := tempSandbox(t)
+	defer done()
+	path := filepath.Join(dir, "empty")
+	if err := io.WriteFile(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := io.ReadLines(path)
+	if err != nil || lines == nil || len(lines) != 0 {
+		t.Errorf("ReadLines of an empty file = %q, %v; want no lines", lines, err)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	dir, done := tempSandbox(t)
+	defer done()
+	if got := io.Sandbox(); !reflect.DeepEqual(got, []string{dir}) {
+		t.Errorf("Sandbox() = %q, want [%q]", got, dir)
+	}
+
+	// The parent of the sandbox, a sibling with the sandbox's name
+	// as a prefix, and a path climbing out with .. are all outside.
+	for _, path := range []string{
+		filepath.Join(dir, "..", "escape"),
+		dir + "-sibling",
+		filepath.Dir(dir),
+	} {
+		err := io.WriteFile(path, "x")
+		if perr, ok := err.(*os.PathError); !ok || perr.Err != io.ErrSandbox {
+			t.Errorf("WriteFile(%s) err=%v, want ErrSandbox", path, err)
+		}
+		if _, err := os.Stat(path); path != filepath.Dir(dir) && !os.IsNotExist(err) {
+			t.Errorf("WriteFile(%s) outside the sandbox created the file", path)
+		}
+		if _, err := io.ReadFile(path); err == nil {
+			t.Errorf("ReadFile(%s) outside the sandbox succeeded", path)
+		}
+		if _, err := io.ListDir(path); err == nil {
+			t.Errorf("ListDir(%s) outside the sandbox succeeded", path)
+		}
+		if err := io.AppendFile(path, "x"); err == nil {
+			t.Errorf("AppendFile(%s) outside the sandbox succeeded", path)
+		}
+		if err := io.Remove(path); err == nil {
+			t.Errorf("Remove(%s) outside the sandbox succeeded", path)
+		}
+	}
+	if io.Exists(filepath.Dir(dir)) {
+		t.Error("Exists is true outside the sandbox")
+	}
+	// The sandbox directory itself and paths that leave and come
+	// back into it are inside.
+	if !io.Exists(dir) || !io.Exists(filepath.Join(dir, "..", filepath.Base(dir))) {
+		t.Error("Exists is false for the sandbox directory")
+	}
+
+	// Several directories may be allowed.
+	other, err := ioutil.TempDir(os.TempDir(), "ng-io-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(other)
+	if err := io.WriteFile(filepath.Join(other, "f"), "x"); err == nil {
+		t.Error("WriteFile to a second dir succeeded before it was allowed")
+	}
+	io.SetSandbox(dir, other)
+	if err := io.WriteFile(filepath.Join(other, "f"), "x"); err != nil {
+		t.Errorf("WriteFile to an allowed second dir: %v", err)
+	}
+
+	// By default the sandbox is the working directory.
+	io.SetSandbox()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := io.Sandbox(); !reflect.DeepEqual(got, []string{wd}) {
+		t.Errorf("default Sandbox() = %q, want [%q]", got, wd)
+	}
+	if !io.Exists("io.go") {
+		t.Error("Exists(io.go) is false in the default sandbox")
+	}
+	if io.Exists(dir) {
+		t.Error("temporary directory is inside the default sandbox")
+	}
+}
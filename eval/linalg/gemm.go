@@ -0,0 +1,12 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !blas
+// +build !blas
+
+package linalg
+
+func gemm(m, n, k int, x, y, z []float64) {
+	matmul(m, n, k, x, y, z)
+}
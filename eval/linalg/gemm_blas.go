@@ -0,0 +1,25 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build blas
+// +build blas
+
+package linalg
+
+import (
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas64"
+)
+
+func gemm(m, n, k int, x, y, z []float64) {
+	if m == 0 || n == 0 || k == 0 {
+		return
+	}
+	blas64.Gemm(blas.NoTrans, blas.NoTrans, 1,
+		blas64.General{Rows: m, Cols: k, Stride: k, Data: x},
+		blas64.General{Rows: k, Cols: n, Stride: n, Data: y},
+		0,
+		blas64.General{Rows: m, Cols: n, Stride: n, Data: z},
+	)
+}
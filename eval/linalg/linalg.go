@@ -0,0 +1,107 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package linalg implements linear algebra on Neugram tables.
+//
+// A table is treated as a matrix with one row per table row and one
+// column per table column. Every cell must hold a number.
+package linalg // import "neugram.io/ng/eval/linalg"
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"neugram.io/ng/eval"
+)
+
+// Matmul returns the matrix product of a and b.
+//
+// The number of columns in a must equal the number of rows in b.
+// The result has the rows of a and the columns of b, and takes its
+// column names from b. Its cells are *big.Float values.
+func Matmul(a, b *eval.Table) (*eval.Table, error) {
+	m, k, x, err := matrix("a", a)
+	if err != nil {
+		return nil, err
+	}
+	bk, n, y, err := matrix("b", b)
+	if err != nil {
+		return nil, err
+	}
+	if k != bk {
+		return nil, fmt.Errorf("linalg: Matmul of %dx%d and %dx%d matrices: a has %d columns, b has %d rows", m, k, bk, n, k, bk)
+	}
+
+	z := make([]float64, m*n)
+	gemm(m, n, k, x, y, z)
+
+	res := &eval.Table{
+		ColNames: append([]string(nil), b.ColNames...),
+		Cols:     make([][]eval.Value, n),
+	}
+	for j := range res.Cols {
+		col := make([]eval.Value, m)
+		for i := range col {
+			v := z[i*n+j]
+			if math.IsNaN(v) {
+				return nil, fmt.Errorf("linalg: Matmul result [%d, %d] is NaN", j, i)
+			}
+			col[i] = big.NewFloat(v)
+		}
+		res.Cols[j] = col
+	}
+	return res, nil
+}
+
+// matrix converts t into a rows x cols matrix stored in row-major order.
+func matrix(name string, t *eval.Table) (rows, cols int, data []float64, err error) {
+	rows, cols = t.Len(), len(t.Cols)
+	data = make([]float64, rows*cols)
+	for j, col := range t.Cols {
+		if len(col) != rows {
+			return 0, 0, nil, fmt.Errorf("linalg: %s column %d has %d rows, want %d", name, j, len(col), rows)
+		}
+		for i, v := range col {
+			f, ok := toFloat64(v)
+			if !ok {
+				return 0, 0, nil, fmt.Errorf("linalg: %s[%d, %d] is %T, not a number", name, j, i, v)
+			}
+			data[i*cols+j] = f
+		}
+	}
+	return rows, cols, data, nil
+}
+
+func toFloat64(v eval.Value) (float64, bool) {
+	switch v := v.(type) {
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *big.Float:
+		f, _ := v.Float64()
+		return f, true
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// matmul sets z to the m x n product of the m x k matrix x and the
+// k x n matrix y. All three are stored in row-major order.
+func matmul(m, n, k int, x, y, z []float64) {
+	for i := 0; i < m; i++ {
+		zi := z[i*n : (i+1)*n]
+		for p := 0; p < k; p++ {
+			xip := x[i*k+p]
+			for j, v := range y[p*n : (p+1)*n] {
+				zi[j] += xip * v
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package linalg_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/linalg"
+)
+
+// table builds a table from rows of int64 values.
+func table(rows ...[]int64) *eval.Table {
+	t := &eval.Table{}
+	if len(rows) == 0 {
+		return t
+	}
+	t.ColNames = make([]string, len(rows[0]))
+	t.Cols = make([][]eval.Value, len(rows[0]))
+	for _, row := range rows {
+		for j, v := range row {
+			t.Cols[j] = append(t.Cols[j], big.NewInt(v))
+		}
+	}
+	return t
+}
+
+// rows formats t row by row.
+func rows(t *eval.Table) string {
+	var s []string
+	for i := 0; i < t.Len(); i++ {
+		var row []string
+		for _, col := range t.Cols {
+			row = append(row, fmt.Sprint(col[i]))
+		}
+		s = append(s, strings.Join(row, " "))
+	}
+	return strings.Join(s, "; ")
+}
+
+var matmulTests = []struct {
+	name string
+	a, b *eval.Table
+	want string
+}{
+	{
+		name: "identity",
+		a:    table([]int64{1, 0, 0}, []int64{0, 1, 0}, []int64{0, 0, 1}),
+		b:    table([]int64{1, 2, 3}, []int64{4, 5, 6}, []int64{7, 8, 9}),
+		want: "1 2 3; 4 5 6; 7 8 9",
+	},
+	{
+		name: "zero",
+		a:    table([]int64{1, 2}, []int64{3, 4}),
+		b:    table([]int64{0, 0}, []int64{0, 0}),
+		want: "0 0; 0 0",
+	},
+	{
+		name: "2x3 by 3x2",
+		a:    table([]int64{1, 2, 3}, []int64{4, 5, 6}),
+		b:    table([]int64{7, 8}, []int64{9, 10}, []int64{11, 12}),
+		want: "58 64; 139 154",
+	},
+	{
+		name: "column by row",
+		a:    table([]int64{1}, []int64{2}),
+		b:    table([]int64{3, 4, 5}),
+		want: "3 4 5; 6 8 10",
+	},
+	{
+		name: "row by column",
+		a:    table([]int64{1, 2, 3}),
+		b:    table([]int64{4}, []int64{5}, []int64{6}),
+		want: "32",
+	},
+}
+
+func TestMatmul(t *testing.T) {
+	for _, test := range matmulTests {
+		got, err := linalg.Matmul(test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got.Len() != test.a.Len() || len(got.Cols) != len(test.b.Cols) {
+			t.Errorf("%s: result is %dx%d, want %dx%d", test.name, got.Len(), len(got.Cols), test.a.Len(), len(test.b.Cols))
+		}
+		if s := rows(got); s != test.want {
+			t.Errorf("%s: Matmul=%s, want %s", test.name, s, test.want)
+		}
+	}
+}
+
+func TestMatmulColNames(t *testing.T) {
+	a := table([]int64{1, 2})
+	b := table([]int64{1, 0}, []int64{0, 1})
+	b.ColNames = []string{"x", "y"}
+	got, err := linalg.Matmul(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got.ColNames) != "[x y]" {
+		t.Errorf("ColNames=%v, want [x y]", got.ColNames)
+	}
+}
+
+func TestMatmulError(t *testing.T) {
+	bad := table([]int64{1, 2})
+	bad.Cols[1][0] = "two"
+
+	for _, test := range []struct {
+		a, b *eval.Table
+		want string
+	}{
+		{
+			table([]int64{1, 2, 3}, []int64{4, 5, 6}),
+			table([]int64{1, 2}, []int64{3, 4}),
+			"Matmul of 2x3 and 2x2 matrices: a has 3 columns, b has 2 rows",
+		},
+		{bad, table([]int64{1}, []int64{2}), `a[1, 0] is string, not a number`},
+	} {
+		_, err := linalg.Matmul(test.a, test.b)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Matmul(%s, %s) error %v, want %q", rows(test.a), rows(test.b), err, test.want)
+		}
+	}
+}
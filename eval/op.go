@@ -60,6 +60,16 @@ func valEq(x, y interface{}) bool {
 }
 
 func binOp(op token.Token, x, y interface{}) (interface{}, error) {
+	// Untyped rune arithmetic is done on untyped integers.
+	if xr, ok := x.(UntypedRune); ok {
+		if yr, ok := y.(UntypedRune); ok {
+			res, err := binOp(op, UntypedInt{big.NewInt(int64(xr.Rune))}, UntypedInt{big.NewInt(int64(yr.Rune))})
+			if i, ok := res.(UntypedInt); ok {
+				res = UntypedRune{rune(i.Int64())}
+			}
+			return res, err
+		}
+	}
 	switch op {
 	case token.Add:
 		switch x := x.(type) {
@@ -182,10 +192,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return x + y, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Add").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Add", x, y), nil
 		}
 	case token.Sub:
 		switch x := x.(type) {
@@ -304,6 +311,8 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 					im.Sub(x.Imag, y.Imag),
 				}, nil
 			}
+		default:
+			return methodOp("Sub", x, y), nil
 		}
 	case token.Mul:
 		switch x := x.(type) {
@@ -439,6 +448,8 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				im.Add(xy, yx)
 				return UntypedComplex{re, im}, nil
 			}
+		default:
+			return methodOp("Mul", x, y), nil
 		}
 	case token.Div:
 		switch x := x.(type) {
@@ -560,6 +571,8 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				im.Quo(im.Sub(xy, yx), den)
 				return UntypedComplex{re, im}, nil
 			}
+		default:
+			return methodOp("Div", x, y), nil
 		}
 	case token.LogicalAnd, token.LogicalOr:
 		panic("logical ops processed before binOp")
@@ -861,10 +874,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.Or(x.Int, y.Int)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Or").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Or", x, y), nil
 		}
 	case token.Pow:
 		switch x := x.(type) {
@@ -925,10 +935,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.Xor(x.Int, y.Int)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Xor").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Xor", x, y), nil
 		}
 	case token.Ref:
 		switch x := x.(type) {
@@ -989,10 +996,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.And(x.Int, y.Int)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("And").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("And", x, y), nil
 		}
 	case token.Rem:
 		switch x := x.(type) {
@@ -1053,10 +1057,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.Rem(x.Int, y.Int)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Rem").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Rem", x, y), nil
 		}
 	case token.TwoLess:
 		switch x := x.(type) {
@@ -1243,10 +1244,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.Lsh(x.Int, n)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Lsh").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Lsh", x, y), nil
 		}
 	case token.TwoGreater:
 		switch x := x.(type) {
@@ -1433,10 +1431,7 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.Rsh(x.Int, n)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("Rsh").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("Rsh", x, y), nil
 		}
 	case token.RefPow:
 		switch x := x.(type) {
@@ -1497,16 +1492,27 @@ func binOp(op token.Token, x, y interface{}) (interface{}, error) {
 				return UntypedInt{z.AndNot(x.Int, y.Int)}, nil
 			}
 		default:
-			xv := reflect.ValueOf(x)
-			yv := reflect.ValueOf(y)
-			res := xv.MethodByName("AndNot").Call([]reflect.Value{yv})
-			return res[0].Interface(), nil
+			return methodOp("AndNot", x, y), nil
 		}
 	}
 	//return nil, fmt.Errorf("type mismatch Left: %T, Right: %T", x, y)
 	panic(fmt.Sprintf("binOp type mismatch Left: %+v (%T), Right: %+v (%T) op: %v", x, x, y, y, op))
 }
 
+// methodOp computes a binary operator on x, a type the operator
+// is not defined on, by calling its method name with y.
+func methodOp(name string, x, y interface{}) interface{} {
+	xv := reflect.ValueOf(x)
+	m := xv.MethodByName(name)
+	if m == (reflect.Value{}) && xv.Kind() != reflect.Ptr {
+		// The method may be on *T, as are those of a methodik.
+		p := reflect.New(xv.Type())
+		p.Elem().Set(xv)
+		m = p.MethodByName(name)
+	}
+	return m.Call([]reflect.Value{reflect.ValueOf(y)})[0].Interface()
+}
+
 func typeConv(t reflect.Type, v reflect.Value) (res reflect.Value) {
 	if v.Type() == t {
 		return v
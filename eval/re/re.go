@@ -0,0 +1,81 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package re implements regular expressions for Neugram programs.
+//
+// It is a thin layer over the regexp package, with the RE2 syntax
+// described at https://golang.org/s/re2syntax. Compiled expressions
+// are cached by pattern, so compiling the same pattern in a loop
+// costs a map lookup.
+package re // import "neugram.io/ng/eval/re"
+
+import (
+	"regexp"
+	"sync"
+)
+
+// Regexp is a compiled regular expression.
+// It is safe for concurrent use.
+type Regexp struct {
+	re *regexp.Regexp
+}
+
+// maxCached is the number of compiled patterns kept by Compile.
+const maxCached = 256
+
+var cache struct {
+	sync.Mutex
+	m map[string]*Regexp
+}
+
+// Compile parses a regular expression.
+//
+// The result is cached: compiling a pattern again returns the same
+// *Regexp. The cache is emptied when it reaches maxCached patterns.
+func Compile(pattern string) (*Regexp, error) {
+	cache.Lock()
+	r := cache.m[pattern]
+	cache.Unlock()
+	if r != nil {
+		return r, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	r = &Regexp{re: re}
+
+	cache.Lock()
+	if cache.m == nil || len(cache.m) >= maxCached {
+		cache.m = make(map[string]*Regexp)
+	}
+	cache.m[pattern] = r
+	cache.Unlock()
+	return r, nil
+}
+
+// String returns the pattern r was compiled from.
+func (r *Regexp) String() string { return r.re.String() }
+
+// Match reports whether s contains a match of r.
+func (r *Regexp) Match(s string) bool { return r.re.MatchString(s) }
+
+// FindString returns the leftmost match of r in s, or "".
+func (r *Regexp) FindString(s string) string { return r.re.FindString(s) }
+
+// FindStringSubmatch returns the leftmost match of r in s followed
+// by the text of each capturing group, or nil if there is no match.
+func (r *Regexp) FindStringSubmatch(s string) []string { return r.re.FindStringSubmatch(s) }
+
+// FindAllString returns up to n successive matches of r in s, or
+// all of them if n < 0.
+func (r *Regexp) FindAllString(s string, n int) []string { return r.re.FindAllString(s, n) }
+
+// ReplaceAll replaces the matches of r in s with repl. Inside repl,
+// $1 or ${name} stands for the text of a capturing group.
+func (r *Regexp) ReplaceAll(s, repl string) string { return r.re.ReplaceAllString(s, repl) }
+
+// Split slices s into the substrings between the matches of r.
+// It returns at most n substrings, or all of them if n < 0.
+func (r *Regexp) Split(s string, n int) []string { return r.re.Split(s, n) }
@@ -0,0 +1,104 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package re_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"neugram.io/ng/eval/re"
+)
+
+func mustCompile(t *testing.T, pattern string) *re.Regexp {
+	r, err := re.Compile(pattern)
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", pattern, err)
+	}
+	return r
+}
+
+var findTests = []struct {
+	pattern, s string
+	all        []string
+}{
+	{`a+`, "baaac aa", []string{"aaa", "aa"}},
+	{`<.+?>`, "<a><b>c</b>", []string{"<a>", "<b>", "</b>"}},
+	{`<.+>`, "<a><b>c</b>", []string{"<a><b>c</b>"}},
+	{`x*?y`, "xxy y", []string{"xxy", "y"}},
+	{`\p{Greek}+`, "a αβ b γ", []string{"αβ", "γ"}},
+	{`[à-ÿ]`, "voilà, déjà", []string{"à", "é", "à"}},
+	{`(?i)straße`, "STRAßE", []string{"STRAßE"}},
+	{`z`, "abc", nil},
+}
+
+func TestFind(t *testing.T) {
+	for _, test := range findTests {
+		r := mustCompile(t, test.pattern)
+		if got := r.FindAllString(test.s, -1); !reflect.DeepEqual(got, test.all) {
+			t.Errorf("%s.FindAllString(%q, -1) = %q, want %q", test.pattern, test.s, got, test.all)
+		}
+		first := ""
+		if len(test.all) > 0 {
+			first = test.all[0]
+		}
+		if got := r.FindString(test.s); got != first {
+			t.Errorf("%s.FindString(%q) = %q, want %q", test.pattern, test.s, got, first)
+		}
+		if got := r.Match(test.s); got != (test.all != nil) {
+			t.Errorf("%s.Match(%q) = %v", test.pattern, test.s, got)
+		}
+	}
+}
+
+func TestSubmatch(t *testing.T) {
+	r := mustCompile(t, `(?P<key>\w+)=(\w*)`)
+	got := r.FindStringSubmatch("set name=ng x=")
+	if want := []string{"name=ng", "name", "ng"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStringSubmatch = %q, want %q", got, want)
+	}
+	if got := r.FindStringSubmatch("none"); got != nil {
+		t.Errorf("FindStringSubmatch without a match = %q, want nil", got)
+	}
+	if got, want := r.ReplaceAll("a=1 b=2", "$2:${key}"), "1:a 2:b"; got != want {
+		t.Errorf("ReplaceAll = %q, want %q", got, want)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	r := mustCompile(t, `\s*[,;]\s*`)
+	if got, want := r.Split("α , β;γ", -1), []string{"α", "β", "γ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+	if got, want := r.Split("a,b,c", 2), []string{"a", "b,c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Split(n=2) = %q, want %q", got, want)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, pattern := range []string{`a(b`, `*`, `[z-a]`, `\8`} {
+		if r, err := re.Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) = %v, want an error", pattern, r)
+		}
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	r := mustCompile(t, `cached+`)
+	if mustCompile(t, `cached+`) != r {
+		t.Error("second Compile of a pattern returned a new Regexp")
+	}
+	if r.String() != `cached+` {
+		t.Errorf("String() = %q", r.String())
+	}
+
+	// Compiling more patterns than the cache holds evicts r.
+	for i := 0; i < 300; i++ {
+		mustCompile(t, fmt.Sprintf("p%d", i))
+	}
+	if r2 := mustCompile(t, `cached+`); r2 == r || !r2.Match("cacheddd") {
+		t.Errorf("Compile after eviction = %p (%v), want a new Regexp", r2, r2)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stats implements statistical aggregates over columns of
+// numbers.
+//
+// Every function skips NaN values. A column that is empty, or holds
+// only NaN values, has no statistic and the result is NaN.
+//
+// The results follow numpy: Variance and StdDev are population
+// statistics, Quantile interpolates linearly between the closest
+// values, and Covariance is the sample covariance.
+package stats // import "neugram.io/ng/eval/stats"
+
+import (
+	"math"
+	"sort"
+)
+
+// dropNaN returns the values of col that are not NaN.
+func dropNaN(col []float64) []float64 {
+	xs := make([]float64, 0, len(col))
+	for _, x := range col {
+		if !math.IsNaN(x) {
+			xs = append(xs, x)
+		}
+	}
+	return xs
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// Mean returns the arithmetic mean of col.
+func Mean(col []float64) float64 {
+	return mean(dropNaN(col))
+}
+
+// Variance returns the population variance of col.
+func Variance(col []float64) float64 {
+	xs := dropNaN(col)
+	m := mean(xs)
+	if math.IsNaN(m) {
+		return m
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return sum / float64(len(xs))
+}
+
+// StdDev returns the population standard deviation of col.
+func StdDev(col []float64) float64 {
+	return math.Sqrt(Variance(col))
+}
+
+// Median returns the middle value of col, or the mean of the two
+// middle values if col has an even number of values.
+func Median(col []float64) float64 {
+	return Quantile(col, 0.5)
+}
+
+// Quantile returns the p-quantile of col, for p in [0, 1].
+// A p outside that range gives NaN.
+func Quantile(col []float64, p float64) float64 {
+	if !(p >= 0 && p <= 1) {
+		return math.NaN()
+	}
+	xs := dropNaN(col)
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(xs)
+	h := float64(len(xs)-1) * p
+	lo := int(h)
+	if lo == len(xs)-1 {
+		return xs[lo]
+	}
+	return xs[lo] + (h-float64(lo))*(xs[lo+1]-xs[lo])
+}
+
+// Covariance returns the sample covariance of a and b.
+//
+// A pair of values is skipped if either is NaN. Slices of different
+// lengths, or fewer than two pairs, give NaN.
+func Covariance(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return math.NaN()
+	}
+	var xs, ys []float64
+	for i := range a {
+		if !math.IsNaN(a[i]) && !math.IsNaN(b[i]) {
+			xs = append(xs, a[i])
+			ys = append(ys, b[i])
+		}
+	}
+	if len(xs) < 2 {
+		return math.NaN()
+	}
+	mx, my := mean(xs), mean(ys)
+	sum := 0.0
+	for i := range xs {
+		sum += (xs[i] - mx) * (ys[i] - my)
+	}
+	return sum / float64(len(xs)-1)
+}
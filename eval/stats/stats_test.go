@@ -0,0 +1,103 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"neugram.io/ng/eval/stats"
+)
+
+var nan = math.NaN()
+
+func near(x, y float64) bool {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	return math.Abs(x-y) <= 1e-12*math.Max(1, math.Abs(y))
+}
+
+// The wanted values are those of numpy's nanmean, nanvar, nanstd,
+// nanmedian and nanquantile (default linear method) on the same
+// columns.
+var colTests = []struct {
+	col                         []float64
+	mean, variance, std, median float64
+}{
+	{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 4, 2, 4.5},
+	{[]float64{2, nan, 4, 4, 4, 5, nan, 5, 7, 9}, 5, 4, 2, 4.5},
+	{[]float64{1.5, -2.25, 3.0, 10.0, 0.1}, 2.47, 17.1636, 4.142897536748888, 1.5},
+	{[]float64{-3}, -3, 0, 0, -3},
+	{[]float64{}, nan, nan, nan, nan},
+	{nil, nan, nan, nan, nan},
+	{[]float64{nan, nan}, nan, nan, nan, nan},
+}
+
+func TestAggregates(t *testing.T) {
+	for _, test := range colTests {
+		for _, f := range []struct {
+			name string
+			fn   func([]float64) float64
+			want float64
+		}{
+			{"Mean", stats.Mean, test.mean},
+			{"Variance", stats.Variance, test.variance},
+			{"StdDev", stats.StdDev, test.std},
+			{"Median", stats.Median, test.median},
+		} {
+			if got := f.fn(test.col); !near(got, f.want) {
+				t.Errorf("%s(%v)=%v, want %v", f.name, test.col, got, f.want)
+			}
+		}
+	}
+}
+
+var quantileTests = []struct {
+	col  []float64
+	p    float64
+	want float64
+}{
+	{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 0, 2},
+	{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 0.25, 4},
+	{[]float64{9, 7, 5, 5, 4, 4, 4, 2}, 0.9, 7.6},
+	{[]float64{2, 4, 4, 4, 5, 5, 7, 9, nan}, 1, 9},
+	{[]float64{1.5, -2.25, 3.0, 10.0, 0.1}, 0.3, 0.38},
+	{[]float64{1.5, -2.25, 3.0, 10.0, 0.1}, 0.9, 7.2},
+	{[]float64{1, 2}, -0.1, nan},
+	{[]float64{1, 2}, 1.1, nan},
+	{[]float64{1, 2}, nan, nan},
+	{nil, 0.5, nan},
+}
+
+func TestQuantile(t *testing.T) {
+	for _, test := range quantileTests {
+		if got := stats.Quantile(test.col, test.p); !near(got, test.want) {
+			t.Errorf("Quantile(%v, %v)=%v, want %v", test.col, test.p, got, test.want)
+		}
+	}
+}
+
+// The wanted values are numpy.cov(a, b)[0, 1].
+var covarianceTests = []struct {
+	a, b []float64
+	want float64
+}{
+	{[]float64{1, 2, 3, 4, 7.5}, []float64{2, 4, 6, 9, -1}, -3.375},
+	{[]float64{1, 2, nan, 3, 4, 7.5}, []float64{2, 4, 100, 6, 9, -1}, -3.375},
+	{[]float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+	{[]float64{1, 2, 3}, []float64{5, 5, 5}, 0},
+	{[]float64{1}, []float64{1}, nan},
+	{[]float64{1, 2}, []float64{1, 2, 3}, nan},
+	{nil, nil, nan},
+}
+
+func TestCovariance(t *testing.T) {
+	for _, test := range covarianceTests {
+		if got := stats.Covariance(test.a, test.b); !near(got, test.want) {
+			t.Errorf("Covariance(%v, %v)=%v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
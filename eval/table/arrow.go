@@ -0,0 +1,235 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+
+	"neugram.io/ng/eval"
+)
+
+// ReadArrow reads a table from an Arrow IPC stream.
+//
+// Arrow int64, float64 and utf8 columns hold *big.Int, *big.Float
+// and string values. A list<float64> column holds a []Value of
+// *big.Float for each row. Nulls, and NaN floats, are nil Values.
+//
+// Record batches are decoded one at a time. Numeric columns are
+// read directly from the Arrow buffers, without per-value accessors.
+func ReadArrow(r io.Reader) (*eval.Table, error) {
+	rdr, err := ipc.NewReader(r, ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return nil, fmt.Errorf("table: %v", err)
+	}
+	defer rdr.Release()
+
+	fields := rdr.Schema().Fields()
+	t := &eval.Table{
+		ColNames: make([]string, len(fields)),
+		Cols:     make([][]eval.Value, len(fields)),
+	}
+	for i, f := range fields {
+		t.ColNames[i] = f.Name
+		if !arrowTypeOK(f.Type) {
+			return nil, fmt.Errorf("table: column %q has unsupported Arrow type %s", f.Name, f.Type)
+		}
+	}
+	for rdr.Next() {
+		for i, col := range rdr.Record().Columns() {
+			t.Cols[i] = appendArrow(t.Cols[i], col)
+		}
+	}
+	if err := rdr.Err(); err != nil {
+		return nil, fmt.Errorf("table: %v", err)
+	}
+	return t, nil
+}
+
+func arrowTypeOK(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.INT64, arrow.FLOAT64, arrow.STRING:
+		return true
+	case arrow.LIST:
+		return dt.(*arrow.ListType).Elem().ID() == arrow.FLOAT64
+	}
+	return false
+}
+
+func floatValue(f float64) eval.Value {
+	if math.IsNaN(f) {
+		return nil
+	}
+	return big.NewFloat(f)
+}
+
+// appendArrow appends the values of col, whose type is accepted by
+// arrowTypeOK, to vals.
+func appendArrow(vals []eval.Value, col arrow.Array) []eval.Value {
+	switch col := col.(type) {
+	case *array.Int64:
+		for i, v := range col.Int64Values() {
+			if col.IsNull(i) {
+				vals = append(vals, nil)
+			} else {
+				vals = append(vals, big.NewInt(v))
+			}
+		}
+	case *array.Float64:
+		for i, v := range col.Float64Values() {
+			if col.IsNull(i) {
+				vals = append(vals, nil)
+			} else {
+				vals = append(vals, floatValue(v))
+			}
+		}
+	case *array.String:
+		for i := 0; i < col.Len(); i++ {
+			if col.IsNull(i) {
+				vals = append(vals, nil)
+			} else {
+				vals = append(vals, col.Value(i))
+			}
+		}
+	case *array.List:
+		elems := col.ListValues().(*array.Float64)
+		floats := elems.Float64Values()
+		for i := 0; i < col.Len(); i++ {
+			if col.IsNull(i) {
+				vals = append(vals, nil)
+				continue
+			}
+			start, end := col.ValueOffsets(i)
+			list := make([]eval.Value, 0, end-start)
+			for j := start; j < end; j++ {
+				if elems.IsNull(int(j)) {
+					list = append(list, nil)
+				} else {
+					list = append(list, floatValue(floats[j]))
+				}
+			}
+			vals = append(vals, list)
+		}
+	}
+	return vals
+}
+
+// WriteArrow writes t as an Arrow IPC stream of one record batch.
+//
+// Each column's Arrow type comes from its first non-nil value:
+// int64 for a *big.Int, float64 for a *big.Float, utf8 for a string
+// and list<float64> for a []Value. A column of nil Values is written
+// as float64 nulls.
+func WriteArrow(w io.Writer, t *eval.Table) error {
+	fields := make([]arrow.Field, len(t.Cols))
+	for i, col := range t.Cols {
+		dt, err := arrowType(col)
+		if err != nil {
+			return fmt.Errorf("table: column %d: %v", i, err)
+		}
+		fields[i] = arrow.Field{Name: t.ColNames[i], Type: dt, Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	for i, col := range t.Cols {
+		if err := buildArrow(b.Field(i), col); err != nil {
+			return fmt.Errorf("table: column %d: %v", i, err)
+		}
+	}
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	iw := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	if err := iw.Write(rec); err != nil {
+		iw.Close()
+		return fmt.Errorf("table: %v", err)
+	}
+	if err := iw.Close(); err != nil {
+		return fmt.Errorf("table: %v", err)
+	}
+	return nil
+}
+
+func arrowType(col []eval.Value) (arrow.DataType, error) {
+	for _, v := range col {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case *big.Int:
+			return arrow.PrimitiveTypes.Int64, nil
+		case *big.Float:
+			return arrow.PrimitiveTypes.Float64, nil
+		case string:
+			return arrow.BinaryTypes.String, nil
+		case []eval.Value:
+			return arrow.ListOf(arrow.PrimitiveTypes.Float64), nil
+		default:
+			return nil, fmt.Errorf("cannot write %T to Arrow", v)
+		}
+	}
+	return arrow.PrimitiveTypes.Float64, nil
+}
+
+func buildArrow(b array.Builder, col []eval.Value) error {
+	for y, v := range col {
+		if v == nil {
+			b.AppendNull()
+			continue
+		}
+		switch b := b.(type) {
+		case *array.Int64Builder:
+			i, ok := v.(*big.Int)
+			if !ok || !i.IsInt64() {
+				return mismatch(y, v, b)
+			}
+			b.Append(i.Int64())
+		case *array.Float64Builder:
+			f, ok := toFloat(v)
+			if !ok {
+				return mismatch(y, v, b)
+			}
+			b.Append(f)
+		case *array.StringBuilder:
+			s, ok := v.(string)
+			if !ok {
+				return mismatch(y, v, b)
+			}
+			b.Append(s)
+		case *array.ListBuilder:
+			list, ok := v.([]eval.Value)
+			if !ok {
+				return mismatch(y, v, b)
+			}
+			b.Append(true)
+			vb := b.ValueBuilder().(*array.Float64Builder)
+			for _, elem := range list {
+				if elem == nil {
+					vb.AppendNull()
+					continue
+				}
+				f, ok := toFloat(elem)
+				if !ok {
+					return fmt.Errorf("row %d: cannot write %T to an Arrow list<float64>", y, elem)
+				}
+				vb.Append(f)
+			}
+		}
+	}
+	return nil
+}
+
+// mismatch is the error for a value v in row y that b cannot hold.
+func mismatch(y int, v eval.Value, b array.Builder) error {
+	return fmt.Errorf("row %d: cannot write %T to an Arrow %T", y, v, b)
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func TestArrowRoundTrip(t *testing.T) {
+	want := &eval.Table{
+		ColNames: []string{"n", "x", "s", "l", "empty"},
+		Cols: [][]eval.Value{
+			col(1, nil, -7),
+			col(2.5, -1e300, nil),
+			{"a", nil, "c, d"},
+			{col(1.0, 2.0), nil, col(0.5, nil)},
+			{nil, nil, nil},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := table.WriteArrow(buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := table.ReadArrow(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if show(got) != show(want) {
+		t.Errorf("round trip:\n%s\nwant:\n%s", show(got), show(want))
+	}
+}
+
+func TestWriteArrowError(t *testing.T) {
+	for _, test := range []struct {
+		t    *eval.Table
+		want string
+	}{
+		{
+			&eval.Table{ColNames: []string{"a"}, Cols: [][]eval.Value{col(1, "x")}},
+			"column 0: row 1: cannot write string",
+		},
+		{
+			&eval.Table{ColNames: []string{"a"}, Cols: [][]eval.Value{{true}}},
+			"column 0: cannot write bool to Arrow",
+		},
+		{
+			&eval.Table{ColNames: []string{"a"}, Cols: [][]eval.Value{{new(big.Int).Lsh(big.NewInt(1), 64)}}},
+			"column 0: row 0: cannot write *big.Int",
+		},
+	} {
+		err := table.WriteArrow(new(bytes.Buffer), test.t)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("WriteArrow(%s): error %v, want %q", show(test.t), err, test.want)
+		}
+	}
+}
+
+func TestReadArrowError(t *testing.T) {
+	if _, err := table.ReadArrow(strings.NewReader("not arrow")); err == nil {
+		t.Error("ReadArrow of garbage: missing error")
+	}
+}
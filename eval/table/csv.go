@@ -0,0 +1,209 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package table reads and writes Neugram table values.
+package table // import "neugram.io/ng/eval/table"
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+
+	"neugram.io/ng/eval"
+)
+
+// CSVOptions configures ReadCSV and WriteCSV.
+type CSVOptions struct {
+	// HasHeader reports whether the first record holds the
+	// column names.
+	HasHeader bool
+
+	// Delimiter separates fields. Zero means ','.
+	Delimiter rune
+
+	// Comment, if not zero, starts a line that is skipped.
+	Comment rune
+
+	// NullValues are the fields read as null, in addition to the
+	// empty field. WriteCSV writes null as NullValues[0], or as
+	// an empty field if there are none.
+	NullValues []string
+}
+
+func (opts *CSVOptions) delimiter() rune {
+	if opts.Delimiter == 0 {
+		return ','
+	}
+	return opts.Delimiter
+}
+
+func (opts *CSVOptions) isNull(field string) bool {
+	if field == "" {
+		return true
+	}
+	for _, null := range opts.NullValues {
+		if field == null {
+			return true
+		}
+	}
+	return false
+}
+
+// A colKind is the type inferred for a CSV column.
+type colKind int
+
+const (
+	kindUnknown colKind = iota // only nulls seen so far
+	kindInt
+	kindFloat
+	kindString
+	kindBool
+)
+
+var kindNames = [...]string{
+	kindInt:    "int64",
+	kindFloat:  "float64",
+	kindString: "string",
+	kindBool:   "bool",
+}
+
+func inferKind(field string) colKind {
+	if _, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return kindInt
+	}
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		return kindFloat
+	}
+	return kindString
+}
+
+// ReadCSV reads a table from CSV data.
+//
+// Each column holds int64 (*big.Int), float64 (*big.Float) or string
+// values. The type is inferred from the first non-null value in the
+// column, normally the one in the first record after the header.
+// A later value that does not parse as that type is an error.
+// An empty field, one listed in opts.NullValues, or a NaN float is
+// a nil Value.
+//
+// Records are read one at a time, so r is never held in memory.
+func ReadCSV(r io.Reader, opts CSVOptions) (*eval.Table, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.delimiter()
+	cr.Comment = opts.Comment
+	cr.ReuseRecord = true
+
+	t := &eval.Table{}
+	var kinds []colKind
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("table: %v", err)
+		}
+		if line == 0 {
+			t.ColNames = make([]string, len(record))
+			t.Cols = make([][]eval.Value, len(record))
+			kinds = make([]colKind, len(record))
+			if opts.HasHeader {
+				copy(t.ColNames, record)
+				continue
+			}
+		}
+		for i, field := range record {
+			if opts.isNull(field) {
+				t.Cols[i] = append(t.Cols[i], nil)
+				continue
+			}
+			if kinds[i] == kindUnknown {
+				kinds[i] = inferKind(field)
+			}
+			v, err := parseField(field, kinds[i])
+			if err != nil {
+				row, _ := cr.FieldPos(i)
+				return nil, fmt.Errorf("table: line %d: column %s: %v", row, colName(t, i), err)
+			}
+			t.Cols[i] = append(t.Cols[i], v)
+		}
+	}
+	return t, nil
+}
+
+func parseField(field string, kind colKind) (eval.Value, error) {
+	switch kind {
+	case kindInt:
+		i, err := strconv.ParseInt(field, 10, 64)
+		if err == nil {
+			return big.NewInt(i), nil
+		}
+	case kindFloat:
+		f, err := strconv.ParseFloat(field, 64)
+		if err == nil {
+			if math.IsNaN(f) {
+				// big.Float has no NaN.
+				return nil, nil
+			}
+			return big.NewFloat(f), nil
+		}
+	default:
+		return field, nil
+	}
+	return nil, fmt.Errorf("cannot parse %q as %s", field, kindNames[kind])
+}
+
+func colName(t *eval.Table, i int) string {
+	if t.ColNames[i] != "" {
+		return strconv.Quote(t.ColNames[i])
+	}
+	return strconv.Itoa(i)
+}
+
+// WriteCSV writes t as CSV data, one record per row.
+//
+// The column names are written first if opts.HasHeader is set.
+// Numbers are written in their shortest exact form and nil Values
+// as null.
+func WriteCSV(w io.Writer, t *eval.Table, opts CSVOptions) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.delimiter()
+	null := ""
+	if len(opts.NullValues) > 0 {
+		null = opts.NullValues[0]
+	}
+
+	if opts.HasHeader {
+		if err := cw.Write(t.ColNames); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(t.Cols))
+	for y := 0; y < t.Len(); y++ {
+		for x, col := range t.Cols {
+			switch v := col[y].(type) {
+			case nil:
+				record[x] = null
+			case *big.Int:
+				record[x] = v.String()
+			case *big.Float:
+				record[x] = v.Text('g', -1)
+			case string:
+				record[x] = v
+			case bool:
+				record[x] = strconv.FormatBool(v)
+			default:
+				return fmt.Errorf("table: cannot write value [%d, %d] of type %T as CSV", x, y, v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
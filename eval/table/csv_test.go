@@ -0,0 +1,177 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+var readCSVTests = []struct {
+	name string
+	in   string
+	opts table.CSVOptions
+	want string
+}{
+	{
+		name: "header",
+		in:   "name,n,x\nab,1,2.5\ncd,-2,3\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["name" "n" "x"]
+"ab" *big.Int(1) *big.Float(2.5)
+"cd" *big.Int(-2) *big.Float(3)`,
+	},
+	{
+		name: "no header",
+		in:   "1,2\n3,4",
+		want: `["" ""]
+*big.Int(1) *big.Int(2)
+*big.Int(3) *big.Int(4)`,
+	},
+	{
+		name: "quoted fields",
+		in:   "a,b\n\"x, y\",\"say \"\"hi\"\"\"\n\"multi\nline\",2\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["a" "b"]
+"x, y" "say \"hi\""
+"multi\nline" "2"`,
+	},
+	{
+		name: "windows line endings",
+		in:   "a,b\r\n1,x\r\n2,y\r\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["a" "b"]
+*big.Int(1) "x"
+*big.Int(2) "y"`,
+	},
+	{
+		name: "empty fields",
+		in:   "a,b,c\n,1,x\n2,,\n3,4,z\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["a" "b" "c"]
+nil *big.Int(1) "x"
+*big.Int(2) nil nil
+*big.Int(3) *big.Int(4) "z"`,
+	},
+	{
+		name: "null values",
+		in:   "a;b\nNA;1.5\n1;NaN\n",
+		opts: table.CSVOptions{HasHeader: true, Delimiter: ';', NullValues: []string{"NA", "NaN"}},
+		want: `["a" "b"]
+nil *big.Float(1.5)
+*big.Int(1) nil`,
+	},
+	{
+		name: "NaN",
+		in:   "a\n1.5\nNaN\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["a"]
+*big.Float(1.5)
+nil`,
+	},
+	{
+		name: "comments",
+		in:   "# generated\na\n1\n# skipped\n2\n",
+		opts: table.CSVOptions{HasHeader: true, Comment: '#'},
+		want: `["a"]
+*big.Int(1)
+*big.Int(2)`,
+	},
+	{
+		name: "header only",
+		in:   "a,b\n",
+		opts: table.CSVOptions{HasHeader: true},
+		want: `["a" "b"]`,
+	},
+	{
+		name: "empty",
+		in:   "",
+		want: `[]`,
+	},
+}
+
+func TestReadCSV(t *testing.T) {
+	for _, test := range readCSVTests {
+		tbl, err := table.ReadCSV(strings.NewReader(test.in), test.opts)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := show(tbl); got != test.want {
+			t.Errorf("%s: ReadCSV=\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadCSVError(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"a,b\n1,2\nx,3\n", `line 3: column "a": cannot parse "x" as int64`},
+		{"a,b\n1.5,2\n2,y\n", `line 3: column "b": cannot parse "y" as int64`},
+		{"a,b\n1,2\n3\n", "wrong number of fields"},
+		{"a\n\"x\n", "extraneous or missing \" in quoted-field"},
+	} {
+		_, err := table.ReadCSV(strings.NewReader(test.in), table.CSVOptions{HasHeader: true})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ReadCSV(%q): error %v, want %q", test.in, err, test.want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tbl := &eval.Table{
+		ColNames: []string{"name", "n", "x"},
+		Cols: [][]eval.Value{
+			{"a, b", "c\"d", nil},
+			col(1, nil, -3),
+			col(2.5, 1e21, true),
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := table.WriteCSV(buf, tbl, table.CSVOptions{HasHeader: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,n,x\n\"a, b\",1,2.5\n\"c\"\"d\",,1e+21\n,-3,true\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV=%q, want %q", got, want)
+	}
+
+	buf.Reset()
+	opts := table.CSVOptions{Delimiter: '\t', NullValues: []string{"NA"}}
+	if err := table.WriteCSV(buf, tbl, opts); err != nil {
+		t.Fatal(err)
+	}
+	want = "a, b\t1\t2.5\n\"c\"\"d\"\tNA\t1e+21\nNA\t-3\ttrue\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV with tabs=%q, want %q", got, want)
+	}
+
+	tbl.Cols[0][0] = []eval.Value{}
+	if err := table.WriteCSV(buf, tbl, opts); err == nil {
+		t.Error("WriteCSV of a slice value: missing error")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	in := "name,n,x\n\"a, b\",1,2.5\n,,\nz,-7,1e-09\n"
+	opts := table.CSVOptions{HasHeader: true}
+	tbl, err := table.ReadCSV(strings.NewReader(in), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := table.WriteCSV(buf, tbl, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != in {
+		t.Errorf("round trip=%q, want %q", got, in)
+	}
+}
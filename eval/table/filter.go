@@ -0,0 +1,462 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/token"
+)
+
+// Filter returns a table of the rows of t for which predicate
+// returns true. Each row is passed as a new map from column name to
+// value.
+func Filter(t *eval.Table, predicate func(row map[string]eval.Value) bool) (*eval.Table, error) {
+	if err := distinctNames(t); err != nil {
+		return nil, fmt.Errorf("table: Filter: %v", err)
+	}
+	var rows []int
+	for y := 0; y < t.Len(); y++ {
+		row := make(map[string]eval.Value, len(t.Cols))
+		for x, col := range t.Cols {
+			row[t.ColNames[x]] = col[y]
+		}
+		if predicate(row) {
+			rows = append(rows, y)
+		}
+	}
+	return takeRows(t, rows), nil
+}
+
+// FilterExpr returns a table of the rows of t for which e is true.
+// Column names are bound as variables in e.
+//
+// The expression is compiled once. Comparisons, arithmetic and the
+// boolean operators are applied directly to the column values; other
+// parts of e are evaluated with eval.Eval, once if they do not use a
+// column and otherwise for each row. A null is not equal to any
+// value, itself included, and is neither less nor greater than one,
+// like NaN. Other operations on a null give a null, and a row whose
+// condition is null is left out.
+func FilterExpr(t *eval.Table, e expr.Expr) (*eval.Table, error) {
+	if err := distinctNames(t); err != nil {
+		return nil, fmt.Errorf("table: FilterExpr: %v", err)
+	}
+	c := &compiler{t: t}
+	cond, err := c.compileCond(e)
+	if err != nil {
+		return nil, fmt.Errorf("table: FilterExpr: %v", err)
+	}
+	var rows []int
+	for y := 0; y < t.Len(); y++ {
+		b, null, err := cond(y)
+		if err != nil {
+			return nil, fmt.Errorf("table: FilterExpr: row %d: %v", y, err)
+		}
+		if b && !null {
+			rows = append(rows, y)
+		}
+	}
+	return takeRows(t, rows), nil
+}
+
+func distinctNames(t *eval.Table) error {
+	seen := make(map[string]bool, len(t.ColNames))
+	for _, name := range t.ColNames {
+		if seen[name] {
+			return fmt.Errorf("duplicate column name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// takeRows returns a table of the given rows of t, in order.
+func takeRows(t *eval.Table, rows []int) *eval.Table {
+	res := &eval.Table{
+		ColNames: append([]string(nil), t.ColNames...),
+		Cols:     make([][]eval.Value, len(t.Cols)),
+	}
+	for x, col := range t.Cols {
+		taken := make([]eval.Value, len(rows))
+		for y, i := range rows {
+			taken[y] = col[i]
+		}
+		res.Cols[x] = taken
+	}
+	return res
+}
+
+// A rowFunc computes the value of an expression for row y.
+type rowFunc func(y int) (eval.Value, error)
+
+// A condFunc computes the value of a boolean expression for row y.
+// A null value is reported as null and false.
+type condFunc func(y int) (b, null bool, err error)
+
+// compiler turns an expression into a function of the rows of t.
+type compiler struct {
+	t *eval.Table
+}
+
+func (c *compiler) compile(e expr.Expr) (rowFunc, error) {
+	if len(c.columns(e)) == 0 {
+		return c.compileEval(e)
+	}
+	switch e := e.(type) {
+	case *expr.Ident:
+		col := c.t.Cols[colIndex(c.t, e.Name)]
+		return func(y int) (eval.Value, error) { return col[y], nil }, nil
+	case *expr.Unary:
+		switch e.Op {
+		case token.LeftParen:
+			return c.compile(e.Expr)
+		case token.Not:
+			return c.condValue(e)
+		case token.Add, token.Sub:
+			return c.compileSign(e)
+		}
+	case *expr.Binary:
+		if isCondOp(e.Op) {
+			return c.condValue(e)
+		}
+		switch e.Op {
+		case token.Add, token.Sub, token.Mul, token.Div, token.Rem:
+			return c.compileArith(e)
+		}
+	}
+	return c.compileEval(e)
+}
+
+func isCondOp(op token.Token) bool {
+	switch op {
+	case token.LogicalAnd, token.LogicalOr,
+		token.Equal, token.NotEqual, token.Less, token.LessEqual, token.Greater, token.GreaterEqual:
+		return true
+	}
+	return false
+}
+
+// condValue compiles a boolean expression to a rowFunc.
+func (c *compiler) condValue(e expr.Expr) (rowFunc, error) {
+	cond, err := c.compileCond(e)
+	if err != nil {
+		return nil, err
+	}
+	return func(y int) (eval.Value, error) {
+		b, null, err := cond(y)
+		if err != nil || null {
+			return nil, err
+		}
+		return b, nil
+	}, nil
+}
+
+func (c *compiler) compileCond(e expr.Expr) (condFunc, error) {
+	switch e := e.(type) {
+	case *expr.Unary:
+		switch e.Op {
+		case token.LeftParen:
+			return c.compileCond(e.Expr)
+		case token.Not:
+			return c.compileNot(e)
+		}
+	case *expr.Binary:
+		switch e.Op {
+		case token.LogicalAnd, token.LogicalOr:
+			return c.compileLogical(e)
+		}
+		if isCondOp(e.Op) {
+			return c.compileCompare(e)
+		}
+	}
+	f, err := c.compile(e)
+	if err != nil {
+		return nil, err
+	}
+	return func(y int) (bool, bool, error) {
+		v, err := f(y)
+		if err != nil {
+			return false, false, err
+		}
+		switch v := v.(type) {
+		case bool:
+			return v, false, nil
+		case nil:
+			return false, true, nil
+		}
+		return false, false, fmt.Errorf("non-bool %s (%T) used as condition", format.Expr(e), v)
+	}, nil
+}
+
+func (c *compiler) compileNot(e *expr.Unary) (condFunc, error) {
+	x, err := c.compileCond(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(y int) (bool, bool, error) {
+		b, null, err := x(y)
+		if err != nil || null {
+			return false, null, err
+		}
+		return !b, false, nil
+	}, nil
+}
+
+// compileLogical compiles && and ||. A null operand is unknown: it
+// decides nothing, so null && false is false and null || true is
+// true, but null && true is null.
+func (c *compiler) compileLogical(e *expr.Binary) (condFunc, error) {
+	left, err := c.compileCond(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.compileCond(e.Right)
+	if err != nil {
+		return nil, err
+	}
+	or := e.Op == token.LogicalOr
+	return func(y int) (bool, bool, error) {
+		l, lnull, err := left(y)
+		if err != nil {
+			return false, false, err
+		}
+		if !lnull && l == or {
+			return l, false, nil // short circuit
+		}
+		r, rnull, err := right(y)
+		if err != nil {
+			return false, false, err
+		}
+		if !rnull && r == or {
+			return r, false, nil
+		}
+		if lnull || rnull {
+			return false, true, nil
+		}
+		return r, false, nil
+	}, nil
+}
+
+func (c *compiler) compileCompare(e *expr.Binary) (condFunc, error) {
+	left, err := c.compile(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.compile(e.Right)
+	if err != nil {
+		return nil, err
+	}
+	op := e.Op
+	return func(y int) (bool, bool, error) {
+		l, err := left(y)
+		if err != nil {
+			return false, false, err
+		}
+		r, err := right(y)
+		if err != nil {
+			return false, false, err
+		}
+		if l == nil || r == nil {
+			return op == token.NotEqual, false, nil
+		}
+		if lb, ok := l.(bool); ok {
+			if rb, ok := r.(bool); ok && (op == token.Equal || op == token.NotEqual) {
+				return (lb == rb) == (op == token.Equal), false, nil
+			}
+		}
+		cmp, err := compareNumbers(l, r)
+		if err != nil {
+			return false, false, fmt.Errorf("%s: %v", format.Expr(e), err)
+		}
+		switch op {
+		case token.Equal:
+			return cmp == 0, false, nil
+		case token.NotEqual:
+			return cmp != 0, false, nil
+		case token.Less:
+			return cmp < 0, false, nil
+		case token.LessEqual:
+			return cmp <= 0, false, nil
+		case token.Greater:
+			return cmp > 0, false, nil
+		default: // token.GreaterEqual
+			return cmp >= 0, false, nil
+		}
+	}, nil
+}
+
+// compileSign compiles unary + and -.
+func (c *compiler) compileSign(e *expr.Unary) (rowFunc, error) {
+	x, err := c.compile(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	neg := e.Op == token.Sub
+	return func(y int) (eval.Value, error) {
+		v, err := x(y)
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case nil:
+			return nil, nil
+		case *big.Int:
+			if neg {
+				return new(big.Int).Neg(v), nil
+			}
+			return v, nil
+		case *big.Float:
+			if neg {
+				return new(big.Float).Neg(v), nil
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s: operator %s not defined on %T value", format.Expr(e), e.Op, v)
+	}, nil
+}
+
+// compileArith compiles the arithmetic operators.
+func (c *compiler) compileArith(e *expr.Binary) (rowFunc, error) {
+	left, err := c.compile(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.compile(e.Right)
+	if err != nil {
+		return nil, err
+	}
+	return func(y int) (eval.Value, error) {
+		l, err := left(y)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(y)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		v, err := arith(e.Op, l, r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", format.Expr(e), err)
+		}
+		return v, nil
+	}, nil
+}
+
+// arith applies op to x and y as eval.Eval does: integers give an
+// integer, division truncating, other numbers a float, and + joins
+// strings.
+func arith(op token.Token, x, y eval.Value) (eval.Value, error) {
+	switch x := x.(type) {
+	case string:
+		if y, ok := y.(string); ok && op == token.Add {
+			return x + y, nil
+		}
+	case *big.Int:
+		if y, ok := y.(*big.Int); ok {
+			z := new(big.Int)
+			switch op {
+			case token.Add:
+				return z.Add(x, y), nil
+			case token.Sub:
+				return z.Sub(x, y), nil
+			case token.Mul:
+				return z.Mul(x, y), nil
+			}
+			if y.Sign() == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if op == token.Div {
+				return z.Quo(x, y), nil
+			}
+			return z.Rem(x, y), nil
+		}
+	}
+	xf, yf := toBigFloat(x), toBigFloat(y)
+	if xf == nil || yf == nil || op == token.Rem {
+		return nil, fmt.Errorf("operator %s not defined on %T and %T values", op, x, y)
+	}
+	switch op {
+	case token.Add:
+		return xf.Add(xf, yf), nil
+	case token.Sub:
+		return xf.Sub(xf, yf), nil
+	case token.Mul:
+		return xf.Mul(xf, yf), nil
+	}
+	if yf.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return xf.Quo(xf, yf), nil
+}
+
+// compareNumbers is compareValues with the conversions skipped when
+// both numbers already have the same type.
+func compareNumbers(x, y eval.Value) (int, error) {
+	switch x := x.(type) {
+	case *big.Int:
+		if y, ok := y.(*big.Int); ok {
+			return x.Cmp(y), nil
+		}
+	case *big.Float:
+		if y, ok := y.(*big.Float); ok {
+			return x.Cmp(y), nil
+		}
+	}
+	return compareValues(x, y)
+}
+
+// compileEval compiles e to a call of eval.Eval. If e uses no
+// columns it is evaluated now, otherwise for each row in an Env
+// holding the columns it uses.
+func (c *compiler) compileEval(e expr.Expr) (rowFunc, error) {
+	cols := c.columns(e)
+	env := eval.NewEnv(nil)
+	if len(cols) == 0 {
+		v, err := eval.Eval(e, env)
+		if err != nil {
+			return nil, err
+		}
+		return func(int) (eval.Value, error) { return v, nil }, nil
+	}
+	return func(y int) (eval.Value, error) {
+		for _, x := range cols {
+			v := c.t.Cols[x][y]
+			if v == nil {
+				return nil, nil
+			}
+			env.Set(c.t.ColNames[x], v)
+		}
+		return eval.Eval(e, env)
+	}, nil
+}
+
+// columns lists the positions of the columns of c.t used in e.
+func (c *compiler) columns(e expr.Expr) []int {
+	var cols []int
+	used := make(map[int]bool)
+	syntax.Walk(e, func(cur *syntax.Cursor) bool {
+		if _, ok := cur.Parent.(*expr.Selector); ok && cur.Name == "Right" {
+			return false
+		}
+		if id, ok := cur.Node.(*expr.Ident); ok {
+			if x := colIndex(c.t, id.Name); x >= 0 && !used[x] {
+				used[x] = true
+				cols = append(cols, x)
+			}
+		}
+		return true
+	}, nil)
+	return cols
+}
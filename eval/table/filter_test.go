@@ -0,0 +1,190 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"math/big"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+)
+
+func filterTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"id", "x", "y", "ok"},
+		Cols: [][]eval.Value{
+			{"a", "b", "c", "d", "e"},
+			col(1, 2.5, nil, 4, 0.5),
+			col(2, 5, 3, nil, 1),
+			{true, false, true, nil, true},
+		},
+	}
+}
+
+func parseExpr(t *testing.T, src string) expr.Expr {
+	s, err := parser.ParseStmt([]byte(src))
+	if err != nil {
+		t.Fatalf("ParseStmt(%q): %v", src, err)
+	}
+	return s.(*stmt.Simple).Expr
+}
+
+func TestFilter(t *testing.T) {
+	got, err := table.Filter(filterTable(), func(row map[string]eval.Value) bool {
+		x, ok := row["x"].(*big.Int)
+		return ok && x.Sign() > 0 && row["ok"] == true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["id" "x" "y" "ok"]
+"a" *big.Int(1) *big.Int(2) bool(true)`
+	if s := show(got); s != want {
+		t.Errorf("Filter=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+var filterExprTests = []struct {
+	expr string
+	ids  string
+}{
+	{`x > 1`, "b d"},
+	{`x > 1 && y < 5`, ""},
+	{`x < 4 && y >= 3 || id == "d"`, "b d"},
+	{`x*2 >= y`, "a b e"},
+	{`(x + y) <= 3`, "a e"},
+	{`ok`, "a c e"},
+	{`!ok`, "b"},
+	{`ok == false`, "b"},
+	{`id != "c" && id != "e"`, "a b d"},
+
+	// Nulls compare like NaN.
+	{`x == x`, "a b d e"},
+	{`x != x`, "c"},
+	{`y != 3`, "a b d e"},
+	{`!(x > 1)`, "a c e"},
+	{`x + 1 > 0`, "a b d e"},
+
+	// Arithmetic.
+	{`y - x > 0`, "a b e"},
+	{`y / 2 == 1`, "a c"},
+	{`y % 2 == 1`, "b c e"},
+	{`x / 2 > 1`, "b d"},
+	{`-x < -1`, "b d"},
+	{`+y > 4`, "b"},
+	{`id + "!" == "a!"`, "a"},
+	{`x * (1 + 1) >= y`, "a b e"},
+
+	// A null condition is unknown.
+	{`ok || x > 3`, "a c d e"},
+	{`ok && x > 0`, "a e"},
+
+	{`x > 100`, ""},
+	{`false`, ""},
+	{`1 < 2`, "a b c d e"},
+}
+
+func TestFilterExpr(t *testing.T) {
+	for _, test := range filterExprTests {
+		got, err := table.FilterExpr(filterTable(), parseExpr(t, test.expr))
+		if err != nil {
+			t.Errorf("FilterExpr(%s): %v", test.expr, err)
+			continue
+		}
+		if ids := rowIDs(got, 0); ids != test.ids {
+			t.Errorf("FilterExpr(%s) rows %q, want %q", test.expr, ids, test.ids)
+		}
+	}
+}
+
+func TestFilterEmpty(t *testing.T) {
+	got, err := table.FilterExpr(filterTable(), parseExpr(t, `y > 5`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != 0 || len(got.Cols) != 4 {
+		t.Errorf("FilterExpr(y > 5) has %d rows and %d columns, want 0 and 4", got.Len(), len(got.Cols))
+	}
+	if s, want := show(got), `["id" "x" "y" "ok"]`; s != want {
+		t.Errorf("FilterExpr(y > 5)=\n%s\nwant:\n%s", s, want)
+	}
+
+	got, err = table.Filter(&eval.Table{}, func(map[string]eval.Value) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != 0 {
+		t.Errorf("Filter of an empty table has %d rows", got.Len())
+	}
+}
+
+func TestFilterError(t *testing.T) {
+	for _, test := range []struct {
+		expr, err string
+	}{
+		{`x + 1`, `table: FilterExpr: row 0: non-bool x + 1 (*big.Int) used as condition`},
+		{`z > 1`, `table: FilterExpr: eval: undefined: z`},
+		{`id < 3`, `table: FilterExpr: row 0: id < 3: cannot compare string and *big.Int values`},
+		{`x && ok`, `table: FilterExpr: row 0: non-bool x (*big.Int) used as condition`},
+		{`y / (x - 1) > 0`, `table: FilterExpr: row 0: y / (x - 1): division by zero`},
+		{`x % 2 == 0`, `table: FilterExpr: row 1: x % 2: operator % not defined on *big.Float and *big.Int values`},
+		{`id * 2 == 0`, `table: FilterExpr: row 0: id * 2: operator * not defined on string and *big.Int values`},
+		{`-id == 0`, `table: FilterExpr: row 0: -id: operator - not defined on string value`},
+	} {
+		_, err := table.FilterExpr(filterTable(), parseExpr(t, test.expr))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("FilterExpr(%s) err=%v, want %q", test.expr, err, test.err)
+		}
+	}
+
+	dup := &eval.Table{ColNames: []string{"a", "a"}, Cols: [][]eval.Value{{}, {}}}
+	_, err := table.Filter(dup, func(map[string]eval.Value) bool { return true })
+	if want := `table: Filter: duplicate column name "a"`; err == nil || err.Error() != want {
+		t.Errorf("Filter with duplicate names err=%v, want %q", err, want)
+	}
+}
+
+// benchTable returns a table of n rows with an integer column x.
+func benchTable(n int) *eval.Table {
+	x := make([]eval.Value, n)
+	for y := range x {
+		x[y] = big.NewInt(int64(y))
+	}
+	return &eval.Table{ColNames: []string{"x"}, Cols: [][]eval.Value{x}}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	t := benchTable(100000)
+	one, limit := big.NewInt(1), big.NewInt(50000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := table.Filter(t, func(row map[string]eval.Value) bool {
+			x := new(big.Int).Add(row["x"].(*big.Int), one)
+			return x.Cmp(limit) > 0
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilterExpr(b *testing.B) {
+	t := benchTable(100000)
+	s, err := parser.ParseStmt([]byte("x + 1 > 50000"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	e := s.(*stmt.Simple).Expr
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := table.FilterExpr(t, e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"neugram.io/ng/eval"
+)
+
+// An Aggregation computes one value from the rows of a group.
+type Aggregation interface {
+	// Column is the name of the column read by the aggregation,
+	// or "" if it reads none.
+	Column() string
+
+	// Name is the name of the result column.
+	Name() string
+
+	// Aggregate computes the result from the values of Column in
+	// the rows of one group, in row order. If Column is "", vals
+	// holds a nil for each row.
+	Aggregate(vals []eval.Value) (eval.Value, error)
+}
+
+type aggregation struct {
+	fn  string
+	col string
+	agg func(vals []eval.Value) (eval.Value, error)
+}
+
+func (a *aggregation) Column() string { return a.col }
+func (a *aggregation) Name() string   { return a.fn + "(" + a.col + ")" }
+func (a *aggregation) Aggregate(vals []eval.Value) (eval.Value, error) {
+	v, err := a.agg(vals)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", a.Name(), err)
+	}
+	return v, nil
+}
+
+// Sum adds the numbers in col. The sum is a *big.Int if every
+// number is, and a *big.Float otherwise. Nulls are skipped.
+func Sum(col string) Aggregation {
+	return &aggregation{fn: "sum", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		sum, _, err := sum(vals)
+		return sum, err
+	}}
+}
+
+// Mean computes the mean of the numbers in col as a *big.Float.
+// Nulls are skipped. The mean of no numbers is null.
+func Mean(col string) Aggregation {
+	return &aggregation{fn: "mean", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		s, n, err := sum(vals)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		f := toBigFloat(s)
+		return f.Quo(f, new(big.Float).SetInt64(int64(n))), nil
+	}}
+}
+
+// Min finds the smallest number or string in col. Nulls are
+// skipped. The minimum of no values is null.
+func Min(col string) Aggregation {
+	return &aggregation{fn: "min", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		return extreme(vals, -1)
+	}}
+}
+
+// Max finds the largest number or string in col. Nulls are
+// skipped. The maximum of no values is null.
+func Max(col string) Aggregation {
+	return &aggregation{fn: "max", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		return extreme(vals, +1)
+	}}
+}
+
+// Count counts the rows of each group as a *big.Int.
+func Count() Aggregation {
+	return &aggregation{fn: "count", agg: func(vals []eval.Value) (eval.Value, error) {
+		return big.NewInt(int64(len(vals))), nil
+	}}
+}
+
+// First takes the value of col in the first row of each group.
+func First(col string) Aggregation {
+	return &aggregation{fn: "first", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		return vals[0], nil
+	}}
+}
+
+// Last takes the value of col in the last row of each group.
+func Last(col string) Aggregation {
+	return &aggregation{fn: "last", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		return vals[len(vals)-1], nil
+	}}
+}
+
+// Collect gathers the values of col in each group into a []Value.
+func Collect(col string) Aggregation {
+	return &aggregation{fn: "collect", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		return append([]eval.Value(nil), vals...), nil
+	}}
+}
+
+func toBigFloat(v eval.Value) *big.Float {
+	switch v := v.(type) {
+	case *big.Int:
+		return new(big.Float).SetInt(v)
+	case *big.Float:
+		return new(big.Float).Set(v)
+	}
+	return nil
+}
+
+// sum adds the non-nil values of vals, and reports how many there
+// were.
+func sum(vals []eval.Value) (eval.Value, int, error) {
+	isum := new(big.Int)
+	var fsum *big.Float
+	n := 0
+	for _, v := range vals {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case *big.Int:
+			isum.Add(isum, v)
+		case *big.Float:
+			if fsum == nil {
+				fsum = new(big.Float)
+			}
+			fsum.Add(fsum, v)
+		default:
+			return nil, 0, fmt.Errorf("cannot add %T values", v)
+		}
+		n++
+	}
+	if fsum == nil {
+		return isum, n, nil
+	}
+	return fsum.Add(fsum, new(big.Float).SetInt(isum)), n, nil
+}
+
+// extreme returns the smallest (dir < 0) or largest (dir > 0)
+// non-nil value of vals.
+func extreme(vals []eval.Value, dir int) (eval.Value, error) {
+	var best eval.Value
+	for _, v := range vals {
+		if v == nil {
+			continue
+		}
+		if best == nil {
+			switch v.(type) {
+			case *big.Int, *big.Float, string:
+				best = v
+				continue
+			}
+			return nil, fmt.Errorf("cannot compare %T values", v)
+		}
+		c, err := compareValues(v, best)
+		if err != nil {
+			return nil, err
+		}
+		if c == dir {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+// compareValues compares two numbers or two strings.
+func compareValues(x, y eval.Value) (int, error) {
+	if xs, ok := x.(string); ok {
+		if ys, ok := y.(string); ok {
+			return strings.Compare(xs, ys), nil
+		}
+	} else if xf, yf := toBigFloat(x), toBigFloat(y); xf != nil && yf != nil {
+		return xf.Cmp(yf), nil
+	}
+	return 0, fmt.Errorf("cannot compare %T and %T values", x, y)
+}
+
+// GroupBy groups the rows of t by the values of the keys columns
+// and computes aggs for each group.
+//
+// The result has the keys columns followed by one column for each
+// aggregation, named by its Name. It has a row for each distinct
+// combination of key values, in the order of their first row in t.
+// Numbers are equal keys if their values are, so 1 and 1.0 are
+// grouped together.
+func GroupBy(t *eval.Table, keys []string, aggs []Aggregation) (*eval.Table, error) {
+	keyCols := make([][]eval.Value, len(keys))
+	for i, name := range keys {
+		x := colIndex(t, name)
+		if x < 0 {
+			return nil, fmt.Errorf("table: GroupBy: no column %q", name)
+		}
+		keyCols[i] = t.Cols[x]
+	}
+	aggCols := make([][]eval.Value, len(aggs))
+	for i, agg := range aggs {
+		if agg.Column() == "" {
+			continue
+		}
+		x := colIndex(t, agg.Column())
+		if x < 0 {
+			return nil, fmt.Errorf("table: GroupBy: %s: no column %q", agg.Name(), agg.Column())
+		}
+		aggCols[i] = t.Cols[x]
+	}
+
+	// Each group is the list of its rows, found by a map keyed
+	// by the serialized key tuple.
+	var groups [][]int
+	index := make(map[string]int)
+	buf := new(bytes.Buffer)
+	for y := 0; y < t.Len(); y++ {
+		buf.Reset()
+		for i, col := range keyCols {
+			if err := writeKey(buf, col[y]); err != nil {
+				return nil, fmt.Errorf("table: GroupBy: column %q: %v", keys[i], err)
+			}
+		}
+		g, ok := index[buf.String()]
+		if !ok {
+			g = len(groups)
+			index[buf.String()] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], y)
+	}
+
+	res := &eval.Table{
+		ColNames: append([]string(nil), keys...),
+		Cols:     make([][]eval.Value, len(keys)+len(aggs)),
+	}
+	for i, col := range keyCols {
+		vals := make([]eval.Value, len(groups))
+		for g, rows := range groups {
+			vals[g] = col[rows[0]]
+		}
+		res.Cols[i] = vals
+	}
+	var vals []eval.Value
+	for i, agg := range aggs {
+		res.ColNames = append(res.ColNames, agg.Name())
+		out := make([]eval.Value, len(groups))
+		for g, rows := range groups {
+			vals = vals[:0]
+			for _, y := range rows {
+				if aggCols[i] == nil {
+					vals = append(vals, nil)
+				} else {
+					vals = append(vals, aggCols[i][y])
+				}
+			}
+			v, err := agg.Aggregate(vals)
+			if err != nil {
+				return nil, fmt.Errorf("table: GroupBy: %v", err)
+			}
+			out[g] = v
+		}
+		res.Cols[len(keys)+i] = out
+	}
+	return res, nil
+}
+
+// writeKey writes an unambiguous encoding of v to buf.
+func writeKey(buf *bytes.Buffer, v eval.Value) error {
+	var tag byte
+	var text string
+	switch v := v.(type) {
+	case nil:
+		tag = 'z'
+	case *big.Int, *big.Float:
+		// The 'p' format is exact and does not depend on the
+		// precision of f, so equal numbers encode alike.
+		tag = 'n'
+		if f := toBigFloat(v); f.Sign() != 0 {
+			text = f.Text('p', 0)
+		}
+	case string:
+		tag, text = 's', v
+	case bool:
+		tag, text = 'b', strconv.FormatBool(v)
+	default:
+		return fmt.Errorf("cannot group by %T values", v)
+	}
+	buf.WriteByte(tag)
+	buf.WriteString(strconv.Itoa(len(text)))
+	buf.WriteByte(':')
+	buf.WriteString(text)
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func salesTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"region", "year", "sales", "price", "rep"},
+		Cols: [][]eval.Value{
+			{"east", "west", "east", "west", "east", "north"},
+			col(2016, 2016, 2017, 2016, 2016, 2017),
+			col(10, 20, 30, 5, nil, 7),
+			col(1.5, 2.0, 2.5, 3, 0.5, nil),
+			{"ann", "bob", "cy", "dee", "eve", "fay"},
+		},
+	}
+}
+
+var groupByTests = []struct {
+	name string
+	keys []string
+	aggs []table.Aggregation
+	want string
+}{
+	{
+		name: "single key",
+		keys: []string{"region"},
+		aggs: []table.Aggregation{
+			table.Sum("sales"),
+			table.Mean("price"),
+			table.Count(),
+			table.Min("rep"),
+			table.Max("price"),
+		},
+		want: `["region" "sum(sales)" "mean(price)" "count()" "min(rep)" "max(price)"]
+"east" *big.Int(40) *big.Float(1.5) *big.Int(3) "ann" *big.Float(2.5)
+"west" *big.Int(25) *big.Float(2.5) *big.Int(2) "bob" *big.Int(3)
+"north" *big.Int(7) nil *big.Int(1) "fay" nil`,
+	},
+	{
+		name: "multiple keys",
+		keys: []string{"year", "region"},
+		aggs: []table.Aggregation{
+			table.First("rep"),
+			table.Last("rep"),
+			table.Collect("sales"),
+			table.Sum("price"),
+		},
+		want: `["year" "region" "first(rep)" "last(rep)" "collect(sales)" "sum(price)"]
+*big.Int(2016) "east" "ann" "eve" []eval.Value([10 <nil>]) *big.Float(2)
+*big.Int(2016) "west" "bob" "dee" []eval.Value([20 5]) *big.Float(5)
+*big.Int(2017) "east" "cy" "cy" []eval.Value([30]) *big.Float(2.5)
+*big.Int(2017) "north" "fay" "fay" []eval.Value([7]) *big.Int(0)`,
+	},
+	{
+		name: "no keys",
+		aggs: []table.Aggregation{table.Count(), table.Sum("sales")},
+		want: `["count()" "sum(sales)"]
+*big.Int(6) *big.Int(72)`,
+	},
+	{
+		name: "no aggregations",
+		keys: []string{"year"},
+		want: `["year"]
+*big.Int(2016)
+*big.Int(2017)`,
+	},
+}
+
+func TestGroupBy(t *testing.T) {
+	for _, test := range groupByTests {
+		got, err := table.GroupBy(salesTable(), test.keys, test.aggs)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if s := show(got); s != test.want {
+			t.Errorf("%s: GroupBy=\n%s\nwant:\n%s", test.name, s, test.want)
+		}
+	}
+}
+
+func TestGroupByEmpty(t *testing.T) {
+	empty := &eval.Table{
+		ColNames: []string{"k", "v"},
+		Cols:     [][]eval.Value{{}, {}},
+	}
+	got, err := table.GroupBy(empty, []string{"k"}, []table.Aggregation{table.Sum("v"), table.Count()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := show(got), `["k" "sum(v)" "count()"]`; s != want {
+		t.Errorf("GroupBy of empty table=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestGroupByNumericKeys(t *testing.T) {
+	big70 := new(big.Int).Lsh(big.NewInt(1), 70)
+	tbl := &eval.Table{
+		ColNames: []string{"k"},
+		Cols: [][]eval.Value{{
+			big.NewInt(1), big.NewFloat(1), big70, big.NewFloat(1 << 70),
+			big.NewFloat(0), new(big.Float).Neg(big.NewFloat(0)), nil, nil, "1",
+		}},
+	}
+	got, err := table.GroupBy(tbl, []string{"k"}, []table.Aggregation{table.Count()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["k" "count()"]
+*big.Int(1) *big.Int(2)
+*big.Int(1180591620717411303424) *big.Int(2)
+*big.Float(0) *big.Int(2)
+nil *big.Int(2)
+"1" *big.Int(1)`
+	if s := show(got); s != want {
+		t.Errorf("GroupBy=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestGroupByError(t *testing.T) {
+	bad := salesTable()
+	bad.Cols[2][1] = "twenty"
+	for _, test := range []struct {
+		t    *eval.Table
+		keys []string
+		aggs []table.Aggregation
+		want string
+	}{
+		{salesTable(), []string{"city"}, nil, `GroupBy: no column "city"`},
+		{salesTable(), nil, []table.Aggregation{table.Mean("cost")}, `GroupBy: mean(cost): no column "cost"`},
+		{bad, []string{"region"}, []table.Aggregation{table.Sum("sales")}, "sum(sales): cannot add string values"},
+		{bad, []string{"region"}, []table.Aggregation{table.Max("sales")}, "max(sales): cannot compare *big.Int and string values"},
+		{bad, []string{"region"}, []table.Aggregation{table.Min("year"), table.Collect("sales")}, ""},
+	} {
+		_, err := table.GroupBy(test.t, test.keys, test.aggs)
+		if test.want == "" {
+			if err != nil {
+				t.Errorf("GroupBy(%v): %v", test.keys, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("GroupBy(%v): error %v, want %q", test.keys, err, test.want)
+		}
+	}
+
+	list := &eval.Table{ColNames: []string{"k"}, Cols: [][]eval.Value{{[]eval.Value{}}}}
+	if _, err := table.GroupBy(list, []string{"k"}, nil); err == nil || !strings.Contains(err.Error(), `column "k": cannot group by []eval.Value values`) {
+		t.Errorf("GroupBy of list keys: error %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"neugram.io/ng/eval"
+)
+
+// col returns a table column of vals, with each int made a *big.Int
+// and each float64 a *big.Float.
+func col(vals ...interface{}) []eval.Value {
+	c := make([]eval.Value, len(vals))
+	for i, v := range vals {
+		switch v := v.(type) {
+		case int:
+			c[i] = big.NewInt(int64(v))
+		case float64:
+			c[i] = big.NewFloat(v)
+		default:
+			c[i] = v
+		}
+	}
+	return c
+}
+
+// show formats t as its column names followed by its rows, with
+// each value annotated by its Go type.
+func show(t *eval.Table) string {
+	s := []string{fmt.Sprintf("%q", t.ColNames)}
+	for y := 0; y < t.Len(); y++ {
+		var row []string
+		for _, col := range t.Cols {
+			switch v := col[y].(type) {
+			case nil:
+				row = append(row, "nil")
+			case string:
+				row = append(row, fmt.Sprintf("%q", v))
+			default:
+				row = append(row, fmt.Sprintf("%T(%v)", v, v))
+			}
+		}
+		s = append(s, strings.Join(row, " "))
+	}
+	return strings.Join(s, "\n")
+}
+
+// rowIDs names each row of t by the values of the columns at
+// positions cols, and lists the names separated by spaces.
+func rowIDs(t *eval.Table, cols ...int) string {
+	ids := make([]string, t.Len())
+	for y := range ids {
+		for _, x := range cols {
+			ids[y] += fmt.Sprint(t.Cols[x][y])
+		}
+	}
+	return strings.Join(ids, " ")
+}
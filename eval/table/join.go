@@ -0,0 +1,168 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"bytes"
+	"fmt"
+
+	"neugram.io/ng/eval"
+)
+
+// JoinKind selects which unmatched rows a Join keeps.
+type JoinKind int
+
+const (
+	Inner      JoinKind = iota // only matched rows
+	LeftOuter                  // and unmatched left rows
+	RightOuter                 // and unmatched right rows
+	FullOuter                  // and unmatched rows of both
+)
+
+func (k JoinKind) String() string {
+	switch k {
+	case Inner:
+		return "inner"
+	case LeftOuter:
+		return "left outer"
+	case RightOuter:
+		return "right outer"
+	case FullOuter:
+		return "full outer"
+	}
+	return fmt.Sprintf("JoinKind(%d)", int(k))
+}
+
+// Join joins the rows of left and right that have equal values in
+// the on columns, which must be in both tables.
+//
+// The result has the on columns, then the other columns of left,
+// then the other columns of right. A non-key column name found in
+// both tables gets the suffix "_left" or "_right". The columns of a
+// missing left or right row in an outer join are null.
+//
+// As in SQL, a row with a null key matches no row. With no on
+// columns every pair of rows matches.
+//
+// Matched rows are in the order of left, and for each left row in
+// the order of right. An unmatched left row is in the place of its
+// matches. Unmatched right rows follow, in the order of right.
+func Join(left, right *eval.Table, on []string, kind JoinKind) (*eval.Table, error) {
+	if kind < Inner || kind > FullOuter {
+		return nil, fmt.Errorf("table: Join: unknown join kind %v", kind)
+	}
+	isKey := make(map[string]bool)
+	leftKeys := make([]int, len(on))
+	rightKeys := make([]int, len(on))
+	for i, name := range on {
+		if leftKeys[i] = colIndex(left, name); leftKeys[i] < 0 {
+			return nil, fmt.Errorf("table: Join: left table has no key column %q", name)
+		}
+		if rightKeys[i] = colIndex(right, name); rightKeys[i] < 0 {
+			return nil, fmt.Errorf("table: Join: right table has no key column %q", name)
+		}
+		isKey[name] = true
+	}
+
+	// Hash the rows of right by their keys.
+	buf := new(bytes.Buffer)
+	rightRows := make(map[string][]int)
+	for y := 0; y < right.Len(); y++ {
+		key, ok, err := joinKey(buf, right, rightKeys, y)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rightRows[key] = append(rightRows[key], y)
+		}
+	}
+
+	// Pair the rows. A -1 stands for a missing row.
+	var pairs [][2]int
+	matched := make([]bool, right.Len())
+	for y := 0; y < left.Len(); y++ {
+		key, ok, err := joinKey(buf, left, leftKeys, y)
+		if err != nil {
+			return nil, err
+		}
+		var rows []int
+		if ok {
+			rows = rightRows[key]
+		}
+		for _, ry := range rows {
+			pairs = append(pairs, [2]int{y, ry})
+			matched[ry] = true
+		}
+		if len(rows) == 0 && (kind == LeftOuter || kind == FullOuter) {
+			pairs = append(pairs, [2]int{y, -1})
+		}
+	}
+	if kind == RightOuter || kind == FullOuter {
+		for ry, ok := range matched {
+			if !ok {
+				pairs = append(pairs, [2]int{-1, ry})
+			}
+		}
+	}
+
+	res := &eval.Table{}
+	addCol := func(name string, vals []eval.Value, side int) {
+		col := make([]eval.Value, len(pairs))
+		for i, p := range pairs {
+			if p[side] >= 0 {
+				col[i] = vals[p[side]]
+			}
+		}
+		res.ColNames = append(res.ColNames, name)
+		res.Cols = append(res.Cols, col)
+	}
+	for i, name := range on {
+		col := make([]eval.Value, len(pairs))
+		for j, p := range pairs {
+			if p[0] >= 0 {
+				col[j] = left.Cols[leftKeys[i]][p[0]]
+			} else {
+				col[j] = right.Cols[rightKeys[i]][p[1]]
+			}
+		}
+		res.ColNames = append(res.ColNames, name)
+		res.Cols = append(res.Cols, col)
+	}
+	for x, name := range left.ColNames {
+		if isKey[name] {
+			continue
+		}
+		if name != "" && colIndex(right, name) >= 0 {
+			name += "_left"
+		}
+		addCol(name, left.Cols[x], 0)
+	}
+	for x, name := range right.ColNames {
+		if isKey[name] {
+			continue
+		}
+		if name != "" && colIndex(left, name) >= 0 {
+			name += "_right"
+		}
+		addCol(name, right.Cols[x], 1)
+	}
+	return res, nil
+}
+
+// joinKey encodes the key columns of row y of t. It reports false
+// if any key is null.
+func joinKey(buf *bytes.Buffer, t *eval.Table, keys []int, y int) (string, bool, error) {
+	buf.Reset()
+	for _, x := range keys {
+		v := t.Cols[x][y]
+		if v == nil {
+			return "", false, nil
+		}
+		if err := writeKey(buf, v); err != nil {
+			return "", false, fmt.Errorf("table: Join: column %q: %v", t.ColNames[x], err)
+		}
+	}
+	return buf.String(), true, nil
+}
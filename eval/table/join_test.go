@@ -0,0 +1,136 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func joinTables() (left, right *eval.Table) {
+	left = &eval.Table{
+		ColNames: []string{"id", "name", "score"},
+		Cols: [][]eval.Value{
+			col(1, 2, 3, nil, 2),
+			{"ann", "bob", "cy", "dan", "bo2"},
+			col(10, 20, 30, 40, 25),
+		},
+	}
+	right = &eval.Table{
+		ColNames: []string{"score", "id", "city"},
+		Cols: [][]eval.Value{
+			col(200, 400, 100, 201, 0),
+			col(2, 4, 1.0, 2, nil),
+			{"paris", "rome", "oslo", "lima", "none"},
+		},
+	}
+	return left, right
+}
+
+const joinHeader = `["id" "name" "score_left" "score_right" "city"]`
+
+var joinTests = []struct {
+	kind table.JoinKind
+	want string
+}{
+	{table.Inner, joinHeader + `
+*big.Int(1) "ann" *big.Int(10) *big.Int(100) "oslo"
+*big.Int(2) "bob" *big.Int(20) *big.Int(200) "paris"
+*big.Int(2) "bob" *big.Int(20) *big.Int(201) "lima"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(200) "paris"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(201) "lima"`},
+	{table.LeftOuter, joinHeader + `
+*big.Int(1) "ann" *big.Int(10) *big.Int(100) "oslo"
+*big.Int(2) "bob" *big.Int(20) *big.Int(200) "paris"
+*big.Int(2) "bob" *big.Int(20) *big.Int(201) "lima"
+*big.Int(3) "cy" *big.Int(30) nil nil
+nil "dan" *big.Int(40) nil nil
+*big.Int(2) "bo2" *big.Int(25) *big.Int(200) "paris"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(201) "lima"`},
+	{table.RightOuter, joinHeader + `
+*big.Int(1) "ann" *big.Int(10) *big.Int(100) "oslo"
+*big.Int(2) "bob" *big.Int(20) *big.Int(200) "paris"
+*big.Int(2) "bob" *big.Int(20) *big.Int(201) "lima"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(200) "paris"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(201) "lima"
+*big.Int(4) nil nil *big.Int(400) "rome"
+nil nil nil *big.Int(0) "none"`},
+	{table.FullOuter, joinHeader + `
+*big.Int(1) "ann" *big.Int(10) *big.Int(100) "oslo"
+*big.Int(2) "bob" *big.Int(20) *big.Int(200) "paris"
+*big.Int(2) "bob" *big.Int(20) *big.Int(201) "lima"
+*big.Int(3) "cy" *big.Int(30) nil nil
+nil "dan" *big.Int(40) nil nil
+*big.Int(2) "bo2" *big.Int(25) *big.Int(200) "paris"
+*big.Int(2) "bo2" *big.Int(25) *big.Int(201) "lima"
+*big.Int(4) nil nil *big.Int(400) "rome"
+nil nil nil *big.Int(0) "none"`},
+}
+
+func TestJoin(t *testing.T) {
+	for _, test := range joinTests {
+		left, right := joinTables()
+		got, err := table.Join(left, right, []string{"id"}, test.kind)
+		if err != nil {
+			t.Errorf("%v join: %v", test.kind, err)
+			continue
+		}
+		if s := show(got); s != test.want {
+			t.Errorf("%v join=\n%s\nwant:\n%s", test.kind, s, test.want)
+		}
+	}
+}
+
+func TestJoinMultipleKeys(t *testing.T) {
+	left := &eval.Table{
+		ColNames: []string{"a", "b", "x"},
+		Cols: [][]eval.Value{
+			col(1, 1, 2),
+			{"p", "q", "p"},
+			{"first", "second", "third"},
+		},
+	}
+	right := &eval.Table{
+		ColNames: []string{"b", "a", "y"},
+		Cols: [][]eval.Value{
+			{"p", "p", "q"},
+			col(2, 1, 2),
+			{"R0", "R1", "R2"},
+		},
+	}
+	got, err := table.Join(left, right, []string{"a", "b"}, table.FullOuter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["a" "b" "x" "y"]
+*big.Int(1) "p" "first" "R1"
+*big.Int(1) "q" "second" nil
+*big.Int(2) "p" "third" "R0"
+*big.Int(2) "q" nil "R2"`
+	if s := show(got); s != want {
+		t.Errorf("Join=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestJoinError(t *testing.T) {
+	left, right := joinTables()
+	for _, test := range []struct {
+		on   []string
+		kind table.JoinKind
+		want string
+	}{
+		{[]string{"name"}, table.Inner, `right table has no key column "name"`},
+		{[]string{"city"}, table.Inner, `left table has no key column "city"`},
+		{[]string{"id"}, table.JoinKind(7), "unknown join kind JoinKind(7)"},
+	} {
+		_, err := table.Join(left, right, test.on, test.kind)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Join(%v, %v): error %v, want %q", test.on, test.kind, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax/tipe"
+)
+
+// JSONOptions configures MarshalJSON and UnmarshalJSON.
+type JSONOptions struct {
+	// Columnar selects the column-oriented format
+	//	{"col1": [v1, v2, ...], "col2": [v3, v4, ...]}
+	// instead of the default array of row objects
+	//	[{"col1": v1, "col2": v3}, {"col1": v2, "col2": v4}, ...]
+	Columnar bool
+}
+
+// MarshalJSON encodes t as JSON.
+//
+// Every column must have a distinct, non-empty name. Numbers are
+// written in their shortest exact form and nil Values as null.
+func MarshalJSON(t *eval.Table, opts JSONOptions) ([]byte, error) {
+	seen := make(map[string]bool)
+	names := make([][]byte, len(t.Cols))
+	for x := range t.Cols {
+		if x >= len(t.ColNames) || t.ColNames[x] == "" {
+			return nil, fmt.Errorf("table: JSON needs a name for column %d", x)
+		}
+		name := t.ColNames[x]
+		if seen[name] {
+			return nil, fmt.Errorf("table: duplicate column name %q", name)
+		}
+		seen[name] = true
+		names[x], _ = json.Marshal(name)
+	}
+
+	buf := new(bytes.Buffer)
+	if opts.Columnar {
+		buf.WriteByte('{')
+		for x, col := range t.Cols {
+			if x > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(names[x])
+			buf.WriteString(":[")
+			for y, v := range col {
+				if y > 0 {
+					buf.WriteByte(',')
+				}
+				if err := writeJSONValue(buf, v, x, y); err != nil {
+					return nil, err
+				}
+			}
+			buf.WriteByte(']')
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+	}
+
+	buf.WriteByte('[')
+	for y := 0; y < t.Len(); y++ {
+		if y > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for x, col := range t.Cols {
+			if x > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(names[x])
+			buf.WriteByte(':')
+			if err := writeJSONValue(buf, col[y], x, y); err != nil {
+				return nil, err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v eval.Value, x, y int) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case *big.Int:
+		buf.WriteString(v.String())
+	case *big.Float:
+		if v.IsInf() {
+			return fmt.Errorf("table: cannot write value [%d, %d] %v as JSON", x, y, v)
+		}
+		buf.WriteString(v.Text('g', -1))
+	case string:
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		return fmt.Errorf("table: cannot write value [%d, %d] of type %T as JSON", x, y, v)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a table from JSON written by MarshalJSON.
+//
+// Columns are ordered as their names first appear. In the row format
+// a column missing from a row holds null for that row.
+//
+// If schema is not nil, every value must have the type schema.Type.
+// A null is then read as "" in a string column. Otherwise, and in
+// numeric columns, null is a nil Value.
+// Without a schema, integers are read as *big.Int and other numbers
+// as *big.Float.
+func UnmarshalJSON(data []byte, schema *tipe.Table, opts JSONOptions) (*eval.Table, error) {
+	u := &unmarshaler{
+		dec:   json.NewDecoder(bytes.NewReader(data)),
+		t:     &eval.Table{},
+		index: make(map[string]int),
+	}
+	u.dec.UseNumber()
+	if schema != nil && schema.Type != nil {
+		u.kind = schemaKind(schema.Type)
+		if u.kind == kindUnknown {
+			return nil, fmt.Errorf("table: unsupported column type %s", format.Type(schema.Type))
+		}
+		u.typeName = format.Type(schema.Type)
+	}
+
+	var err error
+	if opts.Columnar {
+		err = u.columns()
+	} else {
+		err = u.rows()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("table: unexpected data after JSON table")
+	}
+	return u.t, nil
+}
+
+func schemaKind(t tipe.Type) colKind {
+	b, _ := tipe.Underlying(tipe.Unalias(t)).(tipe.Basic)
+	switch b {
+	case tipe.Integer, tipe.Int, tipe.Int8, tipe.Int16, tipe.Int32, tipe.Int64,
+		tipe.Uint, tipe.Uint8, tipe.Uint16, tipe.Uint32, tipe.Uint64:
+		return kindInt
+	case tipe.Float, tipe.Float32, tipe.Float64:
+		return kindFloat
+	case tipe.String:
+		return kindString
+	case tipe.Bool:
+		return kindBool
+	}
+	return kindUnknown
+}
+
+type unmarshaler struct {
+	dec      *json.Decoder
+	t        *eval.Table
+	index    map[string]int // column name -> index in t.Cols
+	kind     colKind        // kind of every column, if there is a schema
+	typeName string
+}
+
+func (u *unmarshaler) null() eval.Value {
+	if u.kind == kindString {
+		return ""
+	}
+	return nil
+}
+
+// delim reads the JSON delimiter want.
+func (u *unmarshaler) delim(want json.Delim) error {
+	tok, err := u.dec.Token()
+	if err != nil {
+		return fmt.Errorf("table: %v", err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("table: expected JSON %q, found %v", want, tok)
+	}
+	return nil
+}
+
+// key reads an object key naming a column.
+func (u *unmarshaler) key() (string, error) {
+	tok, err := u.dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("table: %v", err)
+	}
+	return tok.(string), nil // the decoder only returns string keys
+}
+
+// column returns the index of the column called name, adding an
+// empty column of rows nulls if it is new.
+func (u *unmarshaler) column(name string, rows int) int {
+	x, ok := u.index[name]
+	if !ok {
+		x = len(u.t.Cols)
+		u.index[name] = x
+		u.t.ColNames = append(u.t.ColNames, name)
+		col := make([]eval.Value, rows)
+		for y := range col {
+			col[y] = u.null()
+		}
+		u.t.Cols = append(u.t.Cols, col)
+	}
+	return x
+}
+
+func (u *unmarshaler) rows() error {
+	if err := u.delim('['); err != nil {
+		return err
+	}
+	for y := 0; u.dec.More(); y++ {
+		if err := u.delim('{'); err != nil {
+			return err
+		}
+		for u.dec.More() {
+			name, err := u.key()
+			if err != nil {
+				return err
+			}
+			x := u.column(name, y)
+			if len(u.t.Cols[x]) > y {
+				return fmt.Errorf("table: row %d: duplicate column %q", y, name)
+			}
+			v, err := u.value(name, y)
+			if err != nil {
+				return err
+			}
+			u.t.Cols[x] = append(u.t.Cols[x], v)
+		}
+		if err := u.delim('}'); err != nil {
+			return err
+		}
+		for x, col := range u.t.Cols {
+			if len(col) == y {
+				u.t.Cols[x] = append(col, u.null())
+			}
+		}
+	}
+	return u.delim(']')
+}
+
+func (u *unmarshaler) columns() error {
+	if err := u.delim('{'); err != nil {
+		return err
+	}
+	for u.dec.More() {
+		name, err := u.key()
+		if err != nil {
+			return err
+		}
+		if _, dup := u.index[name]; dup {
+			return fmt.Errorf("table: duplicate column %q", name)
+		}
+		x := u.column(name, 0)
+		if err := u.delim('['); err != nil {
+			return err
+		}
+		for y := 0; u.dec.More(); y++ {
+			v, err := u.value(name, y)
+			if err != nil {
+				return err
+			}
+			u.t.Cols[x] = append(u.t.Cols[x], v)
+		}
+		if err := u.delim(']'); err != nil {
+			return err
+		}
+		if n, rows := len(u.t.Cols[x]), len(u.t.Cols[0]); n != rows {
+			return fmt.Errorf("table: column %q has %d rows, want %d", name, n, rows)
+		}
+	}
+	return u.delim('}')
+}
+
+// value reads the value in row y of the named column.
+func (u *unmarshaler) value(name string, y int) (eval.Value, error) {
+	var v interface{}
+	if err := u.dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("table: %v", err)
+	}
+	var res eval.Value
+	switch v := v.(type) {
+	case nil:
+		return u.null(), nil
+	case json.Number:
+		if u.kind != kindFloat {
+			if i, ok := new(big.Int).SetString(string(v), 10); ok {
+				res = i
+				break
+			}
+		}
+		if u.kind != kindInt {
+			if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+				res = big.NewFloat(f)
+			}
+		}
+	case string:
+		if u.kind == kindUnknown || u.kind == kindString {
+			res = v
+		}
+	case bool:
+		if u.kind == kindUnknown || u.kind == kindBool {
+			res = v
+		}
+	}
+	if res != nil {
+		return res, nil
+	}
+	b, _ := json.Marshal(v)
+	if u.typeName == "" {
+		return nil, fmt.Errorf("table: row %d, column %q: unsupported JSON value %s", y, name, b)
+	}
+	return nil, fmt.Errorf("table: row %d, column %q: cannot use %s as %s", y, name, b, u.typeName)
+}
@@ -0,0 +1,197 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+	"neugram.io/ng/syntax/tipe"
+)
+
+func jsonTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"name", "n", "x"},
+		Cols: [][]eval.Value{
+			{"a", "b \"q\"", nil},
+			col(1, nil, -3),
+			col(2.5, 1e21, true),
+		},
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, test := range []struct {
+		opts table.JSONOptions
+		want string
+	}{
+		{
+			table.JSONOptions{},
+			`[{"name":"a","n":1,"x":2.5},{"name":"b \"q\"","n":null,"x":1e+21},{"name":null,"n":-3,"x":true}]`,
+		},
+		{
+			table.JSONOptions{Columnar: true},
+			`{"name":["a","b \"q\"",null],"n":[1,null,-3],"x":[2.5,1e+21,true]}`,
+		},
+	} {
+		b, err := table.MarshalJSON(jsonTable(), test.opts)
+		if err != nil {
+			t.Errorf("MarshalJSON(%+v): %v", test.opts, err)
+			continue
+		}
+		if got := string(b); got != test.want {
+			t.Errorf("MarshalJSON(%+v)=%s, want %s", test.opts, got, test.want)
+		}
+	}
+
+	b, err := table.MarshalJSON(&eval.Table{}, table.JSONOptions{})
+	if err != nil || string(b) != "[]" {
+		t.Errorf("MarshalJSON of empty table=%s, %v, want []", b, err)
+	}
+}
+
+func TestMarshalJSONError(t *testing.T) {
+	unnamed := jsonTable()
+	unnamed.ColNames[1] = ""
+	dup := jsonTable()
+	dup.ColNames[2] = "name"
+	inf := jsonTable()
+	inf.Cols[2][0] = new(big.Float).SetInf(false)
+
+	for _, test := range []struct {
+		t    *eval.Table
+		want string
+	}{
+		{unnamed, "JSON needs a name for column 1"},
+		{dup, `duplicate column name "name"`},
+		{inf, "cannot write value [2, 0] +Inf as JSON"},
+	} {
+		_, err := table.MarshalJSON(test.t, table.JSONOptions{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("MarshalJSON(%s): error %v, want %q", show(test.t), err, test.want)
+		}
+	}
+}
+
+var unmarshalJSONTests = []struct {
+	name   string
+	in     string
+	schema *tipe.Table
+	opts   table.JSONOptions
+	want   string
+}{
+	{
+		name: "rows",
+		in:   `[{"a": 1, "b": "x"}, {"a": 2.5, "b": null}, {"b": true, "c": 12345678901234567890}]`,
+		want: `["a" "b" "c"]
+*big.Int(1) "x" nil
+*big.Float(2.5) nil nil
+nil bool(true) *big.Int(12345678901234567890)`,
+	},
+	{
+		name: "columns",
+		in:   `{"a": [1, 2], "b": ["x", null]}`,
+		opts: table.JSONOptions{Columnar: true},
+		want: `["a" "b"]
+*big.Int(1) "x"
+*big.Int(2) nil`,
+	},
+	{
+		name:   "float schema",
+		in:     `[{"a": 1, "b": 2.5}, {"a": null, "b": -1}]`,
+		schema: &tipe.Table{Type: tipe.Float64},
+		want: `["a" "b"]
+*big.Float(1) *big.Float(2.5)
+nil *big.Float(-1)`,
+	},
+	{
+		name:   "string schema",
+		in:     `{"a": ["x", null], "b": [null, "y"]}`,
+		schema: &tipe.Table{Type: tipe.String},
+		opts:   table.JSONOptions{Columnar: true},
+		want: `["a" "b"]
+"x" ""
+"" "y"`,
+	},
+	{
+		name:   "missing string",
+		in:     `[{"a": "x"}, {"b": "y"}]`,
+		schema: &tipe.Table{Type: tipe.String},
+		want: `["a" "b"]
+"x" ""
+"" "y"`,
+	},
+	{
+		name: "empty rows",
+		in:   `[]`,
+		want: `[]`,
+	},
+	{
+		name: "empty columns",
+		in:   `{}`,
+		opts: table.JSONOptions{Columnar: true},
+		want: `[]`,
+	},
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	for _, test := range unmarshalJSONTests {
+		tbl, err := table.UnmarshalJSON([]byte(test.in), test.schema, test.opts)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := show(tbl); got != test.want {
+			t.Errorf("%s: UnmarshalJSON=\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUnmarshalJSONError(t *testing.T) {
+	ints := &tipe.Table{Type: tipe.Int64}
+	for _, test := range []struct {
+		in       string
+		schema   *tipe.Table
+		columnar bool
+		want     string
+	}{
+		{`[{"a": 1}, {"a": 1.5}]`, ints, false, `row 1, column "a": cannot use 1.5 as int64`},
+		{`[{"a": "x"}]`, ints, false, `row 0, column "a": cannot use "x" as int64`},
+		{`[{"a": [1]}]`, nil, false, `row 0, column "a": unsupported JSON value [1]`},
+		{`[{"a": 1, "a": 2}]`, nil, false, `row 0: duplicate column "a"`},
+		{`{"a": [1, 2], "b": [3]}`, nil, true, `column "b" has 1 rows, want 2`},
+		{`{"a": [1], "a": [2]}`, nil, true, `duplicate column "a"`},
+		{`{"a": 1}`, nil, false, `expected JSON "[", found {`},
+		{`[1]`, nil, false, `expected JSON "{", found 1`},
+		{`[{"a": 1}] []`, nil, false, "unexpected data after JSON table"},
+		{`[{"a": 1}`, nil, false, "unexpected end of JSON input"},
+		{`[]`, &tipe.Table{Type: &tipe.Slice{Elem: tipe.Int64}}, false, "unsupported column type []int64"},
+	} {
+		opts := table.JSONOptions{Columnar: test.columnar}
+		_, err := table.UnmarshalJSON([]byte(test.in), test.schema, opts)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("UnmarshalJSON(%s): error %v, want %q", test.in, err, test.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, opts := range []table.JSONOptions{{}, {Columnar: true}} {
+		b, err := table.MarshalJSON(jsonTable(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tbl, err := table.UnmarshalJSON(b, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := show(tbl), show(jsonTable()); got != want {
+			t.Errorf("round trip %+v:\n%s\nwant:\n%s", opts, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"neugram.io/ng/eval"
+)
+
+// Pivot reshapes t from long to wide format.
+//
+// The result has the index column followed by a column for each
+// distinct value of the columns column, named by its text. A row
+// for each distinct index value holds the values column of the rows
+// with that index. Rows and columns are in order of first
+// appearance, and a missing combination is null.
+//
+// Two rows with the same index and columns values are an error.
+func Pivot(t *eval.Table, index, columns, values string) (*eval.Table, error) {
+	var cols [3][]eval.Value
+	for i, name := range []string{index, columns, values} {
+		x := colIndex(t, name)
+		if x < 0 {
+			return nil, fmt.Errorf("table: Pivot: no column %q", name)
+		}
+		cols[i] = t.Cols[x]
+	}
+	indexCol, nameCol, valueCol := cols[0], cols[1], cols[2]
+
+	res := &eval.Table{ColNames: []string{index}, Cols: [][]eval.Value{nil}}
+	rows := make(map[string]int)    // encoded index value -> result row
+	newCols := make(map[string]int) // column name -> result column
+	filled := make(map[[2]int]bool) // result row and column set
+	buf := new(bytes.Buffer)
+	for y := 0; y < t.Len(); y++ {
+		buf.Reset()
+		if err := writeKey(buf, indexCol[y]); err != nil {
+			return nil, fmt.Errorf("table: Pivot: column %q: %v", index, err)
+		}
+		row, ok := rows[buf.String()]
+		if !ok {
+			row = len(res.Cols[0])
+			rows[buf.String()] = row
+			for x := range res.Cols {
+				res.Cols[x] = append(res.Cols[x], nil)
+			}
+			res.Cols[0][row] = indexCol[y]
+		}
+
+		name, err := valueName(nameCol[y])
+		if err != nil {
+			return nil, fmt.Errorf("table: Pivot: column %q: %v", columns, err)
+		}
+		x, ok := newCols[name]
+		if !ok {
+			if name == index {
+				return nil, fmt.Errorf("table: Pivot: new column %q has the name of the index column", name)
+			}
+			x = len(res.Cols)
+			newCols[name] = x
+			res.ColNames = append(res.ColNames, name)
+			res.Cols = append(res.Cols, make([]eval.Value, len(res.Cols[0])))
+		} else if filled[[2]int{row, x}] {
+			return nil, fmt.Errorf("table: Pivot: duplicate entry for %s %v and %s %q", index, indexCol[y], columns, name)
+		}
+		res.Cols[x][row] = valueCol[y]
+		filled[[2]int{row, x}] = true
+	}
+	return res, nil
+}
+
+// valueName is the text of v used as a column name.
+func valueName(v eval.Value) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case *big.Int:
+		return v.String(), nil
+	case *big.Float:
+		return v.Text('g', -1), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("null cannot be a column name")
+	}
+	return "", fmt.Errorf("%T value cannot be a column name", v)
+}
+
+// Unpivot, also called melt, reshapes t from wide to long format.
+//
+// Each column of t other than idCols becomes one row for every row
+// of t. The result has the idCols columns, a varName column holding
+// the name of the original column and a valueName column holding
+// its value. Rows are ordered by original column, then by row.
+// An empty varName or valueName means "variable" or "value".
+func Unpivot(t *eval.Table, idCols []string, varName, valueName string) (*eval.Table, error) {
+	if varName == "" {
+		varName = "variable"
+	}
+	if valueName == "" {
+		valueName = "value"
+	}
+	isID := make(map[string]bool)
+	ids := make([][]eval.Value, len(idCols))
+	for i, name := range idCols {
+		x := colIndex(t, name)
+		if x < 0 {
+			return nil, fmt.Errorf("table: Unpivot: no column %q", name)
+		}
+		if name == varName || name == valueName {
+			return nil, fmt.Errorf("table: Unpivot: id column %q has the name of a result column", name)
+		}
+		isID[name] = true
+		ids[i] = t.Cols[x]
+	}
+
+	res := &eval.Table{
+		ColNames: append(append([]string(nil), idCols...), varName, valueName),
+		Cols:     make([][]eval.Value, len(idCols)+2),
+	}
+	for x, name := range t.ColNames {
+		if isID[name] {
+			continue
+		}
+		for y := 0; y < t.Len(); y++ {
+			for i, col := range ids {
+				res.Cols[i] = append(res.Cols[i], col[y])
+			}
+			res.Cols[len(ids)] = append(res.Cols[len(ids)], name)
+			res.Cols[len(ids)+1] = append(res.Cols[len(ids)+1], t.Cols[x][y])
+		}
+	}
+	return res, nil
+}
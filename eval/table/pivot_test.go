@@ -0,0 +1,145 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func longTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"city", "month", "temp"},
+		Cols: [][]eval.Value{
+			{"oslo", "oslo", "rome", "rome", "lima"},
+			{"jan", "feb", "jan", "mar", "feb"},
+			col(-4.5, -3.0, 7.5, 11.0, 23.0),
+		},
+	}
+}
+
+const wide = `["city" "jan" "feb" "mar"]
+"oslo" *big.Float(-4.5) *big.Float(-3) nil
+"rome" *big.Float(7.5) nil *big.Float(11)
+"lima" nil *big.Float(23) nil`
+
+func TestPivot(t *testing.T) {
+	got, err := table.Pivot(longTable(), "city", "month", "temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := show(got); s != wide {
+		t.Errorf("Pivot=\n%s\nwant:\n%s", s, wide)
+	}
+
+	// Numeric column values are named by their text.
+	years := &eval.Table{
+		ColNames: []string{"k", "year", "v"},
+		Cols: [][]eval.Value{
+			{"a", "a"},
+			col(2016, 2017.5),
+			{"x", "y"},
+		},
+	}
+	got, err = table.Pivot(years, "k", "year", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := show(got), "[\"k\" \"2016\" \"2017.5\"]\n\"a\" \"x\" \"y\""; s != want {
+		t.Errorf("Pivot of years=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestPivotError(t *testing.T) {
+	dup := longTable()
+	dup.Cols[1][1] = "jan"
+	nullName := longTable()
+	nullName.Cols[1][2] = nil
+	dupNull := longTable()
+	dupNull.Cols[2][0] = nil
+	dupNull.Cols[1][1] = "jan"
+	indexName := longTable()
+	indexName.Cols[1][0] = "city"
+
+	for _, test := range []struct {
+		t                      *eval.Table
+		index, columns, values string
+		want                   string
+	}{
+		{longTable(), "town", "month", "temp", `Pivot: no column "town"`},
+		{longTable(), "city", "month", "rain", `Pivot: no column "rain"`},
+		{dup, "city", "month", "temp", `duplicate entry for city oslo and month "jan"`},
+		{dupNull, "city", "month", "temp", `duplicate entry for city oslo and month "jan"`},
+		{nullName, "city", "month", "temp", `column "month": null cannot be a column name`},
+		{indexName, "city", "month", "temp", `new column "city" has the name of the index column`},
+	} {
+		_, err := table.Pivot(test.t, test.index, test.columns, test.values)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Pivot(%s, %s, %s): error %v, want %q", test.index, test.columns, test.values, err, test.want)
+		}
+	}
+}
+
+func TestUnpivot(t *testing.T) {
+	w, err := table.Pivot(longTable(), "city", "month", "temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := table.Unpivot(w, []string{"city"}, "month", "temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["city" "month" "temp"]
+"oslo" "jan" *big.Float(-4.5)
+"rome" "jan" *big.Float(7.5)
+"lima" "jan" nil
+"oslo" "feb" *big.Float(-3)
+"rome" "feb" nil
+"lima" "feb" *big.Float(23)
+"oslo" "mar" nil
+"rome" "mar" *big.Float(11)
+"lima" "mar" nil`
+	if s := show(got); s != want {
+		t.Errorf("Unpivot=\n%s\nwant:\n%s", s, want)
+	}
+
+	// Pivoting the long table back restores the wide one.
+	back, err := table.Pivot(got, "city", "month", "temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := show(back); s != wide {
+		t.Errorf("Pivot(Unpivot)=\n%s\nwant:\n%s", s, wide)
+	}
+
+	got, err = table.Unpivot(w, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != 12 || strings.Join(got.ColNames, " ") != "variable value" {
+		t.Errorf("Unpivot with no ids: %d rows, columns %q", got.Len(), got.ColNames)
+	}
+}
+
+func TestUnpivotError(t *testing.T) {
+	for _, test := range []struct {
+		ids       []string
+		varName   string
+		valueName string
+		want      string
+	}{
+		{[]string{"town"}, "", "", `Unpivot: no column "town"`},
+		{[]string{"city"}, "city", "", `id column "city" has the name of a result column`},
+		{[]string{"city"}, "", "city", `id column "city" has the name of a result column`},
+	} {
+		_, err := table.Unpivot(longTable(), test.ids, test.varName, test.valueName)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Unpivot(%v, %q, %q): error %v, want %q", test.ids, test.varName, test.valueName, err, test.want)
+		}
+	}
+}
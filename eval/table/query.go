@@ -0,0 +1,777 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/token"
+)
+
+// Query runs a restricted SQL query against t:
+//
+//	SELECT items FROM name [WHERE cond] [GROUP BY cols]
+//		[ORDER BY terms] [LIMIT n]
+//
+// The FROM name always refers to t. Each SELECT item is * or an
+// expression with an optional AS alias. Expressions are Neugram
+// expressions over the columns of t, written with SQL's =, <>,
+// AND, OR, NOT, NULL and 'single-quoted' strings. A column is
+// named by an identifier, matched case-insensitively if there is
+// no exact match, or by a "double-quoted" name.
+//
+// The aggregate functions SUM, AVG, MIN, MAX and COUNT may be
+// applied to expressions in the SELECT items. COUNT(*) counts rows,
+// COUNT(x) counts the rows where x is not null. With GROUP BY, the
+// result has a row for each group of the GROUP BY columns, and
+// other columns may only be used inside an aggregate. Without it,
+// a query using aggregates has a single row.
+//
+// ORDER BY terms name a result column, by its alias, its column
+// name or its text as written in the SELECT list, or give its
+// 1-based position. Each may be followed by ASC or DESC.
+//
+// Comparisons follow FilterExpr: a null is not equal to anything.
+// Joins are not supported; use Join first.
+func Query(t *eval.Table, sql string) (*eval.Table, error) {
+	res, err := runQuery(t, sql)
+	if err != nil {
+		return nil, fmt.Errorf("table: Query: %v", err)
+	}
+	return res, nil
+}
+
+type sqlKind int
+
+const (
+	sqlIdent  sqlKind = iota // name or keyword
+	sqlQuoted                // "quoted name"
+	sqlString                // 'string'
+	sqlNumber
+	sqlOp
+)
+
+type sqlToken struct {
+	kind     sqlKind
+	text     string // the unquoted value of names and strings
+	pos, end int    // offsets in the query
+}
+
+func (tok sqlToken) is(keyword string) bool {
+	return tok.kind == sqlIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func (tok sqlToken) isOp(op string) bool {
+	return tok.kind == sqlOp && tok.text == op
+}
+
+var sqlOps = []string{"<>", "!=", "<=", ">=", "==", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", "."}
+
+func lexSQL(src string) ([]sqlToken, error) {
+	var toks []sqlToken
+	for i := 0; i < len(src); {
+		r, w := utf8.DecodeRuneInString(src[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += w
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				r, w := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += w
+			}
+			toks = append(toks, sqlToken{kind: sqlIdent, text: src[start:i]})
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+			}
+			toks = append(toks, sqlToken{kind: sqlNumber, text: src[start:i]})
+		case r == '\'' || r == '"':
+			// A doubled quote stands for itself.
+			var text []byte
+			i++
+			for {
+				if i == len(src) {
+					return nil, fmt.Errorf("unterminated %c at offset %d", r, start)
+				}
+				if src[i] == byte(r) {
+					if i+1 < len(src) && src[i+1] == byte(r) {
+						text = append(text, byte(r))
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text = append(text, src[i])
+				i++
+			}
+			kind := sqlString
+			if r == '"' {
+				kind = sqlQuoted
+			}
+			toks = append(toks, sqlToken{kind: kind, text: string(text)})
+		default:
+			for _, op := range sqlOps {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, sqlToken{kind: sqlOp, text: op})
+					i += len(op)
+					break
+				}
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q at offset %d", r, start)
+			}
+		}
+		toks[len(toks)-1].pos, toks[len(toks)-1].end = start, i
+	}
+	return toks, nil
+}
+
+// splitTop splits toks at the commas outside parentheses.
+func splitTop(toks []sqlToken) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	for i, tok := range toks {
+		switch {
+		case tok.isOp("("):
+			depth++
+		case tok.isOp(")"):
+			depth--
+		case tok.isOp(",") && depth == 0:
+			parts = append(parts, toks[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, toks[start:])
+}
+
+// sqlClauses are the clauses of a query, in the order they must
+// appear.
+var sqlClauses = []string{"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT"}
+
+// splitClauses splits a query into its clauses.
+func splitClauses(toks []sqlToken) (map[string][]sqlToken, error) {
+	clauses := make(map[string][]sqlToken)
+	next, cur, start, depth := 0, "", 0, 0
+	for i := 0; i <= len(toks); i++ {
+		clause := ""
+		if i == len(toks) {
+			clause = "end"
+		} else if tok := toks[i]; tok.isOp("(") {
+			depth++
+		} else if tok.isOp(")") {
+			depth--
+		} else if depth == 0 && tok.kind == sqlIdent {
+			for _, c := range sqlClauses {
+				words := strings.Fields(c)
+				if !tok.is(words[0]) {
+					continue
+				}
+				if len(words) == 2 && (i+1 == len(toks) || !toks[i+1].is(words[1])) {
+					return nil, fmt.Errorf("%s must be followed by %s", words[0], words[1])
+				}
+				clause = c
+			}
+		}
+		if clause == "" {
+			continue
+		}
+		if cur == "" && (i > 0 || clause != "SELECT") {
+			return nil, fmt.Errorf("query must start with SELECT")
+		}
+		if cur != "" {
+			if i == start {
+				return nil, fmt.Errorf("empty %s clause", cur)
+			}
+			clauses[cur] = toks[start:i]
+		}
+		if clause == "end" {
+			break
+		}
+		for next < len(sqlClauses) && sqlClauses[next] != clause {
+			next++
+		}
+		if next == len(sqlClauses) {
+			return nil, fmt.Errorf("%s clause out of order", clause)
+		}
+		next++
+		cur = clause
+		start = i + len(strings.Fields(clause))
+	}
+	if cur == "" {
+		return nil, fmt.Errorf("query must start with SELECT")
+	}
+	if _, ok := clauses["FROM"]; !ok {
+		return nil, fmt.Errorf("missing FROM clause")
+	}
+	return clauses, nil
+}
+
+// selectItem is an item of the SELECT list.
+type selectItem struct {
+	name string
+	e    expr.Expr
+	star bool
+}
+
+// aggCall is an aggregate function used in the SELECT list.
+type aggCall struct {
+	fn  string
+	arg expr.Expr // nil for COUNT(*)
+}
+
+// An aggPlaceholder is the name of the column holding the results of
+// an aggregate. No column of a table given to Query can have it, as
+// it cannot be written in SQL.
+func aggPlaceholder(i int) string { return "\x00agg" + strconv.Itoa(i) }
+
+type query struct {
+	sql  string
+	t    *eval.Table
+	aggs []aggCall
+
+	// grouped is set when the SELECT items are evaluated over the
+	// groups, and keys holds the GROUP BY columns.
+	grouped bool
+	keys    map[string]bool
+}
+
+func runQuery(t *eval.Table, sql string) (*eval.Table, error) {
+	if err := distinctNames(t); err != nil {
+		return nil, err
+	}
+	toks, err := lexSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	clauses, err := splitClauses(toks)
+	if err != nil {
+		return nil, err
+	}
+	if from := clauses["FROM"]; len(from) != 1 || (from[0].kind != sqlIdent && from[0].kind != sqlQuoted) {
+		return nil, fmt.Errorf("FROM must name a single table")
+	}
+	q := &query{sql: sql, t: t}
+
+	// WHERE
+	if where, ok := clauses["WHERE"]; ok {
+		e, err := q.parse(where, inWhere)
+		if err != nil {
+			return nil, err
+		}
+		cond, err := (&compiler{t: t}).compileCond(e)
+		if err != nil {
+			return nil, err
+		}
+		var rows []int
+		for y := 0; y < t.Len(); y++ {
+			b, null, err := cond(y)
+			if err != nil {
+				return nil, fmt.Errorf("WHERE: row %d: %v", y, err)
+			}
+			if b && !null {
+				rows = append(rows, y)
+			}
+		}
+		t = takeRows(t, rows)
+		q.t = t
+	}
+
+	// GROUP BY
+	var keys []string
+	if group, ok := clauses["GROUP BY"]; ok {
+		q.grouped = true
+		q.keys = make(map[string]bool)
+		for _, part := range splitTop(group) {
+			if len(part) != 1 || (part[0].kind != sqlIdent && part[0].kind != sqlQuoted) {
+				return nil, fmt.Errorf("GROUP BY %s is not a column", q.text(part))
+			}
+			x, err := q.column(part[0])
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, t.ColNames[x])
+			q.keys[t.ColNames[x]] = true
+		}
+	}
+
+	// SELECT
+	selectToks := clauses["SELECT"]
+	for i, tok := range selectToks {
+		if _, ok := sqlAggs[strings.ToLower(tok.text)]; ok && tok.kind == sqlIdent && i+1 < len(selectToks) && selectToks[i+1].isOp("(") {
+			// Aggregates without GROUP BY make one group
+			// of every row.
+			q.grouped = true
+		}
+	}
+	var items []selectItem
+	for _, part := range splitTop(selectToks) {
+		item, err := q.selectItem(part)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	src := t
+	if q.grouped {
+		if src, err = q.group(keys); err != nil {
+			return nil, err
+		}
+	}
+	res := &eval.Table{}
+	for _, item := range items {
+		if item.star {
+			if q.grouped {
+				return nil, fmt.Errorf("SELECT * cannot be used with GROUP BY or aggregates")
+			}
+			for x, col := range src.Cols {
+				res.ColNames = append(res.ColNames, src.ColNames[x])
+				res.Cols = append(res.Cols, append([]eval.Value(nil), col...))
+			}
+			continue
+		}
+		f, err := (&compiler{t: src}).compile(item.e)
+		if err != nil {
+			return nil, err
+		}
+		col := make([]eval.Value, src.Len())
+		for y := range col {
+			if col[y], err = f(y); err != nil {
+				return nil, fmt.Errorf("%s: row %d: %v", item.name, y, err)
+			}
+		}
+		res.ColNames = append(res.ColNames, item.name)
+		res.Cols = append(res.Cols, col)
+	}
+	if err := distinctNames(res); err != nil {
+		return nil, err
+	}
+
+	// ORDER BY
+	if order, ok := clauses["ORDER BY"]; ok {
+		var cols []string
+		var ascending []bool
+		for _, part := range splitTop(order) {
+			asc := true
+			if n := len(part); n > 1 && (part[n-1].is("ASC") || part[n-1].is("DESC")) {
+				asc = part[n-1].is("ASC")
+				part = part[:n-1]
+			}
+			x, err := q.orderColumn(res, items, part)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, res.ColNames[x])
+			ascending = append(ascending, asc)
+		}
+		if res, err = SortBy(res, cols, ascending); err != nil {
+			return nil, err
+		}
+	}
+
+	// LIMIT
+	if limit, ok := clauses["LIMIT"]; ok {
+		if len(limit) != 1 || limit[0].kind != sqlNumber {
+			return nil, fmt.Errorf("LIMIT %s is not a count", q.text(limit))
+		}
+		n, err := strconv.Atoi(limit[0].text)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("LIMIT %s is not a count", q.text(limit))
+		}
+		if n < res.Len() {
+			for x := range res.Cols {
+				res.Cols[x] = res.Cols[x][:n]
+			}
+		}
+	}
+	return res, nil
+}
+
+// text returns the query text of toks.
+func (q *query) text(toks []sqlToken) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return q.sql[toks[0].pos:toks[len(toks)-1].end]
+}
+
+// column finds the column named by tok: exactly, or for an unquoted
+// name, case-insensitively if that matches a single column.
+func (q *query) column(tok sqlToken) (int, error) {
+	if x := colIndex(q.t, tok.text); x >= 0 {
+		return x, nil
+	}
+	if tok.kind == sqlIdent {
+		found := -1
+		for x, name := range q.t.ColNames {
+			if strings.EqualFold(name, tok.text) {
+				if found >= 0 {
+					return -1, fmt.Errorf("column %s is ambiguous", tok.text)
+				}
+				found = x
+			}
+		}
+		if found >= 0 {
+			return found, nil
+		}
+	}
+	return -1, fmt.Errorf("no column %q", tok.text)
+}
+
+func (q *query) selectItem(toks []sqlToken) (selectItem, error) {
+	if len(toks) == 1 && toks[0].isOp("*") {
+		return selectItem{star: true}, nil
+	}
+	var item selectItem
+	if n := len(toks); n > 2 && toks[n-2].is("AS") {
+		if alias := toks[n-1]; alias.kind == sqlIdent || alias.kind == sqlQuoted {
+			item.name = alias.text
+			toks = toks[:n-2]
+		}
+	}
+	if item.name == "" {
+		item.name = q.text(toks)
+		if len(toks) == 1 && (toks[0].kind == sqlIdent || toks[0].kind == sqlQuoted) {
+			if x, err := q.column(toks[0]); err == nil {
+				item.name = q.t.ColNames[x]
+			}
+		}
+	}
+	e, err := q.parse(toks, inSelect)
+	if err != nil {
+		return selectItem{}, err
+	}
+	item.e = e
+	return item, nil
+}
+
+// orderColumn finds the result column named by an ORDER BY term.
+func (q *query) orderColumn(res *eval.Table, items []selectItem, toks []sqlToken) (int, error) {
+	if len(toks) == 1 && toks[0].kind == sqlNumber {
+		n, err := strconv.Atoi(toks[0].text)
+		if err != nil || n < 1 || n > len(res.Cols) {
+			return -1, fmt.Errorf("ORDER BY position %s is not in the SELECT list", toks[0].text)
+		}
+		return n - 1, nil
+	}
+	text := q.text(toks)
+	if len(toks) == 1 && (toks[0].kind == sqlIdent || toks[0].kind == sqlQuoted) {
+		text = toks[0].text
+	}
+	if x := colIndex(res, text); x >= 0 {
+		return x, nil
+	}
+	for x, name := range res.ColNames {
+		if strings.EqualFold(name, text) {
+			return x, nil
+		}
+	}
+	return -1, fmt.Errorf("ORDER BY %s is not in the SELECT list", q.text(toks))
+}
+
+// sqlAggs maps the SQL aggregate functions to their Neugram names.
+var sqlAggs = map[string]string{
+	"sum":   "sum",
+	"avg":   "mean",
+	"min":   "min",
+	"max":   "max",
+	"count": "count",
+}
+
+// columnPrefix starts the Neugram name of the column at the index
+// that follows it while an expression is parsed.
+const columnPrefix = "sql_column_"
+
+// parse translates toks into a Neugram expression and parses it
+// with the Neugram parser. Column names become identifiers that
+// cannot clash with any other name in the expression, which are
+// replaced by the real column names after parsing.
+func (q *query) parse(toks []sqlToken, ctx exprContext) (expr.Expr, error) {
+	var buf strings.Builder
+	// SQL's NOT binds more loosely than comparisons, so its operand
+	// is parenthesized up to the next AND, OR or closing parenthesis
+	// at the same depth. nots holds the depths of the open ones.
+	var nots []int
+	depth := 0
+	closeNots := func() {
+		for len(nots) > 0 && nots[len(nots)-1] == depth {
+			buf.WriteString(" )")
+			nots = nots[:len(nots)-1]
+		}
+	}
+	for i, tok := range toks {
+		if tok.is("AND") || tok.is("OR") || tok.isOp(")") {
+			closeNots()
+		}
+		switch {
+		case tok.isOp("("):
+			depth++
+		case tok.isOp(")"):
+			depth--
+		}
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		call := i+1 < len(toks) && toks[i+1].isOp("(")
+		switch tok.kind {
+		case sqlIdent:
+			switch strings.ToUpper(tok.text) {
+			case "AND":
+				buf.WriteString("&&")
+				continue
+			case "OR":
+				buf.WriteString("||")
+				continue
+			case "NOT":
+				buf.WriteString("!(")
+				nots = append(nots, depth)
+				continue
+			case "TRUE", "FALSE":
+				buf.WriteString(strings.ToLower(tok.text))
+				continue
+			case "NULL":
+				buf.WriteString("nil")
+				continue
+			}
+			if call {
+				// Functions keep their names, with SQL's
+				// aggregates in lower case.
+				if _, ok := sqlAggs[strings.ToLower(tok.text)]; ok {
+					buf.WriteString(strings.ToLower(tok.text))
+				} else {
+					buf.WriteString(tok.text)
+				}
+				continue
+			}
+			fallthrough
+		case sqlQuoted:
+			x, err := q.column(tok)
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(columnPrefix + strconv.Itoa(x))
+		case sqlString:
+			buf.WriteString(strconv.Quote(tok.text))
+		case sqlNumber:
+			buf.WriteString(tok.text)
+		case sqlOp:
+			switch {
+			case tok.isOp("="):
+				buf.WriteString("==")
+			case tok.isOp("<>"):
+				buf.WriteString("!=")
+			case tok.isOp("*") && i > 0 && toks[i-1].isOp("(") && i+1 < len(toks) && toks[i+1].isOp(")"):
+				// COUNT(*)
+			default:
+				buf.WriteString(tok.text)
+			}
+		}
+	}
+	for len(nots) > 0 {
+		depth = nots[len(nots)-1]
+		closeNots()
+	}
+	s, err := parser.ParseStmt([]byte(buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", q.text(toks), err)
+	}
+	simple, ok := s.(*stmt.Simple)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an expression", q.text(toks))
+	}
+	return q.rewrite(simple.Expr, ctx, q.text(toks))
+}
+
+// exprContext is where an expression appears in a query.
+type exprContext int
+
+const (
+	inWhere exprContext = iota
+	inSelect
+	inAggregate // the argument of an aggregate
+)
+
+// rewrite replaces the column identifiers in e by the column names
+// and the aggregate calls by the columns that will hold their
+// results.
+func (q *query) rewrite(e expr.Expr, ctx exprContext, text string) (expr.Expr, error) {
+	switch e := e.(type) {
+	case *expr.BasicLiteral:
+		return e, nil
+	case *expr.Ident:
+		if !strings.HasPrefix(e.Name, columnPrefix) {
+			return e, nil // true, false, nil
+		}
+		x, _ := strconv.Atoi(e.Name[len(columnPrefix):])
+		name := q.t.ColNames[x]
+		if q.grouped && ctx == inSelect && !q.keys[name] {
+			return nil, fmt.Errorf("column %q must be in GROUP BY or used in an aggregate", name)
+		}
+		return &expr.Ident{Position: e.Position, Name: name}, nil
+	case *expr.Unary:
+		if e.Op != token.LeftParen && e.Op != token.Not && e.Op != token.Add && e.Op != token.Sub {
+			break
+		}
+		x, err := q.rewrite(e.Expr, ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Unary{Position: e.Position, Op: e.Op, Expr: x}, nil
+	case *expr.Binary:
+		left, err := q.rewrite(e.Left, ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		right, err := q.rewrite(e.Right, ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Binary{Position: e.Position, Op: e.Op, Left: left, Right: right}, nil
+	case *expr.Call:
+		fn, ok := e.Func.(*expr.Ident)
+		if !ok || e.Ellipsis {
+			break
+		}
+		agg, ok := sqlAggs[fn.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s in %s", fn.Name, text)
+		}
+		switch ctx {
+		case inWhere:
+			return nil, fmt.Errorf("aggregate %s not allowed in WHERE", strings.ToUpper(fn.Name))
+		case inAggregate:
+			return nil, fmt.Errorf("aggregate %s inside an aggregate in %s", strings.ToUpper(fn.Name), text)
+		}
+		call := aggCall{fn: agg}
+		switch {
+		case len(e.Args) == 0 && agg == "count":
+		case len(e.Args) == 1:
+			// The argument is evaluated over the rows of each
+			// group, so any column may be used.
+			arg, err := q.rewrite(e.Args[0], inAggregate, text)
+			if err != nil {
+				return nil, err
+			}
+			call.arg = arg
+		default:
+			return nil, fmt.Errorf("%s takes one argument in %s", strings.ToUpper(fn.Name), text)
+		}
+		q.aggs = append(q.aggs, call)
+		return &expr.Ident{Position: e.Position, Name: aggPlaceholder(len(q.aggs) - 1)}, nil
+	}
+	return nil, fmt.Errorf("unsupported expression in %s", text)
+}
+
+// group computes the aggregates of q over the groups of the keys
+// columns of q.t. The result has the keys columns and a column for
+// each aggregate, named by its placeholder.
+func (q *query) group(keys []string) (*eval.Table, error) {
+	// Aggregate arguments that are not columns are computed into
+	// new columns of a copy of q.t.
+	w := &eval.Table{
+		ColNames: append([]string(nil), q.t.ColNames...),
+		Cols:     append([][]eval.Value(nil), q.t.Cols...),
+	}
+	aggs := make([]Aggregation, len(q.aggs))
+	for i, call := range q.aggs {
+		col := ""
+		if id, ok := call.arg.(*expr.Ident); ok && colIndex(w, id.Name) >= 0 {
+			col = id.Name
+		} else if call.arg != nil {
+			f, err := (&compiler{t: q.t}).compile(call.arg)
+			if err != nil {
+				return nil, err
+			}
+			vals := make([]eval.Value, q.t.Len())
+			for y := range vals {
+				if vals[y], err = f(y); err != nil {
+					return nil, fmt.Errorf("row %d: %v", y, err)
+				}
+			}
+			col = "\x00arg" + strconv.Itoa(i)
+			w.ColNames = append(w.ColNames, col)
+			w.Cols = append(w.Cols, vals)
+		}
+		switch call.fn {
+		case "sum":
+			aggs[i] = Sum(col)
+		case "mean":
+			aggs[i] = Mean(col)
+		case "min":
+			aggs[i] = Min(col)
+		case "max":
+			aggs[i] = Max(col)
+		case "count":
+			if col == "" {
+				aggs[i] = Count()
+			} else {
+				aggs[i] = countValues(col)
+			}
+		}
+	}
+
+	var res *eval.Table
+	if len(keys) > 0 {
+		var err error
+		if res, err = GroupBy(w, keys, aggs); err != nil {
+			return nil, err
+		}
+	} else {
+		// One group of every row, even if there are none.
+		res = &eval.Table{Cols: make([][]eval.Value, len(aggs))}
+		for i, agg := range aggs {
+			vals := make([]eval.Value, w.Len())
+			if x := colIndex(w, agg.Column()); x >= 0 {
+				vals = w.Cols[x]
+			}
+			v, err := agg.Aggregate(vals)
+			if err != nil {
+				return nil, err
+			}
+			res.ColNames = append(res.ColNames, agg.Name())
+			res.Cols[i] = []eval.Value{v}
+		}
+	}
+	for i := range aggs {
+		res.ColNames[len(keys)+i] = aggPlaceholder(i)
+	}
+	return res, nil
+}
+
+// countValues counts the values in col that are not null.
+func countValues(col string) Aggregation {
+	return &aggregation{fn: "count", col: col, agg: func(vals []eval.Value) (eval.Value, error) {
+		n := 0
+		for _, v := range vals {
+			if v != nil {
+				n++
+			}
+		}
+		return big.NewInt(int64(n)), nil
+	}}
+}
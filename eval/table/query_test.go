@@ -0,0 +1,134 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func queryTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"col1", "col2", "col3", "unit price"},
+		Cols: [][]eval.Value{
+			{"b", "a", "b", "c", "a", "b", "c"},
+			col(1, 2, 3, 4, 5, 6, 7),
+			col(0.5, -1, 2, nil, 1.5, 0, 3),
+			col(1.25, 2, 3, 0.5, 1, 4, nil),
+		},
+	}
+}
+
+var queryTests = []struct {
+	sql, want string
+}{
+	{
+		"SELECT col1, SUM(col2) FROM t WHERE col3 > 0 GROUP BY col1 ORDER BY col1",
+		`["col1" "SUM(col2)"]
+"a" *big.Int(5)
+"b" *big.Int(4)
+"c" *big.Int(7)`,
+	},
+	{
+		"select COL1 as k, count(*) as n, avg(col2), max(col2) - min(col2) as spread from t group by col1 order by n desc, k",
+		`["k" "n" "avg(col2)" "spread"]
+"b" *big.Int(3) *big.Float(3.3333333333333333333) *big.Int(5)
+"a" *big.Int(2) *big.Float(3.5) *big.Int(3)
+"c" *big.Int(2) *big.Float(5.5) *big.Int(3)`,
+	},
+	{
+		`SELECT col1, SUM(col2 * "unit price") AS total, COUNT("unit price") FROM t GROUP BY col1 ORDER BY total DESC`,
+		`["col1" "total" "COUNT(\"unit price\")"]
+"b" *big.Float(34.25) *big.Int(3)
+"a" *big.Int(9) *big.Int(2)
+"c" *big.Float(2) *big.Int(1)`,
+	},
+	{
+		"SELECT col2, col2 * 2 + 1 AS odd, col3 FROM t WHERE col1 = 'b' AND NOT col3 < 0.5 LIMIT 5",
+		`["col2" "odd" "col3"]
+*big.Int(1) *big.Int(3) *big.Float(0.5)
+*big.Int(3) *big.Int(7) *big.Int(2)`,
+	},
+	{
+		"SELECT * FROM t WHERE col3 <> col3 OR col2 = 1",
+		`["col1" "col2" "col3" "unit price"]
+"b" *big.Int(1) *big.Float(0.5) *big.Float(1.25)
+"c" *big.Int(4) nil *big.Float(0.5)`,
+	},
+	{
+		"SELECT SUM(col2), COUNT(col3), MIN(col1) FROM t",
+		`["SUM(col2)" "COUNT(col3)" "MIN(col1)"]
+*big.Int(28) *big.Int(6) "a"`,
+	},
+	{
+		"SELECT COUNT(*), SUM(col2) FROM t WHERE col2 > 100",
+		`["COUNT(*)" "SUM(col2)"]
+*big.Int(0) *big.Int(0)`,
+	},
+	{
+		"SELECT col1, col2 FROM t ORDER BY 2 DESC LIMIT 3",
+		`["col1" "col2"]
+"c" *big.Int(7)
+"b" *big.Int(6)
+"a" *big.Int(5)`,
+	},
+	{
+		"SELECT col2 FROM t WHERE NOT (col2 > 2 AND NOT col2 >= 6) OR col1 = 'c'",
+		`["col2"]
+*big.Int(1)
+*big.Int(2)
+*big.Int(4)
+*big.Int(6)
+*big.Int(7)`,
+	},
+	{
+		"SELECT col1 FROM t WHERE col1 = 'it''s'",
+		`["col1"]`,
+	},
+}
+
+func TestQuery(t *testing.T) {
+	for _, test := range queryTests {
+		got, err := table.Query(queryTable(), test.sql)
+		if err != nil {
+			t.Errorf("Query(%s): %v", test.sql, err)
+			continue
+		}
+		if s := show(got); s != test.want {
+			t.Errorf("Query(%s)=\n%s\nwant:\n%s", test.sql, s, test.want)
+		}
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	for _, test := range []struct {
+		sql, err string
+	}{
+		{"FROM t", "query must start with SELECT"},
+		{"SELECT col1", "missing FROM clause"},
+		{"SELECT col1 FROM t, u", "FROM must name a single table"},
+		{"SELECT col1 FROM t ORDER col1", "ORDER must be followed by BY"},
+		{"SELECT col1 FROM t LIMIT 1 WHERE col2 > 1", "WHERE clause out of order"},
+		{"SELECT col4 FROM t", `no column "col4"`},
+		{"SELECT col1, col2 FROM t GROUP BY col1", `column "col2" must be in GROUP BY or used in an aggregate`},
+		{"SELECT col2, SUM(col3) FROM t", `column "col2" must be in GROUP BY or used in an aggregate`},
+		{"SELECT * FROM t GROUP BY col1", "SELECT * cannot be used with GROUP BY or aggregates"},
+		{"SELECT col1 FROM t WHERE SUM(col2) > 1", "aggregate SUM not allowed in WHERE"},
+		{"SELECT SUM(MAX(col2)) FROM t", "aggregate MAX inside an aggregate in SUM(MAX(col2))"},
+		{"SELECT sqrt(col2) FROM t", "unknown function sqrt in sqrt(col2)"},
+		{"SELECT col1 FROM t ORDER BY col2", "ORDER BY col2 is not in the SELECT list"},
+		{"SELECT col1 FROM t LIMIT -1", "LIMIT -1 is not a count"},
+		{"SELECT col1, col1 FROM t", `duplicate column name "col1"`},
+		{"SELECT col1 FROM t WHERE col1 = 'a", "unterminated ' at offset 32"},
+		{"SELECT col1 FROM t WHERE col1 > 1", "WHERE: row 0: col1 > 1: cannot compare string and *big.Int values"},
+	} {
+		_, err := table.Query(queryTable(), test.sql)
+		if want := "table: Query: " + test.err; err == nil || err.Error() != want {
+			t.Errorf("Query(%s) err=%v, want %q", test.sql, err, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"neugram.io/ng/eval"
+)
+
+// RollingOptions configures Rolling.
+type RollingOptions struct {
+	// MinPeriods is the number of non-NaN values a window needs
+	// for fn to be called. Zero means the window size, or 1 for an
+	// expanding window, as in pandas.
+	MinPeriods int
+
+	// Name is the name of the new column. The default is
+	// "rolling(col)".
+	Name string
+}
+
+// Rolling returns a copy of t with a new last column computed over
+// a moving window of the numbers in col.
+//
+// Row i of the new column is fn(vals[max(0, i-window+1):i+1]), where
+// vals are the values of col as float64s and nulls are NaN. A window
+// of 0 or less expands from the first row, so row i is
+// fn(vals[:i+1]). A window with fewer than opts.MinPeriods non-NaN
+// values, or a NaN result of fn, gives a null.
+func Rolling(t *eval.Table, col string, window int, fn func([]float64) float64, opts RollingOptions) (*eval.Table, error) {
+	x := colIndex(t, col)
+	if x < 0 {
+		return nil, fmt.Errorf("table: Rolling: no column %q", col)
+	}
+	name := opts.Name
+	if name == "" {
+		name = "rolling(" + col + ")"
+	}
+	if colIndex(t, name) >= 0 {
+		return nil, fmt.Errorf("table: Rolling: table already has a column %q", name)
+	}
+	minPeriods := opts.MinPeriods
+	if minPeriods <= 0 {
+		minPeriods = window
+		if window <= 0 {
+			minPeriods = 1
+		}
+	}
+
+	vals := make([]float64, len(t.Cols[x]))
+	for y, v := range t.Cols[x] {
+		if v == nil {
+			vals[y] = math.NaN()
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("table: Rolling: column %q row %d holds %T, not a number", col, y, v)
+		}
+		vals[y] = f
+	}
+
+	out := make([]eval.Value, len(vals))
+	count := 0 // non-NaN values in the window
+	for i, v := range vals {
+		if !math.IsNaN(v) {
+			count++
+		}
+		start := 0
+		if window > 0 && i >= window {
+			start = i - window + 1
+			if !math.IsNaN(vals[start-1]) {
+				count--
+			}
+		}
+		if count < minPeriods {
+			continue
+		}
+		if r := fn(vals[start : i+1]); !math.IsNaN(r) {
+			out[i] = big.NewFloat(r)
+		}
+	}
+
+	res := &eval.Table{
+		ColNames: append(append([]string(nil), t.ColNames...), name),
+		Cols:     make([][]eval.Value, 0, len(t.Cols)+1),
+	}
+	for _, c := range t.Cols {
+		res.Cols = append(res.Cols, append([]eval.Value(nil), c...))
+	}
+	res.Cols = append(res.Cols, out)
+	return res, nil
+}
+
+func toFloat(v eval.Value) (float64, bool) {
+	switch v := v.(type) {
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *big.Float:
+		f, _ := v.Float64()
+		return f, true
+	}
+	return 0, false
+}
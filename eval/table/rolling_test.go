@@ -0,0 +1,118 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/stats"
+	"neugram.io/ng/eval/table"
+)
+
+var rollingTests = []struct {
+	name   string
+	window int
+	opts   table.RollingOptions
+	want   []float64 // NaN for null, as pandas prints it
+}{
+	// pd.Series([1, 2, nan, 4, 5, 6]).rolling(3).mean()
+	{"fixed", 3, table.RollingOptions{}, []float64{nan, nan, nan, nan, nan, 5}},
+	// .rolling(3, min_periods=2).mean()
+	{"min periods", 3, table.RollingOptions{MinPeriods: 2}, []float64{nan, 1.5, 1.5, 3, 4.5, 5}},
+	// .expanding().mean()
+	{"expanding", 0, table.RollingOptions{}, []float64{1, 1.5, 1.5, 7.0 / 3, 3, 3.6}},
+	// .expanding(min_periods=4).mean()
+	{"expanding min periods", 0, table.RollingOptions{MinPeriods: 4}, []float64{nan, nan, nan, nan, 3, 3.6}},
+}
+
+var nan = math.NaN()
+
+func TestRolling(t *testing.T) {
+	for _, test := range rollingTests {
+		t.Run(test.name, func(t *testing.T) {
+			in := &eval.Table{
+				ColNames: []string{"day", "x"},
+				Cols: [][]eval.Value{
+					{"mo", "tu", "we", "th", "fr", "sa"},
+					col(1, 2.0, nil, 4, 5.0, 6),
+				},
+			}
+			got, err := table.Rolling(in, "x", test.window, stats.Mean, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.ColNames) != 3 || got.ColNames[2] != "rolling(x)" {
+				t.Fatalf("ColNames=%q, want a third column rolling(x)", got.ColNames)
+			}
+			for i, want := range test.want {
+				v := got.Cols[2][i]
+				if math.IsNaN(want) {
+					if v != nil {
+						t.Errorf("row %d: %v, want nil", i, v)
+					}
+					continue
+				}
+				f, ok := v.(*big.Float)
+				if !ok {
+					t.Errorf("row %d: %T(%v), want *big.Float", i, v, v)
+					continue
+				}
+				if g, _ := f.Float64(); math.Abs(g-want) > 1e-12 {
+					t.Errorf("row %d: %v, want %v", i, g, want)
+				}
+			}
+			if s := show(&eval.Table{ColNames: in.ColNames, Cols: got.Cols[:2]}); s != show(in) {
+				t.Errorf("original columns changed:\n%s\nwant:\n%s", s, show(in))
+			}
+		})
+	}
+}
+
+func TestRollingFunc(t *testing.T) {
+	in := &eval.Table{
+		ColNames: []string{"x"},
+		Cols:     [][]eval.Value{col(3, 1, 2)},
+	}
+	var windows [][]float64
+	got, err := table.Rolling(in, "x", 2, func(w []float64) float64 {
+		windows = append(windows, append([]float64(nil), w...))
+		return w[len(w)-1] - w[0]
+	}, table.RollingOptions{MinPeriods: 1, Name: "diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 3 || len(windows[0]) != 1 || len(windows[1]) != 2 || windows[2][0] != 1 || windows[2][1] != 2 {
+		t.Errorf("windows=%v, want [[3] [3 1] [1 2]]", windows)
+	}
+	want := `["x" "diff"]
+*big.Int(3) *big.Float(0)
+*big.Int(1) *big.Float(-2)
+*big.Int(2) *big.Float(1)`
+	if s := show(got); s != want {
+		t.Errorf("Rolling=\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestRollingError(t *testing.T) {
+	in := &eval.Table{
+		ColNames: []string{"x", "s"},
+		Cols:     [][]eval.Value{col(1), {"a"}},
+	}
+	for _, test := range []struct {
+		col, name, err string
+	}{
+		{"y", "", `table: Rolling: no column "y"`},
+		{"s", "", `table: Rolling: column "s" row 0 holds string, not a number`},
+		{"x", "s", `table: Rolling: table already has a column "s"`},
+	} {
+		_, err := table.Rolling(in, test.col, 2, stats.Mean, table.RollingOptions{Name: test.name})
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Rolling(%q, name %q) err=%v, want %q", test.col, test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"neugram.io/ng/eval"
+)
+
+// SortBy returns a copy of t with its rows sorted by the named
+// columns, in priority order. Column cols[i] is sorted in increasing
+// order if ascending[i] is set, and in decreasing order otherwise.
+//
+// Numbers compare numerically, strings with strings.Compare and
+// false before true. A column may not mix numbers, strings and
+// bools. Nulls (nil Values) sort last in either direction.
+// Rows that compare equal keep their order.
+func SortBy(t *eval.Table, cols []string, ascending []bool) (*eval.Table, error) {
+	if len(cols) != len(ascending) {
+		return nil, fmt.Errorf("table: SortBy has %d columns but %d directions", len(cols), len(ascending))
+	}
+	keys := make([]*sortKey, len(cols))
+	for i, name := range cols {
+		x := colIndex(t, name)
+		if x < 0 {
+			return nil, fmt.Errorf("table: SortBy: no column %q", name)
+		}
+		key, err := newSortKey(t.Cols[x])
+		if err != nil {
+			return nil, fmt.Errorf("table: SortBy: column %q %v", name, err)
+		}
+		key.desc = !ascending[i]
+		keys[i] = key
+	}
+
+	var perm []int
+	if len(keys) == 1 && (keys[0].kind == kindInt || keys[0].kind == kindFloat) && uint64(t.Len()) <= math.MaxUint32 {
+		perm = keys[0].sortNumbers()
+	} else {
+		perm = make([]int, t.Len())
+		for i := range perm {
+			perm[i] = i
+		}
+		// Ties are broken by row number, which makes the sort stable
+		// without paying for sort.SliceStable.
+		sort.Slice(perm, func(a, b int) bool {
+			i, j := perm[a], perm[b]
+			for _, key := range keys {
+				if c := key.compare(i, j); c != 0 {
+					return c < 0
+				}
+			}
+			return i < j
+		})
+	}
+
+	return takeRows(t, perm), nil
+}
+
+// colIndex returns the index of the first column of t called name,
+// or -1.
+func colIndex(t *eval.Table, name string) int {
+	for x, colName := range t.ColNames {
+		if colName == name {
+			return x
+		}
+	}
+	return -1
+}
+
+// A sortKey holds the values of one column in a form that is cheap
+// to compare. Only the slice for kind is set.
+type sortKey struct {
+	kind   colKind
+	desc   bool
+	null   []bool
+	ints   []int64
+	floats []float64
+	strs   []string
+	bools  []bool
+}
+
+func newSortKey(col []eval.Value) (*sortKey, error) {
+	key := &sortKey{null: make([]bool, len(col))}
+	var first eval.Value
+	for i, v := range col {
+		var kind colKind
+		switch v := v.(type) {
+		case nil:
+			key.null[i] = true
+			continue
+		case *big.Int:
+			kind = kindInt
+			if !v.IsInt64() {
+				kind = kindFloat
+			}
+		case *big.Float:
+			kind = kindFloat
+		case string:
+			kind = kindString
+		case bool:
+			kind = kindBool
+		default:
+			return nil, fmt.Errorf("cannot sort %T values", v)
+		}
+		switch {
+		case key.kind == kindUnknown:
+			key.kind, first = kind, v
+		case key.kind == kindInt && kind == kindFloat:
+			key.kind = kindFloat
+		case key.kind == kindFloat && kind == kindInt:
+		case key.kind != kind:
+			return nil, fmt.Errorf("mixes %T and %T values", first, v)
+		}
+	}
+
+	switch key.kind {
+	case kindInt:
+		key.ints = make([]int64, len(col))
+		for i, v := range col {
+			if !key.null[i] {
+				key.ints[i] = v.(*big.Int).Int64()
+			}
+		}
+	case kindFloat:
+		key.floats = make([]float64, len(col))
+		for i, v := range col {
+			switch v := v.(type) {
+			case *big.Int:
+				key.floats[i], _ = new(big.Float).SetInt(v).Float64()
+			case *big.Float:
+				key.floats[i], _ = v.Float64()
+			}
+		}
+	case kindString:
+		key.strs = make([]string, len(col))
+		for i, v := range col {
+			if !key.null[i] {
+				key.strs[i] = v.(string)
+			}
+		}
+	case kindBool:
+		key.bools = make([]bool, len(col))
+		for i, v := range col {
+			if !key.null[i] {
+				key.bools[i] = v.(bool)
+			}
+		}
+	}
+	return key, nil
+}
+
+// compare compares rows i and j, returning -1, 0 or +1.
+func (key *sortKey) compare(i, j int) int {
+	if ni, nj := key.null[i], key.null[j]; ni || nj {
+		switch {
+		case ni && nj:
+			return 0
+		case ni:
+			return +1
+		}
+		return -1
+	}
+	c := 0
+	switch key.kind {
+	case kindInt:
+		c = compareOrdered(key.ints[i] < key.ints[j], key.ints[i] > key.ints[j])
+	case kindFloat:
+		c = compareOrdered(key.floats[i] < key.floats[j], key.floats[i] > key.floats[j])
+	case kindString:
+		c = strings.Compare(key.strs[i], key.strs[j])
+	case kindBool:
+		c = compareOrdered(!key.bools[i] && key.bools[j], key.bools[i] && !key.bools[j])
+	}
+	if key.desc {
+		return -c
+	}
+	return c
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return +1
+	}
+	return 0
+}
+
+// sortNumbers returns the row order of a numeric key.
+//
+// It uses an LSD radix sort on the bits of the values, which is
+// stable and linear in the number of rows. This is the common case
+// of sorting a large table by one column.
+func (key *sortKey) sortNumbers() []int {
+	n := len(key.null)
+	bits := make([]uint64, 0, n)
+	rows := make([]uint32, 0, n)
+	var nulls []int
+	for i := 0; i < n; i++ {
+		if key.null[i] {
+			nulls = append(nulls, i)
+			continue
+		}
+		var b uint64
+		if key.kind == kindInt {
+			b = uint64(key.ints[i]) ^ 1<<63
+		} else {
+			// Flip the sign bit of positive floats and every bit of
+			// negative ones, so the bits order as the values do.
+			b = math.Float64bits(key.floats[i])
+			if b>>63 == 1 {
+				b = ^b
+			} else {
+				b |= 1 << 63
+			}
+		}
+		if key.desc {
+			b = ^b
+		}
+		bits = append(bits, b)
+		rows = append(rows, uint32(i))
+	}
+	rows = radixSort(bits, rows)
+
+	perm := make([]int, 0, n)
+	for _, i := range rows {
+		perm = append(perm, int(i))
+	}
+	return append(perm, nulls...)
+}
+
+// radixSort sorts rows by the corresponding keys, keeping the order
+// of rows with equal keys, and returns the sorted rows. Both slices
+// are overwritten.
+func radixSort(keys []uint64, rows []uint32) []uint32 {
+	const digit = 16
+	const mask = 1<<digit - 1
+	if len(keys) == 0 {
+		return rows
+	}
+	count := make([]int, 1<<digit)
+	tmpKeys := make([]uint64, len(keys))
+	tmpRows := make([]uint32, len(rows))
+	for shift := uint(0); shift < 64; shift += digit {
+		for i := range count {
+			count[i] = 0
+		}
+		for _, k := range keys {
+			count[k>>shift&mask]++
+		}
+		if count[keys[0]>>shift&mask] == len(keys) {
+			continue // every key has the same digit
+		}
+		sum := 0
+		for i, c := range count {
+			count[i] = sum
+			sum += c
+		}
+		for i, k := range keys {
+			d := k >> shift & mask
+			tmpKeys[count[d]] = k
+			tmpRows[count[d]] = rows[i]
+			count[d]++
+		}
+		keys, tmpKeys = tmpKeys, keys
+		rows, tmpRows = tmpRows, rows
+	}
+	return rows
+}
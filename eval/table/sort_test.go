@@ -0,0 +1,92 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table_test
+
+import (
+	"strings"
+	"testing"
+
+	"neugram.io/ng/eval"
+	"neugram.io/ng/eval/table"
+)
+
+func sortTable() *eval.Table {
+	return &eval.Table{
+		ColNames: []string{"name", "n", "x", "ok", "id"},
+		Cols: [][]eval.Value{
+			{"b", "a", "c", "a", "b"},
+			col(2, 1, nil, 2, 1),
+			col(0.5, 3, -1.0, nil, 2.5),
+			{true, false, true, nil, false},
+			col(0, 1, 2, 3, 4),
+		},
+	}
+}
+
+var sortByTests = []struct {
+	cols      []string
+	ascending []bool
+	want      string // the name and id of the sorted rows
+}{
+	{nil, nil, "b0 a1 c2 a3 b4"},
+	{[]string{"name"}, []bool{true}, "a1 a3 b0 b4 c2"},
+	{[]string{"name"}, []bool{false}, "c2 b0 b4 a1 a3"},
+	{[]string{"n"}, []bool{true}, "a1 b4 b0 a3 c2"},
+	{[]string{"n"}, []bool{false}, "b0 a3 a1 b4 c2"},
+	{[]string{"x"}, []bool{true}, "c2 b0 b4 a1 a3"},
+	{[]string{"x"}, []bool{false}, "a1 b4 b0 c2 a3"},
+	{[]string{"ok"}, []bool{true}, "a1 b4 b0 c2 a3"},
+	{[]string{"name", "n"}, []bool{true, false}, "a3 a1 b0 b4 c2"},
+	{[]string{"n", "name"}, []bool{false, false}, "b0 a3 b4 a1 c2"},
+}
+
+func TestSortBy(t *testing.T) {
+	for _, test := range sortByTests {
+		orig := sortTable()
+		got, err := table.SortBy(orig, test.cols, test.ascending)
+		if err != nil {
+			t.Errorf("SortBy(%v, %v): %v", test.cols, test.ascending, err)
+			continue
+		}
+		if ids := rowIDs(got, 0, 4); ids != test.want {
+			t.Errorf("SortBy(%v, %v)=%s, want %s", test.cols, test.ascending, ids, test.want)
+		}
+		if show(orig) != show(sortTable()) {
+			t.Errorf("SortBy(%v, %v) modified its input", test.cols, test.ascending)
+		}
+	}
+
+	// With no columns, SortBy returns a copy.
+	orig := sortTable()
+	cp, err := table.SortBy(orig, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp.Cols[0][0] = "changed"
+	cp.ColNames[0] = "changed"
+	if show(orig) != show(sortTable()) {
+		t.Error("SortBy with no columns did not copy its input")
+	}
+}
+
+func TestSortByError(t *testing.T) {
+	mixed := sortTable()
+	mixed.Cols[2][1] = "three"
+	for _, test := range []struct {
+		t         *eval.Table
+		cols      []string
+		ascending []bool
+		want      string
+	}{
+		{sortTable(), []string{"name", "y"}, []bool{true, true}, `no column "y"`},
+		{sortTable(), []string{"name"}, nil, "SortBy has 1 columns but 0 directions"},
+		{mixed, []string{"x"}, []bool{true}, `column "x" mixes *big.Float and string values`},
+	} {
+		_, err := table.SortBy(test.t, test.cols, test.ascending)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("SortBy(%v, %v): error %v, want %q", test.cols, test.ascending, err, test.want)
+		}
+	}
+}
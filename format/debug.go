@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"os/exec"
 	"reflect"
@@ -18,7 +19,7 @@ import (
 type debugPrinter struct {
 	buf     *bytes.Buffer
 	ptrseen map[interface{}]int // ptr -> count seen
-	ptrdone map[interface{}]bool
+	ptrid   map[interface{}]int // ptr -> label, for ptrs seen more than once
 	indent  int
 }
 
@@ -116,18 +117,25 @@ func (p *debugPrinter) printv(v reflect.Value) {
 			p.printf("unexported")
 			return
 		}
-		p.printf("&")
 		ptr := v.Interface()
-		if p.ptrdone[ptr] {
-			p.printf("%p", ptr)
-		} else if p.ptrseen[ptr] > 1 {
-			// TODO: p.printv(v.Elem())
-			p.printf(" (TODO type %T)", ptr)
-			p.ptrdone[ptr] = true
-			p.printf(" (ptr %p)", ptr)
-		} else {
-			p.printv(v.Elem())
+		switch ptr := ptr.(type) {
+		case *big.Int, *big.Float:
+			// Print the value, not the representation.
+			p.printf("%T(%v)", ptr, ptr)
+			return
+		}
+		if id := p.ptrid[ptr]; id > 0 {
+			// Printed already, refer back to it.
+			p.printf("#%d", id)
+			return
+		}
+		if p.ptrseen[ptr] > 1 {
+			id := len(p.ptrid) + 1
+			p.ptrid[ptr] = id
+			p.printf("#%d=", id)
 		}
+		p.printf("&")
+		p.printv(v.Elem())
 	case reflect.Interface:
 		if repack := reflect.ValueOf(v.Interface()); repack.Kind() == reflect.Ptr {
 			p.printv(repack)
@@ -271,13 +279,16 @@ func WriteDebug(buf *bytes.Buffer, e interface{}) {
 	p := debugPrinter{
 		buf:     buf,
 		ptrseen: make(map[interface{}]int),
-		ptrdone: make(map[interface{}]bool),
+		ptrid:   make(map[interface{}]int),
 	}
 	v := reflect.ValueOf(e)
 	p.collectPtrs(v)
 	p.printv(v)
 }
 
+// Debug returns a detailed printout of e, for debugging and tests.
+// A pointer that is reachable more than once is printed in full the
+// first time, labeled #n=, and as #n after that.
 func Debug(e interface{}) string {
 	buf := new(bytes.Buffer)
 	WriteDebug(buf, e)
@@ -0,0 +1,207 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package format_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/debug")
+
+func ident(name string) *expr.Ident { return &expr.Ident{Name: name} }
+
+func intLit(x int64) *expr.BasicLiteral { return &expr.BasicLiteral{Value: big.NewInt(x)} }
+
+// deepExpr returns a chain of depth calls, selectors and binary
+// expressions, each wrapping the next.
+func deepExpr(depth int) expr.Expr {
+	var e expr.Expr = ident("x")
+	for i := 0; i < depth; i++ {
+		switch i % 3 {
+		case 0:
+			e = &expr.Binary{Op: token.Add, Left: e, Right: intLit(int64(i))}
+		case 1:
+			e = &expr.Call{Func: &expr.Selector{Left: e, Right: ident("f")}}
+		case 2:
+			e = &expr.Unary{Op: token.LeftParen, Expr: e}
+		}
+	}
+	return e
+}
+
+var sharedIdent = ident("a")
+
+var debugTests = []struct {
+	name string
+	e    interface{}
+}{
+	{"binary", &expr.Binary{Op: token.Mul, Left: ident("a"), Right: intLit(2)}},
+	{"unary", &expr.Unary{Op: token.Not, Expr: ident("ok")}},
+	{"bad", &expr.Bad{Error: errors.New("bad expression")}},
+	{"selector", &expr.Selector{Left: ident("pkg"), Right: ident("Name")}},
+	{"slice", &expr.Slice{Low: intLit(1), Max: ident("n")}},
+	{"index", &expr.Index{Left: ident("s"), Indicies: []expr.Expr{intLit(0)}}},
+	{"table_index", &expr.Index{
+		Left: ident("t"),
+		Indicies: []expr.Expr{
+			&expr.Slice{Low: intLit(1), High: intLit(3)},
+			&expr.BasicLiteral{Value: "Col"},
+		},
+	}},
+	{"type_assert", &expr.TypeAssert{Left: ident("v"), Type: tipe.String}},
+	{"type_switch", &expr.TypeAssert{Left: ident("v")}},
+	{"basic_literal", []expr.Expr{
+		&expr.BasicLiteral{Value: big.NewInt(-42)},
+		&expr.BasicLiteral{Value: big.NewFloat(1.5)},
+		&expr.BasicLiteral{Value: "a \"string\"\n"},
+		&expr.BasicLiteral{Value: 'π'},
+	}},
+	{"func_literal", &expr.FuncLiteral{
+		Name:         "Len",
+		ReceiverName: "l",
+		Type: &tipe.Func{
+			Params:  &tipe.Tuple{Elems: []tipe.Type{tipe.Int}},
+			Results: &tipe.Tuple{Elems: []tipe.Type{tipe.Int}},
+		},
+		ParamNames: []string{"n"},
+		Body: &stmt.Block{Stmts: []stmt.Stmt{
+			&stmt.Return{Exprs: []expr.Expr{ident("n")}},
+		}},
+	}},
+	{"comp_literal", &expr.CompLiteral{
+		Type:   &tipe.Unresolved{Name: "T"},
+		Keys:   []expr.Expr{ident("X"), ident("Y")},
+		Values: []expr.Expr{intLit(1), intLit(2)},
+	}},
+	{"map_literal", &expr.MapLiteral{
+		Type:   &tipe.Map{Key: tipe.String, Value: tipe.Int},
+		Keys:   []expr.Expr{&expr.BasicLiteral{Value: "one"}},
+		Values: []expr.Expr{intLit(1)},
+	}},
+	{"array_literal", &expr.ArrayLiteral{
+		Type:   &tipe.Array{Len: 2, Elem: tipe.Int},
+		Keys:   []expr.Expr{intLit(1)},
+		Values: []expr.Expr{intLit(7)},
+	}},
+	{"slice_literal", &expr.SliceLiteral{
+		Type:   &tipe.Slice{Elem: tipe.Int},
+		Values: []expr.Expr{intLit(1), intLit(2)},
+	}},
+	{"table_literal", &expr.TableLiteral{
+		Type:     &tipe.Table{Type: tipe.Float64},
+		ColNames: []expr.Expr{&expr.BasicLiteral{Value: "a"}, &expr.BasicLiteral{Value: "b"}},
+		Rows:     [][]expr.Expr{{intLit(1), intLit(2)}, {intLit(3), intLit(4)}},
+	}},
+	{"type", &expr.Type{Type: &tipe.Pointer{Elem: tipe.Byte}}},
+	{"ident", ident("x")},
+	{"call", &expr.Call{Func: ident("f"), Args: []expr.Expr{ident("xs")}, Ellipsis: true, ElideError: true}},
+	{"shell", &expr.Shell{
+		Cmds: []*expr.ShellList{{
+			AndOr: []*expr.ShellAndOr{{
+				Pipeline: []*expr.ShellPipeline{
+					{Cmd: []*expr.ShellCmd{
+						{SimpleCmd: &expr.ShellSimpleCmd{
+							Assign: []expr.ShellAssign{{Key: "K", Value: "V"}},
+							Args:   []string{"env"},
+						}},
+						{SimpleCmd: &expr.ShellSimpleCmd{
+							Redirect: []*expr.ShellRedirect{{Token: token.Greater, Filename: "out"}},
+							Args:     []string{"grep", "K="},
+						}},
+					}},
+					{Bang: true, Cmd: []*expr.ShellCmd{
+						{Subshell: &expr.ShellList{AndOr: []*expr.ShellAndOr{{
+							Pipeline: []*expr.ShellPipeline{{Cmd: []*expr.ShellCmd{
+								{SimpleCmd: &expr.ShellSimpleCmd{Args: []string{"false"}}},
+							}}},
+							Background: true,
+						}}}},
+					}},
+				},
+				Sep: []token.Token{token.LogicalOr},
+			}},
+		}},
+		TrapOut:  true,
+		FreeVars: []string{"K"},
+	}},
+	{"shell_parts", []expr.Expr{
+		&expr.ShellRedirect{Number: new(int), Token: token.Less, Filename: "in"},
+		&expr.ShellAssign{Key: "A", Value: "$B"},
+	}},
+	{"nil", nilExprs},
+	{"zero", []expr.Expr{&expr.Binary{}, &expr.Call{}, &expr.Shell{}}},
+	{"deep", deepExpr(30)},
+	{"shared", &expr.Binary{
+		Op:    token.Add,
+		Left:  sharedIdent,
+		Right: &expr.Binary{Op: token.Mul, Left: sharedIdent, Right: sharedIdent},
+	}},
+}
+
+// debugOutput returns the contents of the golden file for e.
+// The elements of a list are printed one by one, with their types.
+func debugOutput(e interface{}) []byte {
+	list, ok := e.([]expr.Expr)
+	if !ok {
+		return []byte(format.Debug(e) + "\n")
+	}
+	buf := new(bytes.Buffer)
+	for _, e := range list {
+		fmt.Fprintf(buf, "// %T\n%s\n", e, format.Debug(e))
+	}
+	return buf.Bytes()
+}
+
+func TestDebugGolden(t *testing.T) {
+	for _, test := range debugTests {
+		got := debugOutput(test.e)
+		golden := filepath.Join("testdata", "debug", test.name+".golden")
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0666); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v (run go test -update to create it)", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: Debug output does not match %s:\n%s", test.name, golden, got)
+		}
+	}
+}
+
+func TestDebugTypes(t *testing.T) {
+	// Every node type is printed by some golden test.
+	var all string
+	for _, test := range debugTests {
+		if test.name != "nil" {
+			all += format.Debug(test.e)
+		}
+	}
+	for _, e := range nilExprs {
+		name := reflect.TypeOf(e).Elem().String()
+		if !strings.Contains(all, name+"{") {
+			t.Errorf("no golden test prints a %s", name)
+		}
+	}
+}
@@ -9,8 +9,15 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
 
+	"neugram.io/ng/internal/bigcplx"
+	"neugram.io/ng/syntax"
 	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
 	"neugram.io/ng/syntax/stmt"
 	"neugram.io/ng/syntax/token"
 )
@@ -18,25 +25,59 @@ import (
 type printer struct {
 	buf    *bytes.Buffer
 	indent int
+
+	// Set when printing a whole file, see WriteFile.
+	file     bool
+	comments []*syntax.Comment // comments not yet printed
+	blanks   []int32           // blank lines in the source
+	end      src.Pos           // end of the block being printed
 }
 
 func (p *printer) expr(e expr.Expr) {
+	// Partially parsed or constructed trees may have missing
+	// nodes. Print them as placeholders instead of panicking.
+	if e == nil {
+		p.buf.WriteString("<nil>")
+		return
+	}
+	if v := reflect.ValueOf(e); v.Kind() == reflect.Ptr && v.IsNil() {
+		p.printf("<nil %T>", e)
+		return
+	}
+	switch e := e.(type) {
+	case *expr.Binary, *expr.Selector, *expr.Index, *expr.TypeAssert, *expr.Call:
+		// Positioned at the token after their first operand.
+	case *expr.Slice:
+		if e.Low == nil {
+			p.inlineComments(e.Position, false)
+		}
+	default:
+		p.inlineComments(e.Pos(), false)
+	}
 	switch e := e.(type) {
 	case *expr.Binary:
-		WriteExpr(p.buf, e.Left)
-		p.buf.WriteString(e.Op.String())
-		WriteExpr(p.buf, e.Right)
+		prec := e.Op.Precedence()
+		p.operand(e.Left, prec)
+		p.inlineComments(e.Position, true)
+		p.buf.WriteString(" " + e.Op.String() + " ")
+		p.operand(e.Right, prec+1) // binary operators are left-associative
 	case *expr.Unary:
 		p.buf.WriteString(e.Op.String())
-		WriteExpr(p.buf, e.Expr)
 		if e.Op == token.LeftParen {
+			p.expr(e.Expr)
 			p.buf.WriteByte(')')
+		} else {
+			if u, isUnary := e.Expr.(*expr.Unary); isUnary && fuses(e.Op, u.Op) {
+				p.buf.WriteByte(' ')
+			}
+			p.operand(e.Expr, token.Mul.Precedence()+1)
 		}
 	case *expr.Bad:
 		fmt.Fprintf(p.buf, "bad(%q)", e.Error)
 	case *expr.Slice:
 		if e.Low != nil {
 			p.expr(e.Low)
+			p.inlineComments(e.Position, true)
 		}
 		p.buf.WriteString(":")
 		if e.High != nil {
@@ -47,10 +88,36 @@ func (p *printer) expr(e expr.Expr) {
 			p.expr(e.Max)
 		}
 	case *expr.Selector:
-		p.expr(e.Left)
-		p.buf.WriteString("." + e.Right.Name)
+		p.primary(e.Left)
+		p.inlineComments(e.Position, true)
+		p.buf.WriteString(".")
+		p.expr(e.Right)
 	case *expr.BasicLiteral:
-		p.buf.WriteString(fmt.Sprintf("%v", e.Value))
+		if e.Text != "" {
+			p.buf.WriteString(e.Text)
+			break
+		}
+		switch v := e.Value.(type) {
+		case string:
+			p.buf.WriteString(strconv.Quote(v))
+		case rune:
+			p.buf.WriteString(strconv.QuoteRune(v))
+		case *big.Float:
+			str := v.Text('g', -1)
+			if !strings.ContainsAny(str, ".e") {
+				str += ".0" // keep it a float literal
+			}
+			p.buf.WriteString(str)
+		case *bigcplx.Complex:
+			if v.Real.Sign() != 0 || v.Imag.Sign() < 0 {
+				p.buf.WriteString(v.String()) // not an imaginary literal
+				break
+			}
+			p.buf.WriteString(v.Imag.Text('g', -1))
+			p.buf.WriteByte('i')
+		default:
+			p.printf("%v", e.Value)
+		}
 	case *expr.FuncLiteral:
 		p.buf.WriteString("func")
 		if e.ReceiverName != "" {
@@ -102,7 +169,11 @@ func (p *printer) expr(e expr.Expr) {
 
 		if e.Body != nil {
 			p.buf.WriteString(" ")
-			p.stmt(e.Body.(*stmt.Block))
+			if p.file {
+				p.funcBody(e.Body.(*stmt.Block))
+			} else {
+				p.stmt(e.Body.(*stmt.Block))
+			}
 		}
 	case *expr.CompLiteral:
 		p.tipe(e.Type)
@@ -110,11 +181,13 @@ func (p *printer) expr(e expr.Expr) {
 		if len(e.Keys) > 0 {
 			p.indent++
 			for i, key := range e.Keys {
+				p.commentsBefore(key.Pos())
 				p.newline()
 				p.expr(key)
 				p.print(": ")
 				p.expr(e.Values[i])
 				p.print(",")
+				p.keyComment(e.Position, e.Keys, i)
 			}
 			p.indent--
 			p.newline()
@@ -129,14 +202,20 @@ func (p *printer) expr(e expr.Expr) {
 		p.print("}")
 	case *expr.MapLiteral:
 		p.tipe(e.Type)
+		if len(e.Keys) == 0 {
+			p.print("{}")
+			break
+		}
 		p.print("{")
 		p.indent++
 		for i, key := range e.Keys {
+			p.commentsBefore(key.Pos())
 			p.newline()
 			p.expr(key)
 			p.print(": ")
 			p.expr(e.Values[i])
 			p.print(",")
+			p.keyComment(e.Position, e.Keys, i)
 		}
 		p.indent--
 		p.newline()
@@ -198,20 +277,16 @@ func (p *printer) expr(e expr.Expr) {
 			}
 			p.print("|}")
 		}
-		if len(e.Rows) > 0 {
-			p.print(", ")
-			for i, row := range e.Rows {
-				if i > 0 {
+		for i, row := range e.Rows {
+			if i > 0 || len(e.ColNames) > 0 {
+				p.print(", ")
+			}
+			p.print("{")
+			for j, r := range row {
+				if j > 0 {
 					p.print(", ")
 				}
-				p.print("{")
-				for j, r := range row {
-					if j > 0 {
-						p.print(", ")
-					}
-					p.expr(r)
-				}
-				p.print("}")
+				p.expr(r)
 			}
 			p.print("}")
 		}
@@ -221,17 +296,19 @@ func (p *printer) expr(e expr.Expr) {
 	case *expr.Ident:
 		p.buf.WriteString(e.Name)
 	case *expr.Index:
-		p.expr(e.Left)
+		p.primary(e.Left)
+		p.inlineComments(e.Position, true)
 		p.buf.WriteString("[")
 		for i, idx := range e.Indicies {
 			if i > 0 {
-				p.buf.WriteString(":")
+				p.buf.WriteString(", ")
 			}
 			p.expr(idx)
 		}
 		p.buf.WriteString("]")
 	case *expr.TypeAssert:
-		p.expr(e.Left)
+		p.primary(e.Left)
+		p.inlineComments(e.Position, true)
 		p.buf.WriteString(".(")
 		if e.Type == nil {
 			p.buf.WriteString("type")
@@ -240,17 +317,22 @@ func (p *printer) expr(e expr.Expr) {
 		}
 		p.buf.WriteString(")")
 	case *expr.Call:
-		WriteExpr(p.buf, e.Func)
+		p.primary(e.Func)
+		p.inlineComments(e.Position, true)
 		p.buf.WriteString("(")
 		for i, arg := range e.Args {
 			if i > 0 {
 				p.buf.WriteString(", ")
 			}
-			WriteExpr(p.buf, arg)
+			p.expr(arg)
+		}
+		if e.Ellipsis {
+			p.buf.WriteString("...")
 		}
 		p.buf.WriteString(")")
 	case *expr.Shell:
-		if len(e.Cmds) == 1 {
+		// A block is kept on several lines if it is in the source.
+		if len(e.Cmds) == 1 && (e.Position.Line == 0 || e.Cmds[0].Position.Line == e.Position.Line) {
 			p.buf.WriteString("$$ ")
 			p.expr(e.Cmds[0])
 			p.buf.WriteString(" $$")
@@ -355,6 +437,62 @@ func (p *printer) printf(format string, args ...interface{}) {
 	fmt.Fprintf(p.buf, format, args...)
 }
 
+// operand prints e, wrapping it in parentheses if it is a binary
+// expression that binds less tightly than prec.
+//
+// Parentheses written in the source are kept by the parser as
+// Unary expressions, so this only matters for constructed trees.
+func (p *printer) operand(e expr.Expr, prec int) {
+	if b, isBinary := e.(*expr.Binary); isBinary && b.Op.Precedence() < prec {
+		p.buf.WriteByte('(')
+		p.expr(e)
+		p.buf.WriteByte(')')
+		return
+	}
+	p.expr(e)
+}
+
+// primary prints e as the operand of a selector, index, type
+// assertion or call, parenthesizing it if it is an operator expression
+// or a number, which would absorb a following '.'.
+func (p *printer) primary(e expr.Expr) {
+	switch e := e.(type) {
+	case *expr.Binary:
+	case *expr.Unary:
+		if e.Op == token.LeftParen {
+			p.expr(e)
+			return
+		}
+	case *expr.BasicLiteral:
+		switch e.Value.(type) {
+		case *big.Int, *big.Float:
+		default:
+			p.expr(e)
+			return
+		}
+	default:
+		p.expr(e)
+		return
+	}
+	p.buf.WriteByte('(')
+	p.expr(e)
+	p.buf.WriteByte(')')
+}
+
+// fuses reports whether writing unary operator op2 directly after op1
+// would scan as a different token, as - - does as --.
+func fuses(op1, op2 token.Token) bool {
+	switch op1 {
+	case token.Sub:
+		return op2 == token.Sub
+	case token.Add:
+		return op2 == token.Add
+	case token.Ref:
+		return op2 == token.Ref || op2 == token.Pow
+	}
+	return false
+}
+
 func (p *printer) print(args ...interface{}) {
 	fmt.Fprint(p.buf, args...)
 }
@@ -378,3 +516,14 @@ func Expr(e expr.Expr) string {
 	WriteExpr(buf, e)
 	return buf.String()
 }
+
+// keyComment prints the comment ending the line of keys[i] in a
+// literal starting at lbrace. A literal on one line in the source
+// has its comment printed after the statement.
+func (p *printer) keyComment(lbrace src.Pos, keys []expr.Expr, i int) {
+	line := keys[i].Pos().Line
+	if line == lbrace.Line || i+1 < len(keys) && keys[i+1].Pos().Line == line {
+		return
+	}
+	p.trailingComment(line)
+}
@@ -0,0 +1,469 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package format
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+)
+
+// WriteFile writes f to buf as canonically formatted Neugram source.
+//
+// Each statement is on its own line, blocks are indented with tabs,
+// and top-level declarations are separated by a blank line. Comments
+// are printed before the statement that follows them, or at the end
+// of the line of a statement printed on one line. A comment inside
+// an expression is kept between the same tokens.
+func WriteFile(buf *bytes.Buffer, f *syntax.File) {
+	p := &printer{
+		buf:      new(bytes.Buffer),
+		file:     true,
+		comments: f.Comments,
+		blanks:   f.BlankLines,
+	}
+	p.stmtList(f.Stmts, src.Pos{Line: math.MaxInt32})
+	out := bytes.TrimPrefix(p.buf.Bytes(), []byte("\n"))
+	if len(out) > 0 {
+		buf.Write(out)
+		buf.WriteByte('\n')
+	}
+}
+
+// File returns f formatted as by WriteFile.
+func File(f *syntax.File) string {
+	buf := new(bytes.Buffer)
+	WriteFile(buf, f)
+	return buf.String()
+}
+
+// fileStmt prints the statements whose layout differs when printing a
+// whole file. It reports whether s was one of them.
+func (p *printer) fileStmt(s stmt.Stmt) bool {
+	switch s := s.(type) {
+	case *stmt.ImportSet:
+		entries := make([]stmt.Stmt, len(s.Imports))
+		for i, imp := range s.Imports {
+			entries[i] = imp
+		}
+		p.group("import", entries)
+	case *stmt.ConstSet:
+		entries := make([]stmt.Stmt, len(s.Consts))
+		for i, c := range s.Consts {
+			entries[i] = c
+		}
+		p.group("const", entries)
+	case *stmt.VarSet:
+		entries := make([]stmt.Stmt, len(s.Vars))
+		for i, v := range s.Vars {
+			entries[i] = v
+		}
+		p.group("var", entries)
+	case *stmt.TypeDeclSet:
+		entries := make([]stmt.Stmt, len(s.TypeDecls))
+		for i, t := range s.TypeDecls {
+			entries[i] = t
+		}
+		p.group("type", entries)
+	case *stmt.Block:
+		p.block(s)
+	case *stmt.Switch:
+		p.buf.WriteString("switch ")
+		if s.Init != nil {
+			p.stmt(s.Init)
+			p.buf.WriteString("; ")
+		}
+		if s.Cond != nil {
+			p.expr(s.Cond)
+			p.buf.WriteString(" ")
+		}
+		p.buf.WriteString("{")
+		for _, c := range s.Cases {
+			p.commentsBefore(c.Position)
+			p.newline()
+			if c.Default {
+				p.buf.WriteString("default:")
+			} else {
+				p.buf.WriteString("case ")
+				for i, e := range c.Conds {
+					if i > 0 {
+						p.buf.WriteString(", ")
+					}
+					p.expr(e)
+				}
+				p.buf.WriteString(":")
+			}
+			p.trailingComment(c.Position.Line)
+			p.caseBody(c.Body)
+		}
+		p.newline()
+		p.buf.WriteString("}")
+	case *stmt.TypeSwitch:
+		p.buf.WriteString("switch ")
+		if s.Init != nil {
+			p.stmt(s.Init)
+			p.buf.WriteString("; ")
+		}
+		p.stmt(s.Assign)
+		p.buf.WriteString(" {")
+		for _, c := range s.Cases {
+			p.commentsBefore(c.Position)
+			p.newline()
+			if c.Default {
+				p.buf.WriteString("default:")
+			} else {
+				p.buf.WriteString("case ")
+				for i, t := range c.Types {
+					if i > 0 {
+						p.buf.WriteString(", ")
+					}
+					p.tipe(t)
+				}
+				p.buf.WriteString(":")
+			}
+			p.trailingComment(c.Position.Line)
+			p.caseBody(c.Body)
+		}
+		p.newline()
+		p.buf.WriteString("}")
+	case *stmt.Select:
+		p.buf.WriteString("select {")
+		for _, c := range s.Cases {
+			p.commentsBefore(c.Position)
+			p.newline()
+			if c.Default {
+				p.buf.WriteString("default:")
+			} else {
+				p.buf.WriteString("case ")
+				p.stmt(c.Stmt)
+				p.buf.WriteString(":")
+			}
+			p.trailingComment(c.Position.Line)
+			p.caseBody(c.Body)
+		}
+		p.newline()
+		p.buf.WriteString("}")
+	case *stmt.MethodikDecl:
+		p.buf.WriteString("methodik ")
+		p.buf.WriteString(s.Name)
+		p.buf.WriteString(" ")
+		p.tipe(s.Type.Type)
+		if len(s.Methods) == 0 {
+			p.buf.WriteString(" {}")
+			return true
+		}
+		p.buf.WriteString(" {")
+		p.indent++
+		for i, m := range s.Methods {
+			if i > 0 {
+				p.buf.WriteByte('\n')
+			}
+			p.commentsBefore(m.Position)
+			p.newline()
+			p.expr(m)
+			if b, ok := m.Body.(*stmt.Block); ok {
+				p.trailingComment(b.End.Line)
+			}
+		}
+		p.indent--
+		p.newline()
+		p.buf.WriteString("}")
+	default:
+		return false
+	}
+	return true
+}
+
+// group prints the entries of a parenthesized declaration, one per
+// line, without their keyword.
+func (p *printer) group(keyword string, entries []stmt.Stmt) {
+	p.buf.WriteString(keyword)
+	p.buf.WriteString(" (")
+	if len(entries) == 0 {
+		p.buf.WriteString(")")
+		return
+	}
+	p.indent++
+	for _, e := range entries {
+		p.commentsBefore(e.Pos())
+		p.newline()
+		switch e := e.(type) {
+		case *stmt.Import:
+			p.importSpec(e)
+		case *stmt.Const:
+			p.valueSpec(e.NameList, e.Type, e.Values)
+		case *stmt.Var:
+			p.valueSpec(e.NameList, e.Type, e.Values)
+		case *stmt.TypeDecl:
+			p.typeSpec(e)
+		}
+		p.trailingComment(lastLine(e))
+	}
+	p.indent--
+	p.newline()
+	p.buf.WriteString(")")
+}
+
+// funcBody prints the body of a function literal. A body with one
+// statement written on one line is kept on one line.
+func (p *printer) funcBody(b *stmt.Block) {
+	if len(b.Stmts) == 1 && b.Position.Line != 0 && b.Position.Line == b.End.Line && !p.commentBefore(b.End) {
+		start := p.buf.Len()
+		p.buf.WriteString("{ ")
+		p.stmt(b.Stmts[0])
+		if bytes.IndexByte(p.buf.Bytes()[start:], '\n') < 0 {
+			p.buf.WriteString(" }")
+			return
+		}
+		p.buf.Truncate(start)
+	}
+	p.block(b)
+}
+
+func (p *printer) block(b *stmt.Block) {
+	if len(b.Stmts) == 0 && !p.commentBefore(b.End) {
+		p.buf.WriteString("{}")
+		return
+	}
+	p.buf.WriteString("{")
+	p.indent++
+	p.stmtList(b.Stmts, b.End)
+	p.indent--
+	p.newline()
+	p.buf.WriteString("}")
+}
+
+func (p *printer) caseBody(b *stmt.Block) {
+	p.indent++
+	p.stmtList(b.Stmts, b.End)
+	p.indent--
+}
+
+// stmtList prints each statement on a new line, preceded by the
+// comments before it, followed by the comments before end.
+//
+// A blank line between two statements or comments in the source is
+// kept, as one blank line.
+func (p *printer) stmtList(stmts []stmt.Stmt, end src.Pos) {
+	defer func(end src.Pos) { p.end = end }(p.end)
+	p.end = end
+
+	var prev stmt.Stmt
+	last := int32(0) // last line of the previous statement or comment
+	for _, s := range stmts {
+		blank := p.indent == 0 && prev != nil && declBreak(prev, s)
+		for p.commentBefore(s.Pos()) {
+			last = p.nextComment(blank, last)
+			blank = false
+		}
+		if blank || p.blankBetween(last, s.Pos().Line) {
+			p.buf.WriteByte('\n')
+		}
+		p.newline()
+
+		start := p.buf.Len()
+		p.stmt(s)
+		prev, last = s, lastLine(s)
+		if endsInBlock(s) || bytes.IndexByte(p.buf.Bytes()[start:], '\n') < 0 {
+			p.trailingComment(last)
+		}
+	}
+	for p.commentBefore(end) {
+		last = p.nextComment(false, last)
+	}
+}
+
+// nextComment prints the next comment on a new line, after a blank
+// line if blank is set or there is one in the source between it and
+// line last. It returns the last line of the comment.
+func (p *printer) nextComment(blank bool, last int32) int32 {
+	c := p.comments[0]
+	p.comments = p.comments[1:]
+	if blank || p.blankBetween(last, c.Position.Line) {
+		p.buf.WriteByte('\n')
+	}
+	p.newline()
+	p.comment(c)
+	return c.Position.Line + int32(strings.Count(c.Text, "\n"))
+}
+
+// blankBetween reports whether there is a blank line in the source
+// after line a and before line b. It is false if a is unknown.
+func (p *printer) blankBetween(a, b int32) bool {
+	if a == 0 {
+		return false
+	}
+	i := sort.Search(len(p.blanks), func(i int) bool { return p.blanks[i] > a })
+	return i < len(p.blanks) && p.blanks[i] < b
+}
+
+// trailingComment prints the next comment at the end of the current
+// line if it is a one-line comment on the given line of the source.
+func (p *printer) trailingComment(line int32) {
+	if len(p.comments) == 0 || !p.commentBefore(p.end) {
+		return
+	}
+	if c := p.comments[0]; c.Position.Line == line && !strings.Contains(c.Text, "\n") {
+		p.comments = p.comments[1:]
+		p.buf.WriteByte(' ')
+		p.comment(c)
+	}
+}
+
+// inlineComments prints the comments before pos in the expression
+// being printed, so they stay between the same tokens. Each comment
+// is followed by a space, or if after is set it follows an operand
+// and is preceded by one. A // comment ends the line, and the
+// expression continues on the next.
+func (p *printer) inlineComments(pos src.Pos, after bool) {
+	for p.commentBefore(pos) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		if after {
+			p.buf.WriteByte(' ')
+		}
+		p.comment(c)
+		if strings.HasPrefix(c.Text, "//") {
+			p.indent++
+			p.newline()
+			p.indent--
+		} else if !after {
+			p.buf.WriteByte(' ')
+		}
+	}
+}
+
+// commentsBefore prints, each on a new line, the comments before pos.
+func (p *printer) commentsBefore(pos src.Pos) {
+	for p.commentBefore(pos) {
+		p.nextComment(false, 0)
+	}
+}
+
+// commentBefore reports whether the next comment is before pos.
+// Nothing is before an unknown position.
+func (p *printer) commentBefore(pos src.Pos) bool {
+	if len(p.comments) == 0 || pos.Line == 0 {
+		return false
+	}
+	c := p.comments[0].Position
+	return c.Line < pos.Line || c.Line == pos.Line && c.Column < pos.Column
+}
+
+// comment prints c without trailing spaces. The lines of a /* */
+// comment after the first are printed as they are in the source.
+func (p *printer) comment(c *syntax.Comment) {
+	lines := strings.Split(c.Text, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			p.buf.WriteByte('\n')
+		}
+		p.buf.WriteString(strings.TrimRight(line, " \t\r"))
+	}
+}
+
+// declBreak reports whether the top-level statements a and b are
+// separated by a blank line. They are if either is a declaration,
+// except between consecutive imports, consts or vars.
+func declBreak(a, b stmt.Stmt) bool {
+	if !isDecl(a) && !isDecl(b) {
+		return false
+	}
+	switch a.(type) {
+	case *stmt.Import:
+		_, ok := b.(*stmt.Import)
+		return !ok
+	case *stmt.Const:
+		_, ok := b.(*stmt.Const)
+		return !ok
+	case *stmt.Var:
+		_, ok := b.(*stmt.Var)
+		return !ok
+	}
+	return true
+}
+
+// lastLine returns the last line of s that has a position in the
+// syntax tree, which is the last line of s unless it ends in an
+// expression spanning several lines, such as a composite literal.
+func lastLine(s stmt.Stmt) int32 {
+	var line int32
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Struct:
+			if pos, ok := v.Interface().(src.Pos); ok {
+				if pos.Line > line {
+					line = pos.Line
+				}
+				return
+			}
+			for i := 0; i < v.NumField(); i++ {
+				if f := v.Field(i); f.Type() != tipeType && f.CanInterface() {
+					walk(f)
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(s))
+	return line
+}
+
+// tipeType is skipped by lastLine: types have no positions, and once
+// resolved they may refer to themselves.
+var tipeType = reflect.TypeOf((*tipe.Type)(nil)).Elem()
+
+// endsInBlock reports whether the last token of s is the closing
+// brace of a block, so that lastLine(s) is the line it ends on.
+func endsInBlock(s stmt.Stmt) bool {
+	switch s := s.(type) {
+	case *stmt.Block, *stmt.If, *stmt.For, *stmt.Range:
+		return true
+	case *stmt.Switch:
+		return len(s.Cases) > 0
+	case *stmt.TypeSwitch:
+		return len(s.Cases) > 0
+	case *stmt.Select:
+		return len(s.Cases) > 0
+	case *stmt.Labeled:
+		return endsInBlock(s.Stmt)
+	case *stmt.Simple:
+		_, ok := s.Expr.(*expr.FuncLiteral)
+		return ok
+	case *stmt.Assign:
+		_, ok := s.Right[len(s.Right)-1].(*expr.FuncLiteral)
+		return ok
+	}
+	return false
+}
+
+func isDecl(s stmt.Stmt) bool {
+	switch s := s.(type) {
+	case *stmt.Import, *stmt.ImportSet, *stmt.Const, *stmt.ConstSet,
+		*stmt.Var, *stmt.VarSet, *stmt.TypeDecl, *stmt.TypeDeclSet,
+		*stmt.MethodikDecl:
+		return true
+	case *stmt.Simple:
+		fn, ok := s.Expr.(*expr.FuncLiteral)
+		return ok && fn.Name != ""
+	}
+	return false
+}
@@ -1,11 +1,18 @@
 package format_test
 
 import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"neugram.io/ng/format"
 	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
 	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
 )
 
 var roundTripExprs = []string{
@@ -22,8 +29,16 @@ $$`,
 	"func() (err error) {return nil}",
 	"func(x int, y bool) (b []byte, err error) {return nil, nil}",
 
+	"x + y * z",
+	"(x + y) * z",
+	"!(x && y) || z",
 	"x[:y]",
 	"x[y:z:t]",
+	"x[i, j]",
+	"x[1:2, :]",
+	"[|]int{{|a, b|}, {1, 2}, {3, 4}}",
+	"[|]int{{|a|}}",
+	"[|]int{{1, 2}}",
 	"new(int)",
 }
 
@@ -35,6 +50,9 @@ var roundTripStmts = []string{
 	"path/filepath"
 )`,
 	"type Ints []int",
+	"c <- x + 1",
+	"if x {print(x)}",
+	"if x := f(); x > 0 {print(x)} else if x < 0 {} else {print(0)}",
 
 	`methodik foo struct {
 	S string
@@ -94,6 +112,9 @@ var typeTests = []string{
 	M2(*int) error
 }`,
 	`struct{}`,
+	`chan<- <-chan int`,
+	"struct {\n\tName string `json:\"name\"`\n\tKind string \"id`\"\n\tReader\n\t*Writer\n}",
+	`func(int, ...string) error`,
 }
 
 func TestTypes(t *testing.T) {
@@ -114,3 +135,345 @@ func TestTypes(t *testing.T) {
 		}
 	}
 }
+
+// typeGen generates random type trees for round-trip testing.
+type typeGen struct {
+	r *rand.Rand
+}
+
+var genTypeNames = []*tipe.Unresolved{
+	{Name: "int"},
+	{Name: "string"},
+	{Name: "float64"},
+	{Name: "T"},
+	{Package: "io", Name: "Reader"},
+}
+
+func (g *typeGen) named() tipe.Type {
+	u := *genTypeNames[g.r.Intn(len(genTypeNames))]
+	return &u
+}
+
+func (g *typeGen) tuple(depth, max int) *tipe.Tuple {
+	t := &tipe.Tuple{}
+	for i := g.r.Intn(max + 1); i > 0; i-- {
+		t.Elems = append(t.Elems, g.typ(depth))
+	}
+	return t
+}
+
+func (g *typeGen) fn(depth int) *tipe.Func {
+	f := &tipe.Func{Params: g.tuple(depth, 2)}
+	if results := g.tuple(depth, 2); len(results.Elems) > 0 {
+		f.Results = results
+	}
+	return f
+}
+
+func (g *typeGen) typ(depth int) tipe.Type {
+	if depth <= 0 {
+		return g.named()
+	}
+	depth--
+	switch g.r.Intn(10) {
+	case 0:
+		return g.named()
+	case 1:
+		return &tipe.Slice{Elem: g.typ(depth)}
+	case 2:
+		return &tipe.Array{Len: g.r.Int63n(10), Elem: g.typ(depth)}
+	case 3:
+		return &tipe.Pointer{Elem: g.typ(depth)}
+	case 4:
+		return &tipe.Map{Key: g.typ(depth), Value: g.typ(depth)}
+	case 5:
+		return &tipe.Chan{Direction: tipe.ChanDirection(g.r.Intn(3)), Elem: g.typ(depth)}
+	case 6:
+		return &tipe.Table{Type: g.typ(depth)}
+	case 7:
+		return g.fn(depth)
+	case 8:
+		s := &tipe.Struct{}
+		for i := g.r.Intn(3); i > 0; i-- {
+			s.Fields = append(s.Fields, tipe.StructField{
+				Name: fmt.Sprintf("f%d", i),
+				Type: g.typ(depth),
+			})
+		}
+		return s
+	default:
+		iface := &tipe.Interface{Methods: make(map[string]*tipe.Func)}
+		for i := g.r.Intn(3); i > 0; i-- {
+			iface.Methods[fmt.Sprintf("M%d", i)] = g.fn(depth)
+		}
+		return iface
+	}
+}
+
+func TestTypeRoundTripRandom(t *testing.T) {
+	g := &typeGen{r: rand.New(rand.NewSource(1))}
+	for i := 0; i < 2000; i++ {
+		typ := g.typ(4)
+		src := format.Type(typ)
+		got, err := parser.ParseType([]byte(src))
+		if err != nil {
+			t.Errorf("ParseType(%q): %v", src, err)
+			continue
+		}
+		if !tipe.EqualUnresolved(got, typ) {
+			t.Errorf("%q does not round trip, got %q", src, format.Type(got))
+		}
+	}
+}
+
+// typeStringTests covers types that the type checker constructs
+// but the parser does not produce.
+var typeStringTests = []struct {
+	t    tipe.Type
+	want string
+}{
+	{tipe.UntypedFloat, "untyped float"},
+	{tipe.Byte, "byte"},
+	{tipe.Len, "builtin len"},
+	{&tipe.Tuple{Elems: []tipe.Type{tipe.Int, tipe.Bool}}, "(int, bool)"},
+	{&tipe.Named{Name: "Celsius", Type: tipe.Float64}, "Celsius"},
+	{&tipe.Package{Path: "io"}, "package io"},
+	{
+		&tipe.Func{
+			Params:   &tipe.Tuple{Elems: []tipe.Type{tipe.String, &tipe.Slice{Elem: tipe.Int}}},
+			Variadic: true,
+		},
+		"func(string, ...int)",
+	},
+}
+
+func TestTypeString(t *testing.T) {
+	for _, test := range typeStringTests {
+		if got := format.Type(test.t); got != test.want {
+			t.Errorf("Type(%#v) = %q, want %q", test.t, got, test.want)
+		}
+	}
+}
+
+func bin(op token.Token, x, y expr.Expr) expr.Expr {
+	return &expr.Binary{Op: op, Left: x, Right: y}
+}
+
+var (
+	a = &expr.Ident{Name: "a"}
+	b = &expr.Ident{Name: "b"}
+	c = &expr.Ident{Name: "c"}
+)
+
+var exprTests = []struct {
+	e    expr.Expr
+	want string
+}{
+	{bin(token.Add, a, b), "a + b"},
+	{bin(token.Add, bin(token.Add, a, b), c), "a + b + c"},
+	{bin(token.Add, a, bin(token.Add, b, c)), "a + (b + c)"},
+	{bin(token.Mul, bin(token.Add, a, b), c), "(a + b) * c"},
+	{bin(token.Add, a, bin(token.Mul, b, c)), "a + b * c"},
+	{bin(token.Sub, a, bin(token.Sub, b, c)), "a - (b - c)"},
+	{bin(token.LogicalOr, bin(token.LogicalAnd, a, b), c), "a && b || c"},
+	{bin(token.LogicalAnd, bin(token.LogicalOr, a, b), c), "(a || b) && c"},
+	{&expr.Unary{Op: token.Sub, Expr: bin(token.Add, a, b)}, "-(a + b)"},
+	{&expr.Unary{Op: token.Sub, Expr: a}, "-a"},
+	{&expr.Call{Func: &expr.Ident{Name: "f"}, Args: []expr.Expr{a, bin(token.Add, b, c)}}, "f(a, b + c)"},
+}
+
+func TestExpr(t *testing.T) {
+	for _, test := range exprTests {
+		if got := format.Expr(test.e); got != test.want {
+			t.Errorf("Expr(%s) = %q, want %q", format.Debug(test.e), got, test.want)
+		}
+	}
+}
+
+var nilExprs = []expr.Expr{
+	(*expr.Binary)(nil),
+	(*expr.Unary)(nil),
+	(*expr.Bad)(nil),
+	(*expr.Selector)(nil),
+	(*expr.Slice)(nil),
+	(*expr.Index)(nil),
+	(*expr.TypeAssert)(nil),
+	(*expr.BasicLiteral)(nil),
+	(*expr.FuncLiteral)(nil),
+	(*expr.CompLiteral)(nil),
+	(*expr.MapLiteral)(nil),
+	(*expr.ArrayLiteral)(nil),
+	(*expr.SliceLiteral)(nil),
+	(*expr.TableLiteral)(nil),
+	(*expr.Type)(nil),
+	(*expr.Ident)(nil),
+	(*expr.Call)(nil),
+	(*expr.ShellList)(nil),
+	(*expr.ShellAndOr)(nil),
+	(*expr.ShellPipeline)(nil),
+	(*expr.ShellCmd)(nil),
+	(*expr.ShellSimpleCmd)(nil),
+	(*expr.ShellRedirect)(nil),
+	(*expr.ShellAssign)(nil),
+	(*expr.Shell)(nil),
+}
+
+func TestNilExpr(t *testing.T) {
+	if got := format.Expr(nil); got != "<nil>" {
+		t.Errorf("Expr(nil) = %q, want %q", got, "<nil>")
+	}
+	for _, e := range nilExprs {
+		want := fmt.Sprintf("<nil %T>", e)
+		if got := format.Expr(e); got != want {
+			t.Errorf("Expr(%T(nil)) = %q, want %q", e, got, want)
+		}
+
+		// A zero node has nil children.
+		zero := reflect.New(reflect.TypeOf(e).Elem()).Interface().(expr.Expr)
+		func() {
+			defer func() {
+				if x := recover(); x != nil {
+					t.Errorf("Expr of zero %T panicked: %v", zero, x)
+				}
+			}()
+			format.Expr(zero)
+		}()
+	}
+}
+
+// exprGen generates random expression trees for round-trip testing.
+type exprGen struct {
+	r *rand.Rand
+}
+
+var (
+	genBinaryOps = []token.Token{
+		token.Add, token.Sub, token.Mul, token.Div, token.Rem,
+		token.Pow, token.Ref, token.RefPow, token.TwoLess, token.TwoGreater,
+		token.LogicalAnd, token.LogicalOr,
+		token.Equal, token.NotEqual, token.Less, token.LessEqual, token.Greater, token.GreaterEqual,
+	}
+	genUnaryOps = []token.Token{
+		token.Add, token.Sub, token.Not, token.Mul, token.Ref, token.ChanOp,
+	}
+	genNames   = []string{"a", "b", "x", "y", "longerName"}
+	genStrings = []string{"", "s", `"quoted"`, `back\slash`, "new\nline", "tab\t", "é", "\x00\x7f", "日本"}
+)
+
+func (g *exprGen) ident() *expr.Ident {
+	return &expr.Ident{Name: genNames[g.r.Intn(len(genNames))]}
+}
+
+func (g *exprGen) leaf() expr.Expr {
+	switch g.r.Intn(6) {
+	case 0:
+		return &expr.BasicLiteral{Value: big.NewInt(g.r.Int63n(1000))}
+	case 1:
+		return &expr.BasicLiteral{Value: genStrings[g.r.Intn(len(genStrings))]}
+	case 2:
+		return &expr.BasicLiteral{Value: big.NewFloat(float64(g.r.Intn(100)) / 4)}
+	case 3:
+		return &expr.BasicLiteral{Value: rune("a'\\\n世"[g.r.Intn(5)])}
+	default:
+		return g.ident()
+	}
+}
+
+func (g *exprGen) expr(depth int) expr.Expr {
+	if depth <= 0 {
+		return g.leaf()
+	}
+	switch g.r.Intn(8) {
+	case 0:
+		return g.leaf()
+	case 1, 2:
+		return &expr.Binary{
+			Op:    genBinaryOps[g.r.Intn(len(genBinaryOps))],
+			Left:  g.expr(depth - 1),
+			Right: g.expr(depth - 1),
+		}
+	case 3:
+		return &expr.Unary{
+			Op:   genUnaryOps[g.r.Intn(len(genUnaryOps))],
+			Expr: g.expr(depth - 1),
+		}
+	case 4:
+		call := &expr.Call{Func: g.expr(depth - 1)}
+		for i := g.r.Intn(3); i > 0; i-- {
+			call.Args = append(call.Args, g.expr(depth-1))
+		}
+		return call
+	case 5:
+		return &expr.Selector{Left: g.expr(depth - 1), Right: g.ident()}
+	case 6:
+		index := &expr.Index{Left: g.expr(depth - 1)}
+		if g.r.Intn(2) == 0 {
+			index.Indicies = []expr.Expr{g.expr(depth - 1)}
+		} else {
+			slice := &expr.Slice{}
+			if g.r.Intn(2) == 0 {
+				slice.Low = g.expr(depth - 1)
+			}
+			if g.r.Intn(2) == 0 {
+				slice.High = g.expr(depth - 1)
+			}
+			index.Indicies = []expr.Expr{slice}
+		}
+		return index
+	default:
+		return &expr.TypeAssert{
+			Left: g.expr(depth - 1),
+			Type: &tipe.Unresolved{Name: "T"},
+		}
+	}
+}
+
+// stripParens removes the parenthesis nodes the parser records, so a
+// reparsed tree can be compared with the tree it was printed from.
+func stripParens(e expr.Expr) expr.Expr {
+	switch e := e.(type) {
+	case *expr.Unary:
+		if e.Op == token.LeftParen {
+			return stripParens(e.Expr)
+		}
+		e.Expr = stripParens(e.Expr)
+	case *expr.Binary:
+		e.Left = stripParens(e.Left)
+		e.Right = stripParens(e.Right)
+	case *expr.Call:
+		e.Func = stripParens(e.Func)
+		for i, arg := range e.Args {
+			e.Args[i] = stripParens(arg)
+		}
+	case *expr.Selector:
+		e.Left = stripParens(e.Left)
+	case *expr.Index:
+		e.Left = stripParens(e.Left)
+		for i, index := range e.Indicies {
+			e.Indicies[i] = stripParens(index)
+		}
+	case *expr.Slice:
+		e.Low = stripParens(e.Low)
+		e.High = stripParens(e.High)
+	case *expr.TypeAssert:
+		e.Left = stripParens(e.Left)
+	}
+	return e
+}
+
+func TestRoundTripRandom(t *testing.T) {
+	g := &exprGen{r: rand.New(rand.NewSource(1))}
+	for i := 0; i < 2000; i++ {
+		e := g.expr(4)
+		src := format.Expr(e)
+		s, err := parser.ParseStmt([]byte("(" + src + ")"))
+		if err != nil {
+			t.Errorf("ParseStmt(%q): %v", src, err)
+			continue
+		}
+		got := stripParens(s.(*stmt.Simple).Expr)
+		if !parser.EqualExpr(got, e) {
+			t.Errorf("%q does not round trip:\n%s", src, format.Diff(e, got))
+		}
+	}
+}
@@ -6,19 +6,20 @@ package format
 
 import (
 	"bytes"
-	"fmt"
 
+	"neugram.io/ng/syntax/expr"
 	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
 )
 
 func (p *printer) stmt(s stmt.Stmt) {
+	if p.file && p.fileStmt(s) {
+		return
+	}
 	switch s := s.(type) {
 	case *stmt.Import:
-		if s.Name != "" {
-			fmt.Fprintf(p.buf, "import %s %q", s.Name, s.Path)
-		} else {
-			fmt.Fprintf(p.buf, "import %q", s.Path)
-		}
+		p.buf.WriteString("import ")
+		p.importSpec(s)
 	case *stmt.ImportSet:
 		p.buf.WriteString("import (")
 		if len(s.Imports) == 0 {
@@ -28,20 +29,50 @@ func (p *printer) stmt(s stmt.Stmt) {
 		p.indent++
 		for _, imp := range s.Imports {
 			p.newline()
-			if imp.Name != "" {
-				fmt.Fprintf(p.buf, "%s %q", imp.Name, imp.Path)
-			} else {
-				fmt.Fprintf(p.buf, "%q", imp.Path)
-			}
+			p.importSpec(imp)
 		}
 		p.indent--
 		p.newline()
 		p.buf.WriteString(")")
 	case *stmt.TypeDecl:
 		p.buf.WriteString("type ")
-		p.buf.WriteString(s.Name)
-		p.buf.WriteString(" ")
-		p.tipe(s.Type.Type)
+		p.typeSpec(s)
+	case *stmt.TypeDeclSet:
+		p.buf.WriteString("type (")
+		p.indent++
+		for _, t := range s.TypeDecls {
+			p.newline()
+			p.typeSpec(t)
+		}
+		p.indent--
+		p.newline()
+		p.buf.WriteString(")")
+	case *stmt.Const:
+		p.buf.WriteString("const ")
+		p.valueSpec(s.NameList, s.Type, s.Values)
+	case *stmt.ConstSet:
+		p.buf.WriteString("const (")
+		p.indent++
+		for _, c := range s.Consts {
+			p.newline()
+			p.valueSpec(c.NameList, c.Type, c.Values)
+		}
+		p.indent--
+		p.newline()
+		p.buf.WriteString(")")
+	case *stmt.Var:
+		p.buf.WriteString("var ")
+		p.valueSpec(s.NameList, s.Type, s.Values)
+	case *stmt.VarSet:
+		p.buf.WriteString("var (")
+		p.indent++
+		for _, v := range s.Vars {
+			p.newline()
+			p.valueSpec(v.NameList, v.Type, v.Values)
+		}
+		p.indent--
+		p.newline()
+		p.buf.WriteString(")")
 	case *stmt.MethodikDecl:
 		p.buf.WriteString("methodik ")
 		p.buf.WriteString(s.Name)
@@ -93,7 +124,7 @@ func (p *printer) stmt(s stmt.Stmt) {
 		}
 	case *stmt.Send:
 		p.expr(s.Chan)
-		p.buf.WriteString("<-")
+		p.buf.WriteString(" <- ")
 		p.expr(s.Value)
 	case *stmt.Switch:
 		p.buf.WriteString("switch ")
@@ -180,18 +211,131 @@ func (p *printer) stmt(s stmt.Stmt) {
 			p.stmt(c.Body)
 		}
 		p.buf.WriteString("}")
+	case *stmt.If:
+		p.buf.WriteString("if ")
+		if s.Init != nil {
+			p.stmt(s.Init)
+			p.buf.WriteString("; ")
+		}
+		p.expr(s.Cond)
+		p.buf.WriteString(" ")
+		p.stmt(s.Body)
+		if s.Else != nil {
+			p.buf.WriteString(" else ")
+			p.stmt(s.Else)
+		}
 	case *stmt.Block:
 		p.buf.WriteString("{")
 		for _, s := range s.Stmts {
 			p.stmt(s)
 		}
 		p.buf.WriteString("}")
+	case *stmt.For:
+		p.buf.WriteString("for ")
+		if s.Init != nil || s.Post != nil {
+			if s.Init != nil {
+				p.stmt(s.Init)
+			}
+			p.buf.WriteString("; ")
+			if s.Cond != nil {
+				p.expr(s.Cond)
+			}
+			p.buf.WriteString("; ")
+			if s.Post != nil {
+				p.stmt(s.Post)
+			}
+			p.buf.WriteString(" ")
+		} else if s.Cond != nil {
+			p.expr(s.Cond)
+			p.buf.WriteString(" ")
+		}
+		p.stmt(s.Body)
+	case *stmt.Range:
+		p.buf.WriteString("for ")
+		if s.Key != nil {
+			p.expr(s.Key)
+			if s.Val != nil {
+				p.buf.WriteString(", ")
+				p.expr(s.Val)
+			}
+			if s.Decl {
+				p.buf.WriteString(" := ")
+			} else {
+				p.buf.WriteString(" = ")
+			}
+		}
+		p.buf.WriteString("range ")
+		p.expr(s.Expr)
+		p.buf.WriteString(" ")
+		p.stmt(s.Body)
+	case *stmt.Go:
+		p.buf.WriteString("go ")
+		p.expr(s.Call)
+	case *stmt.Defer:
+		p.buf.WriteString("defer ")
+		p.expr(s.Expr)
+	case *stmt.Branch:
+		p.buf.WriteString(s.Type.String())
+		if s.Label != "" {
+			p.buf.WriteString(" ")
+			p.buf.WriteString(s.Label)
+		}
+	case *stmt.Labeled:
+		p.buf.WriteString(s.Label)
+		p.buf.WriteString(":")
+		p.newline()
+		p.stmt(s.Stmt)
 	default:
 		p.printf("format: unknown stmt %T: ", s)
 		WriteDebug(p.buf, s)
 	}
 }
 
+// importSpec prints an import without the import keyword.
+func (p *printer) importSpec(s *stmt.Import) {
+	if s.Name != "" {
+		p.buf.WriteString(s.Name)
+		p.buf.WriteString(" ")
+	}
+	p.printf("%q", s.Path)
+}
+
+// typeSpec prints a type declaration without the type keyword.
+func (p *printer) typeSpec(s *stmt.TypeDecl) {
+	p.buf.WriteString(s.Name)
+	if s.Alias != nil {
+		p.buf.WriteString(" = ")
+		p.tipe(s.Alias.Type)
+		return
+	}
+	p.buf.WriteString(" ")
+	p.tipe(s.Type.Type)
+}
+
+// valueSpec prints the names, type and values of a const or var
+// declaration without the keyword.
+func (p *printer) valueSpec(names []string, t tipe.Type, values []expr.Expr) {
+	for i, name := range names {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		p.buf.WriteString(name)
+	}
+	if t != nil {
+		p.buf.WriteString(" ")
+		p.tipe(t)
+	}
+	if len(values) > 0 {
+		p.buf.WriteString(" = ")
+	}
+	for i, e := range values {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		p.expr(e)
+	}
+}
+
 func WriteStmt(buf *bytes.Buffer, s stmt.Stmt) {
 	p := &printer{
 		buf: buf,
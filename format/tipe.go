@@ -7,7 +7,9 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"sort"
+	"strconv"
 
 	"neugram.io/ng/syntax/tipe"
 )
@@ -17,6 +19,10 @@ func (p *printer) tipe(t tipe.Type) {
 		p.buf.WriteString("<nil>")
 		return
 	}
+	if v := reflect.ValueOf(t); v.Kind() == reflect.Ptr && v.IsNil() {
+		p.printf("<nil %T>", t)
+		return
+	}
 	switch t := t.(type) {
 	case tipe.Basic:
 		p.buf.WriteString(string(t))
@@ -29,21 +35,27 @@ func (p *printer) tipe(t tipe.Type) {
 		p.indent++
 		maxlen := 0
 		for _, sf := range t.Fields {
-			if len(sf.Name) > maxlen {
+			if !sf.Embedded && len(sf.Name) > maxlen {
 				maxlen = len(sf.Name)
 			}
 		}
 		for _, sf := range t.Fields {
 			p.newline()
-			name := sf.Name
-			if name == "" {
-				name = "*ERROR*No*Name*"
+			if !sf.Embedded {
+				name := sf.Name
+				if name == "" {
+					name = "*ERROR*No*Name*"
+				}
+				p.buf.WriteString(name)
+				for i := len(name); i <= maxlen; i++ {
+					p.buf.WriteByte(' ')
+				}
 			}
-			p.buf.WriteString(name)
-			for i := len(name); i <= maxlen; i++ {
+			p.tipe(sf.Type)
+			if sf.Tag != "" {
 				p.buf.WriteByte(' ')
+				p.buf.WriteString(quoteTag(string(sf.Tag)))
 			}
-			p.tipe(sf.Type)
 		}
 		p.indent--
 		p.newline()
@@ -106,6 +118,13 @@ func (p *printer) tipe(t tipe.Type) {
 			p.buf.WriteString("<-")
 		}
 		p.buf.WriteByte(' ')
+		if elem, ok := t.Elem.(*tipe.Chan); ok && t.Direction == tipe.ChanBoth && elem.Direction == tipe.ChanRecv {
+			// chan <-chan T would be read as chan<- chan T
+			p.buf.WriteByte('(')
+			p.tipe(t.Elem)
+			p.buf.WriteByte(')')
+			break
+		}
 		p.tipe(t.Elem)
 	case *tipe.Func:
 		p.buf.WriteString("func")
@@ -124,6 +143,11 @@ func (p *printer) tipe(t tipe.Type) {
 	case *tipe.Ellipsis:
 		p.buf.WriteString("...")
 		p.tipe(t.Elem)
+	case tipe.Builtin:
+		p.buf.WriteString(string(t))
+	case *tipe.Package:
+		p.buf.WriteString("package ")
+		p.buf.WriteString(t.Path)
 	default:
 		p.buf.WriteString("format: unknown type: ")
 		WriteDebug(p.buf, t)
@@ -137,6 +161,12 @@ func (p *printer) tipeFuncSig(t *tipe.Func) {
 			if i > 0 {
 				p.buf.WriteString(", ")
 			}
+			// By the time a variadic function is type checked,
+			// its final ...T parameter may have become []T.
+			if slice, isSlice := elem.(*tipe.Slice); isSlice && t.Variadic && i == len(t.Params.Elems)-1 {
+				p.buf.WriteString("...")
+				elem = slice.Elem
+			}
 			p.tipe(elem)
 		}
 	}
@@ -158,6 +188,14 @@ func (p *printer) tipeFuncSig(t *tipe.Func) {
 	}
 }
 
+// quoteTag quotes a struct tag, preferring a raw string.
+func quoteTag(tag string) string {
+	if strconv.CanBackquote(tag) {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
+
 func WriteType(buf *bytes.Buffer, t tipe.Type) {
 	p := &printer{
 		buf: buf,
@@ -41,6 +41,7 @@ import (
 //	Len() (int, error)
 //
 // Maybe TODO:
+//
 //	Slice(Rectangle) Frame
 //	Read(dst interface{}, col, off int) error // dst is []T.
 type Frame interface {
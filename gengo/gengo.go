@@ -54,12 +54,22 @@ package %s
 `, outGoPkgName)
 
 	usesShell := false
+	usesTable := false
+	var unsupported error
 	builtins := make(map[string]bool)
 	importPaths := []string{}
 	preFn := func(c *syntax.Cursor) bool {
 		switch node := c.Node.(type) {
 		case *stmt.Import:
 			importPaths = append(importPaths, node.Path)
+		case *expr.Index:
+			if _, isTable := p.c.Type(node.Left).(*tipe.Table); isTable && unsupported == nil {
+				for _, index := range node.Indicies {
+					if _, isSlice := index.(*expr.Slice); isSlice {
+						unsupported = fmt.Errorf("gengo: %s: table index with a range is not supported", format.Expr(node))
+					}
+				}
+			}
 		case *expr.Ident:
 			// TODO: look up the typecheck.Obj for builtins
 			switch node.Name {
@@ -73,9 +83,22 @@ package %s
 		case *expr.ShellList:
 			usesShell = true
 		}
+		if e, isExpr := c.Node.(expr.Expr); isExpr {
+			if _, isTable := p.c.Type(e).(*tipe.Table); isTable {
+				usesTable = true
+			}
+		}
 		return true
 	}
 	syntax.Walk(p.pkg.Syntax, preFn, nil)
+	if unsupported != nil {
+		return nil, unsupported
+	}
+	for _, obj := range p.pkg.Globals {
+		if _, isTable := obj.Type.(*tipe.Table); isTable {
+			usesTable = true
+		}
+	}
 
 	// Lift imports to the top-level.
 	importSet := make(map[string]bool)
@@ -120,6 +143,10 @@ package %s
 		p.newline()
 		p.printf(`"neugram.io/ng/syntax/token"`)
 	}
+	if usesTable {
+		p.newline()
+		p.printf(`gengort "neugram.io/ng/gengo/rt"`)
+	}
 
 	// Stable output is ensured by gofmt's sorting later.
 	for name, imp := range namedImports {
@@ -143,6 +170,11 @@ package %s
 	for _, obj := range p.pkg.Globals {
 		switch obj.Kind {
 		case typecheck.ObjType:
+			if a, isAlias := obj.Type.(*tipe.Alias); isAlias {
+				p.printf("type %s = ", obj.Name)
+				p.tipe(a.Type)
+				break
+			}
 			n := obj.Type.(*tipe.Named)
 			if len(n.Methods) > 0 {
 				continue // methodiks are hoisted elsewhere
@@ -431,18 +463,27 @@ func (p *printer) newline() {
 func (p *printer) expr(e expr.Expr) {
 	switch e := e.(type) {
 	case *expr.BasicLiteral:
-		if str, isStr := e.Value.(string); isStr {
-			p.printf("%q", str)
-		} else {
-			p.printf("%v", e.Value)
-		}
+		// Neugram literals are written as in Go. In particular,
+		// a float literal keeps its decimal point and a rune
+		// literal its quotes, so the Go constant has their kind.
+		p.print(format.Expr(e))
 	case *expr.Binary:
 		p.expr(e.Left)
 		p.printf(" %s ", e.Op)
 		p.expr(e.Right)
 	case *expr.Call:
 		if e.ElideError {
-			fnName := p.elider(p.c.Type(e))
+			// Inside a larger expression the call has the
+			// type of its elided result, so the elider is
+			// built from the function's full results.
+			t := p.c.Type(e)
+			if fn, ok := p.c.Type(e.Func).(*tipe.Func); ok {
+				t = fn.Results
+				if len(fn.Results.Elems) == 1 {
+					t = fn.Results.Elems[0]
+				}
+			}
+			fnName := p.elider(t)
 			p.printf("%s(", fnName)
 		}
 		p.expr(e.Func)
@@ -500,6 +541,10 @@ func (p *printer) expr(e expr.Expr) {
 			p.print(e.Name)
 		}
 	case *expr.Index:
+		if t, isTable := p.c.Type(e.Left).(*tipe.Table); isTable {
+			p.tableIndex(e, t)
+			return
+		}
 		p.expr(e.Left)
 		p.print("[")
 		for i, index := range e.Indicies {
@@ -599,6 +644,43 @@ func (p *printer) expr(e expr.Expr) {
 			}
 		}
 		p.print("}")
+	case *expr.TableLiteral:
+		p.print("gengort.NewTable(")
+		if len(e.ColNames) == 0 {
+			p.print("nil")
+		} else {
+			p.print("[]string{")
+			for i, name := range e.ColNames {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.expr(name)
+			}
+			p.print("}")
+		}
+		p.print(", [][]interface{}{")
+		p.indent++
+		for _, row := range e.Rows {
+			p.newline()
+			p.print("{")
+			for i, elem := range row {
+				if i > 0 {
+					p.print(", ")
+				}
+				// Convert so untyped constants get the
+				// table's element type.
+				p.tipe(e.Type.Type)
+				p.print("(")
+				p.expr(elem)
+				p.print(")")
+			}
+			p.print("},")
+		}
+		p.indent--
+		if len(e.Rows) > 0 {
+			p.newline()
+		}
+		p.print("})")
 	case *expr.Type:
 		p.tipe(e.Type)
 	case *expr.TypeAssert:
@@ -683,16 +765,22 @@ func (p *printer) stmt(s stmt.Stmt) {
 		p.print("}")
 	case *stmt.For:
 		p.print("for ")
-		if s.Init != nil {
-			p.stmt(s.Init)
+		if s.Init != nil || s.Post != nil {
+			if s.Init != nil {
+				p.stmt(s.Init)
+			}
 			p.print("; ")
-		}
-		if s.Cond != nil {
-			p.expr(s.Cond)
+			if s.Cond != nil {
+				p.expr(s.Cond)
+			}
 			p.print("; ")
-		}
-		if s.Post != nil {
-			p.stmt(s.Post)
+			if s.Post != nil {
+				p.stmt(s.Post)
+				p.print(" ")
+			}
+		} else if s.Cond != nil {
+			p.expr(s.Cond)
+			p.print(" ")
 		}
 		p.stmt(s.Body)
 	case *stmt.Go:
@@ -759,15 +847,14 @@ func (p *printer) stmt(s stmt.Stmt) {
 		p.print(" <- ")
 		p.expr(s.Value)
 	case *stmt.TypeDecl:
-		p.printf("type %s ", s.Name)
-		p.tipe(s.Type.Type)
+		p.print("type ")
+		p.typeSpec(s)
 	case *stmt.TypeDeclSet:
 		p.print("type (")
 		p.indent++
 		for _, t := range s.TypeDecls {
 			p.newline()
-			p.printf("%s ", t.Name)
-			p.tipe(t.Type.Type)
+			p.typeSpec(t)
 		}
 		p.indent--
 		p.newline()
@@ -896,6 +983,16 @@ func (p *printer) isPure(e expr.Expr) bool {
 	}
 }
 
+func (p *printer) typeSpec(s *stmt.TypeDecl) {
+	if s.Alias != nil {
+		p.printf("%s = ", s.Name)
+		p.tipe(s.Alias.Type)
+		return
+	}
+	p.printf("%s ", s.Name)
+	p.tipe(s.Type.Type)
+}
+
 func (p *printer) stmtConst(s *stmt.Const) {
 	for i, n := range s.NameList {
 		if i != 0 {
@@ -943,7 +1040,8 @@ func (p *printer) stmtVar(s *stmt.Var) {
 }
 
 // TODO there is a huge amount of overlap here with the format package.
-//      deduplicate somehow.
+//
+//	deduplicate somehow.
 func (p *printer) tipe(t tipe.Type) {
 	switch t := t.(type) {
 	case tipe.Basic:
@@ -1061,6 +1159,8 @@ func (p *printer) tipe(t tipe.Type) {
 		p.tipeFuncSig(t)
 	case *tipe.Alias:
 		p.print(t.Name)
+	case *tipe.Table:
+		p.print("*gengort.Table")
 	case *tipe.Tuple:
 		p.print("(")
 		for i, elt := range t.Elems {
@@ -1078,6 +1178,20 @@ func (p *printer) tipe(t tipe.Type) {
 	}
 }
 
+// tableIndex prints an index of a table as a call to the runtime.
+// Only a single value, t[col, row], is supported; GenGo reports an
+// index with a range as an error before printing.
+func (p *printer) tableIndex(e *expr.Index, t *tipe.Table) {
+	p.expr(e.Left)
+	p.print(".Get(")
+	p.expr(e.Indicies[0])
+	p.print(", ")
+	p.expr(e.Indicies[1])
+	p.print(").(")
+	p.tipe(t.Type)
+	p.print(")")
+}
+
 func (p *printer) tipeFuncSig(t *tipe.Func) {
 	p.print("(")
 	if t.Params != nil {
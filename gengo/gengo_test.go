@@ -24,10 +24,16 @@ func TestGeneratedPrograms(t *testing.T) {
 	if len(files) == 0 {
 		t.Fatal("cannot find testdata")
 	}
+	// Programs that only gengo supports, such as those using tables.
+	gengoFiles, err := filepath.Glob("testdata/*.ng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, gengoFiles...)
 
 	for _, file := range files {
 		file := file
-		test := file[len("../eval/testdata/") : len(file)-3]
+		test := strings.TrimSuffix(filepath.Base(file), ".ng")
 		exclude := []string{ // TODO remove this list
 			"import3",
 			"error6",
@@ -38,6 +44,7 @@ func TestGeneratedPrograms(t *testing.T) {
 			"import8",
 			"method2",
 			"op1",
+			"op2",
 		}
 		donotrun := false
 		for _, ex := range exclude {
@@ -108,3 +115,21 @@ func TestGeneratedPrograms(t *testing.T) {
 		})
 	}
 }
+
+func TestUnsupportedTableRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gengo-table-range")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "range.ng")
+	src := "t := [|]int{{|\"a\", \"b\"|}, {1, 2}}\nu := t[\"a\", 0:1]\nprint(u)\n"
+	if err := ioutil.WriteFile(file, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	_, err = gengo.GenGo(file, "main")
+	want := `gengo: t["a", 0:1]: table index with a range is not supported`
+	if err == nil || err.Error() != want {
+		t.Errorf("GenGo err=%v, want %q", err, want)
+	}
+}
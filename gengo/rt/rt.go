@@ -0,0 +1,74 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rt is the runtime support imported by Go programs
+// generated by gengo.
+package rt
+
+import "fmt"
+
+// Table is the Go representation of a Neugram table, stored as
+// columns of values.
+type Table struct {
+	ColNames []string
+	Cols     [][]interface{}
+}
+
+// NewTable builds a table from a table literal. If colNames is nil
+// the columns are unnamed and the width is taken from the rows.
+func NewTable(colNames []string, rows [][]interface{}) *Table {
+	w := len(colNames)
+	if colNames == nil && len(rows) > 0 {
+		w = len(rows[0])
+		colNames = make([]string, w)
+	}
+	t := &Table{
+		ColNames: colNames,
+		Cols:     make([][]interface{}, w),
+	}
+	for i, row := range rows {
+		if len(row) != w {
+			panic(fmt.Sprintf("table row %d has %d values, want %d", i, len(row), w))
+		}
+		for x, v := range row {
+			t.Cols[x] = append(t.Cols[x], v)
+		}
+	}
+	return t
+}
+
+// Len returns the number of rows in t.
+func (t *Table) Len() int {
+	if len(t.Cols) == 0 {
+		return 0
+	}
+	return len(t.Cols[0])
+}
+
+// Get returns the value at t[col, row]. The column is either an
+// int index or a string column name.
+func (t *Table) Get(col interface{}, row int) interface{} {
+	x := -1
+	switch col := col.(type) {
+	case int:
+		x = col
+	case string:
+		for i, name := range t.ColNames {
+			if name == col {
+				x = i
+				break
+			}
+		}
+		if x < 0 {
+			panic(fmt.Sprintf("table has no column %q", col))
+		}
+	}
+	if x < 0 || x >= len(t.Cols) {
+		panic(fmt.Sprintf("table column index %d out of range", x))
+	}
+	if row < 0 || row >= t.Len() {
+		panic(fmt.Sprintf("table row index %d out of range", row))
+	}
+	return t.Cols[x][row]
+}
@@ -25,7 +25,7 @@
 // These questions were answered by poking at a running jupyter instance.
 // Some of the answers are probably wrong.
 //
-// Usage
+// # Usage
 //
 // On linux, install the "ng" binary on your PATH, and add a file
 // named $HOME/.local/share/jupyter/kernels/neugram/kernel.json containing:
@@ -30,6 +30,7 @@ type connectionInfo struct {
 // Distinguished by "<IDS|MSG>".
 // It is transmitted as a multipart zeromq router message.
 // Defined in the "General Message Format" section of:
+//
 //	http://jupyter-client.readthedocs.io/en/latest/messaging.html
 type message struct {
 	IDs          [][]byte
@@ -41,6 +42,7 @@ type message struct {
 }
 
 // Defined in the "General Message Format" section of:
+//
 //	http://jupyter-client.readthedocs.io/en/latest/messaging.html
 type header struct {
 	ID       string `json:"msg_id"`
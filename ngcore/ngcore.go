@@ -30,6 +30,9 @@ import (
 	"neugram.io/ng/eval/shell"
 	"neugram.io/ng/format"
 	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
 )
 
 type Neugram struct {
@@ -247,6 +250,32 @@ func (s *Session) Exec(src []byte) ([]reflect.Value, error) {
 	return out, nil
 }
 
+// TypeOf returns the type of the Neugram expression src, type checked
+// in the scope of the session. The expression is not evaluated.
+func (s *Session) TypeOf(src []byte) (tipe.Type, error) {
+	st, err := parser.ParseStmt(src)
+	if err != nil {
+		return nil, Error{Phase: "parser", List: []error{err}}
+	}
+	simple, isSimple := st.(*stmt.Simple)
+	if !isSimple {
+		err := fmt.Errorf("not an expression: %s", format.Stmt(st))
+		return nil, Error{Phase: "parser", List: []error{err}}
+	}
+	e := simple.Expr
+	if fn, isFunc := e.(*expr.FuncLiteral); isFunc && fn.Name != "" {
+		// Checking a named function would declare it.
+		anon := *fn
+		anon.Name = ""
+		e = &anon
+	}
+	s.Program.Types.Add(&stmt.Simple{Position: simple.Position, Expr: e})
+	if errs := s.Program.Types.Errs(); len(errs) > 0 {
+		return nil, Error{Phase: "typecheck", List: errs}
+	}
+	return s.Program.Types.Type(e), nil
+}
+
 // Display displays the results of an execution to w.
 func (s *Session) Display(w io.Writer, vals []reflect.Value) {
 	if len(vals) > 1 {
@@ -367,6 +396,13 @@ func (s *Session) Run(ctx context.Context, startInShell bool, sigint chan os.Sig
 		case <-sigint:
 		default:
 		}
+		if state == parser.StateStmt {
+			if isCmd, quit := s.command(data); quit {
+				return nil
+			} else if isCmd {
+				continue
+			}
+		}
 		res, err := s.Exec([]byte(data))
 		if err != nil {
 			fmt.Fprintf(s.Stderr, "%v\n", err)
@@ -377,6 +413,29 @@ func (s *Session) Run(ctx context.Context, startInShell bool, sigint chan os.Sig
 	return nil
 }
 
+// command runs line if it is a command of the interactive session:
+//
+//	\quit      ends the session, like Ctrl-D
+//	:type x    prints the type of the expression x, without evaluating it
+//
+// It reports whether line is a command, and whether to end the session.
+func (s *Session) command(line string) (isCmd, quit bool) {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == `\quit`:
+		return true, true
+	case line == ":type" || strings.HasPrefix(line, ":type "):
+		t, err := s.TypeOf([]byte(strings.TrimPrefix(line, ":type")))
+		if err != nil {
+			fmt.Fprintf(s.Stderr, "%v\n", err)
+		} else {
+			fmt.Fprintln(s.Stdout, format.Type(t))
+		}
+		return true, false
+	}
+	return false, false
+}
+
 func (s *Session) Close() {
 	s.neugram.mu.Lock()
 	delete(s.neugram.sessions, s.name)
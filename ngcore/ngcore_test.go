@@ -0,0 +1,114 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ngcore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/format"
+)
+
+func newTestSession(t *testing.T, ng *Neugram) *Session {
+	s, err := ng.NewSession(context.Background(), "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{"x := 1", "n := 0", "inc := func() int { n++; return n }"} {
+		if _, err := s.Exec([]byte(src)); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	return s
+}
+
+func TestTypeOf(t *testing.T) {
+	ng := New()
+	defer ng.Close()
+	s := newTestSession(t, ng)
+	defer s.Close()
+
+	tests := []struct {
+		src, want string
+	}{
+		{"x + 1", "int"},
+		{"inc()", "int"},
+		{"1.5", "untyped float"},
+		{"func f(s string) int { return len(s) }", "func(string) int"},
+	}
+	for _, test := range tests {
+		typ, err := s.TypeOf([]byte(test.src))
+		if err != nil {
+			t.Errorf("TypeOf(%q): %v", test.src, err)
+			continue
+		}
+		if got := format.Type(typ); got != test.want {
+			t.Errorf("TypeOf(%q) = %s, want %s", test.src, got, test.want)
+		}
+	}
+
+	// Neither is the expression evaluated, nor a function declared.
+	vals, err := s.Exec([]byte("n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vals) != 1 || vals[0].Interface() != 0 {
+		t.Errorf("after TypeOf(inc()), n = %v, want 0", vals)
+	}
+	if obj := s.Program.Types.Lookup("f"); obj != nil {
+		t.Errorf("TypeOf declared f")
+	}
+
+	for _, src := range []string{"y + 1", "z := 2", "x +"} {
+		if typ, err := s.TypeOf([]byte(src)); err == nil {
+			t.Errorf("TypeOf(%q) = %s, want error", src, format.Type(typ))
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	ng := New()
+	defer ng.Close()
+	s := newTestSession(t, ng)
+	defer s.Close()
+
+	out, err := ioutil.TempFile("", "ngcoretest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	s.Stdout, s.Stderr = out, out
+
+	tests := []struct {
+		line        string
+		isCmd, quit bool
+		out         string
+	}{
+		{line: `\quit`, isCmd: true, quit: true},
+		{line: "  :type x == 1", isCmd: true, out: "bool\n"},
+		{line: ":type y", isCmd: true, out: "undefined: y"},
+		{line: ":types := 1"},
+		{line: "x"},
+	}
+	for _, test := range tests {
+		out.Truncate(0)
+		out.Seek(0, 0)
+		isCmd, quit := s.command(test.line)
+		if isCmd != test.isCmd || quit != test.quit {
+			t.Errorf("command(%q) = %v, %v, want %v, %v", test.line, isCmd, quit, test.isCmd, test.quit)
+		}
+		b, err := ioutil.ReadFile(out.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); !strings.Contains(got, test.out) || got == "" && test.out != "" {
+			t.Errorf("command(%q) printed %q, want %q", test.line, got, test.out)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ngfmt formats Neugram source code.
+//
+// Source is parsed and its syntax tree printed by format.WriteFile, so
+// formatting does not change the meaning of a program. The output is
+// canonical: formatting it again leaves it unchanged.
+//
+// This is not part of package format, which the parser uses to print
+// its errors.
+package ngfmt // import "neugram.io/ng/ngfmt"
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"neugram.io/ng/format"
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/src"
+)
+
+// Source formats the Neugram source src.
+//
+// A #! line at the start of src is kept as it is. The rest is laid
+// out as described by format.WriteFile.
+func Source(src []byte) ([]byte, error) {
+	return File("", src)
+}
+
+// File is Source for the contents of the named file. The name is used
+// in error messages.
+func File(filename string, src []byte) ([]byte, error) {
+	f, err := parse(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if line := firstLine(src); len(line) > 2 && line[0] == '#' && line[1] == '!' {
+		buf.Write(bytes.TrimRight(line, " \t\r"))
+		buf.WriteByte('\n')
+		if len(f.BlankLines) > 0 && f.BlankLines[0] == 2 {
+			buf.WriteByte('\n')
+		}
+	}
+	format.WriteFile(buf, f)
+
+	if err := check(filename, f, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parse(filename string, src []byte) (*syntax.File, error) {
+	p := parser.New(filename)
+	defer p.Close()
+	return p.Parse(src)
+}
+
+func firstLine(src []byte) []byte {
+	if i := bytes.IndexByte(src, '\n'); i >= 0 {
+		return src[:i]
+	}
+	return src
+}
+
+// check reports an error if the formatted source out is not the same
+// program as f. It guards against mistakes in the printer, which would
+// otherwise silently change the files it formats.
+func check(filename string, f *syntax.File, out []byte) error {
+	f2, err := parse(filename, out)
+	if err != nil {
+		return fmt.Errorf("formatted source does not parse: %v", err)
+	}
+	if len(f2.Stmts) != len(f.Stmts) {
+		return fmt.Errorf("formatting changed %d statements to %d", len(f.Stmts), len(f2.Stmts))
+	}
+	for i, s := range f.Stmts {
+		if !parser.EqualStmt(s, f2.Stmts[i]) {
+			return fmt.Errorf("%s: formatting changed the statement", s.Pos())
+		}
+	}
+	if len(f2.Comments) != len(f.Comments) {
+		return fmt.Errorf("formatting changed %d comments to %d", len(f.Comments), len(f2.Comments))
+	}
+	before, before2 := nodesBefore(f), nodesBefore(f2)
+	for i, c := range f.Comments {
+		if before[i] != before2[i] {
+			return fmt.Errorf("%s: formatting moved the comment", c.Position)
+		}
+	}
+	return nil
+}
+
+// nodesBefore returns, for each comment of f, how many nodes of the
+// syntax tree start before it. The numbers are the same in another
+// parse of f if each comment is still between the same nodes.
+func nodesBefore(f *syntax.File) []int {
+	var pos []src.Pos
+	for _, s := range f.Stmts {
+		syntax.Walk(s, func(c *syntax.Cursor) bool {
+			if c.Node == nil {
+				return false
+			}
+			if p := c.Node.Pos(); p.Line != 0 {
+				pos = append(pos, p)
+			}
+			return true
+		}, nil)
+	}
+	sort.Slice(pos, func(i, j int) bool { return less(pos[i], pos[j]) })
+	n := make([]int, len(f.Comments))
+	for i, c := range f.Comments {
+		n[i] = sort.Search(len(pos), func(j int) bool { return !less(pos[j], c.Position) })
+	}
+	return n
+}
+
+func less(a, b src.Pos) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
@@ -0,0 +1,334 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ngfmt_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/ngfmt"
+)
+
+var sourceTests = []struct {
+	name string
+	src  string
+	want string
+}{
+	{
+		name: "spacing",
+		src:  "x:=1+2*3  \ny  :=  f( x,-x )\r\nz = x[1 : 2]",
+		want: "x := 1 + 2 * 3\ny := f(x, -x)\nz = x[1:2]\n",
+	},
+	{
+		name: "indentation",
+		src: `func f(x int) int {
+  if x > 0 {
+        return x
+  }
+  for i := 0; i < x; i = i + 1 { print(i) }
+  return 0
+}
+`,
+		want: `func f(x int) int {
+	if x > 0 {
+		return x
+	}
+	for i := 0; i < x; i = i + 1 {
+		print(i)
+	}
+	return 0
+}
+`,
+	},
+	{
+		name: "one-line functions",
+		src: `func f() int { return 1 }
+g := func(x int) int {   return x*2 }
+func h() {}
+`,
+		want: `func f() int { return 1 }
+
+g := func(x int) int { return x * 2 }
+
+func h() {}
+`,
+	},
+	{
+		name: "declarations",
+		src: `import "fmt"
+import "os"
+const a = 1
+const b = 2
+x := a
+type T struct { A int; Bee string }
+methodik M int {
+	func (m) String() string { return fmt.Sprint(int(m)) }
+	func (m) Zero() bool { return m == 0 }
+}
+print(x)
+print(os.Args)
+`,
+		want: `import "fmt"
+import "os"
+
+const a = 1
+const b = 2
+
+x := a
+
+type T struct {
+	A   int
+	Bee string
+}
+
+methodik M int {
+	func (m) String() string { return fmt.Sprint(int(m)) }
+
+	func (m) Zero() bool { return m == 0 }
+}
+
+print(x)
+print(os.Args)
+`,
+	},
+	{
+		name: "blank lines",
+		src: `x := 1
+
+
+y := 2
+if x < y {
+
+	print(x)
+
+
+	print(y)
+}
+`,
+		want: `x := 1
+
+y := 2
+if x < y {
+	print(x)
+
+	print(y)
+}
+`,
+	},
+	{
+		name: "comments",
+		src: `#!/usr/bin/env ng
+
+// Package doc.
+
+import (
+	"strings" // for Join
+)
+
+// f does nothing.
+func f() {
+	// nothing
+}   // end f
+
+x := 1  // one
+switch x {
+case 1:   // first
+	/* block */
+	print(x)
+default:
+	// none
+}
+// the end
+`,
+		want: `#!/usr/bin/env ng
+
+// Package doc.
+
+import (
+	"strings" // for Join
+)
+
+// f does nothing.
+func f() {
+	// nothing
+} // end f
+
+x := 1 // one
+switch x {
+case 1: // first
+	/* block */
+	print(x)
+default:
+	// none
+}
+// the end
+`,
+	},
+	{
+		name: "shell",
+		src:  "$$   echo a   b|wc $$\ny := $$ echo \"a  b\" $$\n",
+		want: "$$ echo a b | wc $$\ny := $$ echo \"a  b\" $$\n",
+	},
+	{
+		name: "literals",
+		src: "a := 0xFF+1_000_000\nb := 1e6\nc := 0b101\nd := `raw\\n`\ne := \"\\u00e9\"\n" +
+			"f := 1.50\ng := 3.14159265358979323846264338327950288\nh := 'x'\ni := 2i\n",
+		want: "a := 0xFF + 1_000_000\nb := 1e6\nc := 0b101\nd := `raw\\n`\ne := \"\\u00e9\"\n" +
+			"f := 1.50\ng := 3.14159265358979323846264338327950288\nh := 'x'\ni := 2i\n",
+	},
+	{
+		name: "inline comments",
+		src: `f(1,  /* inline */ 2)
+x := 1 /* one */ + 2
+m := map[string]int{
+	"a": 1, // first
+	// second
+	"b": 2,
+}
+`,
+		want: `f(1, /* inline */ 2)
+x := 1 /* one */ + 2
+m := map[string]int{
+	"a": 1, // first
+	// second
+	"b": 2,
+}
+`,
+	},
+	{
+		name: "shell block",
+		src:  "$$\necho a\necho  b\n$$\nx := 1\n",
+		want: "$$\necho a\necho b\n$$\nx := 1\n",
+	},
+	{
+		name: "empty",
+		src:  "\n\n",
+		want: "",
+	},
+}
+
+func TestSource(t *testing.T) {
+	for _, test := range sourceTests {
+		got, err := ngfmt.Source([]byte(test.src))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if got, err := ngfmt.Source([]byte("x := (1 +\n")); err == nil {
+		t.Errorf("Source succeeded with %q, want error", got)
+	}
+}
+
+// testdata returns the sources of the Neugram test programs.
+func testdata(t *testing.T) map[string][]byte {
+	files, err := filepath.Glob("../eval/testdata/*.ng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test programs")
+	}
+	srcs := make(map[string][]byte)
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs[filepath.Base(file)] = src
+	}
+	return srcs
+}
+
+// idempotent checks that formatting the result of Source again does
+// not change it, and returns the result.
+func idempotent(t *testing.T, name string, src []byte) []byte {
+	res, err := ngfmt.Source(src)
+	if err != nil {
+		return nil // not a valid program, not formatted
+	}
+	res2, err := ngfmt.Source(res)
+	if err != nil {
+		t.Errorf("%s: formatted source: %v\n%s", name, err, res)
+		return nil
+	}
+	if !bytes.Equal(res, res2) {
+		t.Errorf("%s: formatting again changes:\n%s\nto:\n%s", name, res, res2)
+	}
+	return res
+}
+
+func TestTestdataIdempotent(t *testing.T) {
+	formatted := 0
+	for name, src := range testdata(t) {
+		if idempotent(t, name, src) != nil {
+			formatted++
+		}
+	}
+	if formatted == 0 {
+		t.Error("no test program could be formatted")
+	}
+}
+
+// respace returns src with random white space: lines are re-indented
+// and given trailing blanks, and blank lines and comments added.
+// If layout is false only the spaces at the ends of lines are changed,
+// which does not change the formatted source.
+func respace(r *rand.Rand, src []byte, layout bool) []byte {
+	spaces := []string{"", " ", "  ", "\t", "\t\t", "    "}
+	var buf bytes.Buffer
+	for i, line := range strings.Split(string(src), "\n") {
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			buf.WriteString(line + "\n")
+			continue
+		}
+		if layout {
+			switch r.Intn(8) {
+			case 0:
+				buf.WriteString("\n")
+			case 1:
+				buf.WriteString(spaces[r.Intn(len(spaces))] + "// c\n")
+			}
+		}
+		buf.WriteString(spaces[r.Intn(len(spaces))])
+		buf.WriteString(strings.TrimLeft(line, " \t"))
+		buf.WriteString(spaces[r.Intn(len(spaces))])
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+func TestRandomIdempotent(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for name, src := range testdata(t) {
+		want, err := ngfmt.Source(src)
+		if err != nil {
+			continue
+		}
+		for i := 0; i < 5; i++ {
+			idempotent(t, name+" with new layout", respace(r, src, true))
+
+			// Raw strings, shell commands and /* */ comments can
+			// contain white space that is kept as it is.
+			if bytes.ContainsAny(src, "`$") || bytes.Contains(src, []byte("/*")) {
+				continue
+			}
+			got := idempotent(t, name+" with new spaces", respace(r, src, false))
+			if got != nil && !bytes.Equal(got, want) {
+				t.Errorf("%s: changing white space changes the formatted source to:\n%s\nwant:\n%s", name, got, want)
+			}
+		}
+	}
+}
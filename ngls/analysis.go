@@ -0,0 +1,693 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ngls
+
+import (
+	"fmt"
+	"go/constant"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"neugram.io/ng/format"
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/typecheck"
+)
+
+// An analysis is what the server knows about one version of a
+// document: its syntax tree, errors and types.
+//
+// Source positions in an analysis are 1-based lines and byte columns,
+// as in package src. The server converts them to and from the
+// positions of the protocol.
+type analysis struct {
+	lines     []string
+	file      *syntax.File
+	parseErrs []parser.Error
+	checker   *typecheck.Checker
+	pkg       *typecheck.Package // nil if the type checker failed
+	typeErrs  []error
+
+	idents    []*expr.Ident // in source order
+	selectors map[*expr.Ident]*expr.Selector
+	funcs     []*expr.FuncLiteral
+	blocks    []*stmt.Block
+}
+
+// analyze parses and type checks text, the contents of the named file.
+func analyze(filename, text string) *analysis {
+	a := &analysis{
+		lines:     strings.Split(text, "\n"),
+		selectors: make(map[*expr.Ident]*expr.Selector),
+	}
+	p := parser.New(filename)
+	f, err := p.Parse([]byte(text))
+	p.Close()
+	switch err := err.(type) {
+	case parser.Error:
+		a.parseErrs = []parser.Error{err}
+	case parser.Errors:
+		a.parseErrs = err
+	}
+	a.file = f
+
+	// The type checker is not written for the broken programs of
+	// an editor. Report its failures rather than end the session.
+	defer func() {
+		if x := recover(); x != nil {
+			a.pkg = nil
+			a.typeErrs = append(a.typeErrs, fmt.Errorf("type checker failed: %v", x))
+		}
+	}()
+	a.checker = typecheck.New(filename)
+	a.pkg, a.typeErrs = a.checker.CheckFile(f)
+
+	syntax.Walk(f, func(c *syntax.Cursor) bool {
+		switch n := c.Node.(type) {
+		case *expr.Ident:
+			if n.Position.Line > 0 {
+				a.idents = append(a.idents, n)
+			}
+		case *expr.Selector:
+			a.selectors[n.Right] = n
+		case *expr.FuncLiteral:
+			a.funcs = append(a.funcs, n)
+		case *stmt.Block:
+			a.blocks = append(a.blocks, n)
+		}
+		return true
+	}, nil)
+	return a
+}
+
+// diagnostics returns the errors in the document.
+//
+// Type errors are only reported for a document that parses, most
+// are noise that follows from the syntax errors.
+func (a *analysis) diagnostics() []diagnostic {
+	diags := []diagnostic{}
+	for _, err := range a.parseErrs {
+		diags = append(diags, diagnostic{
+			Range:    a.span(err.Line, err.Col, err.Len),
+			Severity: severityError,
+			Source:   "ng",
+			Message:  err.Msg,
+		})
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+	for _, err := range a.typeErrs {
+		d := diagnostic{Severity: severityError, Source: "ng", Message: err.Error()}
+		if err, hasPos := err.(typecheck.Error); hasPos {
+			d.Range = a.span(int(err.Pos.Line), int(err.Pos.Column), 0)
+			d.Message = err.Msg
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// hover describes the identifier at line and col, or returns nil.
+func (a *analysis) hover(line, col int) *hover {
+	id := a.identAt(line, col)
+	if id == nil {
+		return nil
+	}
+	text := a.describe(id)
+	if text == "" {
+		return nil
+	}
+	r := a.span(int(id.Position.Line), int(id.Position.Column), len(id.Name))
+	return &hover{
+		Contents: markupContent{Kind: "plaintext", Value: text},
+		Range:    &r,
+	}
+}
+
+// describe returns the declaration of the identifier id, with its
+// inferred type.
+func (a *analysis) describe(id *expr.Ident) string {
+	if sel := a.selectors[id]; sel != nil {
+		t := a.checker.Type(sel)
+		if t == nil {
+			return ""
+		}
+		name := format.Expr(sel.Left) + "." + id.Name
+		if fn, isFunc := t.(*tipe.Func); isFunc {
+			return "func " + name + signature(fn)
+		}
+		return name + " " + format.Type(t)
+	}
+	obj := a.checker.Ident(id)
+	if obj == nil {
+		if t := a.checker.Type(id); t != nil {
+			return id.Name + " " + format.Type(t)
+		}
+		return ""
+	}
+	switch obj.Kind {
+	case typecheck.ObjVar:
+		if fn, isFunc := obj.Type.(*tipe.Func); isFunc {
+			return "func " + id.Name + signature(fn)
+		}
+		return "var " + id.Name + " " + format.Type(obj.Type)
+	case typecheck.ObjConst:
+		s := "const " + id.Name + " " + format.Type(obj.Type)
+		if v, isConst := obj.Decl.(constant.Value); isConst {
+			s += " = " + v.ExactString()
+		}
+		return s
+	case typecheck.ObjType:
+		if t, isNamed := obj.Type.(*tipe.Named); isNamed {
+			return "type " + id.Name + " " + format.Type(t.Type)
+		}
+		return "type " + id.Name
+	case typecheck.ObjPkg:
+		if t, isPkg := obj.Type.(*tipe.Package); isPkg {
+			return "package " + id.Name + " (" + strconv.Quote(t.Path) + ")"
+		}
+		return "package " + id.Name
+	}
+	return ""
+}
+
+// signature returns the parameters and results of a function type.
+func signature(fn *tipe.Func) string {
+	return strings.TrimPrefix(format.Type(fn), "func")
+}
+
+// definition returns the position of the declaration of the
+// identifier at line and col.
+//
+// Only names declared in the document have a definition. Those in
+// other packages and the builtins have none.
+func (a *analysis) definition(line, col int) (textRange, bool) {
+	id := a.identAt(line, col)
+	if id == nil || a.selectors[id] != nil {
+		return textRange{}, false
+	}
+	obj := a.checker.Ident(id)
+	if obj == nil || typecheck.Universe.Objs[id.Name] == obj {
+		return textRange{}, false
+	}
+	pos, found := a.declPos(obj, id)
+	if !found {
+		return textRange{}, false
+	}
+	return a.span(int(pos.Line), int(pos.Column), len(obj.Name)), true
+}
+
+// declPos finds where obj, referred to by the identifier ref, is
+// declared.
+//
+// The type checker records the statement or function literal that
+// declares most objects, the name is found in its first line.
+// Parameters, constants and imports are found in the syntax tree.
+func (a *analysis) declPos(obj *typecheck.Obj, ref *expr.Ident) (src.Pos, bool) {
+	if decl, isNode := obj.Decl.(syntax.Node); isNode {
+		pos := decl.Pos()
+		if pos.Filename != a.file.Filename || pos.Line < 1 || int(pos.Line) > len(a.lines) {
+			return src.Pos{}, false
+		}
+		for _, id := range a.idents {
+			if id.Position.Line == pos.Line && a.checker.Ident(id) == obj {
+				return id.Position, true
+			}
+		}
+		if col := findWord(a.lines[pos.Line-1], obj.Name, 0); col >= 0 {
+			pos.Column = int16(col + 1)
+			return pos, true
+		}
+		return src.Pos{}, false
+	}
+
+	// The innermost function with a parameter of the name.
+	for i := len(a.funcs) - 1; i >= 0; i-- {
+		fn := a.funcs[i]
+		if !a.inFunc(fn, ref.Position) || !declares(fn, obj.Name) {
+			continue
+		}
+		line := a.lines[fn.Position.Line-1]
+		from := int(fn.Position.Column) - 1 + len("func")
+		if fn.Name != "" {
+			from = findWord(line, fn.Name, from) + len(fn.Name)
+		}
+		if col := findWord(line, obj.Name, from); col >= 0 {
+			pos := fn.Position
+			pos.Column = int16(col + 1)
+			return pos, true
+		}
+	}
+
+	var pos src.Pos
+	switch obj.Kind {
+	case typecheck.ObjConst, typecheck.ObjPkg:
+		// The last declaration of the name before ref.
+		syntax.Walk(a.file, func(c *syntax.Cursor) bool {
+			var names []string
+			switch s := c.Node.(type) {
+			case *stmt.Const:
+				names = s.NameList
+			case *stmt.Import:
+				if s.Name != "" {
+					names = []string{s.Name}
+				} else {
+					names = []string{path.Base(s.Path)}
+				}
+			}
+			for _, name := range names {
+				if p := c.Node.Pos(); name == obj.Name && before(p, ref.Position) {
+					pos = p
+				}
+			}
+			return true
+		}, nil)
+	}
+	if pos.Line > 0 {
+		if col := findWord(a.lines[pos.Line-1], obj.Name, int(pos.Column)-1); col >= 0 {
+			pos.Column = int16(col + 1)
+		}
+		return pos, true
+	}
+
+	// Variables of range and type switch statements are declared
+	// by their first identifier.
+	for _, id := range a.idents {
+		if a.checker.Ident(id) == obj {
+			return id.Position, true
+		}
+	}
+	return src.Pos{}, false
+}
+
+// declares reports whether name is a receiver, parameter or result
+// of fn.
+func declares(fn *expr.FuncLiteral, name string) bool {
+	if fn.ReceiverName == name {
+		return true
+	}
+	for _, n := range fn.ParamNames {
+		if n == name {
+			return true
+		}
+	}
+	for _, n := range fn.ResultNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// completion returns the names that can complete the word before
+// col in lineText, the text of line.
+//
+// After a '.' they are the fields and methods of the value, or the
+// exports of the package, before it. Otherwise they are the names in
+// scope.
+//
+// The document is likely not to parse while a name is typed, so the
+// analysis may be of an earlier version of it.
+func (a *analysis) completion(lineText string, line, col int) []completionItem {
+	off := col - 1
+	if off > len(lineText) {
+		off = len(lineText)
+	}
+	start := wordStart(lineText, off)
+	prefix := lineText[start:off]
+
+	var items []completionItem
+	if start > 0 && lineText[start-1] == '.' {
+		items = a.memberCompletions(lineText[:start-1], line, col)
+	} else {
+		items = a.scopeCompletions(line, col)
+	}
+	res := []completionItem{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, prefix) && !seen[item.Label] {
+			seen[item.Label] = true
+			res = append(res, item)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Label < res[j].Label })
+	return res
+}
+
+// memberCompletions returns the members of the value named by the
+// selectors at the end of text, such as x or x.f.
+func (a *analysis) memberCompletions(text string, line, col int) []completionItem {
+	var path []string
+	for {
+		start := wordStart(text, len(text))
+		if start == len(text) {
+			return nil // not a name, such as f().
+		}
+		path = append([]string{text[start:]}, path...)
+		if start == 0 || text[start-1] != '.' {
+			break
+		}
+		text = text[:start-1]
+	}
+
+	obj := a.lookup(path[0], src.Pos{Line: int32(line), Column: int16(col)})
+	if obj == nil {
+		return nil
+	}
+	t := obj.Type
+	for _, name := range path[1:] {
+		var next tipe.Type
+		for _, m := range members(t) {
+			if m.Label == name {
+				next = m.t
+			}
+		}
+		if t = next; t == nil {
+			return nil
+		}
+	}
+	var items []completionItem
+	for _, m := range members(t) {
+		items = append(items, m.completionItem)
+	}
+	return items
+}
+
+// member is a field or method of a type, or an export of a package.
+type member struct {
+	completionItem
+	t tipe.Type
+}
+
+func members(t tipe.Type) []member {
+	if pkg, isPkg := t.(*tipe.Package); isPkg {
+		var res []member
+		for name, t := range pkg.Exports {
+			kind := completionVariable
+			if _, isFunc := t.(*tipe.Func); isFunc {
+				kind = completionFunction
+			}
+			res = append(res, member{completionItem{Label: name, Kind: kind, Detail: format.Type(t)}, t})
+		}
+		return res
+	}
+
+	if ptr, isPtr := tipe.Underlying(t).(*tipe.Pointer); isPtr {
+		t = ptr.Elem
+	}
+	var res []member
+	if named, isNamed := t.(*tipe.Named); isNamed {
+		for i, name := range named.MethodNames {
+			fn := named.Methods[i]
+			res = append(res, member{completionItem{Label: name, Kind: completionMethod, Detail: format.Type(fn)}, fn})
+		}
+		t = named.Type
+	}
+	switch t := t.(type) {
+	case *tipe.Struct:
+		for _, f := range t.Fields {
+			res = append(res, member{completionItem{Label: f.Name, Kind: completionField, Detail: format.Type(f.Type)}, f.Type})
+		}
+	case *tipe.Interface:
+		for name, fn := range t.Methods {
+			res = append(res, member{completionItem{Label: name, Kind: completionMethod, Detail: format.Type(fn)}, fn})
+		}
+	}
+	return res
+}
+
+// lookup finds the object name refers to at pos: the last identifier
+// of the name before pos, or a package-level or universe object.
+func (a *analysis) lookup(name string, pos src.Pos) *typecheck.Obj {
+	var obj *typecheck.Obj
+	for _, id := range a.idents {
+		if id.Name == name && before(id.Position, pos) && a.visible(id.Position, pos) {
+			if o := a.checker.Ident(id); o != nil {
+				obj = o
+			}
+		}
+	}
+	if obj != nil {
+		return obj
+	}
+	if a.pkg != nil && a.pkg.GlobalNames[name] != nil {
+		return a.pkg.GlobalNames[name]
+	}
+	return typecheck.Universe.Objs[name]
+}
+
+// scopeCompletions returns the names in scope at line and col.
+func (a *analysis) scopeCompletions(line, col int) []completionItem {
+	var items []completionItem
+	pos := src.Pos{Line: int32(line), Column: int16(col)}
+	for _, fn := range a.funcs {
+		if !a.inFunc(fn, pos) {
+			continue
+		}
+		if fn.Type.Params != nil {
+			for i, name := range fn.ParamNames {
+				if name != "" && i < len(fn.Type.Params.Elems) {
+					items = append(items, item(name, typecheck.ObjVar, fn.Type.Params.Elems[i]))
+				}
+			}
+		}
+		if fn.Type.Results != nil {
+			for i, name := range fn.ResultNames {
+				if name != "" && i < len(fn.Type.Results.Elems) {
+					items = append(items, item(name, typecheck.ObjVar, fn.Type.Results.Elems[i]))
+				}
+			}
+		}
+	}
+	for _, id := range a.idents {
+		obj := a.checker.Ident(id)
+		if obj == nil || obj.Kind != typecheck.ObjVar || !before(id.Position, pos) || !a.visible(id.Position, pos) {
+			continue
+		}
+		if a.pkg != nil && a.pkg.GlobalNames[obj.Name] == obj {
+			continue // below
+		}
+		items = append(items, item(id.Name, obj.Kind, obj.Type))
+	}
+	if a.pkg != nil {
+		for _, obj := range a.pkg.Globals {
+			items = append(items, item(obj.Name, obj.Kind, obj.Type))
+		}
+	}
+	for name, obj := range typecheck.Universe.Objs {
+		items = append(items, item(name, obj.Kind, obj.Type))
+	}
+	return items
+}
+
+func item(name string, kind typecheck.ObjKind, t tipe.Type) completionItem {
+	item := completionItem{Label: name}
+	if t != nil {
+		item.Detail = format.Type(t)
+	}
+	switch kind {
+	case typecheck.ObjVar:
+		item.Kind = completionVariable
+		if _, isFunc := t.(*tipe.Func); isFunc {
+			item.Kind = completionFunction
+		}
+	case typecheck.ObjConst:
+		item.Kind = completionConstant
+	case typecheck.ObjType:
+		item.Kind = completionClass
+	case typecheck.ObjPkg:
+		item.Kind = completionModule
+	}
+	return item
+}
+
+// visible reports whether a name declared at decl can be in scope at
+// pos: pos is in the innermost block around decl.
+func (a *analysis) visible(decl, pos src.Pos) bool {
+	var inner *stmt.Block
+	for _, b := range a.blocks {
+		if !before(b.Position, decl) || !before(decl, b.End) {
+			continue
+		}
+		if inner == nil || before(inner.Position, b.Position) {
+			inner = b
+		}
+	}
+	return inner == nil || before(inner.Position, pos) && before(pos, inner.End)
+}
+
+// inFunc reports whether pos is in the function literal fn.
+func (a *analysis) inFunc(fn *expr.FuncLiteral, pos src.Pos) bool {
+	body, isBlock := fn.Body.(*stmt.Block)
+	return isBlock && before(fn.Position, pos) && before(pos, body.End)
+}
+
+// before reports whether p is at or before q.
+func before(p, q src.Pos) bool {
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+	return p.Column <= q.Column
+}
+
+// identAt returns the identifier at line and col, including the
+// position just after it.
+func (a *analysis) identAt(line, col int) *expr.Ident {
+	for _, id := range a.idents {
+		start := int(id.Position.Column)
+		if int(id.Position.Line) == line && start <= col && col <= start+len(id.Name) {
+			return id
+		}
+	}
+	return nil
+}
+
+// wordStart returns the start of the identifier that ends at off in s.
+func wordStart(s string, off int) int {
+	for off > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:off])
+		if !isIdentRune(r) {
+			break
+		}
+		off -= size
+	}
+	return off
+}
+
+// findWord returns the offset of the first identifier name in s at
+// or after from, or -1.
+func findWord(s, name string, from int) int {
+	if from < 0 {
+		from = 0
+	}
+	for from <= len(s) {
+		i := strings.Index(s[from:], name)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(name)
+		startOK := i == 0 || !isIdentRune(lastRune(s[:i]))
+		endOK := end == len(s) || !isIdentRune(firstRune(s[end:]))
+		if startOK && endOK {
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// span returns the range of n bytes at the 1-based line and byte
+// column col. If n is 0 the range covers the identifier at col, or
+// the whole line if col is unknown.
+func (a *analysis) span(line, col, n int) textRange {
+	if line < 1 || line > len(a.lines) {
+		return textRange{}
+	}
+	text := a.lines[line-1]
+	if col < 1 {
+		return textRange{
+			Start: a.protoPos(line, 1),
+			End:   a.protoPos(line, len(text)+1),
+		}
+	}
+	if n == 0 && col <= len(text) {
+		word := text[col-1:]
+		n = len(word)
+		for i, r := range word {
+			if !isIdentRune(r) {
+				n = i
+				break
+			}
+		}
+	}
+	if n == 0 {
+		n = 1
+	}
+	return textRange{Start: a.protoPos(line, col), End: a.protoPos(line, col+n)}
+}
+
+// protoPos converts a 1-based line and byte column to a position of
+// the protocol.
+func (a *analysis) protoPos(line, col int) position {
+	return protoPos(a.lines, line, col)
+}
+
+func protoPos(lines []string, line, col int) position {
+	if line < 1 {
+		return position{}
+	}
+	if line > len(lines) {
+		line = len(lines)
+		col = len(lines[line-1]) + 1
+	}
+	text := lines[line-1]
+	off := col - 1
+	if off < 0 {
+		off = 0
+	} else if off > len(text) {
+		off = len(text)
+	}
+	return position{Line: line - 1, Character: utf16Len(text[:off])}
+}
+
+// srcPos converts a position of the protocol to a 1-based line and
+// byte column in lines.
+func srcPos(lines []string, p position) (line, col int) {
+	if p.Line >= len(lines) {
+		return len(lines), len(lines[len(lines)-1]) + 1
+	}
+	if p.Line < 0 {
+		return 1, 1
+	}
+	text := lines[p.Line]
+	units := 0
+	for off, r := range text {
+		if units >= p.Character {
+			return p.Line + 1, off + 1
+		}
+		units += utf16Units(r)
+	}
+	return p.Line + 1, len(text) + 1
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16Units(r)
+	}
+	return n
+}
+
+// utf16Units returns the number of UTF-16 code units that encode r.
+func utf16Units(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
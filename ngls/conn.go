@@ -0,0 +1,88 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ngls
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A conn reads and writes the messages of the base protocol: each is
+// a Content-Length header, a blank line and the JSON content.
+type conn struct {
+	r *bufio.Reader
+
+	mu sync.Mutex // guards w, messages are written whole
+	w  io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReader(r), w: w}
+}
+
+// read reads the next message. At the end of the input it returns
+// io.EOF. Content that is not a JSON message is reported with a
+// *responseError, the connection can still be read after it.
+func (c *conn) read() (*message, error) {
+	length := -1
+	for n := 0; ; n++ {
+		line, err := c.r.ReadString('\n')
+		if err == io.EOF && (n > 0 || line != "") {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("malformed header %q", line)
+		}
+		if name := strings.TrimSpace(line[:i]); strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("bad Content-Length %q", line[i+1:])
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(c.r, content); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	msg := new(message)
+	if err := json.Unmarshal(content, msg); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+// write writes v as a message.
+func (c *conn) write(v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(content)
+	return err
+}
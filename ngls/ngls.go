@@ -0,0 +1,357 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ngls implements a language server for Neugram.
+//
+// A Server speaks version 3.17 of the Language Server Protocol to an
+// editor. It shows the inferred types of names on hover, completes
+// names in scope and the fields, methods and package members after a
+// '.', finds the declarations of names, lists parse and type errors as
+// diagnostics and formats documents with package ngfmt.
+//
+// Documents are sent whole on every change. A changed document is
+// parsed and type checked again once it has not changed for
+// Server.Debounce, and its diagnostics published.
+package ngls // import "neugram.io/ng/ngls"
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"neugram.io/ng/ngfmt"
+)
+
+// A Server is a Neugram language server talking to one client.
+type Server struct {
+	// Debounce is how long a document must be left unchanged before
+	// the server checks it and publishes its diagnostics.
+	Debounce time.Duration
+
+	conn *conn
+
+	mu          sync.Mutex // guards the following, held while handling a message
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// A document is a text document opened by the client.
+type document struct {
+	uri      string
+	filename string
+	version  int
+	text     string
+	timer    *time.Timer // publishes diagnostics after a change
+
+	checked *analysis // of text, or nil if not yet analyzed
+	parsed  *analysis // last analysis without parse errors
+}
+
+// NewServer returns a server that reads the client's messages from r
+// and writes its own to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		Debounce: 200 * time.Millisecond,
+		conn:     newConn(r, w),
+		docs:     make(map[string]*document),
+	}
+}
+
+// Serve handles the client's messages until it sends "exit" or closes
+// the connection. It returns nil if the client asked the server to
+// shut down first, as the protocol requires.
+func (s *Server) Serve() error {
+	defer s.stopTimers()
+	for {
+		msg, err := s.conn.read()
+		if rerr, isResponseError := err.(*responseError); isResponseError {
+			if err := s.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err == io.EOF || (err == nil && msg.Method == "exit") {
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			switch {
+			case shutdown:
+				return nil
+			case err == io.EOF:
+				return errors.New("connection closed before shutdown")
+			}
+			return errors.New("exit before shutdown")
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) stopTimers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.docs {
+		if doc.timer != nil {
+			doc.timer.Stop()
+		}
+	}
+}
+
+// handle handles a request or notification. It returns an error only
+// if the connection fails.
+func (s *Server) handle(msg *message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg.ID == nil {
+		s.notified(msg)
+		return nil
+	}
+	result, err := s.call(msg)
+	return s.reply(msg.ID, result, err)
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err error) error {
+	resp := response{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		rerr, isResponseError := err.(*responseError)
+		if !isResponseError {
+			rerr = &responseError{Code: codeRequestFailed, Message: err.Error()}
+		}
+		resp.Error = rerr
+	} else {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		raw := json.RawMessage(b)
+		resp.Result = &raw
+	}
+	return s.conn.write(resp)
+}
+
+func (s *Server) call(msg *message) (interface{}, error) {
+	switch {
+	case msg.Method == "initialize":
+		s.initialized = true
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:           syncFull,
+				HoverProvider:              true,
+				CompletionProvider:         completionOptions{TriggerCharacters: []string{"."}},
+				DefinitionProvider:         true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "ngls"},
+		}, nil
+	case !s.initialized:
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "not initialized"}
+	case s.shutdown:
+		return nil, &responseError{Code: codeInvalidRequest, Message: "shut down"}
+	}
+
+	switch msg.Method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/hover":
+		doc, line, col, err := s.position(msg)
+		if doc == nil {
+			return nil, err
+		}
+		if h := doc.analysis().hover(line, col); h != nil {
+			return h, nil
+		}
+		return nil, nil
+
+	case "textDocument/completion":
+		doc, line, col, err := s.position(msg)
+		if doc == nil {
+			return nil, err
+		}
+		a := doc.analysis()
+		if len(a.parseErrs) > 0 && doc.parsed != nil {
+			a = doc.parsed
+		}
+		lineText := ""
+		if lines := strings.Split(doc.text, "\n"); line <= len(lines) {
+			lineText = lines[line-1]
+		}
+		return completionList{Items: a.completion(lineText, line, col)}, nil
+
+	case "textDocument/definition":
+		doc, line, col, err := s.position(msg)
+		if doc == nil {
+			return nil, err
+		}
+		if r, found := doc.analysis().definition(line, col); found {
+			return []location{{URI: doc.uri, Range: r}}, nil
+		}
+		return []location{}, nil
+
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: "unknown document " + params.TextDocument.URI}
+		}
+		return doc.format()
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "unknown method " + msg.Method}
+}
+
+// position decodes the parameters of a request at a position in a
+// document. It returns the document and the 1-based line and byte
+// column of the position.
+func (s *Server) position(msg *message) (doc *document, line, col int, err error) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, 0, 0, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	doc = s.docs[params.TextDocument.URI]
+	if doc == nil {
+		return nil, 0, 0, &responseError{Code: codeInvalidParams, Message: "unknown document " + params.TextDocument.URI}
+	}
+	line, col = srcPos(strings.Split(doc.text, "\n"), params.Position)
+	return doc, line, col, nil
+}
+
+// notified handles a notification. Notifications have no reply, those
+// that are malformed or not understood are ignored.
+func (s *Server) notified(msg *message) {
+	if !s.initialized {
+		return
+	}
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return
+		}
+		item := params.TextDocument
+		doc := &document{
+			uri:      item.URI,
+			filename: filename(item.URI),
+			version:  item.Version,
+			text:     item.Text,
+		}
+		if old := s.docs[doc.uri]; old != nil && old.timer != nil {
+			old.timer.Stop()
+		}
+		s.docs[doc.uri] = doc
+		s.publish(doc)
+
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil || len(params.ContentChanges) == 0 {
+			return
+		}
+		doc.version = params.TextDocument.Version
+		doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		doc.checked = nil
+		s.schedule(doc)
+
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return
+		}
+		if doc.timer != nil {
+			doc.timer.Stop()
+		}
+		delete(s.docs, doc.uri)
+		// Clear the diagnostics of the document.
+		s.conn.write(notification{
+			JSONRPC: "2.0",
+			Method:  "textDocument/publishDiagnostics",
+			Params:  publishDiagnosticsParams{URI: doc.uri, Version: doc.version, Diagnostics: []diagnostic{}},
+		})
+	}
+}
+
+// schedule publishes the diagnostics of doc after s.Debounce, unless
+// it changes again before then.
+func (s *Server) schedule(doc *document) {
+	if doc.timer != nil {
+		doc.timer.Stop()
+	}
+	version := doc.version
+	doc.timer = time.AfterFunc(s.Debounce, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.docs[doc.uri] != doc || doc.version != version || s.shutdown {
+			return // closed or changed since
+		}
+		s.publish(doc)
+	})
+}
+
+// publish checks doc and sends its diagnostics to the client.
+func (s *Server) publish(doc *document) {
+	// A failed write ends Serve, which reads from the same client.
+	s.conn.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params: publishDiagnosticsParams{
+			URI:         doc.uri,
+			Version:     doc.version,
+			Diagnostics: doc.analysis().diagnostics(),
+		},
+	})
+}
+
+// analysis returns the analysis of the current text of doc.
+func (doc *document) analysis() *analysis {
+	if doc.checked == nil {
+		doc.checked = analyze(doc.filename, doc.text)
+		if len(doc.checked.parseErrs) == 0 {
+			doc.parsed = doc.checked
+		}
+	}
+	return doc.checked
+}
+
+// format formats doc with ngfmt. The edit, if any, replaces the whole
+// document.
+func (doc *document) format() ([]textEdit, error) {
+	res, err := ngfmt.File(doc.filename, []byte(doc.text))
+	if err != nil {
+		return nil, err
+	}
+	if string(res) == doc.text {
+		return []textEdit{}, nil
+	}
+	lines := strings.Split(doc.text, "\n")
+	end := protoPos(lines, len(lines), len(lines[len(lines)-1])+1)
+	return []textEdit{{Range: textRange{End: end}, NewText: string(res)}}, nil
+}
+
+// filename returns the file name of a file: URI, or the URI itself.
+func filename(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return uri
+}
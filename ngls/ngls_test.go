@@ -0,0 +1,445 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ngls
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clientMessage is any message from the server.
+type clientMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// A client talks to a Server as an editor does.
+type client struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	msgs   chan *clientMessage // from the server
+	done   chan error          // the result of Serve
+}
+
+func newClient(t *testing.T) *client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	s := NewServer(serverR, serverW)
+	s.Debounce = 20 * time.Millisecond
+
+	c := &client{
+		t:    t,
+		conn: newConn(nil, clientW),
+		msgs: make(chan *clientMessage, 100),
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := s.Serve()
+		serverW.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.msgs)
+		r := textproto.NewReader(bufio.NewReader(clientR))
+		for {
+			header, err := r.ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(header.Get("Content-Length"))
+			if err != nil {
+				t.Errorf("bad header: %v", header)
+				return
+			}
+			content := make([]byte, n)
+			if _, err := io.ReadFull(r.R, content); err != nil {
+				return
+			}
+			msg := new(clientMessage)
+			if err := json.Unmarshal(content, msg); err != nil {
+				t.Errorf("bad message %s: %v", content, err)
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	return c
+}
+
+// initialize starts a session.
+func (c *client) initialize() {
+	var res initializeResult
+	if err := c.call("initialize", map[string]interface{}{"capabilities": struct{}{}}, &res); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("initialized", struct{}{})
+}
+
+func (c *client) notify(method string, params interface{}) {
+	if err := c.conn.write(notification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request, waits for its response and decodes the
+// result into res. Notifications sent meanwhile are dropped.
+func (c *client) call(method string, params, res interface{}) *responseError {
+	c.nextID++
+	id := c.nextID
+	req := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int         `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params"`
+	}{"2.0", id, method, params}
+	if err := c.conn.write(req); err != nil {
+		c.t.Fatal(err)
+	}
+	for msg := range c.msgs {
+		if msg.ID == nil || *msg.ID != id {
+			continue
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if res != nil {
+			if err := json.Unmarshal(msg.Result, res); err != nil {
+				c.t.Fatalf("%s: %v", method, err)
+			}
+		}
+		return nil
+	}
+	c.t.Fatalf("%s: connection closed", method)
+	return nil
+}
+
+// diagnostics waits for the next diagnostics published by the server.
+func (c *client) diagnostics() publishDiagnosticsParams {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.msgs:
+			if !ok {
+				c.t.Fatal("connection closed")
+			}
+			if msg.Method != "textDocument/publishDiagnostics" {
+				continue
+			}
+			var params publishDiagnosticsParams
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				c.t.Fatal(err)
+			}
+			return params
+		case <-timeout:
+			c.t.Fatal("no diagnostics published")
+		}
+	}
+}
+
+// exit ends the session and returns the result of Serve.
+func (c *client) exit() error {
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("server did not exit")
+		return nil
+	}
+}
+
+func (c *client) open(uri, text string) publishDiagnosticsParams {
+	c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: uri, LanguageID: "neugram", Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+func (c *client) change(uri string, version int, text string) {
+	c.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: uri, Version: version},
+		ContentChanges: []textDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+// at returns the position of the n'th (from 1) occurrence of word in
+// the text of a test, at offset off in the word.
+func at(t *testing.T, text, word string, n, off int) textDocumentPositionParams {
+	i := -1
+	for ; n > 0; n-- {
+		j := strings.Index(text[i+1:], word)
+		if j < 0 {
+			t.Fatalf("no %q in the source", word)
+		}
+		i += 1 + j
+	}
+	lines := strings.Split(text[:i+off], "\n")
+	pos := position{Line: len(lines) - 1, Character: utf16Len(lines[len(lines)-1])}
+	return textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: testURI}, Position: pos}
+}
+
+const testURI = "file:///tmp/test.ng"
+
+const testSource = `import "strings"
+
+type Point struct {
+	X int
+	Y int
+}
+
+func dist(p Point) int {
+	d := p.X - p.Y
+	if d < 0 {
+		e := -d
+		return e
+	}
+	return d
+}
+
+const limit = 10
+x := 1.5
+s := strings.ToUpper("a")
+n := dist(Point{1, 2})
+print(x, s, n, limit)
+`
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+	if err := c.call("textDocument/hover", at(t, testSource, "x", 1, 0), nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("hover before initialize: %v, want error %d", err, codeServerNotInitialized)
+	}
+	var res initializeResult
+	if err := c.call("initialize", struct{}{}, &res); err != nil {
+		t.Fatal(err)
+	}
+	caps := res.Capabilities
+	if caps.TextDocumentSync != syncFull || !caps.HoverProvider || !caps.DefinitionProvider || !caps.DocumentFormattingProvider {
+		t.Errorf("capabilities: %+v", caps)
+	}
+	if err := c.call("no/such/method", struct{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method: %v, want error %d", err, codeMethodNotFound)
+	}
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.call("textDocument/hover", at(t, testSource, "x", 1, 0), nil); err == nil || err.Code != codeInvalidRequest {
+		t.Errorf("hover after shutdown: %v, want error %d", err, codeInvalidRequest)
+	}
+	if err := c.exit(); err != nil {
+		t.Errorf("exit after shutdown: %v", err)
+	}
+
+	c = newClient(t)
+	c.initialize()
+	if err := c.exit(); err == nil {
+		t.Error("exit without shutdown succeeded")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+
+	if d := c.open(testURI, testSource); len(d.Diagnostics) != 0 {
+		t.Errorf("diagnostics of a good program: %+v", d.Diagnostics)
+	}
+
+	// Changes are checked after the debounce time. Only the last of
+	// quick changes is checked.
+	c.change(testURI, 2, "x := 1 +\n")
+	c.change(testURI, 3, "x := 1\ny := x + \"a\"\n")
+	d := c.diagnostics()
+	if d.Version != 3 || len(d.Diagnostics) != 1 {
+		t.Fatalf("diagnostics of version 3: %+v", d)
+	}
+	want := textRange{Start: position{Line: 1, Character: 2}, End: position{Line: 1, Character: 3}}
+	if got := d.Diagnostics[0]; got.Range != want || !strings.Contains(got.Message, "cannot convert") {
+		t.Errorf("type error: %+v, want range %+v", got, want)
+	}
+
+	c.change(testURI, 4, "x := f(1 2)\n")
+	d = c.diagnostics()
+	if d.Version != 4 || len(d.Diagnostics) != 1 {
+		t.Fatalf("diagnostics of version 4: %+v", d)
+	}
+	if got := d.Diagnostics[0]; got.Range.Start != (position{Line: 0, Character: 9}) {
+		t.Errorf("parse error: %+v", got)
+	}
+
+	c.notify("textDocument/didClose", didCloseTextDocumentParams{TextDocument: textDocumentIdentifier{URI: testURI}})
+	if d := c.diagnostics(); len(d.Diagnostics) != 0 {
+		t.Errorf("diagnostics after close: %+v", d.Diagnostics)
+	}
+}
+
+var hoverTests = []struct {
+	word string
+	n    int // occurrence of word
+	want string
+}{
+	{"x", 2, "var x float64"}, // print(x...
+	{"dist", 2, "func dist(Point) int"},
+	{"p.X", 1, "var p Point"},
+	{"X", 2, "p.X int"},
+	{"ToUpper", 1, "func strings.ToUpper(string) string"},
+	{"strings", 2, `package strings ("strings")`},
+	{"limit", 2, "const limit untyped integer = 10"},
+	{"print", 1, "func print(...interface{})"},
+	{"(", 1, ""},
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open(testURI, testSource)
+
+	for _, test := range hoverTests {
+		var h *hover
+		if err := c.call("textDocument/hover", at(t, testSource, test.word, test.n, 0), &h); err != nil {
+			t.Errorf("%s: %v", test.word, err)
+			continue
+		}
+		got := ""
+		if h != nil {
+			got = h.Contents.Value
+		}
+		if got != test.want {
+			t.Errorf("hover over %s #%d: %q, want %q", test.word, test.n, got, test.want)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open(testURI, testSource)
+
+	tests := []struct {
+		word  string
+		n     int
+		decl  string // the declaration
+		declN int
+	}{
+		{"dist", 2, "dist", 1},
+		{"p.X", 1, "p Point", 1},
+		{"d < 0", 1, "d :=", 1},
+		{"e\n", 1, "e :=", 1},
+		{"limit", 2, "limit", 1},
+		{"strings.", 1, "strings", 1},
+		{"x", 2, "x :=", 1},
+	}
+	for _, test := range tests {
+		var locs []location
+		if err := c.call("textDocument/definition", at(t, testSource, test.word, test.n, 0), &locs); err != nil {
+			t.Errorf("%s: %v", test.word, err)
+			continue
+		}
+		want := at(t, testSource, test.decl, test.declN, 0).Position
+		if len(locs) != 1 || locs[0].URI != testURI || locs[0].Range.Start != want {
+			t.Errorf("definition of %s #%d: %+v, want %+v", test.word, test.n, locs, want)
+		}
+	}
+
+	for _, word := range []string{"print", "ToUpper"} {
+		var locs []location
+		if err := c.call("textDocument/definition", at(t, testSource, word, 1, 0), &locs); err != nil || len(locs) != 0 {
+			t.Errorf("definition of %s: %+v, %v, want none", word, locs, err)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open(testURI, testSource)
+
+	labels := func(text string, word string, off int) []string {
+		var list completionList
+		if err := c.call("textDocument/completion", at(t, text, word, 1, off), &list); err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, item := range list.Items {
+			res = append(res, item.Label)
+		}
+		return res
+	}
+
+	if got, want := labels(testSource, "X - p", 0), []string{"X", "Y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields: %q, want %q", got, want)
+	}
+	if got, want := labels(testSource, "d < 0", 1), []string{"d", "delete", "dist"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("names in the function: %q, want %q", got, want)
+	}
+	if got, want := labels(testSource, "return d\n", len("return d")), []string{"d", "delete", "dist"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("names after the block: %q, want %q", got, want)
+	}
+
+	// While a selector is typed the document does not parse,
+	// the last version that did is used.
+	text := testSource + "strings.ToU"
+	c.change(testURI, 2, text)
+	if got, want := labels(text, "strings.ToU", len("strings.ToU")), []string{"ToUpper", "ToUpperSpecial"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("package members: %q, want %q", got, want)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open(testURI, "x:=1\ny :=x+  2")
+
+	params := documentFormattingParams{TextDocument: textDocumentIdentifier{URI: testURI}}
+	var edits []textEdit
+	if err := c.call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatal(err)
+	}
+	want := []textEdit{{
+		Range:   textRange{End: position{Line: 1, Character: 9}},
+		NewText: "x := 1\ny := x + 2\n",
+	}}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("edits: %+v, want %+v", edits, want)
+	}
+
+	c.change(testURI, 2, "x := (1 +\n")
+	if err := c.call("textDocument/formatting", params, &edits); err == nil {
+		t.Errorf("formatting a broken document: %+v, want error", edits)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	lines := []string{"x := \"é😀\" + y", ""}
+	tests := []struct {
+		line, col int // 1-based line and byte column
+		pos       position
+	}{
+		{1, 1, position{0, 0}},
+		{1, 7, position{0, 6}},   // é
+		{1, 9, position{0, 7}},   // 😀
+		{1, 13, position{0, 9}},  // "
+		{1, 18, position{0, 14}}, // after y
+		{2, 1, position{1, 0}},
+	}
+	for _, test := range tests {
+		if got := protoPos(lines, test.line, test.col); got != test.pos {
+			t.Errorf("protoPos(%d, %d) = %+v, want %+v", test.line, test.col, got, test.pos)
+		}
+		if line, col := srcPos(lines, test.pos); line != test.line || col != test.col {
+			t.Errorf("srcPos(%+v) = %d, %d, want %d, %d", test.pos, line, col, test.line, test.col)
+		}
+	}
+}
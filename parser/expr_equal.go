@@ -8,11 +8,15 @@ import (
 	"fmt"
 	"math/big"
 
+	"neugram.io/ng/internal/bigcplx"
 	"neugram.io/ng/syntax/expr"
 	"neugram.io/ng/syntax/stmt"
 	"neugram.io/ng/syntax/tipe"
 )
 
+// EqualExpr reports whether x and y are structurally equal expression
+// trees. Positions are ignored, basic literals are compared by value,
+// and the bodies of function literals are compared with EqualStmt.
 func EqualExpr(x, y expr.Expr) bool {
 	if x == nil && y == nil {
 		return true
@@ -47,7 +51,7 @@ func EqualExpr(x, y expr.Expr) bool {
 		if x == nil || y == nil {
 			return x == nil && y == nil
 		}
-		return x.Error == y.Error
+		return equalError(x.Error, y.Error)
 	case *expr.BasicLiteral:
 		y, ok := y.(*expr.BasicLiteral)
 		if !ok {
@@ -202,7 +206,7 @@ func EqualExpr(x, y expr.Expr) bool {
 		if x == nil || y == nil {
 			return x == nil && y == nil
 		}
-		if x.Ellipsis != y.Ellipsis {
+		if x.Ellipsis != y.Ellipsis || x.ElideError != y.ElideError {
 			return false
 		}
 		if !EqualExpr(x.Func, y.Func) {
@@ -369,7 +373,7 @@ func EqualExpr(x, y expr.Expr) bool {
 			return false
 		}
 		for i, e := range x.Assign {
-			if e != y.Assign[i] {
+			if e.Key != y.Assign[i].Key || e.Value != y.Assign[i].Value {
 				return false
 			}
 		}
@@ -455,6 +459,18 @@ func equalExprs(x, y []expr.Expr) bool {
 	return true
 }
 
+func equalStrings(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func equalTuple(x, y *tipe.Tuple) bool {
 	if x == nil && y == nil {
 		return true
@@ -486,6 +502,12 @@ func equalTypes(t0, t1 []tipe.Type) bool {
 }
 
 func equalFuncLiteral(f0, f1 *expr.FuncLiteral) bool {
+	if f0.Name != f1.Name || f0.ReceiverName != f1.ReceiverName || f0.PointerReceiver != f1.PointerReceiver {
+		return false
+	}
+	if !equalStrings(f0.ParamNames, f1.ParamNames) || !equalStrings(f0.ResultNames, f1.ResultNames) {
+		return false
+	}
 	if !tipe.EqualUnresolved(f0.Type, f1.Type) {
 		return false
 	}
@@ -656,7 +678,14 @@ func EqualStmt(x, y stmt.Stmt) bool {
 		if x.Name != y.Name {
 			return false
 		}
-		if !tipe.EqualUnresolved(x.Type, y.Type) {
+		if (x.Alias == nil) != (y.Alias == nil) {
+			return false
+		}
+		if x.Alias != nil {
+			if !tipe.EqualUnresolved(x.Alias, y.Alias) {
+				return false
+			}
+		} else if !tipe.EqualUnresolved(x.Type, y.Type) {
 			return false
 		}
 	case *stmt.TypeDeclSet:
@@ -747,6 +776,14 @@ func EqualStmt(x, y stmt.Stmt) bool {
 		if !equalExprs(x.Right, y.Right) {
 			return false
 		}
+	case *stmt.Bad:
+		y, ok := y.(*stmt.Bad)
+		if !ok {
+			return false
+		}
+		if !equalError(x.Error, y.Error) {
+			return false
+		}
 	case *stmt.Block:
 		y, ok := y.(*stmt.Block)
 		if !ok {
@@ -902,6 +939,15 @@ func EqualStmt(x, y stmt.Stmt) bool {
 	return true
 }
 
+// equalError reports whether two errors have the same message, as
+// an error decoded from JSON is not the error that was encoded.
+func equalError(err0, err1 error) bool {
+	if err0 == nil || err1 == nil {
+		return err0 == nil && err1 == nil
+	}
+	return err0.Error() == err1.Error()
+}
+
 func equalLiteral(lit0, lit1 interface{}) bool {
 	if lit0 == lit1 {
 		return true
@@ -915,6 +961,10 @@ func equalLiteral(lit0, lit1 interface{}) bool {
 		if lit1, ok := lit1.(*big.Float); ok {
 			return lit0.Cmp(lit1) == 0
 		}
+	case *bigcplx.Complex:
+		if lit1, ok := lit1.(*bigcplx.Complex); ok {
+			return lit0.Real.Cmp(lit1.Real) == 0 && lit0.Imag.Cmp(lit1.Imag) == 0
+		}
 	}
 	return false
 }
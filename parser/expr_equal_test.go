@@ -0,0 +1,67 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+)
+
+func funcLit(name string, params ...string) *expr.FuncLiteral {
+	f := &expr.FuncLiteral{
+		Name:       name,
+		Type:       &tipe.Func{Params: &tipe.Tuple{}},
+		ParamNames: params,
+		Body:       &stmt.Block{},
+	}
+	for range params {
+		f.Type.Params.Elems = append(f.Type.Params.Elems, tipe.Int64)
+	}
+	return f
+}
+
+var equalExprTests = []struct {
+	x, y  expr.Expr
+	equal bool
+}{
+	{nil, nil, true},
+	{nil, &expr.Ident{Name: "x"}, false},
+	{&expr.Ident{Name: "x"}, nil, false},
+	{&expr.Ident{Name: "x"}, &expr.Ident{Name: "x"}, true},
+	{&expr.Ident{Name: "x"}, &expr.Ident{Name: "y"}, false},
+	{&expr.BasicLiteral{Value: big.NewInt(7)}, &expr.BasicLiteral{Value: big.NewInt(7)}, true},
+	{&expr.BasicLiteral{Value: big.NewInt(7)}, &expr.BasicLiteral{Value: big.NewInt(8)}, false},
+	{&expr.BasicLiteral{Value: big.NewFloat(1.5)}, &expr.BasicLiteral{Value: big.NewFloat(1.5)}, true},
+	{&expr.BasicLiteral{Value: big.NewInt(1)}, &expr.BasicLiteral{Value: big.NewFloat(1)}, false},
+	{&expr.BasicLiteral{Value: "s"}, &expr.BasicLiteral{Value: "s"}, true},
+	{&expr.Ident{Name: "x"}, &expr.BasicLiteral{Value: "x"}, false},
+	{funcLit("f", "a"), funcLit("f", "a"), true},
+	{funcLit("f", "a"), funcLit("g", "a"), false},
+	{funcLit("f", "a"), funcLit("f", "b"), false},
+	{funcLit("f", "a"), funcLit("f", "a", "b"), false},
+	{&expr.Bad{Error: errors.New("bad")}, &expr.Bad{Error: errors.New("bad")}, true},
+	{&expr.Bad{Error: errors.New("bad")}, &expr.Bad{Error: errors.New("worse")}, false},
+	{&expr.Bad{Error: errors.New("bad")}, &expr.Bad{}, false},
+	{&expr.Bad{}, &expr.Bad{}, true},
+	{
+		&expr.Call{Func: &expr.Ident{Name: "f"}, Args: []expr.Expr{&expr.Ident{Name: "x"}}},
+		&expr.Call{Func: &expr.Ident{Name: "f"}, Args: []expr.Expr{&expr.Ident{Name: "x"}}, Ellipsis: true},
+		false,
+	},
+}
+
+func TestEqualExpr(t *testing.T) {
+	for i, test := range equalExprTests {
+		if got := parser.EqualExpr(test.x, test.y); got != test.equal {
+			t.Errorf("%d: EqualExpr(%#v, %#v) = %v, want %v", i, test.x, test.y, got, test.equal)
+		}
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"runtime/debug"
-	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"neugram.io/ng/format"
 	"neugram.io/ng/syntax"
@@ -22,11 +24,41 @@ import (
 	"neugram.io/ng/syntax/token"
 )
 
-func New(filename string) *Parser {
+// DefaultMaxDepth is the default ParseOptions.MaxDepth.
+const DefaultMaxDepth = 1000
+
+// ParseOptions configures a Parser.
+type ParseOptions struct {
+	// MaxDepth limits how deeply expressions may nest, counted
+	// in recursive descents of the expression parser. Zero means
+	// DefaultMaxDepth.
+	MaxDepth int
+}
+
+// A ParseOption sets a field of ParseOptions.
+type ParseOption func(*ParseOptions)
+
+// MaxDepth sets ParseOptions.MaxDepth.
+func MaxDepth(n int) ParseOption {
+	return func(o *ParseOptions) { o.MaxDepth = n }
+}
+
+func newParser(filename string, opts []ParseOption) *Parser {
 	p := &Parser{
 		filename: filename,
 		s:        newScanner(),
 	}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	if p.opts.MaxDepth <= 0 {
+		p.opts.MaxDepth = DefaultMaxDepth
+	}
+	return p
+}
+
+func New(filename string, opts ...ParseOption) *Parser {
+	p := newParser(filename, opts)
 	go p.work()
 	<-p.s.needSrc
 	return p
@@ -46,42 +78,48 @@ const (
 // If non-nil, the returned error is either of type Error or Errors.
 func (p *Parser) Parse(source []byte) (*syntax.File, error) {
 	f := &syntax.File{Filename: p.filename}
+	p.comments = &f.Comments
+	defer func() { p.comments = nil }()
 	var errs Errors
+	var state ParserState
+	var shell *expr.Shell // the top-level shell block being parsed
+	var last []byte       // last line
 	scanner := bufio.NewScanner(bytes.NewReader(source))
-	for i := 0; scanner.Scan(); i++ {
+	i := 0
+	for ; scanner.Scan(); i++ {
 		b := scanner.Bytes()
+		last = b
+		if len(bytes.TrimSpace(b)) == 0 {
+			f.BlankLines = append(f.BlankLines, int32(i+1))
+		}
 		if i == 0 && len(b) > 2 && b[0] == '#' && b[1] == '!' { // shebang
-			p.s.Line++
+			p.s.line++
 			continue
 		}
 		res := p.ParseLine(b)
+		state = res.State
 		if len(res.Stmts) > 0 {
 			f.Stmts = append(f.Stmts, res.Stmts...)
 		}
-		if len(res.Cmds) == 1 {
-			s := &stmt.Simple{
-				Position: res.Cmds[0].Pos(),
-				Expr: &expr.Shell{
-					Position: res.Cmds[0].Pos(),
-					Cmds:     res.Cmds,
-				},
+		for _, s := range res.Stmts {
+			switch s := s.(type) {
+			case *stmt.Import:
+				f.Imports = append(f.Imports, s)
+			case *stmt.ImportSet:
+				f.Imports = append(f.Imports, s.Imports...)
 			}
-			f.Stmts = append(f.Stmts, s)
-		} else if len(res.Cmds) > 1 {
-			s := &stmt.Block{
-				Position: res.Cmds[0].Position,
-			}
-			for _, cmd := range res.Cmds {
-				simple := &stmt.Simple{
-					Position: cmd.Pos(),
-					Expr: &expr.Shell{
-						Position: cmd.Pos(),
-						Cmds:     []*expr.ShellList{cmd},
-					},
-				}
-				s.Stmts = append(s.Stmts, simple)
+		}
+		// The commands of a $$ block spanning several lines are
+		// one statement, as they are in an expression.
+		if len(res.Cmds) > 0 {
+			if shell == nil {
+				shell = &expr.Shell{Position: p.shellPos}
+				f.Stmts = append(f.Stmts, &stmt.Simple{Position: shell.Position, Expr: shell})
 			}
-			f.Stmts = append(f.Stmts, s)
+			shell.Cmds = append(shell.Cmds, res.Cmds...)
+		}
+		if res.State != StateCmd {
+			shell = nil
 		}
 		if len(res.Errs) > 0 {
 			errs = append(errs, res.Errs...)
@@ -94,6 +132,16 @@ func (p *Parser) Parse(source []byte) (*syntax.File, error) {
 	if err := scanner.Err(); err != nil {
 		panic("parser.Parse: impossible scanner error: " + err.Error())
 	}
+	if state == StateStmtPartial || state == StateCmdPartial {
+		errs = append(errs, Error{
+			Pos:     src.Pos{Filename: p.filename, Line: int32(i), Column: int16(len(last) + 1)},
+			Line:    i,
+			Col:     len(last) + 1,
+			Code:    ErrUnexpectedEOF,
+			Msg:     "unexpected end of file",
+			SrcLine: string(last),
+		})
+	}
 
 	if len(errs) == 1 {
 		return f, errs[0]
@@ -117,9 +165,17 @@ type Parser struct {
 
 	res Result
 
-	interactive bool
-	noCompLit   bool // to resolve composite literal parsing
-	s           *Scanner
+	opts  ParseOptions
+	depth int // current nesting depth of expressions
+
+	interactive  bool
+	shellPos     src.Pos // the $$ starting the current top-level shell block
+	noCompLit    bool    // to resolve composite literal parsing
+	switchHeader bool    // parsing a switch header, where x.(type) is permitted
+	s            *Scanner
+	tokErr       error // the scanner's error in the current token, once reported
+
+	comments *[]*syntax.Comment // if non-nil, where Parse records comments
 }
 
 // Result is the result of parsing a line of input.
@@ -140,7 +196,7 @@ func (p *Parser) work() {
 		// Work is processed on a separate goroutine. Avoid panicing
 		// here so there's an oppertunity to clean up terminal state.
 		if x := recover(); x != nil {
-			err := p.errorf("panic: %v", x)
+			err := p.errorf(ErrInternal, "panic: %v", x)
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			debug.PrintStack()
 			close(p.s.needSrc)
@@ -157,7 +213,7 @@ func (p *Parser) work() {
 			if p.s.r == -1 {
 				break // no more work
 			}
-			p.errorf("unknown token: '%s'", p.s.Literal)
+			p.errorf(ErrUnknownToken, "unknown token: '%s'", p.s.Literal)
 			p.s.drain()
 			continue
 		}
@@ -170,6 +226,7 @@ func (p *Parser) work() {
 		// makes a simple expression behave like an interactive shell.
 		if p.res.State != StateCmd && p.s.Token == token.Shell {
 			p.res.State = StateCmd
+			p.shellPos = p.pos()
 		} else if p.res.State == StateCmd {
 			p.interactive = true
 			cmd := p.parseShellList()
@@ -191,8 +248,103 @@ func (p *Parser) work() {
 	}
 }
 
-func ParseStmt(src []byte) (stmt stmt.Stmt, err error) {
-	p := New("<single statement>")
+// ParseFile parses the Neugram source file filename.
+// If src is nil, the source is read from filename.
+// If non-nil, the returned error is either of type Error or Errors,
+// or an error reading the file.
+func ParseFile(filename string, src []byte, opts ...ParseOption) (*syntax.File, error) {
+	if src == nil {
+		var err error
+		src, err = ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := New(filename, opts...)
+	defer p.Close()
+	return p.Parse(src)
+}
+
+// ParseType parses a type expression, such as "map[string][]float64".
+// It is the inverse of format.Type. Named types, including the basic
+// types, are returned unresolved.
+func ParseType(src []byte, opts ...ParseOption) (t tipe.Type, err error) {
+	p := newParser("<type>", opts)
+	p.s.src = append(append([]byte{}, src...), '\n')
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// All of the source is present, so the scanner
+		// asks for more at most once, when it reaches the end.
+		select {
+		case <-p.s.needSrc:
+			p.s.addSrc <- nil
+		case <-done:
+		}
+	}()
+	defer func() {
+		if x := recover(); x != nil {
+			t, err = nil, p.errorf(ErrInternal, "panic: %v", x)
+		}
+	}()
+
+	p.s.next()
+	p.next()
+	t = p.parseTypeExpr()
+	if p.s.Token == token.Semicolon {
+		p.next()
+	}
+	if len(p.res.Errs) == 0 && (p.s.Token != token.Unknown || p.s.r != -1) {
+		p.errorf(ErrExpectedType, "unexpected %s after type", p.s.Token)
+	}
+	if len(p.res.Errs) > 0 {
+		return nil, Errors(p.res.Errs)
+	}
+	return t, nil
+}
+
+// ParseExpr parses exactly one expression from src, such as
+// "x.y[1] + f(z)". The returned error is of type Errors.
+func ParseExpr(src []byte, opts ...ParseOption) (e expr.Expr, err error) {
+	p := newParser("<expr>", opts)
+	p.s.src = append(append([]byte{}, src...), '\n')
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// As in ParseType, the scanner asks for more at most once.
+		select {
+		case <-p.s.needSrc:
+			p.s.addSrc <- nil
+		case <-done:
+		}
+	}()
+	defer func() {
+		if x := recover(); x != nil {
+			e, err = nil, Errors{p.errorf(ErrInternal, "panic: %v", x).(Error)}
+		}
+	}()
+
+	p.s.next()
+	p.next()
+	e = p.parseExpr()
+	if p.s.Token == token.Semicolon {
+		p.next()
+	}
+	if len(p.res.Errs) == 0 && (p.s.Token != token.Unknown || p.s.r != -1) {
+		p.errorf(ErrExpectedToken, "unexpected %s after expression", p.s.Token)
+	}
+	if len(p.res.Errs) > 0 {
+		return nil, Errors(p.res.Errs)
+	}
+	return e, nil
+}
+
+// ParseStmt parses exactly one statement from src.
+// The statement may span several lines and need not end in a
+// semicolon or newline. Anything but whitespace and comments after
+// the statement is an error.
+func ParseStmt(src []byte, opts ...ParseOption) (stmt stmt.Stmt, err error) {
+	p := New("<single statement>", opts...)
 	defer p.Close()
 	res := p.ParseLine(src)
 	if res.State == StateStmtPartial {
@@ -201,38 +353,67 @@ func ParseStmt(src []byte) (stmt stmt.Stmt, err error) {
 	if len(res.Errs) > 0 {
 		return nil, Errors(res.Errs)
 	}
-	if len(res.Stmts) != 1 {
-		return nil, fmt.Errorf("parser.ParseStmt: expected 1 statement, got %d", len(res.Stmts))
+	switch len(res.Stmts) {
+	case 0:
+		return nil, fmt.Errorf("parser.ParseStmt: no statement")
+	case 1:
+		return res.Stmts[0], nil
 	}
-	return res.Stmts[0], nil
+	return nil, fmt.Errorf("parser.ParseStmt: unexpected content after statement on line %d", res.Stmts[1].Pos().Line)
 }
 
 func (p *Parser) next() {
 	p.s.Next()
+	p.tokErr = nil
+	if p.s.err != nil {
+		p.tokErr = p.scanError()
+	}
 	if p.s.Token == token.Comment {
+		if p.comments != nil {
+			*p.comments = append(*p.comments, &syntax.Comment{
+				Position: p.pos(),
+				Text:     p.s.Literal.(string),
+			})
+		}
 		p.next()
 	}
 }
 
 func (p *Parser) pos() src.Pos {
-	col := p.s.Column // positioned on the last byte of the token
-	if p.s.Literal != nil {
-		if s, isString := p.s.Literal.(string); isString {
-			col -= int16(len(s) - 1)
-		}
-	} else {
-		str := p.s.Token.String()
-		col -= int16(len(str) - 1)
-	}
-
 	return src.Pos{
 		Filename: p.filename,
-		Line:     p.s.Line,
-		Column:   col,
+		Line:     int32(p.s.Line),
+		Column:   int16(p.s.Col),
 	}
 }
 
+// descend records a recursive descent into the expression parser.
+// If it exceeds the maximum nesting depth, it reports an error and
+// returns a Bad expression to use in place of the nested one.
+// Otherwise the caller must call ascend on return.
+func (p *Parser) descend() *expr.Bad {
+	p.depth++
+	if p.depth <= p.opts.MaxDepth {
+		return nil
+	}
+	p.depth--
+	bad := &expr.Bad{
+		Position: p.pos(),
+		Error:    p.error(ErrMaxDepth, "maximum nesting depth exceeded"),
+	}
+	p.synchronize()
+	return bad
+}
+
+func (p *Parser) ascend() {
+	p.depth--
+}
+
 func (p *Parser) parseExpr() expr.Expr {
+	if bad := p.descend(); bad != nil {
+		return bad
+	}
+	defer p.ascend()
 	return p.parseBinaryExpr(1)
 }
 
@@ -262,18 +443,15 @@ func (p *Parser) parseBinaryExpr(minPrec int) expr.Expr {
 }
 
 func (p *Parser) parseUnaryExpr() expr.Expr {
+	if bad := p.descend(); bad != nil {
+		return bad
+	}
+	defer p.ascend()
 	pos := p.pos()
 	switch p.s.Token {
 	case token.Add, token.Sub, token.Not, token.Ref:
 		op := p.s.Token
 		p.next()
-		if p.s.err != nil {
-			bad := &expr.Bad{
-				Position: pos,
-				Error:    p.s.err,
-			}
-			return bad
-		}
 		x := p.parseUnaryExpr()
 		// TODO: distinguish expr from types, when we have types
 		unary := &expr.Unary{Position: pos, Op: op, Expr: x}
@@ -292,12 +470,12 @@ func (p *Parser) parseUnaryExpr() expr.Expr {
 			// parsed a channel type, add in the receive prefix '<-'
 			if t, ok := extyp.Type.(*tipe.Chan); ok {
 				if t.Direction == tipe.ChanRecv {
-					p.error(`expected "chan", found "<-"`)
+					p.error(ErrExpectedType, `expected "chan", found "<-"`)
 				}
 				// TODO: nested channel types
 				t.Direction = tipe.ChanRecv
 			} else {
-				p.errorf(`expected "chan", found %q`, format.Type(t))
+				p.errorf(ErrExpectedType, `expected "chan", found %q`, format.Type(t))
 			}
 			return x
 		}
@@ -314,8 +492,9 @@ func (p *Parser) expectCommaOr(otherwise token.Token, msg string) bool {
 	case p.s.Token == token.Comma:
 		return true
 	case p.s.Token != otherwise:
-		p.error("missing ',' in " + msg + " (got " + p.s.Token.String() + ")")
-		return true // fake it
+		p.errorf(ErrMissingComma, "missing ',' in %s (got %s)", msg, p.s.Token)
+		// Fake it, unless the list has run into the end of the statement.
+		return p.s.Token != token.Semicolon && p.s.Token != token.RightBrace
 	default:
 		return false
 	}
@@ -332,12 +511,17 @@ func (p *Parser) parseArgs() []expr.Expr {
 		}
 		p.next()
 	}
-	p.expect(token.RightParen)
-	p.next()
+	if p.expect(token.RightParen) {
+		p.next()
+	}
 	return args
 }
 
 func (p *Parser) parsePrimaryExpr() expr.Expr {
+	if bad := p.descend(); bad != nil {
+		return bad
+	}
+	defer p.ascend()
 	x := p.parseOperand()
 	for {
 		pos := p.pos()
@@ -355,7 +539,13 @@ func (p *Parser) parsePrimaryExpr() expr.Expr {
 			case token.LeftParen:
 				x = p.parseTypeAssert(x)
 			default:
-				panic("TODO expect selector type assertion")
+				x = &expr.Bad{
+					Position: pos,
+					Error:    p.errorf(ErrExpectedSelector, "expected selector or type assertion, found %s", p.s.Token),
+				}
+				if p.s.Token != token.Semicolon && p.s.Token != token.RightBrace {
+					p.next() // make progress
+				}
 			}
 		case token.LeftBracket:
 			x = p.parseIndex(x)
@@ -363,7 +553,11 @@ func (p *Parser) parsePrimaryExpr() expr.Expr {
 			p.next()
 			var args []expr.Expr
 			var ellipsis bool
-			for p.s.Token != token.RightParen && p.s.r > 0 && !ellipsis {
+			for p.s.Token != token.RightParen && p.s.r > 0 {
+				if ellipsis {
+					p.error(ErrEllipsis, "can only use ... with final argument in list")
+					ellipsis = false
+				}
 				args = append(args, p.parseExpr())
 				if p.s.Token == token.Ellipsis {
 					ellipsis = true
@@ -374,8 +568,9 @@ func (p *Parser) parsePrimaryExpr() expr.Expr {
 				}
 				p.next()
 			}
-			p.expect(token.RightParen)
-			p.next()
+			if p.expect(token.RightParen) {
+				p.next()
+			}
 
 			x = &expr.Call{
 				Position: pos,
@@ -385,18 +580,11 @@ func (p *Parser) parsePrimaryExpr() expr.Expr {
 			}
 		case token.LeftBraceTable, token.LeftBrace:
 			if tExpr, isType := x.(*expr.Type); isType {
-				switch t := tExpr.Type.(type) {
-				case *tipe.Array:
-					x = p.parseArrayLiteral(t)
-				case *tipe.Slice:
-					x = p.parseSliceLiteral(t)
-				case *tipe.Table:
-					x = p.parseTableLiteral(t)
-				case *tipe.Map:
-					x = p.parseMapLiteral(t)
-				default:
-					x = p.parseCompLiteral(t)
+				x = p.parseLiteral(tExpr.Type)
+				if p.noCompLit {
+					return x
 				}
+				continue
 			}
 
 			// The problem is that in expressions like
@@ -417,6 +605,8 @@ func (p *Parser) parsePrimaryExpr() expr.Expr {
 			} else if t := maybePackageType(x); t != nil {
 				t := &expr.Type{Position: pos, Type: t}
 				x = t
+			} else {
+				return x // the brace is not a literal of x
 			}
 		default:
 			return x
@@ -448,6 +638,9 @@ func (p *Parser) parseTypeAssert(lhs expr.Expr) expr.Expr {
 
 	var typ tipe.Type
 	if p.s.Token == token.Type {
+		if !p.switchHeader {
+			p.error(ErrTypeSwitch, "use of .(type) outside type switch")
+		}
 		p.expect(token.Type)
 		p.next()
 	} else {
@@ -478,63 +671,74 @@ func (p *Parser) parseIndex(lhs expr.Expr) expr.Expr {
 	for p.s.Token != 0 && p.s.Token != token.RightBracket {
 		if len(res.Indicies) != 0 {
 			if !p.expect(token.Comma) {
+				p.skipToRightBracket()
 				break
 			}
 			p.next()
 		}
 
-		if p.s.Token == token.Colon {
-			slice := &expr.Slice{Position: p.pos()}
-			// [:expr]
-			p.next()
+		var low expr.Expr
+		if p.s.Token != token.Colon {
+			low = p.parseExpr()
 			if p.s.Token == token.RightBracket || p.s.Token == token.Comma {
-				res.Indicies = append(res.Indicies, slice)
+				// [expr]
+				res.Indicies = append(res.Indicies, low)
 				continue
 			}
-			slice.High = p.parseExpr()
-			res.Indicies = append(res.Indicies, slice)
-			continue
+			if !p.expect(token.Colon) {
+				p.skipToRightBracket()
+				break
+			}
 		}
+		res.Indicies = append(res.Indicies, p.parseSlice(low))
+	}
+	p.expect(token.RightBracket)
+	p.next()
+	return res
+}
 
-		e := p.parseExpr()
-		if p.s.Token == token.RightBracket || p.s.Token == token.Comma {
-			// [expr]
-			res.Indicies = append(res.Indicies, e)
-			continue
-		}
-		p.expect(token.Colon)
-		colonPos := p.pos()
-		p.next()
-		if p.s.Token == token.RightBracket || p.s.Token == token.Comma {
-			// [expr:]
-			res.Indicies = append(res.Indicies, &expr.Slice{Position: colonPos, Low: e})
-			continue
-		}
-		high := p.parseExpr()
-		if p.s.Token == token.RightBracket || p.s.Token == token.Comma {
-			// [expr:high]
-			res.Indicies = append(res.Indicies, &expr.Slice{
-				Position: colonPos,
-				Low:      e,
-				High:     high,
-			})
-			continue
+// skipToRightBracket skips tokens up to the bracket closing the
+// current index expression, or the end of the statement.
+func (p *Parser) skipToRightBracket() {
+	depth := 0
+	for p.s.Token > 0 && p.s.Token != token.Semicolon {
+		switch p.s.Token {
+		case token.LeftBracket:
+			depth++
+		case token.RightBracket:
+			if depth == 0 {
+				return
+			}
+			depth--
 		}
-		p.expect(token.Colon)
 		p.next()
-		max := p.parseExpr()
-		// [expr:high:max]
-		res.Indicies = append(res.Indicies, &expr.Slice{
-			Position: colonPos,
-			Low:      e,
-			High:     high,
-			Max:      max,
-		})
+	}
+}
 
+// parseSlice parses the remainder of a slice index, starting at the
+// first colon. Omitted low and high bounds are left nil.
+func (p *Parser) parseSlice(low expr.Expr) *expr.Slice {
+	slice := &expr.Slice{Position: p.pos(), Low: low}
+	p.expect(token.Colon)
+	p.next()
+	if p.s.Token != token.RightBracket && p.s.Token != token.Comma && p.s.Token != token.Colon {
+		slice.High = p.parseExpr()
+	}
+	if p.s.Token != token.Colon {
+		// [low:high]
+		return slice
+	}
+	// [low:high:max]
+	if slice.High == nil {
+		p.error(ErrSliceIndex, "middle index required in 3-index slice")
 	}
-	p.expect(token.RightBracket)
 	p.next()
-	return res
+	if p.s.Token == token.RightBracket || p.s.Token == token.Comma {
+		p.error(ErrSliceIndex, "final index required in 3-index slice")
+		return slice
+	}
+	slice.Max = p.parseExpr()
+	return slice
 }
 
 func (p *Parser) parseRange() (r expr.Range) {
@@ -580,7 +784,7 @@ func (p *Parser) parseParam() (name string, t tipe.Type) {
 		t = first
 	}
 	if t == nil {
-		p.errorf("expected name or type, got %s", p.s.Token)
+		p.errorf(ErrExpectedType, "expected name or type, got %s", p.s.Token)
 		p.next() // make progress
 	} else if p.s.Token == token.Comma {
 		p.next()
@@ -617,7 +821,7 @@ func (p *Parser) parseParamTuple() (names []string, params *tipe.Tuple) {
 			if n == "" {
 				names[i] = typeAsName(params.Elems[i])
 				if names[i] == "" {
-					p.error("function signature mixes named and unnamed arguments")
+					p.error(ErrParams, "function signature mixes named and unnamed arguments")
 					return nil, &tipe.Tuple{}
 				}
 				params.Elems[i] = nil
@@ -631,7 +835,7 @@ func (p *Parser) parseParamTuple() (names []string, params *tipe.Tuple) {
 		}
 		for _, t := range params.Elems {
 			if t == nil {
-				p.error("function signature mixes named and unnamed arguments")
+				p.error(ErrParams, "function signature mixes named and unnamed arguments")
 				return nil, &tipe.Tuple{}
 			}
 		}
@@ -656,7 +860,7 @@ func (p *Parser) parseMethodik(name string) *stmt.MethodikDecl {
 		p.expect(token.Func)
 		m := p.parseFunc(true)
 		if tags[m.Name] {
-			p.errorf("func %s redeclared in methodik %s", m.Name, c.Name)
+			p.errorf(ErrRedeclared, "func %s redeclared in methodik %s", m.Name, c.Name)
 		} else {
 			tags[m.Name] = true
 			c.Type.MethodNames = append(c.Type.MethodNames, m.Name)
@@ -676,7 +880,17 @@ func (p *Parser) parseMethodik(name string) *stmt.MethodikDecl {
 func (p *Parser) parseType() tipe.Type {
 	t := p.maybeParseType()
 	if t == nil {
-		p.errorf("expected type , got %s", p.s.Token)
+		p.errorf(ErrExpectedType, "expected type, got %s", p.s.Token)
+	}
+	return t
+}
+
+// parseTypeExpr parses a type expression on its own, outside of
+// any statement.
+func (p *Parser) parseTypeExpr() tipe.Type {
+	t := p.maybeParseType()
+	if t == nil {
+		p.errorf(ErrExpectedType, "expected type expression, got %s", p.s.Token)
 	}
 	return t
 }
@@ -726,7 +940,7 @@ func (p *Parser) maybeParseType() tipe.Type {
 			p.next()
 			return &tipe.Array{Elem: p.parseType(), Ellipsis: true}
 		default:
-			p.errorf("invalid token=%v in type declaration", p.s.Token)
+			p.errorf(ErrExpectedType, "invalid token=%v in type declaration", p.s.Token)
 			return nil
 		}
 	case token.Mul:
@@ -763,17 +977,12 @@ func (p *Parser) maybeParseType() tipe.Type {
 					t = p.parseType()
 				}
 				if p.s.Token == token.String {
-					str, err := strconv.Unquote(p.s.Literal.(string))
-					if err != nil {
-						p.errorf("error while parsing struct field tag: %v", err)
-						return s
-					}
-					ftag = tipe.StructTag(str)
+					ftag = tipe.StructTag(p.s.Literal.(string))
 					p.next()
 				}
 			}
 			if n != "" && n != "_" && tags[n] {
-				p.errorf("field %s redeclared in struct %s", n, format.Type(s))
+				p.errorf(ErrRedeclared, "field %s redeclared in struct %s", n, format.Type(s))
 			} else {
 				tags[n] = true
 				s.Fields = append(s.Fields, tipe.StructField{
@@ -795,11 +1004,13 @@ func (p *Parser) maybeParseType() tipe.Type {
 		// a nil field type is one from e.g.:
 		//  type T struct { x, y int }
 		// x has no type (but we want it to have 'int'.)
+		// A tag applies to every field in the group.
 		for i := len(s.Fields) - 1; i > 0; i-- {
 			sf := &s.Fields[i]
 			sfn := &s.Fields[i-1]
 			if sfn.Type == nil {
 				sfn.Type = sf.Type
+				sfn.Tag = sf.Tag
 			}
 		}
 		return s
@@ -858,12 +1069,15 @@ func (p *Parser) maybeParseType() tipe.Type {
 		}
 		s.Elem = p.parseType()
 		return s
-	case token.Semicolon, token.Comma, token.RightParen, token.LeftBrace:
-		// no type
-	default:
-		fmt.Printf("maybeParseType: token=%s\n", p.s.Token)
+	case token.LeftParen:
+		// (T), needed to write chan (<-chan T)
+		p.next()
+		t := p.parseType()
+		p.expect(token.RightParen)
+		p.next()
+		return t
 	}
-	return nil
+	return nil // no type
 }
 
 func (p *Parser) parseExprs() []expr.Expr {
@@ -911,7 +1125,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 			if tok != token.Define && tok != token.Assign {
 				right = []expr.Expr{&expr.Bad{
 					Position: rangePos,
-					Error:    p.error("range can only be used inside ':=' or '='"),
+					Error:    p.error(ErrRange, "range can only be used inside ':=' or '='"),
 				}}
 			} else {
 				right = []expr.Expr{&expr.Unary{
@@ -928,7 +1142,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 				if _, ok := e.(*expr.Ident); !ok {
 					exprs[i] = &expr.Bad{
 						Position: e.Pos(),
-						Error:    p.error("expected identifier as declaration"),
+						Error:    p.errorf(ErrNonName, "non-name %s on left side of :=", format.Expr(e)),
 					}
 				}
 			}
@@ -937,7 +1151,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 			if len(exprs) != 1 || len(right) != 1 {
 				right = []expr.Expr{&expr.Bad{
 					Position: tokPos,
-					Error:    p.error(fmt.Sprintf("arithmetic assignement %q only accepts one argument", tok)),
+					Error:    p.errorf(ErrExprCount, "arithmetic assignement %q only accepts one argument", tok),
 				}}
 			} else {
 				right[0] = &expr.Binary{
@@ -964,7 +1178,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 	}
 
 	if len(exprs) != 1 {
-		p.error("expected one expression")
+		p.error(ErrExprCount, "expected one expression")
 	}
 
 	switch p.s.Token {
@@ -983,7 +1197,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 				Position: opPos,
 				Op:       op,
 				Left:     exprs[0],
-				Right:    &expr.BasicLiteral{Value: big.NewInt(1)},
+				Right:    &expr.BasicLiteral{Position: opPos, Value: big.NewInt(1)},
 			}},
 		}
 	case token.ChanOp:
@@ -997,7 +1211,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 	case token.Colon:
 		// check whether this is 'case <-channel:'
 		if e, isUnary := exprs[0].(*expr.Unary); isUnary && e.Op == token.ChanOp {
-			return &stmt.Simple{Expr: e}
+			return &stmt.Simple{Position: e.Pos(), Expr: e}
 		}
 		p.next()
 		// TODO: we can be stricter here, sometimes it is invalid to declare a label.
@@ -1010,7 +1224,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 		}
 		return &stmt.Bad{
 			Position: p.pos(),
-			Error:    p.error("bad label declaration"),
+			Error:    p.error(ErrLabel, "bad label declaration"),
 		}
 	}
 
@@ -1018,7 +1232,7 @@ func (p *Parser) parseSimpleStmt() stmt.Stmt {
 	if e, isShell := exprs[0].(*expr.Shell); isShell {
 		e.TrapOut = false
 	}
-	return &stmt.Simple{Expr: exprs[0]}
+	return &stmt.Simple{Position: exprs[0].Pos(), Expr: exprs[0]}
 }
 
 func (p *Parser) extractExpr(s stmt.Stmt) expr.Expr {
@@ -1027,7 +1241,7 @@ func (p *Parser) extractExpr(s stmt.Stmt) expr.Expr {
 	}
 	return &expr.Bad{
 		Position: s.Pos(),
-		Error:    p.error("expected boolean expression, found statement"),
+		Error:    p.error(ErrExpectedExpr, "expected boolean expression, found statement"),
 	}
 }
 
@@ -1055,33 +1269,15 @@ func (p *Parser) parseStmt() stmt.Stmt {
 	switch p.s.Token {
 	// TODO: many many kinds of statements
 	case token.If:
-		s := &stmt.If{Position: p.pos()}
+		return p.parseIf()
+	case token.Else:
+		pos := p.pos()
+		err := p.error(ErrElse, "else without matching if")
 		p.next()
-		p.noCompLit = true
-		if p.s.Token == token.Semicolon {
-			// Blank Init statement.
-			p.next()
-			s.Cond = p.parseExpr()
-		} else {
-			s.Init = p.parseSimpleStmt()
-			if p.s.Token == token.Semicolon {
-				p.next()
-				s.Cond = p.parseExpr()
-			} else {
-				// No Init statement, make it the condition
-				s.Cond = p.extractExpr(s.Init)
-				s.Init = nil
-			}
+		if p.s.Token == token.If || p.s.Token == token.LeftBrace {
+			p.parseStmt() // skip the dangling branch
 		}
-		p.noCompLit = false
-		s.Body = p.parseBlock()
-		if p.s.Token == token.Else {
-			p.next()
-			s.Else = p.parseStmt()
-		} else {
-			p.expectSemi()
-		}
-		return s
+		return &stmt.Bad{Position: pos, Error: err}
 	case token.Ident, token.Int, token.Float,
 		token.Add, token.Sub, token.Mul, token.ChanOp, token.Not, token.Map,
 		token.Func, token.LeftBracket, token.LeftParen, token.String, token.Rune, token.Shell:
@@ -1099,19 +1295,7 @@ func (p *Parser) parseStmt() stmt.Stmt {
 		p.expectSemi()
 		return s
 	case token.Defer:
-		pos := p.pos()
-		p.next()
-		fct := p.parseExpr()
-		if _, ok := fct.(*expr.Call); !ok {
-			return &stmt.Bad{
-				Position: pos,
-				Error:    p.error("expression in defer must be function call"),
-			}
-		}
-		s := &stmt.Defer{
-			Position: pos,
-			Expr:     fct,
-		}
+		s := p.parseDefer()
 		p.expectSemi()
 		return s
 	case token.LeftBrace:
@@ -1132,8 +1316,27 @@ func (p *Parser) parseStmt() stmt.Stmt {
 		if p.s.Token == token.LeftParen {
 			p.next()
 			s := &stmt.ConstSet{Position: pos}
+			var prev *stmt.Const
 			for p.s.Token > 0 && p.s.Token != token.RightParen {
-				s.Consts = append(s.Consts, p.parseConst())
+				c := p.parseConst()
+				if len(c.Values) == 0 && c.Type == nil {
+					if prev == nil {
+						p.errorf(ErrDecl, "missing init expr for const declaration")
+					} else {
+						// Implicit repetition of the previous
+						// expression list, with a new iota. The
+						// copies are type checked separately.
+						c.Type = prev.Type
+						for _, v := range prev.Values {
+							c.Values = append(c.Values, expr.Clone(v))
+						}
+						p.checkConstArity(c)
+					}
+				}
+				if len(c.Values) > 0 {
+					prev = c
+				}
+				s.Consts = append(s.Consts, c)
 				if p.s.Token == token.Semicolon {
 					p.next()
 				}
@@ -1145,6 +1348,9 @@ func (p *Parser) parseStmt() stmt.Stmt {
 		}
 		s := p.parseConst()
 		s.Position = pos
+		if len(s.Values) == 0 && s.Type == nil {
+			p.errorf(ErrDecl, "missing init expr for const declaration")
+		}
 		p.expectSemi()
 		return s
 	case token.Var:
@@ -1230,24 +1436,55 @@ func (p *Parser) parseStmt() stmt.Stmt {
 		p.expectSemi()
 		return s
 	}
-	panic(fmt.Sprintf("TODO parseStmt %s", p.s.Token))
+	pos := p.pos()
+	err := p.errorf(ErrExpectedStmt, "expected statement, found %s", p.s.Token)
+	if p.s.Token != token.Semicolon && p.s.Token != token.RightBrace {
+		p.next() // make progress
+	}
+	p.synchronize()
+	return &stmt.Bad{Position: pos, Error: err}
+}
+
+// synchronize skips tokens after a syntax error until it reaches
+// one that can end or begin a statement, so parsing can resume
+// without reporting errors that cascade from the first.
+func (p *Parser) synchronize() {
+	for {
+		switch p.s.Token {
+		case token.Semicolon, token.RightBrace,
+			token.If, token.For, token.Switch, token.Select, token.Return,
+			token.Go, token.Defer, token.Break, token.Continue, token.Goto,
+			token.Fallthrough, token.Const, token.Var, token.Type,
+			token.Import, token.Methodik:
+			return
+		case token.Unknown:
+			if p.s.r == -1 {
+				return
+			}
+		}
+		p.next()
+	}
 }
 
 func (p *Parser) parseImport() (s *stmt.Import) {
-	name := ""
-	if p.s.Token == token.Ident {
-		name = p.s.Literal.(string)
+	s = &stmt.Import{Position: p.pos()}
+	switch p.s.Token {
+	case token.Ident:
+		s.Name = p.s.Literal.(string)
+		p.next()
+	case token.Period:
+		s.Name = "."
 		p.next()
 	}
 	if !p.expect(token.String) {
 		p.next()
-		return &stmt.Import{}
+		return s
 	}
 	path := p.s.Literal.(string)
-	s = &stmt.Import{
-		Name: name,
-		Path: path[1 : len(path)-1],
+	if path == "" {
+		p.errorf(ErrImportPath, "invalid import path: %q", path)
 	}
+	s.Path = path
 	p.next()
 	return s
 }
@@ -1262,6 +1499,66 @@ func (p *Parser) parseBranch() *stmt.Branch {
 		s.Label = p.s.Literal.(string)
 		p.next()
 	}
+	switch {
+	case s.Type == token.Goto && s.Label == "":
+		p.errorf(ErrLabel, "missing label in goto statement")
+	case s.Type == token.Fallthrough && s.Label != "":
+		p.errorf(ErrLabel, "fallthrough statement cannot have a label")
+	}
+	return s
+}
+
+func (p *Parser) parseIf() stmt.Stmt {
+	s := &stmt.If{Position: p.pos()}
+	p.expect(token.If)
+	p.next()
+	p.noCompLit = true
+	if p.s.Token == token.Semicolon {
+		// Blank Init statement.
+		p.next()
+		s.Cond = p.parseExpr()
+	} else {
+		s.Init = p.parseSimpleStmt()
+		if p.s.Token == token.Semicolon {
+			p.next()
+			s.Cond = p.parseExpr()
+		} else {
+			// No Init statement, make it the condition
+			s.Cond = p.extractExpr(s.Init)
+			s.Init = nil
+		}
+	}
+	p.noCompLit = false
+	if p.s.Token != token.LeftBrace {
+		err := p.errorf(ErrExpectedToken, "expected '{' after if clause, found %s", p.s.Token)
+		for p.s.Token > 0 && p.s.Token != token.LeftBrace && p.s.Token != token.Semicolon {
+			p.next()
+		}
+		if p.s.Token != token.LeftBrace {
+			return &stmt.Bad{Position: s.Position, Error: err}
+		}
+	}
+	s.Body = p.parseBlock()
+	if p.s.Token != token.Else {
+		p.expectSemi()
+		return s
+	}
+	p.next()
+	switch p.s.Token {
+	case token.If:
+		s.Else = p.parseIf()
+	case token.LeftBrace:
+		s.Else = p.parseBlock()
+		p.expectSemi()
+	default:
+		s.Else = &stmt.Bad{
+			Position: p.pos(),
+			Error:    p.errorf(ErrElse, "else must be followed by if or statement block, found %s", p.s.Token),
+		}
+		for p.s.Token > 0 && p.s.Token != token.Semicolon && p.s.Token != token.RightBrace {
+			p.next()
+		}
+	}
 	return s
 }
 
@@ -1271,18 +1568,33 @@ func (p *Parser) parseGo() stmt.Stmt {
 	}
 	p.expect(token.Go)
 	p.next()
-	e := p.parsePrimaryExpr()
-	call, ok := e.(*expr.Call)
+	call, ok := p.parseExpr().(*expr.Call)
 	if !ok {
 		return &stmt.Bad{
 			Position: g.Pos(),
-			Error:    p.errorf("go statement must invoke function"),
+			Error:    p.error(ErrExpectedCall, "go requires a function call"),
 		}
 	}
 	g.Call = call
 	return g
 }
 
+func (p *Parser) parseDefer() stmt.Stmt {
+	d := &stmt.Defer{
+		Position: p.pos(),
+	}
+	p.expect(token.Defer)
+	p.next()
+	d.Expr = p.parseExpr()
+	if _, ok := d.Expr.(*expr.Call); !ok {
+		return &stmt.Bad{
+			Position: d.Pos(),
+			Error:    p.error(ErrExpectedCall, "defer requires a function call"),
+		}
+	}
+	return d
+}
+
 func (p *Parser) parseConst() *stmt.Const {
 	s := &stmt.Const{
 		Position: p.pos(),
@@ -1312,23 +1624,27 @@ items:
 		case token.Semicolon:
 			break items
 		default:
-			p.errorf("invalid token %v in const declaration", p.s.Token)
+			p.errorf(ErrDecl, "invalid token %v in const declaration", p.s.Token)
 			break items
 		}
 	}
 	p.expectSemi()
 	if len(s.Values) == 0 && s.Type != nil {
-		p.errorf("const declaration cannot have type without expression")
+		p.errorf(ErrDecl, "const declaration cannot have type without expression")
 	}
+	p.checkConstArity(s)
+
+	return s
+}
+
+func (p *Parser) checkConstArity(s *stmt.Const) {
 	switch {
 	case len(s.Values) != 0 && len(s.NameList) > len(s.Values):
-		p.errorf("missing value in const declaration")
+		p.errorf(ErrDecl, "missing value in const declaration")
 
 	case len(s.Values) != 0 && len(s.NameList) < len(s.Values):
-		p.errorf("extra expression in const declaration")
+		p.errorf(ErrDecl, "extra expression in const declaration")
 	}
-
-	return s
 }
 
 func (p *Parser) parseVar() *stmt.Var {
@@ -1341,7 +1657,8 @@ items:
 		s.NameList = append(s.NameList, p.s.Literal.(string))
 		p.next()
 		switch p.s.Token {
-		case token.Chan, token.Ident, token.LeftBracket, token.Map, token.Struct:
+		case token.Chan, token.ChanOp, token.Func, token.Ident, token.Interface,
+			token.LeftBracket, token.Map, token.Mul, token.Struct:
 			s.Type = p.parseType()
 			if p.s.Token == token.Assign {
 				p.next()
@@ -1360,7 +1677,7 @@ items:
 		case token.Semicolon:
 			break items
 		default:
-			p.errorf("invalid token %v in var declaration", p.s.Token)
+			p.errorf(ErrDecl, "invalid token %v in var declaration", p.s.Token)
 			break items
 		}
 	}
@@ -1369,16 +1686,16 @@ items:
 }
 
 func (p *Parser) parseTypeDecl() *stmt.TypeDecl {
-	pos := p.pos()
-	t := &tipe.Named{
-		Name: p.parseIdent().Name,
-		Type: p.parseType(),
-	}
 	s := &stmt.TypeDecl{
-		Position: pos,
-		Name:     t.Name,
-		Type:     t,
+		Position: p.pos(),
+		Name:     p.parseIdent().Name,
+	}
+	if p.s.Token == token.Assign {
+		p.next()
+		s.Alias = &tipe.Alias{Name: s.Name, Type: p.parseType()}
+		return s
 	}
+	s.Type = &tipe.Named{Name: s.Name, Type: p.parseType()}
 	return s
 }
 
@@ -1407,6 +1724,7 @@ func (p *Parser) parseSelect() stmt.Stmt {
 		p.expect(token.Colon)
 		p.next()
 		c.Body = &stmt.Block{Stmts: p.parseStmts()}
+		c.Body.End = p.pos()
 		s.Cases = append(s.Cases, c)
 	}
 	p.expect(token.RightBrace)
@@ -1437,27 +1755,9 @@ func (p *Parser) parseFor() stmt.Stmt {
 		p.next()
 		return &stmt.Range{Position: pos, Expr: p.parseExpr(), Body: body()}
 	}
-	if p.s.Token == token.Semicolon {
-		p.next()
-		if p.s.Token == token.Semicolon {
-			p.next()
-			if p.s.Token == token.LeftBrace {
-				// for ;; { }
-				return &stmt.For{Position: pos, Body: body()}
-			}
-			// for ;;i2 { }
-			i2 := p.parseSimpleStmt()
-			return &stmt.For{Position: pos, Post: i2, Body: body()}
-		}
-		i1 := p.parseSimpleStmt()
-		if p.s.Token == token.Semicolon {
-			// for ;i1; { }
-			p.next()
-			return &stmt.For{Position: pos, Cond: p.extractExpr(i1), Body: body()}
-		}
-		// for ;i1;i2 { }
-		panic("TODO parseFor 'for ;'") // TODO
-	} else {
+
+	f := &stmt.For{Position: pos}
+	if p.s.Token != token.Semicolon {
 		i0 := p.parseSimpleStmt()
 		if p.s.Token == token.LeftBrace {
 			if r := extractRange(i0); r != nil {
@@ -1465,38 +1765,28 @@ func (p *Parser) parseFor() stmt.Stmt {
 				// for k, _ := range r { }
 				r.Body = body()
 				return r
-			} else {
-				// for i0 {}
-				return &stmt.For{Position: pos, Cond: p.extractExpr(i0), Body: body()}
 			}
+			// for i0 { }
+			f.Cond = p.extractExpr(i0)
+			f.Body = body()
+			return f
 		}
-		p.expectSemi()
-		p.next()
-		if p.s.Token == token.Semicolon {
-			// for i0;; {}
-			return &stmt.For{Position: pos, Init: i0, Body: body()}
-		}
-		i1 := p.parseSimpleStmt()
-		p.expectSemi()
-		p.next()
-		if p.s.Token == token.LeftBrace {
-			// for i0;i1; { }
-			return &stmt.For{Position: pos, Init: i0, Cond: p.extractExpr(i1), Body: body()}
-		}
-		i2 := p.parseSimpleStmt()
-		p.expect(token.LeftBrace)
-		// for i0; i1; i2 { }
-		return &stmt.For{
-			Position: pos,
-			Init:     i0,
-			Cond:     p.extractExpr(i1),
-			Post:     i2,
-			Body:     body(),
-		}
+		f.Init = i0
 	}
 
-	// TODO
-	panic("TODO parseFor range")
+	// for i0; i1; i2 { }, where any of i0, i1, and i2 may be empty
+	p.expect(token.Semicolon)
+	p.next()
+	if p.s.Token != token.Semicolon {
+		f.Cond = p.extractExpr(p.parseSimpleStmt())
+	}
+	p.expect(token.Semicolon)
+	p.next()
+	if p.s.Token != token.LeftBrace {
+		f.Post = p.parseSimpleStmt()
+	}
+	f.Body = body()
+	return f
 }
 
 func (p *Parser) parseSwitch() stmt.Stmt {
@@ -1540,10 +1830,17 @@ func (p *Parser) parseSwitchHeader() (stmt.Stmt, stmt.Stmt, bool) {
 
 	if p.s.Token != token.LeftBrace {
 		p.noCompLit = true
-		s1 = p.parseSimpleStmt()
+		p.switchHeader = true
+		if p.s.Token != token.Semicolon {
+			s1 = p.parseSimpleStmt()
+		}
 		switch p.s.Token {
 		case token.Semicolon:
 			p.next()
+			if p.s.Token == token.LeftBrace {
+				// switch x := foo(); { ... }
+				break
+			}
 			s2 = p.parseSimpleStmt()
 			switch s2 := s2.(type) {
 			default:
@@ -1577,6 +1874,7 @@ func (p *Parser) parseSwitchHeader() (stmt.Stmt, stmt.Stmt, bool) {
 			s1 = nil
 		}
 		p.noCompLit = false
+		p.switchHeader = false
 	}
 	p.expect(token.LeftBrace)
 
@@ -1589,6 +1887,7 @@ func (p *Parser) parseExprSwitch(s *stmt.Switch) {
 
 	for p.s.Token > 0 && p.s.Token != token.RightBrace {
 		var c stmt.SwitchCase
+		c.Position = p.pos()
 		switch p.s.Token {
 		case token.Case:
 			p.expect(token.Case)
@@ -1599,12 +1898,14 @@ func (p *Parser) parseExprSwitch(s *stmt.Switch) {
 			p.next()
 			c.Default = true
 		default:
-			p.errorf("syntax error: got token %q, want %q or %q", p.s.Token, token.Case, token.Default)
-			return
+			p.errorf(ErrExpectedCase, "syntax error: got token %q, want %q or %q", p.s.Token, token.Case, token.Default)
+			p.skipToCase()
+			continue
 		}
 		p.expect(token.Colon)
 		p.next()
 		c.Body = &stmt.Block{Stmts: p.parseStmts()}
+		c.Body.End = p.pos()
 		s.Cases = append(s.Cases, c)
 	}
 	p.expect(token.RightBrace)
@@ -1622,11 +1923,11 @@ func (p *Parser) parseExprSwitch(s *stmt.Switch) {
 					continue
 				}
 				if !lastStmt {
-					p.error("fallthrough statement out of place")
+					p.error(ErrFallthrough, "fallthrough statement out of place")
 					return
 				}
 				if lastCase {
-					p.error("cannot fallthrough final case in switch")
+					p.error(ErrFallthrough, "cannot fallthrough final case in switch")
 					return
 				}
 			}
@@ -1634,36 +1935,65 @@ func (p *Parser) parseExprSwitch(s *stmt.Switch) {
 	}
 }
 
+// skipToCase skips tokens up to the next case clause or the end
+// of the enclosing switch body.
+func (p *Parser) skipToCase() {
+	depth := 0
+	for p.s.Token > 0 {
+		switch p.s.Token {
+		case token.LeftBrace:
+			depth++
+		case token.RightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.Case, token.Default:
+			if depth == 0 {
+				return
+			}
+		}
+		p.next()
+	}
+}
+
 func (p *Parser) parseTypeSwitch(s *stmt.TypeSwitch) {
 	p.expect(token.LeftBrace)
 	p.next()
 
-	for p.s.Token != token.RightBrace {
+	for p.s.Token > 0 && p.s.Token != token.RightBrace {
 		var c stmt.TypeSwitchCase
+		c.Position = p.pos()
 		switch p.s.Token {
 		case token.Case:
 			p.expect(token.Case)
 			p.next()
-			for p.s.Token != token.Colon {
+			for p.s.Token > 0 && p.s.Token != token.Colon {
 				c.Types = append(c.Types, p.parseType())
-				if p.s.Token == token.Comma {
-					p.next()
+				if !p.expectCommaOr(token.Colon, "type switch case") {
+					break
 				}
+				p.next()
 			}
 		case token.Default:
 			p.expect(token.Default)
 			p.next()
 			c.Default = true
+		default:
+			p.errorf(ErrExpectedCase, "syntax error: got token %q, want %q or %q", p.s.Token, token.Case, token.Default)
+			p.skipToCase()
+			continue
 		}
 		p.expect(token.Colon)
 		p.next()
 		c.Body = &stmt.Block{Stmts: p.parseStmts()}
+		c.Body.End = p.pos()
 		for _, e := range c.Body.Stmts {
 			// TODO: detect fallthrough statements in non-top-level statements
 			switch e := e.(type) {
 			case *stmt.Branch:
 				if e.Type == token.Fallthrough {
-					p.error("cannot fallthrough in type switch")
+					p.error(ErrFallthrough, "cannot fallthrough in type switch")
 				}
 			}
 		}
@@ -1676,11 +2006,13 @@ func (p *Parser) parseTypeSwitch(s *stmt.TypeSwitch) {
 	p.expectSemi()
 }
 
-func (p *Parser) parseBlock() stmt.Stmt {
+func (p *Parser) parseBlock() *stmt.Block {
 	p.expect(token.LeftBrace)
+	pos := p.pos()
 	p.next()
-	s := &stmt.Block{Stmts: p.parseStmts()}
+	s := &stmt.Block{Position: pos, Stmts: p.parseStmts()}
 	p.expect(token.RightBrace)
+	s.End = p.pos()
 	p.next()
 	return s
 }
@@ -1720,17 +2052,20 @@ func (p *Parser) parseFuncType(method bool) *expr.FuncLiteral {
 	if p.s.Token == token.Ident {
 		f.Name = p.parseIdent().Name
 	} else if method {
-		p.errorf("class method missing name")
+		p.errorf(ErrMissingName, "class method missing name")
 	}
 
 	p.expect(token.LeftParen)
 	p.next()
 	if p.s.Token != token.RightParen {
 		f.ParamNames, f.Type.Params = p.parseParamTuple()
-		if params := f.Type.Params; len(params.Elems) > 0 {
-			last := params.Elems[len(params.Elems)-1]
-			if _, variadic := last.(*tipe.Ellipsis); variadic {
-				f.Type.Variadic = true
+		for i, t := range f.Type.Params.Elems {
+			if _, variadic := t.(*tipe.Ellipsis); variadic {
+				if i == len(f.Type.Params.Elems)-1 {
+					f.Type.Variadic = true
+				} else {
+					p.error(ErrEllipsis, "can only use ... with final parameter in list")
+				}
 			}
 		}
 	} else {
@@ -1744,6 +2079,11 @@ func (p *Parser) parseFuncType(method bool) *expr.FuncLiteral {
 		p.next()
 		if p.s.Token != token.RightParen {
 			f.ResultNames, f.Type.Results = p.parseParamTuple()
+			for _, t := range f.Type.Results.Elems {
+				if _, variadic := t.(*tipe.Ellipsis); variadic {
+					p.error(ErrEllipsis, "cannot use ... in result parameter list")
+				}
+			}
 		}
 		p.expect(token.RightParen)
 		p.next()
@@ -1764,8 +2104,10 @@ func (p *Parser) parseFunc(method bool) *expr.FuncLiteral {
 	f := p.parseFuncType(method)
 	f.Position = funcPos
 	if p.s.Token != token.LeftBrace {
-		p.next()
-		p.errorf("missing function body")
+		p.errorf(ErrMissingFuncBody, "missing function body")
+		if p.s.Token != token.Semicolon && p.s.Token != token.RightBrace {
+			p.next() // make progress
+		}
 		return f
 	}
 	f.Body = p.parseBlock()
@@ -1773,6 +2115,14 @@ func (p *Parser) parseFunc(method bool) *expr.FuncLiteral {
 }
 
 func (p *Parser) parseOperand() expr.Expr {
+	if p.tokErr != nil {
+		bad := &expr.Bad{
+			Position: p.pos(),
+			Error:    p.tokErr,
+		}
+		p.synchronize()
+		return bad
+	}
 	switch p.s.Token {
 	case token.Ident:
 		x := p.parseIdent()
@@ -1781,6 +2131,7 @@ func (p *Parser) parseOperand() expr.Expr {
 		x := &expr.BasicLiteral{
 			Position: p.pos(),
 			Value:    p.s.Literal,
+			Text:     p.s.text(),
 		}
 		p.next()
 		return x
@@ -1788,14 +2139,15 @@ func (p *Parser) parseOperand() expr.Expr {
 		x := &expr.BasicLiteral{
 			Position: p.pos(),
 			Value:    p.s.Literal,
+			Text:     p.s.text(),
 		}
 		p.next()
 		return x
 	case token.String:
-		s, _ := strconv.Unquote(p.s.Literal.(string))
 		x := &expr.BasicLiteral{
 			Position: p.pos(),
-			Value:    s,
+			Value:    p.s.Literal.(string),
+			Text:     p.s.text(),
 		}
 		p.next()
 		return x
@@ -1825,6 +2177,11 @@ func (p *Parser) parseOperand() expr.Expr {
 			p.interactive = false
 			cmd := p.parseShellList()
 			p.interactive = restore
+			if cmd == nil {
+				p.errorf(ErrExpectedOperand, "expected command, got %s", p.s.Token)
+				p.next() // make progress
+				continue
+			}
 			x.Cmds = append(x.Cmds, cmd)
 		}
 		p.expect(token.Shell)
@@ -1840,17 +2197,37 @@ func (p *Parser) parseOperand() expr.Expr {
 		}
 	}
 
-	p.next()
 	res := &expr.Bad{
 		Position: pos,
-		Error:    p.errorf("expected operand, got %s", p.s.Token),
+		Error:    p.errorf(ErrExpectedOperand, "expected operand, got %s", p.s.Token),
+	}
+	if p.s.Token != token.Semicolon && p.s.Token != token.RightBrace {
+		p.next() // make progress
 	}
+	p.synchronize()
 	return res
 }
 
+// parseLiteral parses the body of a composite literal of type t.
+// The current token is the opening brace.
+func (p *Parser) parseLiteral(t tipe.Type) expr.Expr {
+	switch t := t.(type) {
+	case *tipe.Array:
+		return p.parseArrayLiteral(t)
+	case *tipe.Slice:
+		return p.parseSliceLiteral(t)
+	case *tipe.Table:
+		return p.parseTableLiteral(t)
+	case *tipe.Map:
+		return p.parseMapLiteral(t)
+	default:
+		return p.parseCompLiteral(t)
+	}
+}
+
 func (p *Parser) parseArrayLiteral(t tipe.Type) *expr.ArrayLiteral {
 	x := &expr.ArrayLiteral{Position: p.pos(), Type: t.(*tipe.Array)}
-	x.Keys, x.Values = p.parseKeyedLiteral()
+	x.Keys, x.Values = p.parseKeyedLiteral(nil, x.Type.Elem)
 	if x.Type.Ellipsis || len(x.Keys) > 0 {
 		n := int64(len(x.Values))
 		if len(x.Keys) > 0 {
@@ -1868,7 +2245,7 @@ func (p *Parser) parseArrayLiteral(t tipe.Type) *expr.ArrayLiteral {
 
 func (p *Parser) parseSliceLiteral(t tipe.Type) *expr.SliceLiteral {
 	x := &expr.SliceLiteral{Position: p.pos(), Type: t.(*tipe.Slice)}
-	x.Keys, x.Values = p.parseKeyedLiteral()
+	x.Keys, x.Values = p.parseKeyedLiteral(nil, x.Type.Elem)
 	return x
 }
 
@@ -1881,7 +2258,7 @@ func (p *Parser) parseTableLiteral(t tipe.Type) *expr.TableLiteral {
 			p.next()
 			// column names: {|"x","y"|},
 			if len(x.ColNames) != 0 || len(x.Rows) != 0 {
-				p.errorf("column names can only appear at beginning of table literal")
+				p.errorf(ErrCompLit, "column names can only appear at beginning of table literal")
 			}
 			for p.s.Token > 0 && p.s.Token != token.Pipe {
 				x.ColNames = append(x.ColNames, p.parseExpr())
@@ -1917,55 +2294,93 @@ func (p *Parser) parseTableLiteral(t tipe.Type) *expr.TableLiteral {
 
 func (p *Parser) parseMapLiteral(t tipe.Type) *expr.MapLiteral {
 	x := &expr.MapLiteral{Position: p.pos(), Type: t}
-	x.Keys, x.Values = p.parseKeyedLiteral()
+	m := t.(*tipe.Map)
+	x.Keys, x.Values = p.parseKeyedLiteral(m.Key, m.Value)
 	return x
 }
 
 func (p *Parser) parseCompLiteral(t tipe.Type) *expr.CompLiteral {
 	x := &expr.CompLiteral{Position: p.pos(), Type: t}
-	x.Keys, x.Values = p.parseKeyedLiteral()
+	x.Keys, x.Values = p.parseKeyedLiteral(nil, nil)
 	return x
 }
 
-func (p *Parser) parseKeyedLiteral() (keys []expr.Expr, values []expr.Expr) {
+// parseKeyedLiteral parses the elements of a composite literal.
+// If keyType or elemType is non-nil, a key or element written as a
+// bare {...} is parsed as a literal of that type, so []T{{1, 2}} is
+// shorthand for []T{T{1, 2}}.
+func (p *Parser) parseKeyedLiteral(keyType, elemType tipe.Type) (keys []expr.Expr, values []expr.Expr) {
+	// Array and slice keys are integer indices, so a leading
+	// brace can only begin an element.
+	firstType := keyType
+	if firstType == nil {
+		firstType = elemType
+	}
 	p.next()
 	for p.s.Token > 0 && p.s.Token != token.RightBrace {
-		e := p.parseExpr()
+		e := p.parseElement(firstType)
 		if p.s.Token == token.Colon {
 			p.next()
-			v := p.parseExpr()
+			v := p.parseElement(elemType)
 
 			if len(values) > 0 && len(keys) == 0 {
-				p.errorf("mixture of keyed fields and value initializers")
-				continue
+				p.errorf(ErrCompLit, "mixture of keyed fields and value initializers")
+			} else {
+				keys = append(keys, e)
+				values = append(values, v)
 			}
-
-			keys = append(keys, e)
-			values = append(values, v)
 		} else {
 			if len(values) > 0 && len(keys) > 0 {
-				p.errorf("mixture of keyed fields and value initializers")
-				continue
+				p.errorf(ErrCompLit, "mixture of keyed fields and value initializers")
+			} else {
+				values = append(values, e)
 			}
-			values = append(values, e)
 		}
-		if p.s.Token != token.Comma {
+		if p.s.Token == token.Semicolon {
+			p.error(ErrMissingComma, "missing ',' before newline in composite literal")
+			p.next()
+			continue
+		}
+		if !p.expectCommaOr(token.RightBrace, "composite literal") {
 			break
 		}
-		p.next()
+		if p.s.Token == token.Comma {
+			p.next()
+		}
 	}
 	p.expect(token.RightBrace)
 	p.next()
 	return keys, values
 }
 
+// parseElement parses a composite literal key or element whose
+// type, if known, is t.
+func (p *Parser) parseElement(t tipe.Type) expr.Expr {
+	if p.s.Token != token.LeftBrace || t == nil {
+		return p.parseExpr()
+	}
+	if ptr, isPtr := t.(*tipe.Pointer); isPtr {
+		// []*T{{...}} is shorthand for []*T{&T{...}}
+		pos := p.pos()
+		return &expr.Unary{Position: pos, Op: token.Ref, Expr: p.parseLiteral(ptr.Elem)}
+	}
+	return p.parseLiteral(t)
+}
+
 type Errors []Error
 
 func (e Errors) Error() string {
+	width := 0
+	for _, err := range e {
+		if n := len(err.position()); n > width {
+			width = n
+		}
+	}
 	buf := new(bytes.Buffer)
 	buf.WriteString("neugram: parser errors:\n")
 	for _, err := range e {
-		fmt.Fprintf(buf, "off %5d: %v\n", err.Offset, err.Msg)
+		fmt.Fprintf(buf, "%-*s %v\n", width+1, err.position()+":", err.Msg)
+		buf.WriteString(err.excerpt())
 	}
 	return buf.String()
 }
@@ -1973,31 +2388,164 @@ func (e Errors) Error() string {
 type Error struct {
 	Pos    src.Pos
 	Offset int
+	Line   int // 1-based
+	Col    int // 1-based
+	Code   ErrorCode
 	Msg    string
+
+	SrcLine string // source line containing the error
+	Len     int    // byte length of the token at the error
 }
 
+// ErrorCode classifies a parser Error, so tools can filter errors
+// by kind without matching on the message.
+type ErrorCode int
+
+const (
+	ErrInternal         ErrorCode = iota + 1 // parser bug
+	ErrUnknownToken                          // scanner produced an unknown token
+	ErrExpectedToken                         // a specific token was required
+	ErrUnclosedParen                         // missing ')'
+	ErrMissingComma                          // missing ',' in a list
+	ErrExpectedOperand                       // missing expression operand
+	ErrExpectedType                          // missing or malformed type
+	ErrExpectedSelector                      // bad x.y or x.(T)
+	ErrExpectedCall                          // go or defer without a call
+	ErrExpectedCase                          // switch body without case
+	ErrExpectedExpr                          // statement where an expression is required
+	ErrExprCount                             // wrong number of expressions
+	ErrNonName                               // non-name on left side of :=
+	ErrEllipsis                              // misplaced ...
+	ErrTypeSwitch                            // misplaced .(type)
+	ErrRange                                 // misplaced range
+	ErrSliceIndex                            // missing 3-index slice index
+	ErrParams                                // mixed named and unnamed parameters
+	ErrRedeclared                            // duplicate field or method
+	ErrDecl                                  // malformed const or var declaration
+	ErrImportPath                            // invalid import path
+	ErrLabel                                 // missing or misplaced label
+	ErrElse                                  // misplaced else
+	ErrFallthrough                           // misplaced fallthrough
+	ErrMissingName                           // missing method name
+	ErrMissingFuncBody                       // function literal without body
+	ErrCompLit                               // malformed composite or table literal
+	ErrExpectedStmt                          // token cannot begin a statement
+	ErrMaxDepth                              // expressions nested too deeply
+	ErrUnexpectedEOF                         // source ends in a partial statement
+)
+
 func (e Error) Error() string {
-	return fmt.Sprintf("neugram: parser: %s (off %d)", e.Msg, e.Offset)
+	return fmt.Sprintf("neugram: parser: %s (%s)", e.Msg, e.position())
+}
+
+func (e Error) position() string {
+	return fmt.Sprintf("%s:%d:%d", e.Pos.Filename, e.Line, e.Col)
+}
+
+// maxExcerpt is the longest source line excerpt printed for an error.
+const maxExcerpt = 120
+
+// excerpt returns the error's source line with the token at the
+// error underlined, in the style:
+//
+//	x := f(a b)
+//	         ^
+//
+// Lines longer than maxExcerpt are trimmed around the error.
+func (e Error) excerpt() string {
+	line, col, n := e.SrcLine, e.Col-1, e.Len
+	if line == "" || col < 0 || col > len(line) {
+		return ""
+	}
+	prefix, suffix := "", ""
+	if len(line) > maxExcerpt {
+		lo := col - maxExcerpt/2
+		if lo < 0 {
+			lo = 0
+		}
+		hi := lo + maxExcerpt
+		if hi > len(line) {
+			hi = len(line)
+			lo = hi - maxExcerpt
+		}
+		for lo > 0 && !utf8.RuneStart(line[lo]) {
+			lo--
+		}
+		for hi < len(line) && !utf8.RuneStart(line[hi]) {
+			hi++
+		}
+		if lo > 0 {
+			prefix = "..."
+		}
+		if hi < len(line) {
+			suffix = "..."
+		}
+		line, col = line[lo:hi], col-lo
+	}
+	if col+n > len(line) {
+		n = len(line) - col
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "\t%s%s%s\n\t%s", prefix, line, suffix, strings.Repeat(" ", len(prefix)))
+	for _, r := range line[:col] {
+		if r == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	width := utf8.RuneCountInString(line[col : col+n])
+	if width == 0 {
+		width = 1
+	}
+	buf.WriteString(strings.Repeat("^", width))
+	buf.WriteByte('\n')
+	return buf.String()
 }
 
-func (p *Parser) errorf(format string, a ...interface{}) error {
-	return p.error(fmt.Sprintf(format, a...))
+func (p *Parser) errorf(code ErrorCode, format string, a ...interface{}) error {
+	return p.error(code, fmt.Sprintf(format, a...))
 }
 
-func (p *Parser) error(msg string) error {
+func (p *Parser) error(code ErrorCode, msg string) error {
 	err := Error{
 		Pos:    p.pos(),
 		Offset: p.s.Offset,
+		Line:   p.s.Line,
+		Col:    p.s.Col,
+		Code:   code,
 		Msg:    msg,
 	}
+	err.SrcLine, err.Len = p.s.tokenLine()
+	if n := len(p.res.Errs); n > 0 && p.res.Errs[n-1].Line == err.Line {
+		// Only report the first error on a line, later
+		// ones are likely spurious errors that follow from it.
+		return err
+	}
 	p.res.Errs = append(p.res.Errs, err)
 	return err
 }
 
+// scanError reports the error the scanner found in the current
+// token, and clears it.
+func (p *Parser) scanError() error {
+	msg := p.s.err.Error()
+	if err, ok := p.s.err.(scanError); ok {
+		msg = err.msg
+	}
+	p.s.err = nil
+	return p.error(ErrUnknownToken, msg)
+}
+
 func (p *Parser) expect(t token.Token) bool {
 	met := t == p.s.Token
 	if !met {
-		p.error(fmt.Sprintf("expected %q, found %q", t, p.s.Token))
+		code := ErrExpectedToken
+		if t == token.RightParen {
+			code = ErrUnclosedParen
+		}
+		p.errorf(code, "expected %q, found %q", t, p.s.Token)
 	}
 	return met
 }
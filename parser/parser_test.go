@@ -6,13 +6,19 @@ package parser_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"neugram.io/ng/format"
 	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax"
 	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
 	"neugram.io/ng/syntax/stmt"
 	"neugram.io/ng/syntax/tipe"
 	"neugram.io/ng/syntax/token"
@@ -141,6 +147,7 @@ var parserTests = []parserTest{
 				Params:  &tipe.Tuple{},
 				Results: &tipe.Tuple{Elems: []tipe.Type{tinteger}},
 			},
+			ResultNames: []string{""},
 			Body: &stmt.Block{Stmts: []stmt.Stmt{
 				&stmt.Return{Exprs: []expr.Expr{&expr.BasicLiteral{Value: big.NewInt(7)}}},
 			}},
@@ -169,6 +176,64 @@ var parserTests = []parserTest{
 			}},
 		},
 	},
+	{
+		"func(x val) (a, b val, err error) {}",
+		&expr.FuncLiteral{
+			Type: &tipe.Func{
+				Params: &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "val"}}},
+				Results: &tipe.Tuple{Elems: []tipe.Type{
+					&tipe.Unresolved{Name: "val"},
+					&tipe.Unresolved{Name: "val"},
+					&tipe.Unresolved{Name: "error"},
+				}},
+			},
+			ParamNames:  []string{"x"},
+			ResultNames: []string{"a", "b", "err"},
+			Body:        &stmt.Block{},
+		},
+	},
+	{
+		"func(x val) (val, error) {}",
+		&expr.FuncLiteral{
+			Type: &tipe.Func{
+				Params: &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "val"}}},
+				Results: &tipe.Tuple{Elems: []tipe.Type{
+					&tipe.Unresolved{Name: "val"},
+					&tipe.Unresolved{Name: "error"},
+				}},
+			},
+			ParamNames:  []string{"x"},
+			ResultNames: []string{"", ""},
+			Body:        &stmt.Block{},
+		},
+	},
+	{
+		"func(args ...val) {}",
+		&expr.FuncLiteral{
+			Type: &tipe.Func{
+				Params: &tipe.Tuple{Elems: []tipe.Type{
+					&tipe.Ellipsis{Elem: &tipe.Unresolved{Name: "val"}},
+				}},
+				Variadic: true,
+			},
+			ParamNames: []string{"args"},
+			Body:       &stmt.Block{},
+		},
+	},
+	{
+		"func(format string, args ...interface{}) {}",
+		&expr.FuncLiteral{
+			Type: &tipe.Func{
+				Params: &tipe.Tuple{Elems: []tipe.Type{
+					&tipe.Unresolved{Name: "string"},
+					&tipe.Ellipsis{Elem: &tipe.Interface{Methods: map[string]*tipe.Func{}}},
+				}},
+				Variadic: true,
+			},
+			ParamNames: []string{"format", "args"},
+			Body:       &stmt.Block{},
+		},
+	},
 	{
 		`func() int64 {
 			x := 7
@@ -238,7 +303,7 @@ var parserTests = []parserTest{
 					Params:  &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "val"}}},
 					Results: &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "val"}}},
 				},
-				ParamNames:  []string{""},
+				ParamNames:  []string{"x"},
 				ResultNames: []string{""},
 				Body: &stmt.Block{Stmts: []stmt.Stmt{
 					&stmt.Return{Exprs: []expr.Expr{
@@ -265,12 +330,32 @@ var parserTests = []parserTest{
 			}}},
 		},
 	},
+	{
+		"func(int, string) bool { return true }",
+		&expr.FuncLiteral{
+			Type: &tipe.Func{
+				Params: &tipe.Tuple{Elems: []tipe.Type{
+					&tipe.Unresolved{Name: "int"},
+					&tipe.Unresolved{Name: "string"},
+				}},
+				Results: &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "bool"}}},
+			},
+			ParamNames:  []string{"", ""},
+			ResultNames: []string{""},
+			Body: &stmt.Block{Stmts: []stmt.Stmt{
+				&stmt.Return{Exprs: []expr.Expr{&expr.Ident{Name: "true"}}},
+			}},
+		},
+	},
 	{"x.y.z", &expr.Selector{Left: &expr.Selector{Left: &expr.Ident{Name: "x"}, Right: &expr.Ident{Name: "y"}}, Right: &expr.Ident{Name: "z"}}},
 	{"y * /* comment */ z", &expr.Binary{Op: token.Mul, Left: &expr.Ident{Name: "y"}, Right: &expr.Ident{Name: "z"}}},
 	{"y * z//comment", &expr.Binary{Op: token.Mul, Left: &expr.Ident{Name: "y"}, Right: &expr.Ident{Name: "z"}}},
 	{`"hello"`, &expr.BasicLiteral{Value: "hello"}},
 	{`"hello \"neugram\""`, &expr.BasicLiteral{Value: `hello "neugram"`}},
+	{`"back\\" + "slash"`, &expr.Binary{Op: token.Add, Left: &expr.BasicLiteral{Value: `back\`}, Right: &expr.BasicLiteral{Value: "slash"}}},
+	{`f('\\', 'x')`, &expr.Call{Func: &expr.Ident{Name: "f"}, Args: []expr.Expr{&expr.BasicLiteral{Value: '\\'}, &expr.BasicLiteral{Value: 'x'}}}},
 	//TODO{`"\""`, &expr.BasicLiteral{Value:`"\""`}}
+	{"`raw \\n` + \"x\"", &expr.Binary{Op: token.Add, Left: &expr.BasicLiteral{Value: `raw \n`}, Right: &expr.BasicLiteral{Value: "x"}}},
 	{"x[4]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{basic(4)}}},
 	{"x[1+2]", &expr.Index{
 		Left: &expr.Ident{Name: "x"},
@@ -286,6 +371,8 @@ var parserTests = []parserTest{
 	{"x[1:]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{Low: basic(1)}}}},
 	{"x[:3]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{High: basic(3)}}}},
 	{"x[:]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{}}}},
+	{"x[1:3:5]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{Low: basic(1), High: basic(3), Max: basic(5)}}}},
+	{"x[:3:5]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{High: basic(3), Max: basic(5)}}}},
 	{"x[:,:]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{}, &expr.Slice{}}}},
 	{"x[1:,:3]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{Low: basic(1)}, &expr.Slice{High: basic(3)}}}},
 	{"x[1:3,5:7]", &expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Slice{Low: basic(1), High: basic(3)}, &expr.Slice{Low: basic(5), High: basic(7)}}}},
@@ -348,9 +435,79 @@ func TestParseExpr(t *testing.T) {
 				t.Errorf("ParseExpr(%q):\n%v", test.input, diff)
 			}
 		}
+
+		got, err = parser.ParseExpr([]byte(test.input))
+		if err != nil {
+			t.Errorf("ParseExpr(%q): error: %v", test.input, err)
+		} else if !parser.EqualExpr(got, test.want) {
+			t.Errorf("ParseExpr(%q):\n%v", test.input, format.Diff(test.want, got))
+		}
 	}
 }
 
+// FuzzParseExpr checks that ParseExpr returns an expression or an
+// error for any input, without panicking or looping forever.
+func FuzzParseExpr(f *testing.F) {
+	for _, test := range parserTests {
+		f.Add([]byte(test.input))
+	}
+	for _, test := range parserErrTests {
+		f.Add([]byte(test.input))
+	}
+	// Inputs that made the parser panic or loop.
+	for _, src := range []string{
+		`!"`,
+		`'`,
+		`func!A$0`,
+		`func!A..`,
+		`$$`,
+		`$$)`,
+		`$$ls${`,
+		`$$& &0& &`,
+		`$$ls | $$`,
+		`f(){}`,
+		`T{}{}`,
+		`map[string]int{"a": 1 "b"b": `,
+	} {
+		f.Add([]byte(src))
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		var e expr.Expr
+		var err error
+		done := make(chan struct{})
+		go func() {
+			e, err = parser.ParseExpr(src)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("ParseExpr(%q) does not return", src)
+		}
+		if (e == nil) == (err == nil) {
+			t.Fatalf("ParseExpr(%q) = %v, %v, want an expression or an error", src, e, err)
+		}
+		if err == nil {
+			syntax.Walk(e, func(c *syntax.Cursor) bool {
+				if bad, isBad := c.Node.(*expr.Bad); isBad {
+					t.Fatalf("ParseExpr(%q) = %s with no error", src, format.Debug(bad))
+				}
+				return true
+			}, nil)
+			return
+		}
+		errs, isErrors := err.(parser.Errors)
+		if !isErrors {
+			t.Fatalf("ParseExpr(%q) error is a %T, want parser.Errors", src, err)
+		}
+		for _, err := range errs {
+			if err.Code == parser.ErrInternal {
+				t.Fatalf("ParseExpr(%q): %v", src, err)
+			}
+		}
+	})
+}
+
 type parserErrTest struct {
 	input     string
 	errsubstr string
@@ -358,6 +515,44 @@ type parserErrTest struct {
 
 var parserErrTests = []parserErrTest{
 	{`\`, `unknown token: '\'`},
+	{`x.)`, `expected selector or type assertion, found )`},
+	{`x.y.;`, `expected selector or type assertion, found ;`},
+	{`x.()`, `expected type, got )`},
+	{`y := x.(type)`, `use of .(type) outside type switch`},
+	{`f := func()`, `missing function body`},
+	{`f := func(x int) int; x`, `missing function body`},
+	{`if x y`, `expected '{' after if clause, found ident`},
+	{`if x {} else y`, `else must be followed by if or statement block`},
+	{`else {}`, `else without matching if`},
+	{`func() { return a, }`, `expected operand, got }`},
+	{`a.b := x`, `non-name a.b on left side of :=`},
+	{`x, y[0] := 1, 2`, `non-name y[0] on left side of :=`},
+	{`const x`, `missing init expr for const declaration`},
+	{`import ""`, `invalid import path`},
+	{`switch x { y; case 1: }`, `got token "ident", want "case" or "default"`},
+	{`switch x.(type) { y; case int: }`, `got token "ident", want "case" or "default"`},
+	{`switch x.(type) { case int string: }`, `missing ',' in type switch case`},
+	{`func() { goto }`, `missing label in goto statement`},
+	{`fallthrough L`, `fallthrough statement cannot have a label`},
+	{"const (\n\tx\n)", `missing init expr for const declaration`},
+	{"const (\n\tx, y = 1, 2\n\tz\n)", `extra expression in const declaration`},
+	{`defer x+1`, `defer requires a function call`},
+	{`defer f`, `defer requires a function call`},
+	{`go x+1`, `go requires a function call`},
+	{`go f`, `go requires a function call`},
+	{`f(a..., b)`, `can only use ... with final argument in list`},
+	{`f(a..., b...)`, `can only use ... with final argument in list`},
+	{`a, b <- x`, `expected one expression`},
+	{`func() (a int, error) {}`, `function signature mixes named and unnamed arguments`},
+	{`func(a ...int, b int) {}`, `can only use ... with final parameter in list`},
+	{`func(a, b ...int) {}`, `can only use ... with final parameter in list`},
+	{`func() (a ...int) {}`, `cannot use ... in result parameter list`},
+	{`x[1::5]`, `middle index required in 3-index slice`},
+	{`x[:3:]`, `final index required in 3-index slice`},
+	{`x[1:2:3:4]`, `expected ",", found ":"`},
+	{`x[1 2]`, `expected ":", found "integer"`},
+	{`map[string]int{"a": 1 "b": 2}`, `missing ',' in composite literal`},
+	{"x := map[string]int{\n\t\"a\": 1\n}", `missing ',' before newline in composite literal`},
 }
 
 func TestParseError(t *testing.T) {
@@ -374,6 +569,330 @@ func TestParseError(t *testing.T) {
 	}
 }
 
+// scanErrTests are errors the scanner finds in literals.
+var scanErrTests = []parserErrTest{
+	{`!08`, `bad int literal: "08"`},
+	{`-0b12`, `invalid digit '2' in binary literal`},
+	{`!1_`, `'_' must separate successive digits in "1_"`},
+	{`0b12`, `invalid digit '2' in binary literal`},
+	{`1_`, `'_' must separate successive digits in "1_"`},
+	{`1e`, `exponent has no digits`},
+	{`f(1, 1e)`, `exponent has no digits`},
+	{`"abc`, `string literal missing terminating '"'`},
+	{`"a\qb"`, `invalid string literal "a\qb"`},
+	{`'a`, `character literal missing terminating "'"`},
+	{`'\q'`, `invalid rune literal '\q'`},
+}
+
+func TestParseScanError(t *testing.T) {
+	for _, test := range scanErrTests {
+		check := func(fn string, err error) {
+			if err == nil {
+				t.Errorf("%s(%q): missing expected error", fn, test.input)
+			} else if got := err.Error(); !strings.Contains(got, test.errsubstr) {
+				t.Errorf("%s(%q): error %q does not contain %q", fn, test.input, got, test.errsubstr)
+			}
+		}
+		_, err := parser.ParseStmt([]byte(test.input))
+		check("ParseStmt", err)
+		_, err = parser.ParseExpr([]byte(test.input))
+		check("ParseExpr", err)
+		_, err = parser.New("scan.ng").Parse([]byte("x := " + test.input + "\ny := 2\n"))
+		check("Parse", err)
+	}
+}
+
+var parserErrCodeTests = []struct {
+	input string
+	code  parser.ErrorCode
+}{
+	{`f(a b)`, parser.ErrMissingComma},
+	{`x + )`, parser.ErrExpectedOperand},
+	{`(x + y]`, parser.ErrUnclosedParen},
+	{`if x y`, parser.ErrExpectedToken},
+	{`go f`, parser.ErrExpectedCall},
+	{`a.b := x`, parser.ErrNonName},
+	{`const x`, parser.ErrDecl},
+	{`f := func()`, parser.ErrMissingFuncBody},
+}
+
+func TestParseErrorCode(t *testing.T) {
+	for _, test := range parserErrCodeTests {
+		_, err := parser.ParseStmt([]byte(test.input + "\n"))
+		errs, ok := err.(parser.Errors)
+		if !ok || len(errs) == 0 {
+			t.Errorf("ParseStmt(%q): error %v, want parser.Errors", test.input, err)
+			continue
+		}
+		if got := errs[0].Code; got != test.code {
+			t.Errorf("ParseStmt(%q): code %d (%s), want %d", test.input, got, errs[0].Msg, test.code)
+		}
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	src := `x := )
+y := 2
+f(a b, (c d))
+func g() {
+	z := 3 + ]
+	return z
+}
+h := 4
+if x y {
+	h++
+}
+print(h)
+`
+	f, err := parser.New("recover.ng").Parse([]byte(src))
+	errs, ok := err.(parser.Errors)
+	if !ok {
+		t.Fatalf("Parse error = %v (%T), want parser.Errors", err, err)
+	}
+	wantLines := []int{1, 3, 5, 9}
+	if len(errs) != len(wantLines) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(wantLines), errs)
+	}
+	for i, e := range errs {
+		if e.Line != wantLines[i] {
+			t.Errorf("error %d on line %d, want line %d: %s", i, e.Line, wantLines[i], e.Msg)
+		}
+	}
+	if got, want := len(f.Stmts), 7; got != want {
+		t.Errorf("parsed %d statements, want %d", got, want)
+	}
+}
+
+func TestParseErrorExcerpt(t *testing.T) {
+	long := strings.Repeat("a + ", 40)
+	src := "f(a bcd)\n" +
+		"\tif x y {\n\t}\n" +
+		"g := \"αβ\" \"γ\"\n" +
+		"x := " + long + "b c + " + long + "1\n"
+	_, err := parser.New("excerpt.ng").Parse([]byte(src))
+	errs, ok := err.(parser.Errors)
+	if !ok {
+		t.Fatalf("Parse error = %v (%T), want parser.Errors", err, err)
+	}
+	lines := strings.Split(errs.Error(), "\n")
+	var got []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "\t") {
+			got = append(got, line)
+		}
+	}
+	longLine := "x := " + long + "b c + " + long + "1"
+	col := strings.Index(longLine, "c + ")
+	lo := col - 60
+	want := []string{
+		"\tf(a bcd)",
+		"\t    ^^^",
+		"\t\tif x y {",
+		"\t\t     ^",
+		"\tg := \"αβ\" \"γ\"",
+		"\t          ^^^",
+		"\t..." + longLine[lo:lo+120] + "...",
+		"\t" + strings.Repeat(" ", 63) + "^",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d excerpt lines, want %d:\n%s", len(got), len(want), errs)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("excerpt line %d:\n got %q\nwant %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ng-parsefile-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "f.ng")
+	src := "import \"fmt\"\nimport (\n\tstr \"strings\"\n\t\"os\"\n)\nx := 1\n"
+	if err := ioutil.WriteFile(filename, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Filename != filename {
+		t.Errorf("Filename = %q, want %q", f.Filename, filename)
+	}
+	if len(f.Stmts) != 3 {
+		t.Errorf("got %d statements, want 3", len(f.Stmts))
+	}
+	var imports []string
+	for _, imp := range f.Imports {
+		imports = append(imports, imp.Name+" "+imp.Path)
+	}
+	if got, want := strings.Join(imports, ", "), " fmt, str strings,  os"; got != want {
+		t.Errorf("Imports = %q, want %q", got, want)
+	}
+	if pos := f.Stmts[2].Pos(); pos.Filename != filename || pos.Line != 6 {
+		t.Errorf("x := 1 at %s:%d, want %s:6", pos.Filename, pos.Line, filename)
+	}
+
+	_, err = parser.ParseFile("src.ng", []byte("x := )\n"))
+	if err == nil || !strings.Contains(err.Error(), "(src.ng:1:6)") {
+		t.Errorf("ParseFile error = %v, want it to name src.ng:1:6", err)
+	}
+
+	if _, err := parser.ParseFile(filepath.Join(dir, "missing.ng"), nil); !os.IsNotExist(err) {
+		t.Errorf("ParseFile of missing file: %v, want not-exist error", err)
+	}
+}
+
+var parseTypeTests = []struct {
+	input string
+	want  tipe.Type
+}{
+	{"int", &tipe.Unresolved{Name: "int"}},
+	{"io.Reader", &tipe.Unresolved{Package: "io", Name: "Reader"}},
+	{"num", tipe.Num},
+	{"map[string][]float64", &tipe.Map{
+		Key:   &tipe.Unresolved{Name: "string"},
+		Value: &tipe.Slice{Elem: &tipe.Unresolved{Name: "float64"}},
+	}},
+	{"*[4]T", &tipe.Pointer{Elem: &tipe.Array{Len: 4, Elem: &tipe.Unresolved{Name: "T"}}}},
+	{"chan (<-chan int)", &tipe.Chan{Elem: &tipe.Chan{Direction: tipe.ChanRecv, Elem: &tipe.Unresolved{Name: "int"}}}},
+	{"chan<- chan int", &tipe.Chan{Direction: tipe.ChanSend, Elem: &tipe.Chan{Elem: &tipe.Unresolved{Name: "int"}}}},
+	{"[|]bool", &tipe.Table{Type: &tipe.Unresolved{Name: "bool"}}},
+}
+
+var parseTypeErrTests = []struct {
+	input     string
+	errsubstr string
+}{
+	{"", "expected type expression"},
+	{")", "expected type expression, got )"},
+	{"1", "expected type expression, got integer"},
+	{"int)", "unexpected ) after type"},
+	{"int string", "unexpected ident after type"},
+	{"map[string]", "expected type, got ;"},
+}
+
+func TestParseType(t *testing.T) {
+	for _, test := range parseTypeTests {
+		got, err := parser.ParseType([]byte(test.input))
+		if err != nil {
+			t.Errorf("ParseType(%q): %v", test.input, err)
+			continue
+		}
+		if !tipe.EqualUnresolved(got, test.want) {
+			t.Errorf("ParseType(%q) = %s, want %s", test.input, format.Type(got), format.Type(test.want))
+		}
+	}
+	for _, test := range parseTypeErrTests {
+		_, err := parser.ParseType([]byte(test.input))
+		if err == nil {
+			t.Errorf("ParseType(%q): missing expected error", test.input)
+			continue
+		}
+		if got := err.Error(); !strings.Contains(got, test.errsubstr) {
+			t.Errorf("ParseType(%q): error %q does not contain %q", test.input, got, test.errsubstr)
+		}
+	}
+}
+
+func nestedCalls(n int) string {
+	return strings.Repeat("f(", n) + "x" + strings.Repeat(")", n)
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	if _, err := parser.ParseStmt([]byte(nestedCalls(100))); err != nil {
+		t.Errorf("100 nested calls: %v", err)
+	}
+
+	for _, src := range []string{nestedCalls(1000), strings.Repeat("!", 2000) + "x"} {
+		_, err := parser.ParseStmt([]byte(src))
+		errs, ok := err.(parser.Errors)
+		if !ok || len(errs) != 1 || errs[0].Code != parser.ErrMaxDepth {
+			t.Errorf("%.10s...: error %v, want one ErrMaxDepth", src, err)
+			continue
+		}
+		if want := "maximum nesting depth exceeded"; errs[0].Msg != want {
+			t.Errorf("%.10s...: error %q, want %q", src, errs[0].Msg, want)
+		}
+	}
+
+	if _, err := parser.ParseStmt([]byte(nestedCalls(1000)), parser.MaxDepth(10000)); err != nil {
+		t.Errorf("1000 nested calls with MaxDepth(10000): %v", err)
+	}
+	if _, err := parser.ParseStmt([]byte("f(f(f(x)))"), parser.MaxDepth(6)); err == nil {
+		t.Error("f(f(f(x))) with MaxDepth(6): missing error")
+	}
+
+	// The depth of one statement does not carry over to the next.
+	src := nestedCalls(300) + "\n" + nestedCalls(300) + "\n"
+	if _, err := parser.New("depth.ng").Parse([]byte(src)); err != nil {
+		t.Errorf("two statements of 300 nested calls: %v", err)
+	}
+}
+
+var parseStmtTrailingTests = []struct {
+	input string
+	err   string // empty for success
+}{
+	{"x := 1", ""},
+	{"x := 1;", ""},
+	{"x := 1\n\n", ""},
+	{"x := 1 // comment", ""},
+	{"x := 1 /* comment */ ", ""},
+	{"if x {\n\ty()\n}", ""},
+	{"x := 1; y := 2", "unexpected content after statement on line 1"},
+	{"x := 1\nf()", "unexpected content after statement on line 2"},
+	{"x := 1 y", `expected ";", found "ident"`},
+	{"", "no statement"},
+	{" \t", "no statement"},
+	{"if x {", "partial statement"},
+}
+
+func TestParseStmtTrailing(t *testing.T) {
+	for _, test := range parseStmtTrailingTests {
+		_, err := parser.ParseStmt([]byte(test.input))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("ParseStmt(%q): %v", test.input, err)
+		case test.err != "" && err == nil:
+			t.Errorf("ParseStmt(%q): missing error %q", test.input, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("ParseStmt(%q): error %q does not contain %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestParseErrorPos(t *testing.T) {
+	p := parser.New("errpos.ng")
+	_, err := p.Parse([]byte("x := 1\nif x y {\n}\n"))
+	perr, ok := err.(parser.Error)
+	if !ok {
+		t.Fatalf("Parse error = %v (%T), want parser.Error", err, err)
+	}
+	if perr.Line != 2 || perr.Col != 6 {
+		t.Errorf("error %q at %d:%d, want 2:6", perr.Msg, perr.Line, perr.Col)
+	}
+	if got, want := perr.Error(), "neugram: parser: expected '{' after if clause, found ident (errpos.ng:2:6)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	errs := parser.Errors{
+		{Pos: src.Pos{Filename: "f.ng"}, Line: 3, Col: 7, Msg: "first"},
+		{Pos: src.Pos{Filename: "f.ng"}, Line: 12, Col: 10, Msg: "second"},
+	}
+	want := "neugram: parser errors:\n" +
+		"f.ng:3:7:   first\n" +
+		"f.ng:12:10: second\n"
+	if got := errs.Error(); got != want {
+		t.Errorf("Errors.Error() = %q, want %q", got, want)
+	}
+}
+
 var shellTests = []parserTest{
 	{``, &expr.Shell{}},
 	{`ls -l`, simplesh("ls", "-l")},
@@ -669,6 +1188,50 @@ var stmtTests = []stmtTest{
 	{"for ;; {}", &stmt.For{Body: &stmt.Block{}}},
 	{"for true {}", &stmt.For{Cond: &expr.Ident{Name: "true"}, Body: &stmt.Block{}}},
 	{"for ; true; {}", &stmt.For{Cond: &expr.Ident{Name: "true"}, Body: &stmt.Block{}}},
+	{"for i := 0;; {}", &stmt.For{
+		Init: &stmt.Assign{
+			Decl:  true,
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{basic(0)},
+		},
+		Body: &stmt.Block{},
+	}},
+	{"for ;; i++ {}", &stmt.For{
+		Post: &stmt.Assign{
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{&expr.Binary{Op: token.Add, Left: &expr.Ident{Name: "i"}, Right: basic(1)}},
+		},
+		Body: &stmt.Block{},
+	}},
+	{"for i := 0; c; {}", &stmt.For{
+		Init: &stmt.Assign{
+			Decl:  true,
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{basic(0)},
+		},
+		Cond: &expr.Ident{Name: "c"},
+		Body: &stmt.Block{},
+	}},
+	{"for ; c; i++ {}", &stmt.For{
+		Cond: &expr.Ident{Name: "c"},
+		Post: &stmt.Assign{
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{&expr.Binary{Op: token.Add, Left: &expr.Ident{Name: "i"}, Right: basic(1)}},
+		},
+		Body: &stmt.Block{},
+	}},
+	{"for i := 0;; i++ {}", &stmt.For{
+		Init: &stmt.Assign{
+			Decl:  true,
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{basic(0)},
+		},
+		Post: &stmt.Assign{
+			Left:  []expr.Expr{&expr.Ident{Name: "i"}},
+			Right: []expr.Expr{&expr.Binary{Op: token.Add, Left: &expr.Ident{Name: "i"}, Right: basic(1)}},
+		},
+		Body: &stmt.Block{},
+	}},
 	{"for range x {}", &stmt.Range{Expr: &expr.Ident{Name: "x"}, Body: &stmt.Block{}}},
 	{"for k, v := range x {}", &stmt.Range{
 		Key:  &expr.Ident{Name: "k"},
@@ -804,11 +1367,58 @@ var stmtTests = []stmtTest{
 			},
 		},
 	},
+	{
+		`const (
+			x int64 = iota
+			y
+			_
+		)`,
+		&stmt.ConstSet{
+			Consts: []*stmt.Const{
+				{NameList: []string{"x"}, Type: tint64, Values: []expr.Expr{&expr.Ident{Name: "iota"}}},
+				{NameList: []string{"y"}, Type: tint64, Values: []expr.Expr{&expr.Ident{Name: "iota"}}},
+				{NameList: []string{"_"}, Type: tint64, Values: []expr.Expr{&expr.Ident{Name: "iota"}}},
+			},
+		},
+	},
 	{"x.y", &stmt.Simple{Expr: &expr.Selector{Left: &expr.Ident{Name: "x"}, Right: &expr.Ident{Name: "y"}}}},
+	{`import "fmt"`, &stmt.Import{Path: "fmt"}},
+	{"import `fmt`", &stmt.Import{Path: "fmt"}},
+	{
+		`import (
+			"fmt"
+			_ "net/http/pprof"
+			. "math"
+			str "strings"
+		)`,
+		&stmt.ImportSet{Imports: []*stmt.Import{
+			{Path: "fmt"},
+			{Name: "_", Path: "net/http/pprof"},
+			{Name: ".", Path: "math"},
+			{Name: "str", Path: "strings"},
+		}},
+	},
 	{
 		`type A integer`,
 		&stmt.TypeDecl{Name: "A", Type: &tipe.Named{Name: "A", Type: tinteger}},
 	},
+	{
+		`type B = []int`,
+		&stmt.TypeDecl{Name: "B", Alias: &tipe.Alias{
+			Name: "B",
+			Type: &tipe.Slice{Elem: &tipe.Unresolved{Name: "int"}},
+		}},
+	},
+	{
+		`type (
+			C = int
+			D C
+		)`,
+		&stmt.TypeDeclSet{TypeDecls: []*stmt.TypeDecl{
+			{Name: "C", Alias: &tipe.Alias{Name: "C", Type: &tipe.Unresolved{Name: "int"}}},
+			{Name: "D", Type: &tipe.Named{Name: "D", Type: &tipe.Unresolved{Name: "C"}}},
+		}},
+	},
 	{
 		"type Array [2]int",
 		&stmt.TypeDecl{
@@ -863,6 +1473,7 @@ var stmtTests = []stmtTest{
 	{"type T struct { A string `json` }", &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{{
 				Name: "A",
 				Type: &tipe.Unresolved{Name: "string"},
@@ -870,9 +1481,20 @@ var stmtTests = []stmtTest{
 			}}},
 		},
 	}},
+	{"type T struct { A, B string `json` }", &stmt.TypeDecl{
+		Name: "T",
+		Type: &tipe.Named{
+			Name: "T",
+			Type: &tipe.Struct{Fields: []tipe.StructField{
+				{Name: "A", Type: &tipe.Unresolved{Name: "string"}, Tag: `json`},
+				{Name: "B", Type: &tipe.Unresolved{Name: "string"}, Tag: `json`},
+			}},
+		},
+	}},
 	{"type T struct { A string \"json\" }", &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{{
 				Name: "A",
 				Type: &tipe.Unresolved{Name: "string"},
@@ -883,6 +1505,7 @@ var stmtTests = []stmtTest{
 	{"type T struct { A string `json:\"a\"` }", &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{{
 				Name: "A",
 				Type: &tipe.Unresolved{Name: "string"},
@@ -897,6 +1520,7 @@ var stmtTests = []stmtTest{
 	}`, &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{
 				{
 					Name: "_",
@@ -916,6 +1540,7 @@ var stmtTests = []stmtTest{
 	{`type T struct { X, Y int }`, &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{
 				{Name: "X", Type: &tipe.Unresolved{Name: "int"}},
 				{Name: "Y", Type: &tipe.Unresolved{Name: "int"}},
@@ -927,6 +1552,7 @@ var stmtTests = []stmtTest{
 	}`, &stmt.TypeDecl{
 		Name: "T",
 		Type: &tipe.Named{
+			Name: "T",
 			Type: &tipe.Struct{Fields: []tipe.StructField{
 				{Name: "X", Type: &tipe.Unresolved{Name: "int"}},
 				{Name: "Y", Type: &tipe.Unresolved{Name: "int"}},
@@ -959,6 +1585,7 @@ var stmtTests = []stmtTest{
 		&stmt.MethodikDecl{
 			Name: "AnInt",
 			Type: &tipe.Named{
+				Name:        "AnInt",
 				Type:        tinteger,
 				MethodNames: []string{"f"},
 				Methods: []*tipe.Func{{
@@ -973,6 +1600,7 @@ var stmtTests = []stmtTest{
 					Params:  &tipe.Tuple{},
 					Results: &tipe.Tuple{Elems: []tipe.Type{tinteger}},
 				},
+				ResultNames: []string{""},
 				Body: &stmt.Block{Stmts: []stmt.Stmt{
 					&stmt.Return{Exprs: []expr.Expr{&expr.Ident{Name: "a"}}},
 				}},
@@ -984,7 +1612,7 @@ var stmtTests = []stmtTest{
 			x integer
 			y [|]int64
 		} {
-			func (a) f(x integer) integer {
+			func (*a) f(x integer) integer {
 				return a.x
 			}
 		}
@@ -992,6 +1620,7 @@ var stmtTests = []stmtTest{
 		&stmt.MethodikDecl{
 			Name: "T",
 			Type: &tipe.Named{
+				Name: "T",
 				Type: &tipe.Pointer{Elem: &tipe.Struct{Fields: []tipe.StructField{
 					{Name: "x", Type: tinteger},
 					{Name: "y", Type: &tipe.Table{Type: tint64}},
@@ -1010,7 +1639,8 @@ var stmtTests = []stmtTest{
 					Params:  &tipe.Tuple{Elems: []tipe.Type{tinteger}},
 					Results: &tipe.Tuple{Elems: []tipe.Type{tinteger}},
 				},
-				ParamNames: []string{"x"},
+				ParamNames:  []string{"x"},
+				ResultNames: []string{""},
 				Body: &stmt.Block{Stmts: []stmt.Stmt{
 					&stmt.Return{Exprs: []expr.Expr{&expr.Selector{
 						Left:  &expr.Ident{Name: "a"},
@@ -1030,16 +1660,76 @@ var stmtTests = []stmtTest{
 		Keys:   []expr.Expr{basic("foo")},
 		Values: []expr.Expr{basic("bar")},
 	}}},
+	{"map[string]int{\n\t\"a\": 1,\n\t\"b\": 2,\n}", &stmt.Simple{Expr: &expr.MapLiteral{
+		Type:   &tipe.Map{Key: &tipe.Unresolved{Name: "string"}, Value: &tipe.Unresolved{Name: "int"}},
+		Keys:   []expr.Expr{basic("a"), basic("b")},
+		Values: []expr.Expr{basic(1), basic(2)},
+	}}},
 	{"x.y", &stmt.Simple{Expr: &expr.Selector{Left: &expr.Ident{Name: "x"}, Right: &expr.Ident{Name: "y"}}}},
 	{"sync.Mutex{}", &stmt.Simple{Expr: &expr.CompLiteral{
 		Type: &tipe.Unresolved{Package: "sync", Name: "Mutex"},
 	}}},
+	{"[]T{{1, 2}, {X: 3},}", &stmt.Simple{Expr: &expr.SliceLiteral{
+		Type: &tipe.Slice{Elem: &tipe.Unresolved{Name: "T"}},
+		Values: []expr.Expr{
+			&expr.CompLiteral{
+				Type:   &tipe.Unresolved{Name: "T"},
+				Values: []expr.Expr{basic(1), basic(2)},
+			},
+			&expr.CompLiteral{
+				Type:   &tipe.Unresolved{Name: "T"},
+				Keys:   []expr.Expr{&expr.Ident{Name: "X"}},
+				Values: []expr.Expr{basic(3)},
+			},
+		},
+	}}},
+	{"[]*T{{}}", &stmt.Simple{Expr: &expr.SliceLiteral{
+		Type: &tipe.Slice{Elem: &tipe.Pointer{Elem: &tipe.Unresolved{Name: "T"}}},
+		Values: []expr.Expr{&expr.Unary{
+			Op:   token.Ref,
+			Expr: &expr.CompLiteral{Type: &tipe.Unresolved{Name: "T"}},
+		}},
+	}}},
+	{`map[string][]int{"a": {1}}`, &stmt.Simple{Expr: &expr.MapLiteral{
+		Type: &tipe.Map{
+			Key:   &tipe.Unresolved{Name: "string"},
+			Value: &tipe.Slice{Elem: &tipe.Unresolved{Name: "int"}},
+		},
+		Keys: []expr.Expr{basic("a")},
+		Values: []expr.Expr{&expr.SliceLiteral{
+			Type:   &tipe.Slice{Elem: &tipe.Unresolved{Name: "int"}},
+			Values: []expr.Expr{basic(1)},
+		}},
+	}}},
 	{"_ = 5", &stmt.Assign{Left: []expr.Expr{&expr.Ident{Name: "_"}}, Right: []expr.Expr{basic(5)}}},
+	{"_, err := f()", &stmt.Assign{
+		Decl:  true,
+		Left:  []expr.Expr{&expr.Ident{Name: "_"}, &expr.Ident{Name: "err"}},
+		Right: []expr.Expr{&expr.Call{Func: &expr.Ident{Name: "f"}}},
+	}},
 	{"x, _ := 4, 5", &stmt.Assign{
 		Decl:  true,
 		Left:  []expr.Expr{&expr.Ident{Name: "x"}, &expr.Ident{Name: "_"}},
 		Right: []expr.Expr{basic(4), basic(5)},
 	}},
+	{"a, b = b, a", &stmt.Assign{
+		Left:  []expr.Expr{&expr.Ident{Name: "a"}, &expr.Ident{Name: "b"}},
+		Right: []expr.Expr{&expr.Ident{Name: "b"}, &expr.Ident{Name: "a"}},
+	}},
+	{"x[i], x[j] = x[j], x[i]", &stmt.Assign{
+		Left: []expr.Expr{
+			&expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Ident{Name: "i"}}},
+			&expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Ident{Name: "j"}}},
+		},
+		Right: []expr.Expr{
+			&expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Ident{Name: "j"}}},
+			&expr.Index{Left: &expr.Ident{Name: "x"}, Indicies: []expr.Expr{&expr.Ident{Name: "i"}}},
+		},
+	}},
+	{"a, b, c = f()", &stmt.Assign{
+		Left:  []expr.Expr{&expr.Ident{Name: "a"}, &expr.Ident{Name: "b"}, &expr.Ident{Name: "c"}},
+		Right: []expr.Expr{&expr.Call{Func: &expr.Ident{Name: "f"}}},
+	}},
 	{`if x == y && y == z {}`, &stmt.If{
 		Cond: &expr.Binary{
 			Op:    token.LogicalAnd,
@@ -1059,6 +1749,22 @@ var stmtTests = []stmtTest{
 		},
 		Body: &stmt.Block{},
 	}},
+	{`if ; x {} else if y := f(); y {} else { z }`, &stmt.If{
+		Cond: &expr.Ident{Name: "x"},
+		Body: &stmt.Block{},
+		Else: &stmt.If{
+			Init: &stmt.Assign{
+				Decl:  true,
+				Left:  []expr.Expr{&expr.Ident{Name: "y"}},
+				Right: []expr.Expr{&expr.Call{Func: &expr.Ident{Name: "f"}}},
+			},
+			Cond: &expr.Ident{Name: "y"},
+			Body: &stmt.Block{},
+			Else: &stmt.Block{Stmts: []stmt.Stmt{
+				&stmt.Simple{Expr: &expr.Ident{Name: "z"}},
+			}},
+		},
+	}},
 	{
 		`f(x, // a comment
 		y)`,
@@ -1085,7 +1791,22 @@ var stmtTests = []stmtTest{
 			Body: &stmt.Block{},
 		},
 	}}},
+	{`go f.g(x)`, &stmt.Go{Call: &expr.Call{
+		Func: &expr.Selector{
+			Left:  &expr.Ident{Name: "f"},
+			Right: &expr.Ident{Name: "g"},
+		},
+		Args: []expr.Expr{&expr.Ident{Name: "x"}},
+	}}},
 	{"switch {}", &stmt.Switch{}},
+	{"switch ; {}", &stmt.Switch{}},
+	{"switch x := f(); {}", &stmt.Switch{
+		Init: &stmt.Assign{
+			Decl:  true,
+			Left:  []expr.Expr{&expr.Ident{Name: "x"}},
+			Right: []expr.Expr{&expr.Call{Func: &expr.Ident{Name: "f"}}},
+		},
+	}},
 	{`switch {
 	case true:
 		print(true)
@@ -1450,8 +2171,23 @@ var stmtTests = []stmtTest{
 			},
 		},
 	},
+	{"break", &stmt.Branch{Type: token.Break}},
+	{"continue outer", &stmt.Branch{Type: token.Continue, Label: "outer"}},
+	{"goto done", &stmt.Branch{Type: token.Goto, Label: "done"}},
+	{"fallthrough", &stmt.Branch{Type: token.Fallthrough}},
 	{"return", &stmt.Return{}},
 	{"return 1", &stmt.Return{Exprs: []expr.Expr{&expr.BasicLiteral{Value: big.NewInt(1)}}}},
+	{"return (a+b), c", &stmt.Return{Exprs: []expr.Expr{
+		&expr.Unary{
+			Op:   token.LeftParen,
+			Expr: &expr.Binary{Op: token.Add, Left: &expr.Ident{Name: "a"}, Right: &expr.Ident{Name: "b"}},
+		},
+		&expr.Ident{Name: "c"},
+	}}},
+	{"if x { return }", &stmt.If{
+		Cond: &expr.Ident{Name: "x"},
+		Body: &stmt.Block{Stmts: []stmt.Stmt{&stmt.Return{}}},
+	}},
 	{"{ return }", &stmt.Block{Stmts: []stmt.Stmt{&stmt.Return{}}}},
 	{"{ return 1 }", &stmt.Block{Stmts: []stmt.Stmt{&stmt.Return{Exprs: []expr.Expr{&expr.BasicLiteral{Value: big.NewInt(1)}}}}}},
 	{"var i = 10", &stmt.Var{
@@ -1491,6 +2227,30 @@ var stmtTests = []stmtTest{
 		NameList: []string{"i"},
 		Type:     &tipe.Chan{Elem: &tipe.Unresolved{Name: "int"}},
 	}},
+	{"var i <-chan int", &stmt.Var{
+		NameList: []string{"i"},
+		Type:     &tipe.Chan{Direction: tipe.ChanRecv, Elem: &tipe.Unresolved{Name: "int"}},
+	}},
+	{"var i chan<- int = c", &stmt.Var{
+		NameList: []string{"i"},
+		Type:     &tipe.Chan{Direction: tipe.ChanSend, Elem: &tipe.Unresolved{Name: "int"}},
+		Values:   []expr.Expr{&expr.Ident{Name: "c"}},
+	}},
+	{"var i *int", &stmt.Var{
+		NameList: []string{"i"},
+		Type:     &tipe.Pointer{Elem: &tipe.Unresolved{Name: "int"}},
+	}},
+	{"var i func() error", &stmt.Var{
+		NameList: []string{"i"},
+		Type: &tipe.Func{
+			Params:  &tipe.Tuple{},
+			Results: &tipe.Tuple{Elems: []tipe.Type{&tipe.Unresolved{Name: "error"}}},
+		},
+	}},
+	{"var i interface{}", &stmt.Var{
+		NameList: []string{"i"},
+		Type:     &tipe.Interface{Methods: map[string]*tipe.Func{}},
+	}},
 	{"var i []int", &stmt.Var{
 		NameList: []string{"i"},
 		Type:     &tipe.Slice{Elem: &tipe.Unresolved{Name: "int"}},
@@ -1593,11 +2353,19 @@ var stmtTests = []stmtTest{
 	{"0xdeadbeef", &stmt.Simple{Expr: basic(0xdeadbeef)}},
 	{"0xDEADBEEF", &stmt.Simple{Expr: basic(0xDEADBEEF)}},
 	{"0xdEadb33f", &stmt.Simple{Expr: basic(0xdEadb33f)}},
-	{"0X0", &stmt.Simple{Expr: basic(0X0)}},
-	{"0X1", &stmt.Simple{Expr: basic(0X1)}},
-	{"0Xdeadbeef", &stmt.Simple{Expr: basic(0Xdeadbeef)}},
-	{"0XDEADBEEF", &stmt.Simple{Expr: basic(0XDEADBEEF)}},
-	{"0XdEadb33f", &stmt.Simple{Expr: basic(0XdEadb33f)}},
+	{"0X0", &stmt.Simple{Expr: basic(0x0)}},
+	{"0X1", &stmt.Simple{Expr: basic(0x1)}},
+	{"0Xdeadbeef", &stmt.Simple{Expr: basic(0xdeadbeef)}},
+	{"0XDEADBEEF", &stmt.Simple{Expr: basic(0xDEADBEEF)}},
+	{"0XdEadb33f", &stmt.Simple{Expr: basic(0xdEadb33f)}},
+	{"0o17", &stmt.Simple{Expr: basic(0o17)}},
+	{"0O777", &stmt.Simple{Expr: basic(0o777)}},
+	{"0b1010", &stmt.Simple{Expr: basic(0b1010)}},
+	{"0B1", &stmt.Simple{Expr: basic(0b1)}},
+	{"0755", &stmt.Simple{Expr: basic(0755)}},
+	{"1_000_000", &stmt.Simple{Expr: basic(1_000_000)}},
+	{"0xFF_FF", &stmt.Simple{Expr: basic(0xFF_FF)}},
+	{"0b_1010_0101", &stmt.Simple{Expr: basic(0b_1010_0101)}},
 	{"defer f()", &stmt.Defer{Expr: &expr.Call{Func: &expr.Ident{Name: "f"}}}},
 	{"defer f.Close()", &stmt.Defer{Expr: &expr.Call{
 		Func: &expr.Selector{
@@ -1615,6 +2383,23 @@ var stmtTests = []stmtTest{
 			Body: &stmt.Block{},
 		},
 	}}},
+	{"ch <- 1", &stmt.Send{Chan: &expr.Ident{Name: "ch"}, Value: basic(1)}},
+	{"ch <- x + 1", &stmt.Send{
+		Chan: &expr.Ident{Name: "ch"},
+		Value: &expr.Binary{
+			Op:    token.Add,
+			Left:  &expr.Ident{Name: "x"},
+			Right: basic(1),
+		},
+	}},
+	{"a.b <- <-c", &stmt.Send{
+		Chan: &expr.Selector{
+			Left:  &expr.Ident{Name: "a"},
+			Right: &expr.Ident{Name: "b"},
+		},
+		Value: &expr.Unary{Op: token.ChanOp, Expr: &expr.Ident{Name: "c"}},
+	}},
+	{"<-ch", &stmt.Simple{Expr: &expr.Unary{Op: token.ChanOp, Expr: &expr.Ident{Name: "ch"}}}},
 }
 
 func TestParseStmt(t *testing.T) {
@@ -5,9 +5,11 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -17,25 +19,35 @@ import (
 
 const bom = 0xFEFF // byte order marker
 
+// maxLits bounds the identifiers a long-running scanner, such as
+// one reading an interactive session, keeps.
+const maxLits = 4096
+
 func newScanner() *Scanner {
 	s := &Scanner{
 		Line:    1,
+		Col:     1,
+		line:    1,
 		addSrc:  make(chan []byte),
 		needSrc: make(chan struct{}),
+		lits:    make(map[string]interface{}),
 	}
 	return s
 }
 
 type Scanner struct {
 	// Current Token
-	Line      int32
-	Column    int16
-	Offset    int
-	Token     token.Token
-	Literal   interface{} // string, *big.Int, *big.Float
-	lastWidth int16
+	Line    int // 1-based line of the start of Token
+	Col     int // 1-based byte column of the start of Token
+	Offset  int
+	Token   token.Token
+	Literal interface{} // string, *big.Int, *big.Float
 
 	// Scanner state
+	line         int32 // line of r
+	column       int16 // 0-based byte column of r
+	start        int   // offset of the start of Token
+	lastWidth    int16
 	src          []byte
 	r            rune
 	off          int
@@ -44,12 +56,58 @@ type Scanner struct {
 	inShell      bool
 	exitingShell bool // set mid $$ token when we have read ahead too far
 
+	// lits holds the Literal of each identifier scanned so far,
+	// keyed by its name, so scanning one again does not allocate.
+	// It is emptied when it reaches maxLits entries.
+	lits map[string]interface{}
+
 	addSrc  chan []byte
 	needSrc chan struct{}
 }
 
+// A scanError is an error in the token the scanner last read.
+type scanError struct {
+	msg string
+	off int
+}
+
+func (e scanError) Error() string {
+	return fmt.Sprintf("neugram: scanner: %s (off %d)", e.msg, e.off)
+}
+
 func (s *Scanner) errorf(format string, a ...interface{}) {
-	s.err = fmt.Errorf("neugram: scanner: %s (off %d)", fmt.Sprintf(format, a...), s.Offset)
+	s.err = scanError{msg: fmt.Sprintf(format, a...), off: s.Offset}
+}
+
+// tokenLine returns the source line containing the start of the
+// current token, and the length in bytes of the token on that line.
+func (s *Scanner) tokenLine() (line string, n int) {
+	start := s.start
+	if start > len(s.src) {
+		start = len(s.src)
+	}
+	lo := bytes.LastIndexByte(s.src[:start], '\n') + 1
+	hi := len(s.src)
+	if i := bytes.IndexByte(s.src[start:], '\n'); i >= 0 {
+		hi = start + i
+	}
+	end := s.Offset
+	if end > hi {
+		end = hi
+	}
+	if end < start {
+		end = start
+	}
+	return string(s.src[lo:hi]), end - start
+}
+
+// text returns the source text of the current token.
+func (s *Scanner) text() string {
+	end := s.Offset
+	if end > len(s.src) {
+		end = len(s.src)
+	}
+	return string(s.src[s.start:end])
 }
 
 func (s *Scanner) drain() {
@@ -77,9 +135,9 @@ func (s *Scanner) next() {
 
 	s.Offset = s.off
 	if s.r == '\n' {
-		s.Line++
+		s.line++
 		s.lastWidth = 0
-		s.Column = 0
+		s.column = 0
 	}
 	var w int
 	s.r, w = rune(s.src[s.off]), 1
@@ -94,7 +152,7 @@ func (s *Scanner) next() {
 			s.errorf("bad byte order marker")
 		}
 	}
-	s.Column += s.lastWidth
+	s.column += s.lastWidth
 	s.lastWidth = int16(w)
 	s.off += w
 	return
@@ -106,12 +164,12 @@ func (s *Scanner) skipWhitespace() {
 	}
 }
 
-func (s *Scanner) scanIdentifier() string {
+func (s *Scanner) scanIdentifier() []byte {
 	off := s.Offset
 	for unicode.IsLetter(s.r) || unicode.IsDigit(s.r) || s.r == '_' {
 		s.next()
 	}
-	return string(s.src[off:s.Offset])
+	return s.src[off:s.Offset]
 }
 
 func (s *Scanner) scanShellWord() string {
@@ -140,12 +198,12 @@ func (s *Scanner) scanShellWord() string {
 
 				return string(s.src[off : s.Offset-1])
 			case '{':
-				for s.r != '}' {
+				for s.r != '}' && s.r != -1 {
 					s.next()
 				}
 				s.next()
 			}
-		case ' ', '\t', '\n', '\r', '|', '&', ';', '<', '>', '(', ')':
+		case -1, ' ', '\t', '\n', '\r', '|', '&', ';', '<', '>', '(', ')':
 			return string(s.src[off:s.Offset])
 		default:
 			s.next()
@@ -153,44 +211,123 @@ func (s *Scanner) scanShellWord() string {
 	}
 }
 
-func (s *Scanner) scanMantissa() {
-	for '0' <= s.r && s.r <= '9' {
+// scanDigits scans the digits and '_' separators of a number.
+// Decimal digits are always consumed, along with hexadecimal
+// digits if base is 16. It reports the first digit not valid
+// in base, or 0 if all are valid.
+func (s *Scanner) scanDigits(base int) (invalid rune) {
+	for {
+		r := s.r
+		switch {
+		case '0' <= r && r <= '9':
+			if int(r-'0') >= base && invalid == 0 {
+				invalid = r
+			}
+		case base == 16 && ('a' <= r && r <= 'f' || 'A' <= r && r <= 'F'):
+		case r == '_':
+		default:
+			return invalid
+		}
 		s.next()
 	}
 }
 
-func (s *Scanner) scanHexa() {
-	for ('0' <= s.r && s.r <= '9') ||
-		('a' <= s.r && s.r <= 'f') ||
-		('A' <= s.r && s.r <= 'F') {
-		s.next()
+func litName(base int) string {
+	switch base {
+	case 2:
+		return "binary literal"
+	case 8:
+		return "octal literal"
+	case 16:
+		return "hexadecimal literal"
 	}
+	return "decimal literal"
+}
+
+// invalidSep reports whether a '_' in the number literal x does
+// not sit between two digits. A base prefix counts as a digit.
+func invalidSep(x string) bool {
+	hex := false
+	prev := '.' // '0' for a digit, '_', or '.' for anything else
+	i := 0
+	if len(x) >= 2 && x[0] == '0' {
+		switch x[1] {
+		case 'x', 'X':
+			hex = true
+			fallthrough
+		case 'o', 'O', 'b', 'B':
+			prev = '0'
+			i = 2
+		}
+	}
+	for ; i < len(x); i++ {
+		c := rune(x[i])
+		switch {
+		case c == '_':
+			if prev != '0' {
+				return true
+			}
+		case '0' <= c && c <= '9' || hex && ('a' <= c && c <= 'f' || 'A' <= c && c <= 'F'):
+			c = '0'
+		default:
+			if prev == '_' {
+				return true
+			}
+			c = '.'
+		}
+		prev = c
+	}
+	return prev == '_'
 }
 
 func (s *Scanner) scanNumber(seenDot bool) (token.Token, interface{}) {
 	off := s.Offset
 	tok := token.Int
+	base := 10
+	var invalid rune
 
 	if seenDot {
 		off--
 		tok = token.Float
-		s.scanMantissa()
+		s.scanDigits(10)
 		goto exponent
 	}
 
-	s.scanMantissa()
-
-	// hexa
-	if (s.r == 'x' || s.r == 'X') && string(s.src[off:s.Offset]) == "0" {
+	if s.r == '0' {
 		s.next()
-		s.scanHexa()
+		prefix := true
+		switch s.r {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		default:
+			prefix = false
+		}
+		if prefix {
+			s.next()
+			digits := s.Offset
+			invalid = s.scanDigits(base)
+			if s.Offset == digits {
+				s.errorf("%s has no digits", litName(base))
+				return token.Unknown, nil
+			}
+			if invalid != 0 {
+				s.errorf("invalid digit %q in %s", invalid, litName(base))
+				return token.Unknown, nil
+			}
+			goto imaginary
+		}
 	}
+	s.scanDigits(10)
 
 	// fraction
 	if s.r == '.' {
 		tok = token.Float
 		s.next()
-		s.scanMantissa()
+		s.scanDigits(10)
 	}
 
 exponent:
@@ -200,15 +337,34 @@ exponent:
 		if s.r == '-' || s.r == '+' {
 			s.next()
 		}
-		s.scanMantissa()
+		digits := s.Offset
+		s.scanDigits(10)
+		if s.Offset == digits {
+			s.errorf("exponent has no digits")
+			return token.Unknown, nil
+		}
+		if s.r == '.' {
+			s.next()
+			s.scanDigits(10)
+			s.errorf("exponent must be an integer in %q", string(s.src[off:s.Offset]))
+			return token.Unknown, nil
+		}
 	}
 
+imaginary:
 	if s.r == 'i' {
 		tok = token.Imaginary
 		s.next()
 	}
 
 	str := string(s.src[off:s.Offset])
+	if strings.IndexByte(str, '_') >= 0 {
+		if invalidSep(str) {
+			s.errorf("'_' must separate successive digits in %q", str)
+			return token.Unknown, nil
+		}
+		str = strings.Replace(str, "_", "", -1)
+	}
 	var value interface{}
 	switch tok {
 	case token.Int:
@@ -261,21 +417,31 @@ func (s *Scanner) scanSingleQuotedShellWord() string {
 	return `'` + string(s.src[off:s.Offset])
 }
 
+// scanRawString scans a `raw string` and returns its content.
+// As in Go, carriage returns inside the literal are discarded.
 func (s *Scanner) scanRawString() string {
 	off := s.Offset
+	hasCR := false
 
 	for {
 		r := s.r
 		if r <= 0 {
 			s.errorf("raw string literal not terminated")
-			break
+			return string(s.src[off:s.Offset])
 		}
 		s.next()
 		if r == '`' {
 			break
 		}
+		if r == '\r' {
+			hasCR = true
+		}
+	}
+	str := string(s.src[off : s.Offset-1])
+	if hasCR {
+		str = strings.Replace(str, "\r", "", -1)
 	}
-	return "`" + string(s.src[off:s.Offset-1]) + "`"
+	return str
 }
 
 func (s *Scanner) scanRune() rune {
@@ -285,11 +451,11 @@ func (s *Scanner) scanRune() rune {
 		r := s.r
 		if r <= 0 || r == '\n' {
 			s.errorf("character literal missing terminating \"'\"")
-			break
+			return 0
 		}
 		s.next()
 		if r == '\\' {
-			if s.r == '\'' {
+			if s.r == '\'' || s.r == '\\' {
 				s.next()
 			}
 		}
@@ -301,7 +467,7 @@ func (s *Scanner) scanRune() rune {
 	str := string(s.src[off : s.Offset-1])
 	v, _, _, err := strconv.UnquoteChar(str, '\'')
 	if err != nil {
-		s.errorf("rune literal %v", err)
+		s.errorf("invalid rune literal '%s'", str)
 	}
 	return v
 }
@@ -313,11 +479,11 @@ func (s *Scanner) scanString(spanNewlines bool) string {
 		r := s.r
 		if r <= 0 || (!spanNewlines && r == '\n') {
 			s.errorf("string literal missing terminating '\"'")
-			break
+			return `"` + string(s.src[off:s.Offset]) + `"`
 		}
 		s.next()
 		if r == '\\' {
-			if s.r == '"' {
+			if s.r == '"' || s.r == '\\' {
 				s.next()
 			}
 		}
@@ -326,11 +492,7 @@ func (s *Scanner) scanString(spanNewlines bool) string {
 		}
 	}
 
-	str := `"` + string(s.src[off:s.Offset-1]) + `"`
-	if _, err := strconv.Unquote(str); err != nil {
-		s.errorf("string literal %v", err)
-	}
-	return str
+	return `"` + string(s.src[off:s.Offset-1]) + `"`
 }
 
 func (s *Scanner) scanComment() string {
@@ -356,7 +518,7 @@ func (s *Scanner) scanComment() string {
 			}
 		}
 		if !terminated {
-			s.err = fmt.Errorf("multi-line comment not terminated") // TODO offset
+			s.errorf("multi-line comment not terminated")
 		}
 	}
 
@@ -378,6 +540,8 @@ func (s *Scanner) nextInShell() {
 		return
 	}
 	switch s.r {
+	case -1:
+		s.Token = token.Unknown
 	case '$':
 		s.next()
 		if s.r == '$' {
@@ -465,6 +629,9 @@ func (s *Scanner) Next() {
 		fmt.Printf("\n")
 	}()*/
 	s.skipWhitespace()
+	s.Line = int(s.line)
+	s.Col = int(s.column) + 1
+	s.start = s.Offset
 	//fmt.Printf("Next: s.r=%v (%s) s.off=%d\n", s.r, string(s.r), s.off)
 
 	wasSemi := s.semi
@@ -478,17 +645,24 @@ func (s *Scanner) Next() {
 		return
 	case unicode.IsLetter(r) || r == '_':
 		lit := s.scanIdentifier()
-		s.Token = token.Keyword(lit)
+		s.Token = token.Keywords[string(lit)] // does not allocate
 		if s.Token == token.Unknown {
 			s.Token = token.Ident
-			s.Literal = lit
+			s.Literal = s.lits[string(lit)] // does not allocate
+			if s.Literal == nil {
+				if len(s.lits) >= maxLits {
+					s.lits = make(map[string]interface{})
+				}
+				s.Literal = string(lit)
+				s.lits[s.Literal.(string)] = s.Literal
+			}
 		}
 		switch s.Token {
 		case token.Ident, token.Break, token.Continue, token.Fallthrough, token.Return:
 			s.semi = true
 		}
 		return
-	case unicode.IsDigit(r):
+	case '0' <= r && r <= '9':
 		s.semi = true
 		s.Token, s.Literal = s.scanNumber(false)
 		return
@@ -538,7 +712,12 @@ func (s *Scanner) Next() {
 	case '"':
 		s.semi = true
 		s.Token = token.String
-		s.Literal = s.scanString(false)
+		str := s.scanString(false)
+		v, err := strconv.Unquote(str)
+		if err != nil && s.err == nil {
+			s.errorf("invalid string literal %s", str)
+		}
+		s.Literal = v
 	case '\'':
 		s.semi = true
 		s.Token = token.Rune
@@ -553,6 +732,9 @@ func (s *Scanner) Next() {
 			if s.r == '.' {
 				s.next()
 				s.Token = token.Ellipsis
+			} else {
+				s.Token = token.Unknown
+				s.Literal = ".."
 			}
 		} else {
 			s.Token = token.Period
@@ -677,8 +859,9 @@ func (s *Scanner) Next() {
 			s.next()
 			s.Token = token.Shell
 			s.inShell = true
-			//default:
-			//	s.Token = token.?
+		default:
+			s.Token = token.Unknown
+			s.Literal = "$"
 		}
 	case '|':
 		switch s.r {
@@ -5,8 +5,12 @@
 package parser
 
 import (
+	"fmt"
 	"math/big"
+	"strings"
+	"testing"
 
+	"neugram.io/ng/internal/bigcplx"
 	"neugram.io/ng/syntax/token"
 )
 
@@ -41,6 +45,355 @@ var scannerSepTests = append([]scannerTest{
 
 var scannerJoinTests = append([]scannerTest{}, scannerBothTests...)
 
+func TestScannerPos(t *testing.T) {
+	type tokPos struct {
+		tok       token.Token
+		line, col int
+	}
+	input := "x := 1\n\tyé+= \"a\"\n\n  f()"
+	want := []tokPos{
+		{token.Ident, 1, 1},
+		{token.Define, 1, 3},
+		{token.Int, 1, 6},
+		{token.Semicolon, 1, 7},
+		{token.Ident, 2, 2},
+		{token.AddAssign, 2, 5},
+		{token.String, 2, 8},
+		{token.Semicolon, 2, 11},
+		{token.Ident, 4, 3},
+		{token.LeftParen, 4, 4},
+		{token.RightParen, 4, 5},
+	}
+
+	s := scanAll(input)
+	for _, w := range want {
+		s.Next()
+		if s.Token != w.tok || s.Line != w.line || s.Col != w.col {
+			t.Errorf("got %s at %d:%d, want %s at %d:%d", s.Token, s.Line, s.Col, w.tok, w.line, w.col)
+		}
+	}
+}
+
+// scanAll returns a Scanner positioned at the start of input,
+// which is all the source it will be given. As with ParseLine,
+// a trailing newline is added.
+func scanAll(input string) *Scanner {
+	s := newScanner()
+	s.src = []byte(input + "\n")
+	go func() {
+		<-s.needSrc
+		s.addSrc <- nil
+	}()
+	s.next()
+	return s
+}
+
+var identTests = []struct {
+	input string
+	token token.Token
+	name  string
+}{
+	{"α", token.Ident, "α"},
+	{"αβγ", token.Ident, "αβγ"},
+	{"xα1", token.Ident, "xα1"},
+	{"_β", token.Ident, "_β"},
+	{"x٣", token.Ident, "x٣"}, // Arabic-Indic digit
+	{"日本語", token.Ident, "日本語"},
+	{"Ωmega", token.Ident, "Ωmega"},
+	{"for", token.For, ""},
+	{"fοr", token.Ident, "fοr"}, // Greek omicron
+	{"٣", token.Unknown, "٣"},
+	{"😀", token.Unknown, "😀"},
+}
+
+func TestScannerIdent(t *testing.T) {
+	for _, test := range identTests {
+		s := scanAll(test.input)
+		s.Next()
+		if s.Token != test.token {
+			t.Errorf("%q: got %s, want %s", test.input, s.Token, test.token)
+			continue
+		}
+		if test.name != "" && s.Literal != test.name {
+			t.Errorf("%q: got literal %q, want %q", test.input, s.Literal, test.name)
+		}
+	}
+}
+
+var numberTests = []struct {
+	input string
+	value int64
+	err   string
+}{
+	{input: "0", value: 0},
+	{input: "42", value: 42},
+	{input: "0755", value: 0755},
+	{input: "0xff", value: 0xff},
+	{input: "0XAbC", value: 0xabc},
+	{input: "0o17", value: 0o17},
+	{input: "0O0", value: 0},
+	{input: "0b1010", value: 0b1010},
+	{input: "0B1", value: 1},
+	{input: "1_000_000", value: 1000000},
+	{input: "0xFF_FF", value: 0xffff},
+	{input: "0b_1", value: 1},
+	{input: "0b2", err: "invalid digit '2' in binary literal"},
+	{input: "0b1012", err: "invalid digit '2' in binary literal"},
+	{input: "0o8", err: "invalid digit '8' in octal literal"},
+	{input: "0x", err: "hexadecimal literal has no digits"},
+	{input: "0o", err: "octal literal has no digits"},
+	{input: "0x_1", value: 1},
+	{input: "0_7", value: 7},
+	{input: "1_", err: "'_' must separate successive digits"},
+	{input: "1__0", err: "'_' must separate successive digits"},
+	{input: "0x_", err: "'_' must separate successive digits"},
+	{input: "0b1_", err: "'_' must separate successive digits"},
+}
+
+var floatTests = []struct {
+	input string
+	value string
+	err   string
+}{
+	{input: "1.5", value: "1.5"},
+	{input: "1.5e10", value: "1.5e+10"},
+	{input: "2.3E-4", value: "0.00023"},
+	{input: "1e6", value: "1000000"},
+	{input: "1E+2", value: "100"},
+	{input: "0.5e-0", value: "0.5"},
+	{input: "1_000.5", value: "1000.5"},
+	{input: "1_0.2_5e1_0", value: "1.025e+11"},
+	{input: "3_1.4_1i", value: "31.41"},
+	{input: "1_.0", err: "'_' must separate successive digits"},
+	{input: "1._0", err: "'_' must separate successive digits"},
+	{input: "1.0_e2", err: "'_' must separate successive digits"},
+	{input: "1e_2", err: "'_' must separate successive digits"},
+	{input: "1.5_i", err: "'_' must separate successive digits"},
+	{input: "1e", err: "exponent has no digits"},
+	{input: "1.5E-", err: "exponent has no digits"},
+	{input: "1e1.5", err: `exponent must be an integer in "1e1.5"`},
+}
+
+func TestScannerFloat(t *testing.T) {
+	for _, test := range floatTests {
+		s := scanAll(test.input)
+		s.Next()
+		if test.err != "" {
+			if s.err == nil || !strings.Contains(s.err.Error(), test.err) {
+				t.Errorf("%q: error %v, want %q", test.input, s.err, test.err)
+			}
+			continue
+		}
+		if s.err != nil {
+			t.Errorf("%q: %v", test.input, s.err)
+			continue
+		}
+		var f *big.Float
+		switch v := s.Literal.(type) {
+		case *big.Float:
+			if s.Token != token.Float {
+				t.Errorf("%q: got %s, want float", test.input, s.Token)
+			}
+			f = v
+		case *bigcplx.Complex:
+			f = v.Imag
+		default:
+			t.Errorf("%q: got %s %v, want a float", test.input, s.Token, s.Literal)
+			continue
+		}
+		if got := f.Text('g', 10); got != test.value {
+			t.Errorf("%q: got %s, want %s", test.input, got, test.value)
+		}
+	}
+}
+
+func TestScannerNumber(t *testing.T) {
+	for _, test := range numberTests {
+		s := scanAll(test.input)
+		s.Next()
+		if test.err != "" {
+			if s.err == nil || !strings.Contains(s.err.Error(), test.err) {
+				t.Errorf("%q: error %v, want %q", test.input, s.err, test.err)
+			}
+			continue
+		}
+		if s.err != nil {
+			t.Errorf("%q: %v", test.input, s.err)
+			continue
+		}
+		v, ok := s.Literal.(*big.Int)
+		if s.Token != token.Int || !ok || v.Cmp(big.NewInt(test.value)) != 0 {
+			t.Errorf("%q: got %s %v, want int %d", test.input, s.Token, s.Literal, test.value)
+		}
+		if s.r != '\n' {
+			t.Errorf("%q: literal not fully scanned, stopped at %q", test.input, s.r)
+		}
+	}
+}
+
+var rawStringTests = []struct {
+	input   string
+	literal string
+}{
+	{"``", ""},
+	{"`a`", "a"},
+	{"`\\n\"`", `\n"`},
+	{"`line 1\nline 2\n`", "line 1\nline 2\n"},
+	{"`a\r\nb`", "a\nb"},
+}
+
+func TestScannerRawString(t *testing.T) {
+	for _, test := range rawStringTests {
+		s := scanAll(test.input)
+		s.Next()
+		if s.err != nil {
+			t.Errorf("%q: %v", test.input, s.err)
+			continue
+		}
+		if s.Token != token.String || s.Literal != test.literal {
+			t.Errorf("%q: got %s %q, want string %q", test.input, s.Token, s.Literal, test.literal)
+		}
+	}
+
+	s := scanAll("x := `one\ntwo\n\tthree` + y")
+	for i := 0; i < 4; i++ {
+		s.Next()
+	}
+	if s.Token != token.Add || s.Line != 3 || s.Col != 9 {
+		t.Errorf("after multi-line raw string: got %s at %d:%d, want + at 3:9", s.Token, s.Line, s.Col)
+	}
+
+	s = scanAll("`unterminated")
+	s.Next()
+	if s.err == nil {
+		t.Error("unterminated raw string: missing error")
+	}
+}
+
+// scanCorpus is representative Neugram source for benchmarking.
+const scanCorpus = `x := 1 + 2*3 - (4/5)%6
+y := x<<2 | x&0xff ^ 0b1010
+z := 1.5e3 * float64(y) / 2.25
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+func sum(xs ...int) (total int) {
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+t := [|]num{{|"name", "count"|}, {1, 2}, {3, 4}, {5, 6}}
+m := map[string]int{"one": 1, "two": 2}
+print(fib(10), sum(1, 2, 3), t, m, 'r')
+`
+
+// scanTokens scans s to the end of its source and returns the
+// number of tokens.
+func scanTokens(tb testing.TB, s *Scanner) int {
+	n := 0
+	for {
+		s.Next()
+		if s.err != nil {
+			tb.Fatal(s.err)
+		}
+		if s.r == -1 && s.Token == token.Unknown {
+			return n
+		}
+		n++
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	src := strings.Repeat(scanCorpus, 20)
+	b.SetBytes(int64(len(src)))
+	tokens := 0
+	for i := 0; i < b.N; i++ {
+		tokens += scanTokens(b, scanAll(src))
+	}
+	b.ReportMetric(float64(tokens)/b.Elapsed().Seconds(), "tokens/s")
+}
+
+// allocTests are sources of one kind of token for measuring the
+// allocations per token.
+var allocTests = []struct {
+	name, src string
+	zero      bool // scanning allocates nothing once each token is seen
+}{
+	{"ident", "alpha beta gamma delta ", true},
+	{"keyword", "for if func return ", true},
+	{"int", "1 22 333 4444 ", false},
+	{"operator", "+ - * / ( ) [ ] ", true},
+	{"string", `"a" "bc" "def" `, false},
+}
+
+// warmScanner returns a scanner of n copies of src that has already
+// scanned the first copy.
+func warmScanner(src string, n int) *Scanner {
+	s := scanAll(strings.Repeat(src, n+1))
+	for range strings.Fields(src) {
+		s.Next()
+	}
+	return s
+}
+
+// BenchmarkScannerAlloc reports the allocations per token, as
+// allocs/op, for different kinds of tokens.
+func BenchmarkScannerAlloc(b *testing.B) {
+	for _, test := range allocTests {
+		b.Run(test.name, func(b *testing.B) {
+			s := warmScanner(test.src, b.N/len(strings.Fields(test.src))+1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.Next()
+			}
+		})
+	}
+}
+
+func TestScannerAllocs(t *testing.T) {
+	for _, test := range allocTests {
+		if !test.zero {
+			continue
+		}
+		s := warmScanner(test.src, 100)
+		if n := testing.AllocsPerRun(100, s.Next); n != 0 {
+			t.Errorf("scanning %s tokens %q: %v allocs per token, want 0", test.name, test.src, n)
+		}
+	}
+}
+
+// TestScannerIntValues checks that each integer literal has its own
+// value, as evaluating one may return it to be modified.
+func TestScannerIntValues(t *testing.T) {
+	s := scanAll("7 7")
+	s.Next()
+	x := s.Literal.(*big.Int)
+	s.Next()
+	if y := s.Literal.(*big.Int); x == y {
+		t.Errorf("two scans of 7 share the *big.Int %p", x)
+	}
+}
+
+func TestScannerLitsBound(t *testing.T) {
+	var src strings.Builder
+	for i := 0; i < 2*maxLits; i++ {
+		fmt.Fprintf(&src, "x%d ", i)
+	}
+	s := scanAll(src.String())
+	scanTokens(t, s)
+	if n := len(s.lits); n > maxLits {
+		t.Errorf("scanner keeps %d identifiers, want at most %d", n, maxLits)
+	}
+}
+
 /*
 func TestScannerSep(t *testing.T) {
 	for _, test := range scannerSepTests {
@@ -13,12 +13,14 @@ import (
 )
 
 func (p *Parser) parseShellList() *expr.ShellList {
+	pos := p.pos()
 	andor := p.parseShellAndOr()
 	if andor == nil {
 		return nil
 	}
 	l := &expr.ShellList{
-		AndOr: []*expr.ShellAndOr{andor},
+		Position: pos,
+		AndOr:    []*expr.ShellAndOr{andor},
 	}
 	for p.s.Token == token.Ref || p.s.Token == token.Semicolon {
 		if p.s.Token == token.Ref {
@@ -28,7 +30,12 @@ func (p *Parser) parseShellList() *expr.ShellList {
 		if p.s.Token == token.ShellNewline || p.s.Token == token.Shell {
 			break
 		}
-		l.AndOr = append(l.AndOr, p.parseShellAndOr())
+		andor := p.parseShellAndOr()
+		if andor == nil {
+			p.errorf(ErrExpectedOperand, "expected command, got %s", p.s.Token)
+			break
+		}
+		l.AndOr = append(l.AndOr, andor)
 	}
 	if p.s.Token == token.ShellNewline {
 		if !p.interactive {
@@ -39,22 +46,31 @@ func (p *Parser) parseShellList() *expr.ShellList {
 }
 
 func (p *Parser) parseShellAndOr() *expr.ShellAndOr {
+	pos := p.pos()
 	pl := p.parseShellPipeline()
 	if pl == nil {
 		return nil
 	}
 	l := &expr.ShellAndOr{
+		Position: pos,
 		Pipeline: []*expr.ShellPipeline{pl},
 	}
 	for p.s.Token == token.LogicalAnd || p.s.Token == token.LogicalOr {
 		l.Sep = append(l.Sep, p.s.Token)
 		p.next()
-		l.Pipeline = append(l.Pipeline, p.parseShellPipeline())
+		pl := p.parseShellPipeline()
+		if pl == nil {
+			p.errorf(ErrExpectedOperand, "expected command, got %s", p.s.Token)
+			l.Sep = l.Sep[:len(l.Sep)-1]
+			break
+		}
+		l.Pipeline = append(l.Pipeline, pl)
 	}
 	return l
 }
 
 func (p *Parser) parseShellPipeline() *expr.ShellPipeline {
+	pos := p.pos()
 	bang := false
 	if p.s.Token == token.Not {
 		bang = true
@@ -65,20 +81,28 @@ func (p *Parser) parseShellPipeline() *expr.ShellPipeline {
 		return nil
 	}
 	l := &expr.ShellPipeline{
-		Bang: bang,
-		Cmd:  []*expr.ShellCmd{cmd},
+		Position: pos,
+		Bang:     bang,
+		Cmd:      []*expr.ShellCmd{cmd},
 	}
 	for p.s.Token == token.ShellPipe {
 		p.next()
-		l.Cmd = append(l.Cmd, p.parseShellCmd())
+		cmd := p.parseShellCmd()
+		if cmd == nil {
+			p.errorf(ErrExpectedOperand, "expected command, got %s", p.s.Token)
+			break
+		}
+		l.Cmd = append(l.Cmd, cmd)
 	}
 	return l
 }
 
 func (p *Parser) parseShellCmd() (l *expr.ShellCmd) {
+	pos := p.pos()
 	if p.s.Token == token.LeftParen {
 		p.next()
 		l = &expr.ShellCmd{
+			Position: pos,
 			Subshell: p.parseShellList(),
 		}
 		p.expect(token.RightParen)
@@ -87,6 +111,7 @@ func (p *Parser) parseShellCmd() (l *expr.ShellCmd) {
 		simplecmd := p.parseShellSimpleCmd()
 		if simplecmd != nil {
 			l = &expr.ShellCmd{
+				Position:  pos,
 				SimpleCmd: simplecmd,
 			}
 		}
@@ -108,6 +133,7 @@ func isAssignment(word string) (k, v string) {
 
 func (p *Parser) parseShellSimpleCmd() (l *expr.ShellSimpleCmd) {
 	for {
+		pos := p.pos()
 		w, r := p.maybeParseShellRedirect()
 		if r == nil {
 			if w == "" {
@@ -120,7 +146,7 @@ func (p *Parser) parseShellSimpleCmd() (l *expr.ShellSimpleCmd) {
 			}
 		}
 		if l == nil {
-			l = &expr.ShellSimpleCmd{}
+			l = &expr.ShellSimpleCmd{Position: pos}
 		}
 		if r != nil {
 			l.Redirect = append(l.Redirect, r)
@@ -128,8 +154,9 @@ func (p *Parser) parseShellSimpleCmd() (l *expr.ShellSimpleCmd) {
 			if len(l.Args) == 0 {
 				if k, v := isAssignment(w); k != "" {
 					l.Assign = append(l.Assign, expr.ShellAssign{
-						Key:   k,
-						Value: v,
+						Position: pos,
+						Key:      k,
+						Value:    v,
 					})
 					continue
 				}
@@ -142,6 +169,7 @@ func (p *Parser) parseShellSimpleCmd() (l *expr.ShellSimpleCmd) {
 
 func (p *Parser) maybeParseShellRedirect() (string, *expr.ShellRedirect) {
 	//fmt.Printf("maybeParseShellRedirect p.s.Token=%s\n", p.s.Token)
+	pos := p.pos()
 	lit := ""
 	number := (*int)(nil)
 	if p.s.Token == token.ShellWord {
@@ -159,8 +187,9 @@ func (p *Parser) maybeParseShellRedirect() (string, *expr.ShellRedirect) {
 		return lit, nil
 	}
 	l := &expr.ShellRedirect{
-		Number: number,
-		Token:  p.s.Token,
+		Position: pos,
+		Number:   number,
+		Token:    p.s.Token,
 	}
 	p.next()
 	if p.expect(token.ShellWord) {
@@ -5,7 +5,9 @@
 package parser
 
 import (
+	"io/ioutil"
 	"math/big"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -109,7 +111,14 @@ var srcposWant = &syntax.File{
 					},
 					Body: &stmt.Block{
 						Position: src.Pos{
-							Filename: "", // TODO
+							Filename: "srctest.ng",
+							Line:     int32(2),
+							Column:   int16(11),
+						},
+						End: src.Pos{
+							Filename: "srctest.ng",
+							Line:     int32(5),
+							Column:   int16(1),
 						},
 						Stmts: []stmt.Stmt{
 							&stmt.Send{
@@ -137,9 +146,10 @@ var srcposWant = &syntax.File{
 										Position: src.Pos{
 											Filename: "srctest.ng",
 											Line:     int32(3),
-											Column:   int16(9),
+											Column:   int16(8),
 										},
 										Value: big.NewInt(41),
+										Text:  "41",
 									},
 									Right: &expr.BasicLiteral{
 										Position: src.Pos{
@@ -148,12 +158,15 @@ var srcposWant = &syntax.File{
 											Column:   int16(13),
 										},
 										Value: big.NewInt(1),
+										Text:  "1",
 									},
 								},
 							},
 							&stmt.Simple{
 								Position: src.Pos{
-									Filename: "", // TODO
+									Filename: "srctest.ng",
+									Line:     int32(4),
+									Column:   int16(7),
 								},
 								Expr: &expr.Call{
 									Position: src.Pos{
@@ -246,12 +259,21 @@ var srcposWant = &syntax.File{
 			},
 			Body: &stmt.Block{
 				Position: src.Pos{
-					Filename: "", // TODO
+					Filename: "srctest.ng",
+					Line:     int32(6),
+					Column:   int16(23),
+				},
+				End: src.Pos{
+					Filename: "srctest.ng",
+					Line:     int32(8),
+					Column:   int16(1),
 				},
 				Stmts: []stmt.Stmt{
 					&stmt.Simple{
 						Position: src.Pos{
-							Filename: "", // TODO
+							Filename: "srctest.ng",
+							Line:     int32(7),
+							Column:   int16(7),
 						},
 						Expr: &expr.Call{
 							Position: src.Pos{
@@ -295,3 +317,34 @@ func TestPos(t *testing.T) {
 		t.Errorf("unexpected source positions:\n%s", format.Debug(got))
 	}
 }
+
+func TestExprPos(t *testing.T) {
+	files, err := filepath.Glob("../eval/testdata/*.ng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcs := map[string]string{
+		"incdec":   "x++\nx--\n",
+		"shell":    "$$ A=B echo hi 2>/dev/null | grep -v x && (cd /; pwd) $$\n",
+		"srcinput": srcposInput,
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs[file] = string(b)
+	}
+	for name, source := range srcs {
+		f, err := New(name).Parse([]byte(source))
+		if err != nil {
+			continue // error tests
+		}
+		syntax.Walk(f, func(c *syntax.Cursor) bool {
+			if _, isExpr := c.Node.(expr.Expr); isExpr && c.Node.Pos() == (src.Pos{}) {
+				t.Errorf("%s: %T in %T.%s has no position", name, c.Node, c.Parent, c.Name)
+			}
+			return true
+		}, nil)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Clone returns a deep copy of the expression e.
+//
+// Every expression node is copied, as are the *big.Int and *big.Float
+// values of basic literals. Types are shared between e and its copy.
+// The Body of a FuncLiteral is also shared: it is a *stmt.Block, which
+// this package cannot copy without an import cycle.
+func Clone(e Expr) Expr {
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case *Binary:
+		c := *e
+		c.Left = Clone(e.Left)
+		c.Right = Clone(e.Right)
+		return &c
+	case *Unary:
+		c := *e
+		c.Expr = Clone(e.Expr)
+		return &c
+	case *Bad:
+		c := *e
+		return &c
+	case *Selector:
+		c := *e
+		c.Left = Clone(e.Left)
+		c.Right = cloneIdent(e.Right)
+		return &c
+	case *Slice:
+		c := *e
+		c.Low = Clone(e.Low)
+		c.High = Clone(e.High)
+		c.Max = Clone(e.Max)
+		return &c
+	case *Index:
+		c := *e
+		c.Left = Clone(e.Left)
+		c.Indicies = cloneExprs(e.Indicies)
+		return &c
+	case *TypeAssert:
+		c := *e
+		c.Left = Clone(e.Left)
+		return &c
+	case *BasicLiteral:
+		c := *e
+		switch v := e.Value.(type) {
+		case *big.Int:
+			c.Value = new(big.Int).Set(v)
+		case *big.Float:
+			c.Value = new(big.Float).Copy(v)
+		}
+		return &c
+	case *FuncLiteral:
+		c := *e
+		c.ParamNames = cloneStrings(e.ParamNames)
+		c.ResultNames = cloneStrings(e.ResultNames)
+		return &c
+	case *CompLiteral:
+		c := *e
+		c.Keys = cloneExprs(e.Keys)
+		c.Values = cloneExprs(e.Values)
+		return &c
+	case *MapLiteral:
+		c := *e
+		c.Keys = cloneExprs(e.Keys)
+		c.Values = cloneExprs(e.Values)
+		return &c
+	case *ArrayLiteral:
+		c := *e
+		c.Keys = cloneExprs(e.Keys)
+		c.Values = cloneExprs(e.Values)
+		return &c
+	case *SliceLiteral:
+		c := *e
+		c.Keys = cloneExprs(e.Keys)
+		c.Values = cloneExprs(e.Values)
+		return &c
+	case *TableLiteral:
+		c := *e
+		c.ColNames = cloneExprs(e.ColNames)
+		if e.Rows != nil {
+			c.Rows = make([][]Expr, len(e.Rows))
+			for i, row := range e.Rows {
+				c.Rows[i] = cloneExprs(row)
+			}
+		}
+		return &c
+	case *Type:
+		c := *e
+		return &c
+	case *Ident:
+		return cloneIdent(e)
+	case *Call:
+		c := *e
+		c.Func = Clone(e.Func)
+		c.Args = cloneExprs(e.Args)
+		return &c
+	case *ShellList:
+		return cloneShellList(e)
+	case *ShellAndOr:
+		return cloneShellAndOr(e)
+	case *ShellPipeline:
+		return cloneShellPipeline(e)
+	case *ShellCmd:
+		return cloneShellCmd(e)
+	case *ShellSimpleCmd:
+		return cloneShellSimpleCmd(e)
+	case *ShellRedirect:
+		return cloneShellRedirect(e)
+	case *ShellAssign:
+		c := *e
+		return &c
+	case *Shell:
+		c := *e
+		if e.Cmds != nil {
+			c.Cmds = make([]*ShellList, len(e.Cmds))
+			for i, cmd := range e.Cmds {
+				c.Cmds[i] = cloneShellList(cmd)
+			}
+		}
+		c.FreeVars = cloneStrings(e.FreeVars)
+		return &c
+	default:
+		panic(fmt.Sprintf("expr.Clone: unknown expression type %T", e))
+	}
+}
+
+func cloneExprs(es []Expr) []Expr {
+	if es == nil {
+		return nil
+	}
+	c := make([]Expr, len(es))
+	for i, e := range es {
+		c[i] = Clone(e)
+	}
+	return c
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func cloneIdent(e *Ident) *Ident {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	return &c
+}
+
+func cloneShellList(e *ShellList) *ShellList {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.AndOr != nil {
+		c.AndOr = make([]*ShellAndOr, len(e.AndOr))
+		for i, andOr := range e.AndOr {
+			c.AndOr[i] = cloneShellAndOr(andOr)
+		}
+	}
+	return &c
+}
+
+func cloneShellAndOr(e *ShellAndOr) *ShellAndOr {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.Pipeline != nil {
+		c.Pipeline = make([]*ShellPipeline, len(e.Pipeline))
+		for i, pl := range e.Pipeline {
+			c.Pipeline[i] = cloneShellPipeline(pl)
+		}
+	}
+	if e.Sep != nil {
+		c.Sep = append(c.Sep[:0:0], e.Sep...)
+	}
+	return &c
+}
+
+func cloneShellPipeline(e *ShellPipeline) *ShellPipeline {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.Cmd != nil {
+		c.Cmd = make([]*ShellCmd, len(e.Cmd))
+		for i, cmd := range e.Cmd {
+			c.Cmd[i] = cloneShellCmd(cmd)
+		}
+	}
+	return &c
+}
+
+func cloneShellCmd(e *ShellCmd) *ShellCmd {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	c.SimpleCmd = cloneShellSimpleCmd(e.SimpleCmd)
+	c.Subshell = cloneShellList(e.Subshell)
+	return &c
+}
+
+func cloneShellSimpleCmd(e *ShellSimpleCmd) *ShellSimpleCmd {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.Redirect != nil {
+		c.Redirect = make([]*ShellRedirect, len(e.Redirect))
+		for i, r := range e.Redirect {
+			c.Redirect[i] = cloneShellRedirect(r)
+		}
+	}
+	if e.Assign != nil {
+		c.Assign = append(c.Assign[:0:0], e.Assign...)
+	}
+	c.Args = cloneStrings(e.Args)
+	return &c
+}
+
+func cloneShellRedirect(e *ShellRedirect) *ShellRedirect {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.Number != nil {
+		n := *e.Number
+		c.Number = &n
+	}
+	return &c
+}
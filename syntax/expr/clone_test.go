@@ -0,0 +1,179 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expr_test
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+)
+
+func ident(name string) *expr.Ident { return &expr.Ident{Name: name} }
+
+var two = 2
+
+var cloneTests = []expr.Expr{
+	ident("x"),
+	&expr.Binary{Op: token.Add, Left: ident("x"), Right: ident("y")},
+	&expr.Unary{Op: token.Not, Expr: ident("x")},
+	&expr.Bad{Error: errors.New("bad")},
+	&expr.Selector{Left: ident("x"), Right: ident("y")},
+	&expr.Slice{Low: ident("lo"), High: ident("hi"), Max: ident("max")},
+	&expr.Index{Left: ident("x"), Indicies: []expr.Expr{ident("i"), &expr.Slice{}}},
+	&expr.TypeAssert{Left: ident("x"), Type: tipe.Int64},
+	&expr.BasicLiteral{Value: big.NewInt(7)},
+	&expr.BasicLiteral{Value: big.NewFloat(1.5)},
+	&expr.BasicLiteral{Value: "str"},
+	&expr.FuncLiteral{
+		Name:        "f",
+		Type:        &tipe.Func{Params: &tipe.Tuple{Elems: []tipe.Type{tipe.Int64}}},
+		ParamNames:  []string{"a"},
+		ResultNames: []string{""},
+	},
+	&expr.CompLiteral{
+		Type:   &tipe.Unresolved{Name: "T"},
+		Keys:   []expr.Expr{ident("X")},
+		Values: []expr.Expr{ident("y")},
+	},
+	&expr.MapLiteral{
+		Type:   &tipe.Map{Key: tipe.String, Value: tipe.Int64},
+		Keys:   []expr.Expr{&expr.BasicLiteral{Value: "a"}},
+		Values: []expr.Expr{&expr.BasicLiteral{Value: big.NewInt(1)}},
+	},
+	&expr.ArrayLiteral{
+		Type:   &tipe.Array{Len: 1, Elem: tipe.Int64},
+		Values: []expr.Expr{ident("x")},
+	},
+	&expr.SliceLiteral{
+		Type:   &tipe.Slice{Elem: tipe.Int64},
+		Values: []expr.Expr{ident("x"), ident("y")},
+	},
+	&expr.TableLiteral{
+		Type:     &tipe.Table{Type: tipe.Int64},
+		ColNames: []expr.Expr{&expr.BasicLiteral{Value: "a"}},
+		Rows:     [][]expr.Expr{{ident("x")}, {ident("y")}},
+	},
+	&expr.Type{Type: tipe.Int64},
+	&expr.Call{Func: ident("f"), Args: []expr.Expr{ident("x")}, Ellipsis: true},
+	&expr.Shell{
+		Cmds: []*expr.ShellList{{
+			AndOr: []*expr.ShellAndOr{{
+				Pipeline: []*expr.ShellPipeline{
+					{Cmd: []*expr.ShellCmd{{
+						SimpleCmd: &expr.ShellSimpleCmd{
+							Redirect: []*expr.ShellRedirect{{Number: &two, Token: token.Greater, Filename: "out"}},
+							Assign:   []expr.ShellAssign{{Key: "K", Value: "V"}},
+							Args:     []string{"ls", "-l"},
+						},
+					}}},
+					{Cmd: []*expr.ShellCmd{{
+						Subshell: &expr.ShellList{},
+					}}},
+				},
+				Sep: []token.Token{token.LogicalAnd},
+			}},
+		}},
+		FreeVars: []string{"x"},
+	},
+	&expr.ShellAssign{Key: "K", Value: "V"},
+}
+
+func TestClone(t *testing.T) {
+	for _, e := range cloneTests {
+		c := expr.Clone(e)
+		if !reflect.DeepEqual(e, c) {
+			t.Errorf("Clone(%T) = %#v, want %#v", e, c, e)
+			continue
+		}
+		ptrs := make(map[uintptr]bool)
+		collectPtrs(reflect.ValueOf(e), ptrs)
+		if shared := findPtr(reflect.ValueOf(c), ptrs); shared != "" {
+			t.Errorf("Clone(%T) shares %s with the original", e, shared)
+		}
+	}
+	if expr.Clone(nil) != nil {
+		t.Error("Clone(nil) != nil")
+	}
+}
+
+func TestCloneBigInt(t *testing.T) {
+	e := &expr.BasicLiteral{Value: big.NewInt(1)}
+	c := expr.Clone(e).(*expr.BasicLiteral)
+	c.Value.(*big.Int).SetInt64(2)
+	if got := e.Value.(*big.Int).Int64(); got != 1 {
+		t.Errorf("modifying clone changed original value to %d", got)
+	}
+}
+
+// skipField reports whether a struct field is deliberately shared
+// by Clone.
+func skipField(name string) bool {
+	return name == "Type" || name == "Body" || name == "Error"
+}
+
+func collectPtrs(v reflect.Value, ptrs map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		ptrs[v.Pointer()] = true
+		collectPtrs(v.Elem(), ptrs)
+	case reflect.Interface:
+		collectPtrs(v.Elem(), ptrs)
+	case reflect.Slice:
+		if v.Len() > 0 {
+			ptrs[v.Pointer()] = true
+		}
+		for i := 0; i < v.Len(); i++ {
+			collectPtrs(v.Index(i), ptrs)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !skipField(v.Type().Field(i).Name) {
+				collectPtrs(v.Field(i), ptrs)
+			}
+		}
+	}
+}
+
+func findPtr(v reflect.Value, ptrs map[uintptr]bool) string {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		if ptrs[v.Pointer()] {
+			return v.Type().String()
+		}
+		return findPtr(v.Elem(), ptrs)
+	case reflect.Interface:
+		return findPtr(v.Elem(), ptrs)
+	case reflect.Slice:
+		if v.Len() > 0 && ptrs[v.Pointer()] {
+			return v.Type().String()
+		}
+		for i := 0; i < v.Len(); i++ {
+			if s := findPtr(v.Index(i), ptrs); s != "" {
+				return s
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if skipField(v.Type().Field(i).Name) {
+				continue
+			}
+			if s := findPtr(v.Field(i), ptrs); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -62,6 +62,7 @@ type TypeAssert struct {
 type BasicLiteral struct {
 	Position src.Pos
 	Value    interface{} // string, *big.Int, *big.Float
+	Text     string      // source text, or "" if not parsed
 }
 
 type FuncLiteral struct {
@@ -72,13 +73,21 @@ type FuncLiteral struct {
 	Type            *tipe.Func
 	ParamNames      []string
 	ResultNames     []string
-	Body            interface{} // *stmt.Block, breaking the package import cycle
+	Body            Body
+}
+
+// Body is the body of a function literal, a *stmt.Block.
+//
+// Package stmt imports this one, so a FuncLiteral cannot refer to
+// stmt.Block directly. Only *stmt.Block has the FuncBody method.
+type Body interface {
+	FuncBody()
 }
 
 type CompLiteral struct {
 	Position src.Pos
 	Type     tipe.Type
-	Keys     []Expr // TODO: could make this []string
+	Keys     []Expr // field names of a struct are *Ident, resolved by the type checker
 	Values   []Expr
 }
 
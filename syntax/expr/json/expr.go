@@ -0,0 +1,481 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+)
+
+func (e *encoder) expr(x expr.Expr) interface{} {
+	var o *object
+	switch x := x.(type) {
+	case nil:
+		return nil
+	case *expr.Binary:
+		o = newObject("Binary", x.Position)
+		o.add("op", e.token(x.Op))
+		o.add("left", e.expr(x.Left))
+		o.add("right", e.expr(x.Right))
+	case *expr.Unary:
+		o = newObject("Unary", x.Position)
+		o.add("op", e.token(x.Op))
+		o.add("expr", e.expr(x.Expr))
+	case *expr.Bad:
+		o = newObject("Bad", x.Position)
+		if x.Error != nil {
+			o.add("error", e.str(x.Error.Error()))
+		}
+	case *expr.Selector:
+		o = newObject("Selector", x.Position)
+		o.add("left", e.expr(x.Left))
+		if x.Right != nil {
+			o.add("right", e.expr(x.Right))
+		}
+	case *expr.Slice:
+		o = newObject("Slice", x.Position)
+		o.add("low", e.expr(x.Low))
+		o.add("high", e.expr(x.High))
+		o.add("max", e.expr(x.Max))
+	case *expr.Index:
+		o = newObject("Index", x.Position)
+		o.add("left", e.expr(x.Left))
+		o.add("indices", e.exprs(x.Indicies))
+	case *expr.TypeAssert:
+		o = newObject("TypeAssert", x.Position)
+		o.add("left", e.expr(x.Left))
+		o.add("tipe", e.tipe(x.Type))
+	case *expr.BasicLiteral:
+		o = newObject("BasicLiteral", x.Position)
+		o.add("value", e.literal(x.Value))
+		if x.Text != "" {
+			o.add("text", e.str(x.Text))
+		}
+	case *expr.FuncLiteral:
+		o = newObject("FuncLiteral", x.Position)
+		o.add("name", e.str(x.Name))
+		o.add("receiverName", e.str(x.ReceiverName))
+		o.add("pointerReceiver", x.PointerReceiver)
+		if x.Type != nil {
+			o.add("tipe", e.tipe(x.Type))
+		}
+		o.add("paramNames", e.strs(x.ParamNames))
+		o.add("resultNames", e.strs(x.ResultNames))
+		switch body := x.Body.(type) {
+		case nil:
+		case *stmt.Block:
+			o.add("body", e.block(body))
+		default:
+			e.errorf("function literal body is %T, want *stmt.Block", body)
+		}
+	case *expr.CompLiteral:
+		o = newObject("CompLiteral", x.Position)
+		o.add("tipe", e.tipe(x.Type))
+		o.add("keys", e.exprs(x.Keys))
+		o.add("values", e.exprs(x.Values))
+	case *expr.MapLiteral:
+		o = newObject("MapLiteral", x.Position)
+		o.add("tipe", e.tipe(x.Type))
+		o.add("keys", e.exprs(x.Keys))
+		o.add("values", e.exprs(x.Values))
+	case *expr.ArrayLiteral:
+		o = newObject("ArrayLiteral", x.Position)
+		if x.Type != nil {
+			o.add("tipe", e.tipe(x.Type))
+		}
+		o.add("keys", e.exprs(x.Keys))
+		o.add("values", e.exprs(x.Values))
+	case *expr.SliceLiteral:
+		o = newObject("SliceLiteral", x.Position)
+		if x.Type != nil {
+			o.add("tipe", e.tipe(x.Type))
+		}
+		o.add("keys", e.exprs(x.Keys))
+		o.add("values", e.exprs(x.Values))
+	case *expr.TableLiteral:
+		o = newObject("TableLiteral", x.Position)
+		if x.Type != nil {
+			o.add("tipe", e.tipe(x.Type))
+		}
+		o.add("colNames", e.exprs(x.ColNames))
+		var rows []interface{}
+		for _, row := range x.Rows {
+			rows = append(rows, e.exprs(row))
+		}
+		o.add("rows", rows)
+	case *expr.Type:
+		o = newObject("Type", x.Position)
+		o.add("tipe", e.tipe(x.Type))
+	case *expr.Ident:
+		o = newObject("Ident", x.Position)
+		o.add("name", e.str(x.Name))
+	case *expr.Call:
+		o = newObject("Call", x.Position)
+		o.add("func", e.expr(x.Func))
+		o.add("args", e.exprs(x.Args))
+		o.add("ellipsis", x.Ellipsis)
+		o.add("elideError", x.ElideError)
+	case *expr.ShellList:
+		o = newObject("ShellList", x.Position)
+		var andOr []interface{}
+		for _, a := range x.AndOr {
+			andOr = append(andOr, e.expr(a))
+		}
+		o.add("andOr", andOr)
+	case *expr.ShellAndOr:
+		o = newObject("ShellAndOr", x.Position)
+		var pipeline, sep []interface{}
+		for _, p := range x.Pipeline {
+			pipeline = append(pipeline, e.expr(p))
+		}
+		for _, t := range x.Sep {
+			sep = append(sep, e.token(t))
+		}
+		o.add("pipeline", pipeline)
+		o.add("sep", sep)
+		o.add("background", x.Background)
+	case *expr.ShellPipeline:
+		o = newObject("ShellPipeline", x.Position)
+		o.add("bang", x.Bang)
+		var cmd []interface{}
+		for _, c := range x.Cmd {
+			cmd = append(cmd, e.expr(c))
+		}
+		o.add("cmd", cmd)
+	case *expr.ShellCmd:
+		o = newObject("ShellCmd", x.Position)
+		if x.SimpleCmd != nil {
+			o.add("simpleCmd", e.expr(x.SimpleCmd))
+		}
+		if x.Subshell != nil {
+			o.add("subshell", e.expr(x.Subshell))
+		}
+	case *expr.ShellSimpleCmd:
+		o = newObject("ShellSimpleCmd", x.Position)
+		var redirect, assign []interface{}
+		for _, r := range x.Redirect {
+			redirect = append(redirect, e.expr(r))
+		}
+		for i := range x.Assign {
+			assign = append(assign, e.expr(&x.Assign[i]))
+		}
+		o.add("redirect", redirect)
+		o.add("assign", assign)
+		o.add("args", e.strs(x.Args))
+	case *expr.ShellRedirect:
+		o = newObject("ShellRedirect", x.Position)
+		if x.Number != nil {
+			o.add("number", *x.Number)
+		}
+		o.add("token", e.token(x.Token))
+		o.add("filename", e.str(x.Filename))
+	case *expr.ShellAssign:
+		o = newObject("ShellAssign", x.Position)
+		o.add("key", e.str(x.Key))
+		o.add("value", e.str(x.Value))
+	case *expr.Shell:
+		o = newObject("Shell", x.Position)
+		var cmds []interface{}
+		for _, c := range x.Cmds {
+			cmds = append(cmds, e.expr(c))
+		}
+		o.add("cmds", cmds)
+		o.add("trapOut", x.TrapOut)
+		o.add("dropOut", x.DropOut)
+		o.add("elideError", x.ElideError)
+		o.add("freeVars", e.strs(x.FreeVars))
+	default:
+		e.errorf("cannot marshal expression of type %T", x)
+		return nil
+	}
+	return o
+}
+
+func (e *encoder) exprs(list []expr.Expr) interface{} {
+	var res []interface{}
+	for _, x := range list {
+		res = append(res, e.expr(x))
+	}
+	return res
+}
+
+func (d *decoder) expr(v interface{}) expr.Expr {
+	f, typ, ok := d.node(v)
+	if !ok {
+		return nil
+	}
+	pos := d.pos(f)
+	switch typ {
+	case "Binary":
+		return &expr.Binary{
+			Position: pos,
+			Op:       d.token(f, "op"),
+			Left:     d.expr(f["left"]),
+			Right:    d.expr(f["right"]),
+		}
+	case "Unary":
+		return &expr.Unary{
+			Position: pos,
+			Op:       d.token(f, "op"),
+			Expr:     d.expr(f["expr"]),
+		}
+	case "Bad":
+		return &expr.Bad{
+			Position: pos,
+			Error:    d.error(f),
+		}
+	case "Selector":
+		x := &expr.Selector{
+			Position: pos,
+			Left:     d.expr(f["left"]),
+		}
+		if right := d.expr(f["right"]); right != nil {
+			x.Right = d.ident(right)
+		}
+		return x
+	case "Slice":
+		return &expr.Slice{
+			Position: pos,
+			Low:      d.expr(f["low"]),
+			High:     d.expr(f["high"]),
+			Max:      d.expr(f["max"]),
+		}
+	case "Index":
+		return &expr.Index{
+			Position: pos,
+			Left:     d.expr(f["left"]),
+			Indicies: d.exprs(f, "indices"),
+		}
+	case "TypeAssert":
+		return &expr.TypeAssert{
+			Position: pos,
+			Left:     d.expr(f["left"]),
+			Type:     d.tipe(f["tipe"]),
+		}
+	case "BasicLiteral":
+		return &expr.BasicLiteral{
+			Position: pos,
+			Value:    d.literal(d.object(f, "value")),
+			Text:     d.str(f, "text"),
+		}
+	case "FuncLiteral":
+		x := &expr.FuncLiteral{
+			Position:        pos,
+			Name:            d.str(f, "name"),
+			ReceiverName:    d.str(f, "receiverName"),
+			PointerReceiver: d.bool(f, "pointerReceiver"),
+			ParamNames:      d.strs(f, "paramNames"),
+			ResultNames:     d.strs(f, "resultNames"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			x.Type = d.funcType(t)
+		}
+		if body := d.block(f["body"]); body != nil {
+			x.Body = body
+		}
+		return x
+	case "CompLiteral":
+		return &expr.CompLiteral{
+			Position: pos,
+			Type:     d.tipe(f["tipe"]),
+			Keys:     d.exprs(f, "keys"),
+			Values:   d.exprs(f, "values"),
+		}
+	case "MapLiteral":
+		return &expr.MapLiteral{
+			Position: pos,
+			Type:     d.tipe(f["tipe"]),
+			Keys:     d.exprs(f, "keys"),
+			Values:   d.exprs(f, "values"),
+		}
+	case "ArrayLiteral":
+		x := &expr.ArrayLiteral{
+			Position: pos,
+			Keys:     d.exprs(f, "keys"),
+			Values:   d.exprs(f, "values"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			var ok bool
+			if x.Type, ok = t.(*tipe.Array); !ok {
+				d.errorf("array literal has type %T", t)
+			}
+		}
+		return x
+	case "SliceLiteral":
+		x := &expr.SliceLiteral{
+			Position: pos,
+			Keys:     d.exprs(f, "keys"),
+			Values:   d.exprs(f, "values"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			var ok bool
+			if x.Type, ok = t.(*tipe.Slice); !ok {
+				d.errorf("slice literal has type %T", t)
+			}
+		}
+		return x
+	case "TableLiteral":
+		x := &expr.TableLiteral{
+			Position: pos,
+			ColNames: d.exprs(f, "colNames"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			var ok bool
+			if x.Type, ok = t.(*tipe.Table); !ok {
+				d.errorf("table literal has type %T", t)
+			}
+		}
+		for _, row := range d.list(f, "rows") {
+			x.Rows = append(x.Rows, d.exprs(fields{"row": row}, "row"))
+		}
+		return x
+	case "Type":
+		return &expr.Type{
+			Position: pos,
+			Type:     d.tipe(f["tipe"]),
+		}
+	case "Ident":
+		return &expr.Ident{
+			Position: pos,
+			Name:     d.str(f, "name"),
+		}
+	case "Call":
+		return &expr.Call{
+			Position:   pos,
+			Func:       d.expr(f["func"]),
+			Args:       d.exprs(f, "args"),
+			Ellipsis:   d.bool(f, "ellipsis"),
+			ElideError: d.bool(f, "elideError"),
+		}
+	case "ShellList":
+		x := &expr.ShellList{Position: pos}
+		for _, v := range d.list(f, "andOr") {
+			a, ok := d.expr(v).(*expr.ShellAndOr)
+			if !ok {
+				d.errorf("ShellList andOr is not a ShellAndOr")
+			}
+			x.AndOr = append(x.AndOr, a)
+		}
+		return x
+	case "ShellAndOr":
+		x := &expr.ShellAndOr{
+			Position:   pos,
+			Background: d.bool(f, "background"),
+		}
+		for _, v := range d.list(f, "pipeline") {
+			p, ok := d.expr(v).(*expr.ShellPipeline)
+			if !ok {
+				d.errorf("ShellAndOr pipeline is not a ShellPipeline")
+			}
+			x.Pipeline = append(x.Pipeline, p)
+		}
+		for _, v := range d.list(f, "sep") {
+			x.Sep = append(x.Sep, d.token(fields{"sep": v}, "sep"))
+		}
+		return x
+	case "ShellPipeline":
+		x := &expr.ShellPipeline{
+			Position: pos,
+			Bang:     d.bool(f, "bang"),
+		}
+		for _, v := range d.list(f, "cmd") {
+			c, ok := d.expr(v).(*expr.ShellCmd)
+			if !ok {
+				d.errorf("ShellPipeline cmd is not a ShellCmd")
+			}
+			x.Cmd = append(x.Cmd, c)
+		}
+		return x
+	case "ShellCmd":
+		x := &expr.ShellCmd{Position: pos}
+		if v := d.expr(f["simpleCmd"]); v != nil {
+			var ok bool
+			if x.SimpleCmd, ok = v.(*expr.ShellSimpleCmd); !ok {
+				d.errorf("ShellCmd simpleCmd is %T", v)
+			}
+		}
+		if v := d.expr(f["subshell"]); v != nil {
+			var ok bool
+			if x.Subshell, ok = v.(*expr.ShellList); !ok {
+				d.errorf("ShellCmd subshell is %T", v)
+			}
+		}
+		return x
+	case "ShellSimpleCmd":
+		x := &expr.ShellSimpleCmd{
+			Position: pos,
+			Args:     d.strs(f, "args"),
+		}
+		for _, v := range d.list(f, "redirect") {
+			r, ok := d.expr(v).(*expr.ShellRedirect)
+			if !ok {
+				d.errorf("ShellSimpleCmd redirect is not a ShellRedirect")
+			}
+			x.Redirect = append(x.Redirect, r)
+		}
+		for _, v := range d.list(f, "assign") {
+			a, ok := d.expr(v).(*expr.ShellAssign)
+			if !ok {
+				d.errorf("ShellSimpleCmd assign is not a ShellAssign")
+				continue
+			}
+			x.Assign = append(x.Assign, *a)
+		}
+		return x
+	case "ShellRedirect":
+		x := &expr.ShellRedirect{
+			Position: pos,
+			Token:    d.token(f, "token"),
+			Filename: d.str(f, "filename"),
+		}
+		if _, ok := f["number"]; ok {
+			n := int(d.int(f, "number", 0))
+			x.Number = &n
+		}
+		return x
+	case "ShellAssign":
+		return &expr.ShellAssign{
+			Position: pos,
+			Key:      d.str(f, "key"),
+			Value:    d.str(f, "value"),
+		}
+	case "Shell":
+		x := &expr.Shell{
+			Position:   pos,
+			TrapOut:    d.bool(f, "trapOut"),
+			DropOut:    d.bool(f, "dropOut"),
+			ElideError: d.bool(f, "elideError"),
+			FreeVars:   d.strs(f, "freeVars"),
+		}
+		for _, v := range d.list(f, "cmds") {
+			l, ok := d.expr(v).(*expr.ShellList)
+			if !ok {
+				d.errorf("Shell cmds element is not a ShellList")
+			}
+			x.Cmds = append(x.Cmds, l)
+		}
+		return x
+	default:
+		d.errorf("unknown expression type %q", typ)
+		return nil
+	}
+}
+
+func (d *decoder) exprs(f fields, key string) []expr.Expr {
+	var res []expr.Expr
+	for _, v := range d.list(f, key) {
+		res = append(res, d.expr(v))
+	}
+	return res
+}
+
+func (d *decoder) ident(x expr.Expr) *expr.Ident {
+	id, ok := x.(*expr.Ident)
+	if !ok {
+		d.errorf("expression is %T, want *expr.Ident", x)
+	}
+	return id
+}
@@ -0,0 +1,451 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package json converts Neugram expression trees to and from JSON.
+//
+// Each node is a JSON object with a "type" field naming its Go type,
+// followed by its fields in lower camel case:
+//
+//	{"type": "Binary", "op": "Add", "left": {...}, "right": {...}}
+//
+// Operators are given by the names of their token constants. Fields
+// holding a tipe.Type are named "tipe" and are encoded the same way,
+// as are the statements in the body of a function literal. Nodes
+// with a position have a "pos" field. Zero-valued fields are left
+// out, so nil and empty slices are not distinguished.
+//
+// The value of a basic literal is an object with a "kind" of string,
+// bytes (a string that is not valid UTF-8, in base64), rune, bigint,
+// bigfloat or bigcomplex. Numbers are written as decimal strings so
+// that no precision is lost:
+//
+//	{"kind": "bigint", "value": "12345678901234567890"}
+//
+// A named type is written out in full the first time it is seen and
+// given an "id". Later uses, including recursive ones, refer back to
+// it with a "ref".
+package json // import "neugram.io/ng/syntax/expr/json"
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
+
+	"neugram.io/ng/internal/bigcplx"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+)
+
+// Marshal returns the JSON encoding of e.
+func Marshal(e expr.Expr) ([]byte, error) {
+	enc := &encoder{named: make(map[*tipe.Named]int)}
+	v := enc.expr(e)
+	if enc.err != nil {
+		return nil, fmt.Errorf("json: Marshal: %v", enc.err)
+	}
+	buf := new(bytes.Buffer)
+	w := json.NewEncoder(buf)
+	w.SetEscapeHTML(false)
+	if err := w.Encode(v); err != nil {
+		return nil, fmt.Errorf("json: Marshal: %v", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Unmarshal decodes an expression encoded by Marshal.
+func Unmarshal(data []byte) (expr.Expr, error) {
+	r := json.NewDecoder(bytes.NewReader(data))
+	r.UseNumber()
+	var v interface{}
+	if err := r.Decode(&v); err != nil {
+		return nil, fmt.Errorf("json: Unmarshal: %v", err)
+	}
+	if _, err := r.Token(); err != io.EOF {
+		return nil, errors.New("json: Unmarshal: trailing data after expression")
+	}
+	dec := &decoder{named: make(map[int64]*tipe.Named)}
+	e := dec.expr(v)
+	if dec.err != nil {
+		return nil, fmt.Errorf("json: Unmarshal: %v", dec.err)
+	}
+	return e, nil
+}
+
+// An object is a JSON object that keeps its fields in order.
+type object []field
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+func newObject(typ string, pos src.Pos) *object {
+	o := &object{{"type", typ}}
+	if pos != (src.Pos{}) {
+		p := &object{}
+		p.add("filename", pos.Filename)
+		if pos.Line != 0 {
+			p.add("line", pos.Line)
+		}
+		if pos.Column != 0 {
+			p.add("column", pos.Column)
+		}
+		o.add("pos", p)
+	}
+	return o
+}
+
+// add appends a field to o, unless value is nil, false, an empty
+// string or an empty list.
+func (o *object) add(key string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case bool:
+		if !v {
+			return
+		}
+	case string:
+		if v == "" {
+			return
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
+	case *object:
+		if v == nil {
+			return
+		}
+	}
+	*o = append(*o, field{key, value})
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, f := range *o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		w := json.NewEncoder(buf)
+		w.SetEscapeHTML(false)
+		if err := w.Encode(f.value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode adds a newline
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// An encoder builds the JSON value of a tree. The first error is
+// kept in err and the rest of the tree is still walked.
+type encoder struct {
+	named map[*tipe.Named]int // named type -> id
+	err   error
+}
+
+func (e *encoder) errorf(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf(format, args...)
+	}
+}
+
+// str checks that s can be represented in JSON.
+func (e *encoder) str(s string) string {
+	if !utf8.ValidString(s) {
+		e.errorf("string %q is not valid UTF-8", s)
+	}
+	return s
+}
+
+func (e *encoder) strs(list []string) interface{} {
+	var res []interface{}
+	for _, s := range list {
+		res = append(res, e.str(s))
+	}
+	return res
+}
+
+func (e *encoder) token(t token.Token) string {
+	if t < 0 || int(t) >= len(tokenNames) {
+		e.errorf("unknown token %d", int(t))
+		return ""
+	}
+	return tokenNames[t]
+}
+
+func (e *encoder) literal(v interface{}) interface{} {
+	o := &object{}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if utf8.ValidString(v) {
+			o.add("kind", "string")
+			o.add("value", v)
+		} else {
+			o.add("kind", "bytes")
+			o.add("value", base64.StdEncoding.EncodeToString([]byte(v)))
+		}
+	case rune:
+		o.add("kind", "rune")
+		o.add("value", strconv.Itoa(int(v)))
+	case *big.Int:
+		o.add("kind", "bigint")
+		o.add("value", v.String())
+	case *big.Float:
+		return e.bigFloat(v)
+	case *bigcplx.Complex:
+		o.add("kind", "bigcomplex")
+		o.add("real", e.bigFloat(v.Real))
+		o.add("imag", e.bigFloat(v.Imag))
+	default:
+		e.errorf("cannot marshal literal of type %T", v)
+	}
+	return o
+}
+
+// bigFloat encodes f with the fewest digits that, at the precision
+// of f, are read back as the same value.
+func (e *encoder) bigFloat(f *big.Float) interface{} {
+	o := &object{}
+	o.add("kind", "bigfloat")
+	o.add("value", f.Text('g', -1))
+	o.add("prec", f.Prec())
+	return o
+}
+
+// A decoder builds a tree from a decoded JSON value. Like encoder it
+// keeps the first error in err.
+type decoder struct {
+	named map[int64]*tipe.Named // id -> named type
+	err   error
+}
+
+// fields is a decoded JSON object.
+type fields map[string]interface{}
+
+func (d *decoder) errorf(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+// node returns the fields and type name of the node v. A JSON null
+// gives ok == false.
+func (d *decoder) node(v interface{}) (f fields, typ string, ok bool) {
+	if v == nil || d.err != nil {
+		return nil, "", false
+	}
+	m, isMap := v.(map[string]interface{})
+	if !isMap {
+		d.errorf("node is %s, want object", describe(v))
+		return nil, "", false
+	}
+	f = fields(m)
+	typ = d.str(f, "type")
+	if typ == "" {
+		d.errorf("node has no type")
+		return nil, "", false
+	}
+	return f, typ, true
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func (d *decoder) str(f fields, key string) string {
+	v, ok := f[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		d.errorf("%q is %s, want string", key, describe(v))
+	}
+	return s
+}
+
+func (d *decoder) bool(f fields, key string) bool {
+	v, ok := f[key]
+	if !ok {
+		return false
+	}
+	b, ok := v.(bool)
+	if !ok {
+		d.errorf("%q is %s, want boolean", key, describe(v))
+	}
+	return b
+}
+
+// int decodes an integer field that must fit in bits bits.
+func (d *decoder) int(f fields, key string, bits int) int64 {
+	v, ok := f[key]
+	if !ok {
+		return 0
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		d.errorf("%q is %s, want number", key, describe(v))
+		return 0
+	}
+	i, err := strconv.ParseInt(string(n), 10, bits)
+	if err != nil {
+		d.errorf("%q: %v", key, err)
+	}
+	return i
+}
+
+func (d *decoder) list(f fields, key string) []interface{} {
+	v, ok := f[key]
+	if !ok || v == nil {
+		return nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		d.errorf("%q is %s, want list", key, describe(v))
+	}
+	return list
+}
+
+func (d *decoder) object(f fields, key string) fields {
+	v, ok := f[key]
+	if !ok || v == nil {
+		return nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		d.errorf("%q is %s, want object", key, describe(v))
+	}
+	return fields(m)
+}
+
+func (d *decoder) strs(f fields, key string) []string {
+	var res []string
+	for _, v := range d.list(f, key) {
+		s, ok := v.(string)
+		if !ok {
+			d.errorf("%q element is %s, want string", key, describe(v))
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func (d *decoder) token(f fields, key string) token.Token {
+	name := d.str(f, key)
+	t, ok := tokensByName[name]
+	if !ok {
+		d.errorf("%q: unknown token %q", key, name)
+	}
+	return t
+}
+
+func (d *decoder) pos(f fields) src.Pos {
+	p := d.object(f, "pos")
+	if p == nil {
+		return src.Pos{}
+	}
+	return src.Pos{
+		Filename: d.str(p, "filename"),
+		Line:     int32(d.int(p, "line", 32)),
+		Column:   int16(d.int(p, "column", 16)),
+	}
+}
+
+func (d *decoder) error(f fields) error {
+	if msg := d.str(f, "error"); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func (d *decoder) literal(f fields) interface{} {
+	if f == nil {
+		return nil
+	}
+	value := d.str(f, "value")
+	switch kind := d.str(f, "kind"); kind {
+	case "string":
+		return value
+	case "bytes":
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			d.errorf("bytes literal: %v", err)
+		}
+		return string(b)
+	case "rune":
+		r, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			d.errorf("rune literal: %v", err)
+		}
+		return rune(r)
+	case "bigint":
+		i, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			d.errorf("bad bigint literal %q", value)
+		}
+		return i
+	case "bigfloat":
+		return d.bigFloat(f)
+	case "bigcomplex":
+		real, imag := d.object(f, "real"), d.object(f, "imag")
+		if real == nil || imag == nil {
+			d.errorf("bigcomplex literal needs real and imag parts")
+			return nil
+		}
+		return &bigcplx.Complex{Real: d.bigFloat(real), Imag: d.bigFloat(imag)}
+	default:
+		d.errorf("unknown literal kind %q", kind)
+		return nil
+	}
+}
+
+func (d *decoder) bigFloat(f fields) *big.Float {
+	if kind := d.str(f, "kind"); kind != "bigfloat" {
+		d.errorf("literal kind is %q, want bigfloat", kind)
+	}
+	value := d.str(f, "value")
+	prec := uint(d.int(f, "prec", 32))
+	x, _, err := big.ParseFloat(value, 10, prec, big.ToNearestEven)
+	if err != nil {
+		d.errorf("bad bigfloat literal %q: %v", value, err)
+		return new(big.Float)
+	}
+	if prec == 0 {
+		x.SetPrec(0) // ParseFloat uses 64 in place of 0
+	}
+	return x
+}
@@ -0,0 +1,249 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"unicode/utf8"
+
+	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/expr/json"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+)
+
+var marshalTests = []struct {
+	e    expr.Expr
+	want string
+}{
+	{
+		&expr.Binary{Op: token.Add, Left: &expr.Ident{Name: "x"}, Right: &expr.Ident{Name: "y"}},
+		`{"type":"Binary","op":"Add","left":{"type":"Ident","name":"x"},"right":{"type":"Ident","name":"y"}}`,
+	},
+	{
+		&expr.BasicLiteral{Value: bigInt("12345678901234567890")},
+		`{"type":"BasicLiteral","value":{"kind":"bigint","value":"12345678901234567890"}}`,
+	},
+	{
+		&expr.BasicLiteral{Value: big.NewFloat(0.1)},
+		`{"type":"BasicLiteral","value":{"kind":"bigfloat","value":"0.1","prec":53}}`,
+	},
+	{
+		&expr.BasicLiteral{Value: "a<b>\xff"},
+		`{"type":"BasicLiteral","value":{"kind":"bytes","value":"YTxiPv8="}}`,
+	},
+	{
+		&expr.Call{Func: &expr.Ident{Name: "f"}, Args: []expr.Expr{&expr.Type{Type: tipe.Byte}}, Ellipsis: true},
+		`{"type":"Call","func":{"type":"Ident","name":"f"},"args":[{"type":"Type","tipe":{"type":"Alias","name":"byte","tipe":{"type":"Basic","name":"uint8"}}}],"ellipsis":true}`,
+	},
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
+
+func TestMarshal(t *testing.T) {
+	for _, test := range marshalTests {
+		got, err := json.Marshal(test.e)
+		if err != nil {
+			t.Errorf("Marshal(%#v): %v", test.e, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("Marshal(%#v)\ngot:  %s\nwant: %s", test.e, got, test.want)
+		}
+		roundTrip(t, test.e)
+	}
+}
+
+// roundTripSources are parsed as statements and every expression in
+// them is encoded and decoded.
+var roundTripSources = []string{
+	`x + y*2 - 12345678901234567890`,
+	`!a && -b || *p == &q`,
+	`f(x, y...)[0]`,
+	`a.b.c`,
+	`s[1:2:3]`,
+	`s[:2]`,
+	`t[0, "a"]`,
+	`x.(int)`,
+	`"hello\xff"`,
+	`'x'`,
+	`1.5e300 + 0.1`,
+	`x := 2i`,
+	`func(a int, b ...string) (r int) { return a }`,
+	`[]int{1, 2}`,
+	`[...]int{0: 1}`,
+	`map[string][]*int{"a": nil}`,
+	`x := struct{ X int "tag" }{X: 1}`,
+	`[|]num{{|"a", "b"|}, {1, 2}}`,
+	`make(chan<- int, 3)`,
+	`new(interface{ M(x int) string })`,
+	`($$ ls -l | wc > out.txt && FOO=bar echo $x || grep a 2>&1 $$)`,
+	`($$ (cd /tmp; ls) & $$)`,
+	`x := func() {
+		var x, y int = 1, 2
+		const c = 1
+		type T struct{ A []T }
+		type U = int
+		for i := 0; i < 3; i++ {
+			continue
+		}
+		for k, v := range m {
+			_ = k + v
+		}
+		switch x {
+		case 1, 2:
+		default:
+		}
+		switch y := z.(type) {
+		case int, string:
+		default:
+		}
+		select {
+		case <-c:
+		case ch <- 1:
+		default:
+		}
+		go f()
+		defer g()
+		ch <- 1
+	L:
+		for {
+			break L
+		}
+		if x := 1; x > 0 {
+		} else if y {
+		} else {
+		}
+		x, y = y, x
+	}`,
+	`methodik T *struct{ X int } {
+		func (*t) Get() int { return t.X }
+	}
+	`,
+	`import "fmt"`,
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, src := range roundTripSources {
+		s, err := parser.ParseStmt([]byte(src))
+		if err != nil {
+			t.Errorf("ParseStmt(%q): %v", src, err)
+			continue
+		}
+		exprs := exprsOf(s)
+		if len(exprs) == 0 && src != `import "fmt"` {
+			t.Errorf("%q: no expressions", src)
+		}
+		for _, e := range exprs {
+			roundTrip(t, e)
+		}
+	}
+}
+
+func exprsOf(s stmt.Stmt) []expr.Expr {
+	var exprs []expr.Expr
+	syntax.Walk(s, func(c *syntax.Cursor) bool {
+		if e, isExpr := c.Node.(expr.Expr); isExpr {
+			exprs = append(exprs, e)
+		}
+		return true
+	}, nil)
+	return exprs
+}
+
+// roundTrip checks that e decodes to an equal expression, and that
+// the result, positions included, encodes the same way.
+func roundTrip(t *testing.T, e expr.Expr) {
+	t.Helper()
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Errorf("Marshal(%#v): %v", e, err)
+		return
+	}
+	got, err := json.Unmarshal(data)
+	if err != nil {
+		t.Errorf("Unmarshal(%s): %v", data, err)
+		return
+	}
+	if !parser.EqualExpr(got, e) {
+		t.Errorf("Unmarshal(%s) = %#v, want %#v", data, got, e)
+	}
+	data2, err := json.Marshal(got)
+	if err != nil {
+		t.Errorf("Marshal(Unmarshal(%s)): %v", data, err)
+		return
+	}
+	if !bytes.Equal(data, data2) {
+		t.Errorf("round trip changed encoding\nfirst:  %s\nsecond: %s", data, data2)
+	}
+}
+
+func TestRecursiveNamed(t *testing.T) {
+	n := &tipe.Named{Name: "List", MethodNames: []string{"Next"}}
+	n.Type = &tipe.Struct{Fields: []tipe.StructField{{Name: "next", Type: &tipe.Pointer{Elem: n}}}}
+	n.Methods = []*tipe.Func{{Results: &tipe.Tuple{Elems: []tipe.Type{n}}}}
+
+	data, err := json.Marshal(&expr.Type{Type: n})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := json.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.(*expr.Type).Type.(*tipe.Named)
+	next := got.Type.(*tipe.Struct).Fields[0].Type.(*tipe.Pointer).Elem
+	if next != got {
+		t.Errorf("field type is %p, want the named type %p", next, got)
+	}
+	if res := got.Methods[0].Results.Elems[0]; res != got {
+		t.Errorf("method result is %p, want the named type %p", res, got)
+	}
+}
+
+var unmarshalErrTests = []string{
+	``,
+	`{}`,
+	`[]`,
+	`{"type":"Nope"}`,
+	`{"type":"Binary","op":"Nope"}`,
+	`{"type":"BasicLiteral","value":{"kind":"bigint","value":"1.5"}}`,
+	`{"type":"Type","tipe":{"type":"Named","ref":1}}`,
+	`{"type":"Ident","name":"x"} {}`,
+}
+
+func TestUnmarshalError(t *testing.T) {
+	for _, data := range unmarshalErrTests {
+		if e, err := json.Unmarshal([]byte(data)); err == nil {
+			t.Errorf("Unmarshal(%s) = %#v, want error", data, e)
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, src := range roundTripSources {
+		f.Add(src)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		if !utf8.ValidString(src) {
+			return
+		}
+		s, err := parser.ParseStmt([]byte(src))
+		if err != nil {
+			return
+		}
+		for _, e := range exprsOf(s) {
+			roundTrip(t, e)
+		}
+	})
+}
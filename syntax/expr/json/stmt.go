@@ -0,0 +1,467 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/stmt"
+)
+
+// stmt encodes the statements found in the bodies of function
+// literals.
+func (e *encoder) stmt(s stmt.Stmt) interface{} {
+	var o *object
+	switch s := s.(type) {
+	case nil:
+		return nil
+	case *stmt.Import:
+		o = newObject("Import", s.Position)
+		o.add("name", e.str(s.Name))
+		o.add("path", e.str(s.Path))
+	case *stmt.ImportSet:
+		o = newObject("ImportSet", s.Position)
+		var imports []interface{}
+		for _, imp := range s.Imports {
+			imports = append(imports, e.stmt(imp))
+		}
+		o.add("imports", imports)
+	case *stmt.TypeDecl:
+		o = newObject("TypeDecl", s.Position)
+		o.add("name", e.str(s.Name))
+		if s.Type != nil {
+			o.add("tipe", e.tipe(s.Type))
+		}
+		if s.Alias != nil {
+			o.add("alias", e.tipe(s.Alias))
+		}
+	case *stmt.TypeDeclSet:
+		o = newObject("TypeDeclSet", s.Position)
+		var decls []interface{}
+		for _, decl := range s.TypeDecls {
+			decls = append(decls, e.stmt(decl))
+		}
+		o.add("typeDecls", decls)
+	case *stmt.MethodikDecl:
+		o = newObject("MethodikDecl", s.Position)
+		o.add("name", e.str(s.Name))
+		if s.Type != nil {
+			o.add("tipe", e.tipe(s.Type))
+		}
+		var methods []interface{}
+		for _, m := range s.Methods {
+			methods = append(methods, e.expr(m))
+		}
+		o.add("methods", methods)
+	case *stmt.Const:
+		o = newObject("Const", s.Position)
+		o.add("names", e.strs(s.NameList))
+		o.add("tipe", e.tipe(s.Type))
+		o.add("values", e.exprs(s.Values))
+	case *stmt.ConstSet:
+		o = newObject("ConstSet", s.Position)
+		var consts []interface{}
+		for _, c := range s.Consts {
+			consts = append(consts, e.stmt(c))
+		}
+		o.add("consts", consts)
+	case *stmt.Var:
+		o = newObject("Var", s.Position)
+		o.add("names", e.strs(s.NameList))
+		o.add("tipe", e.tipe(s.Type))
+		o.add("values", e.exprs(s.Values))
+	case *stmt.VarSet:
+		o = newObject("VarSet", s.Position)
+		var vars []interface{}
+		for _, v := range s.Vars {
+			vars = append(vars, e.stmt(v))
+		}
+		o.add("vars", vars)
+	case *stmt.Assign:
+		o = newObject("Assign", s.Position)
+		o.add("decl", s.Decl)
+		o.add("left", e.exprs(s.Left))
+		o.add("right", e.exprs(s.Right))
+	case *stmt.Block:
+		o = newObject("Block", s.Position)
+		var stmts []interface{}
+		for _, s := range s.Stmts {
+			stmts = append(stmts, e.stmt(s))
+		}
+		o.add("stmts", stmts)
+	case *stmt.If:
+		o = newObject("If", s.Position)
+		o.add("init", e.stmt(s.Init))
+		o.add("cond", e.expr(s.Cond))
+		o.add("body", e.stmt(s.Body))
+		o.add("else", e.stmt(s.Else))
+	case *stmt.For:
+		o = newObject("For", s.Position)
+		o.add("init", e.stmt(s.Init))
+		o.add("cond", e.expr(s.Cond))
+		o.add("post", e.stmt(s.Post))
+		o.add("body", e.stmt(s.Body))
+	case *stmt.Switch:
+		o = newObject("Switch", s.Position)
+		o.add("init", e.stmt(s.Init))
+		o.add("cond", e.expr(s.Cond))
+		var cases []interface{}
+		for i := range s.Cases {
+			cases = append(cases, e.stmt(&s.Cases[i]))
+		}
+		o.add("cases", cases)
+	case *stmt.SwitchCase:
+		o = newObject("SwitchCase", s.Position)
+		o.add("conds", e.exprs(s.Conds))
+		o.add("default", s.Default)
+		o.add("body", e.block(s.Body))
+	case *stmt.TypeSwitch:
+		o = newObject("TypeSwitch", s.Position)
+		o.add("init", e.stmt(s.Init))
+		o.add("assign", e.stmt(s.Assign))
+		var cases []interface{}
+		for i := range s.Cases {
+			cases = append(cases, e.stmt(&s.Cases[i]))
+		}
+		o.add("cases", cases)
+	case *stmt.TypeSwitchCase:
+		o = newObject("TypeSwitchCase", s.Position)
+		o.add("default", s.Default)
+		o.add("types", e.tipes(s.Types))
+		o.add("body", e.block(s.Body))
+	case *stmt.Go:
+		o = newObject("Go", s.Position)
+		if s.Call != nil {
+			o.add("call", e.expr(s.Call))
+		}
+	case *stmt.Range:
+		o = newObject("Range", s.Position)
+		o.add("decl", s.Decl)
+		o.add("key", e.expr(s.Key))
+		o.add("val", e.expr(s.Val))
+		o.add("expr", e.expr(s.Expr))
+		o.add("body", e.stmt(s.Body))
+	case *stmt.Return:
+		o = newObject("Return", s.Position)
+		o.add("exprs", e.exprs(s.Exprs))
+	case *stmt.Defer:
+		o = newObject("Defer", s.Position)
+		o.add("expr", e.expr(s.Expr))
+	case *stmt.Simple:
+		o = newObject("Simple", s.Position)
+		o.add("expr", e.expr(s.Expr))
+	case *stmt.Send:
+		o = newObject("Send", s.Position)
+		o.add("chan", e.expr(s.Chan))
+		o.add("value", e.expr(s.Value))
+	case *stmt.Branch:
+		o = newObject("Branch", s.Position)
+		o.add("token", e.token(s.Type))
+		o.add("label", e.str(s.Label))
+	case *stmt.Labeled:
+		o = newObject("Labeled", s.Position)
+		o.add("label", e.str(s.Label))
+		o.add("stmt", e.stmt(s.Stmt))
+	case *stmt.Select:
+		o = newObject("Select", s.Position)
+		var cases []interface{}
+		for i := range s.Cases {
+			cases = append(cases, e.selectCase(&s.Cases[i]))
+		}
+		o.add("cases", cases)
+	case *stmt.Bad:
+		o = newObject("Bad", s.Position)
+		if s.Error != nil {
+			o.add("error", e.str(s.Error.Error()))
+		}
+	default:
+		e.errorf("cannot marshal statement of type %T", s)
+		return nil
+	}
+	return o
+}
+
+func (e *encoder) block(b *stmt.Block) interface{} {
+	if b == nil {
+		return nil
+	}
+	return e.stmt(b)
+}
+
+// selectCase encodes c like a statement, though SelectCase is not
+// a stmt.Stmt.
+func (e *encoder) selectCase(c *stmt.SelectCase) interface{} {
+	o := newObject("SelectCase", c.Position)
+	o.add("default", c.Default)
+	o.add("stmt", e.stmt(c.Stmt))
+	o.add("body", e.block(c.Body))
+	return o
+}
+
+func (d *decoder) stmt(v interface{}) stmt.Stmt {
+	f, typ, ok := d.node(v)
+	if !ok {
+		return nil
+	}
+	pos := d.pos(f)
+	switch typ {
+	case "Import":
+		return &stmt.Import{
+			Position: pos,
+			Name:     d.str(f, "name"),
+			Path:     d.str(f, "path"),
+		}
+	case "ImportSet":
+		s := &stmt.ImportSet{Position: pos}
+		for _, v := range d.list(f, "imports") {
+			imp, ok := d.stmt(v).(*stmt.Import)
+			if !ok {
+				d.errorf("ImportSet imports element is not an Import")
+			}
+			s.Imports = append(s.Imports, imp)
+		}
+		return s
+	case "TypeDecl":
+		s := &stmt.TypeDecl{
+			Position: pos,
+			Name:     d.str(f, "name"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			s.Type = d.namedType(t)
+		}
+		if t := d.tipe(f["alias"]); t != nil {
+			s.Alias = d.aliasType(t)
+		}
+		return s
+	case "TypeDeclSet":
+		s := &stmt.TypeDeclSet{Position: pos}
+		for _, v := range d.list(f, "typeDecls") {
+			decl, ok := d.stmt(v).(*stmt.TypeDecl)
+			if !ok {
+				d.errorf("TypeDeclSet typeDecls element is not a TypeDecl")
+			}
+			s.TypeDecls = append(s.TypeDecls, decl)
+		}
+		return s
+	case "MethodikDecl":
+		s := &stmt.MethodikDecl{
+			Position: pos,
+			Name:     d.str(f, "name"),
+		}
+		if t := d.tipe(f["tipe"]); t != nil {
+			s.Type = d.namedType(t)
+		}
+		for _, v := range d.list(f, "methods") {
+			m, ok := d.expr(v).(*expr.FuncLiteral)
+			if !ok {
+				d.errorf("MethodikDecl methods element is not a FuncLiteral")
+			}
+			s.Methods = append(s.Methods, m)
+		}
+		return s
+	case "Const":
+		return &stmt.Const{
+			Position: pos,
+			NameList: d.strs(f, "names"),
+			Type:     d.tipe(f["tipe"]),
+			Values:   d.exprs(f, "values"),
+		}
+	case "ConstSet":
+		s := &stmt.ConstSet{Position: pos}
+		for _, v := range d.list(f, "consts") {
+			c, ok := d.stmt(v).(*stmt.Const)
+			if !ok {
+				d.errorf("ConstSet consts element is not a Const")
+			}
+			s.Consts = append(s.Consts, c)
+		}
+		return s
+	case "Var":
+		return &stmt.Var{
+			Position: pos,
+			NameList: d.strs(f, "names"),
+			Type:     d.tipe(f["tipe"]),
+			Values:   d.exprs(f, "values"),
+		}
+	case "VarSet":
+		s := &stmt.VarSet{Position: pos}
+		for _, v := range d.list(f, "vars") {
+			vr, ok := d.stmt(v).(*stmt.Var)
+			if !ok {
+				d.errorf("VarSet vars element is not a Var")
+			}
+			s.Vars = append(s.Vars, vr)
+		}
+		return s
+	case "Assign":
+		return &stmt.Assign{
+			Position: pos,
+			Decl:     d.bool(f, "decl"),
+			Left:     d.exprs(f, "left"),
+			Right:    d.exprs(f, "right"),
+		}
+	case "Block":
+		s := &stmt.Block{Position: pos}
+		for _, v := range d.list(f, "stmts") {
+			s.Stmts = append(s.Stmts, d.stmt(v))
+		}
+		return s
+	case "If":
+		return &stmt.If{
+			Position: pos,
+			Init:     d.stmt(f["init"]),
+			Cond:     d.expr(f["cond"]),
+			Body:     d.stmt(f["body"]),
+			Else:     d.stmt(f["else"]),
+		}
+	case "For":
+		return &stmt.For{
+			Position: pos,
+			Init:     d.stmt(f["init"]),
+			Cond:     d.expr(f["cond"]),
+			Post:     d.stmt(f["post"]),
+			Body:     d.stmt(f["body"]),
+		}
+	case "Switch":
+		s := &stmt.Switch{
+			Position: pos,
+			Init:     d.stmt(f["init"]),
+			Cond:     d.expr(f["cond"]),
+		}
+		for _, v := range d.list(f, "cases") {
+			c, ok := d.stmt(v).(*stmt.SwitchCase)
+			if !ok {
+				d.errorf("Switch cases element is not a SwitchCase")
+				continue
+			}
+			s.Cases = append(s.Cases, *c)
+		}
+		return s
+	case "SwitchCase":
+		return &stmt.SwitchCase{
+			Position: pos,
+			Conds:    d.exprs(f, "conds"),
+			Default:  d.bool(f, "default"),
+			Body:     d.block(f["body"]),
+		}
+	case "TypeSwitch":
+		s := &stmt.TypeSwitch{
+			Position: pos,
+			Init:     d.stmt(f["init"]),
+			Assign:   d.stmt(f["assign"]),
+		}
+		for _, v := range d.list(f, "cases") {
+			c, ok := d.stmt(v).(*stmt.TypeSwitchCase)
+			if !ok {
+				d.errorf("TypeSwitch cases element is not a TypeSwitchCase")
+				continue
+			}
+			s.Cases = append(s.Cases, *c)
+		}
+		return s
+	case "TypeSwitchCase":
+		return &stmt.TypeSwitchCase{
+			Position: pos,
+			Default:  d.bool(f, "default"),
+			Types:    d.tipes(f, "types"),
+			Body:     d.block(f["body"]),
+		}
+	case "Go":
+		s := &stmt.Go{Position: pos}
+		if x := d.expr(f["call"]); x != nil {
+			var ok bool
+			if s.Call, ok = x.(*expr.Call); !ok {
+				d.errorf("Go call is %T", x)
+			}
+		}
+		return s
+	case "Range":
+		return &stmt.Range{
+			Position: pos,
+			Decl:     d.bool(f, "decl"),
+			Key:      d.expr(f["key"]),
+			Val:      d.expr(f["val"]),
+			Expr:     d.expr(f["expr"]),
+			Body:     d.stmt(f["body"]),
+		}
+	case "Return":
+		return &stmt.Return{
+			Position: pos,
+			Exprs:    d.exprs(f, "exprs"),
+		}
+	case "Defer":
+		return &stmt.Defer{
+			Position: pos,
+			Expr:     d.expr(f["expr"]),
+		}
+	case "Simple":
+		return &stmt.Simple{
+			Position: pos,
+			Expr:     d.expr(f["expr"]),
+		}
+	case "Send":
+		return &stmt.Send{
+			Position: pos,
+			Chan:     d.expr(f["chan"]),
+			Value:    d.expr(f["value"]),
+		}
+	case "Branch":
+		return &stmt.Branch{
+			Position: pos,
+			Type:     d.token(f, "token"),
+			Label:    d.str(f, "label"),
+		}
+	case "Labeled":
+		return &stmt.Labeled{
+			Position: pos,
+			Label:    d.str(f, "label"),
+			Stmt:     d.stmt(f["stmt"]),
+		}
+	case "Select":
+		s := &stmt.Select{Position: pos}
+		for _, v := range d.list(f, "cases") {
+			if c := d.selectCase(v); c != nil {
+				s.Cases = append(s.Cases, *c)
+			}
+		}
+		return s
+	case "Bad":
+		return &stmt.Bad{
+			Position: pos,
+			Error:    d.error(f),
+		}
+	default:
+		d.errorf("unknown statement type %q", typ)
+		return nil
+	}
+}
+
+func (d *decoder) block(v interface{}) *stmt.Block {
+	s := d.stmt(v)
+	if s == nil {
+		return nil
+	}
+	b, ok := s.(*stmt.Block)
+	if !ok {
+		d.errorf("statement is %T, want *stmt.Block", s)
+	}
+	return b
+}
+
+func (d *decoder) selectCase(v interface{}) *stmt.SelectCase {
+	f, typ, ok := d.node(v)
+	if !ok {
+		return nil
+	}
+	if typ != "SelectCase" {
+		d.errorf("Select cases element is %q, want SelectCase", typ)
+		return nil
+	}
+	return &stmt.SelectCase{
+		Position: d.pos(f),
+		Default:  d.bool(f, "default"),
+		Stmt:     d.stmt(f["stmt"]),
+		Body:     d.block(f["body"]),
+	}
+}
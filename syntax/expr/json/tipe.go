@@ -0,0 +1,370 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"sort"
+
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/tipe"
+)
+
+var chanDirections = [...]string{
+	tipe.ChanBoth: "",
+	tipe.ChanSend: "send",
+	tipe.ChanRecv: "recv",
+}
+
+func (e *encoder) tipe(t tipe.Type) interface{} {
+	var o *object
+	switch t := t.(type) {
+	case nil:
+		return nil
+	case tipe.Basic:
+		o = newObject("Basic", src.Pos{})
+		o.add("name", e.str(string(t)))
+	case tipe.Builtin:
+		o = newObject("Builtin", src.Pos{})
+		o.add("name", e.str(string(t)))
+	case *tipe.Func:
+		o = newObject("Func", src.Pos{})
+		o.add("num", e.str(string(t.Spec.Num)))
+		o.add("params", e.tuple(t.Params))
+		o.add("results", e.tuple(t.Results))
+		o.add("variadic", t.Variadic)
+		o.add("freeVars", e.strs(t.FreeVars))
+		var mdik []interface{}
+		for _, n := range t.FreeMdik {
+			mdik = append(mdik, e.tipe(n))
+		}
+		o.add("freeMdik", mdik)
+	case *tipe.Struct:
+		o = newObject("Struct", src.Pos{})
+		o.add("num", e.str(string(t.Spec.Num)))
+		var sfs []interface{}
+		for _, sf := range t.Fields {
+			f := &object{}
+			f.add("name", e.str(sf.Name))
+			f.add("tipe", e.tipe(sf.Type))
+			f.add("tag", e.str(string(sf.Tag)))
+			f.add("embedded", sf.Embedded)
+			sfs = append(sfs, f)
+		}
+		o.add("fields", sfs)
+	case *tipe.Named:
+		if id, seen := e.named[t]; seen {
+			o = newObject("Named", src.Pos{})
+			o.add("ref", id)
+			break
+		}
+		id := len(e.named) + 1
+		e.named[t] = id
+		o = newObject("Named", src.Pos{})
+		o.add("id", id)
+		o.add("num", e.str(string(t.Spec.Num)))
+		o.add("tipe", e.tipe(t.Type))
+		o.add("pkgName", e.str(t.PkgName))
+		o.add("pkgPath", e.str(t.PkgPath))
+		o.add("name", e.str(t.Name))
+		o.add("methodNames", e.strs(t.MethodNames))
+		var methods []interface{}
+		for _, m := range t.Methods {
+			methods = append(methods, e.tipe(m))
+		}
+		o.add("methods", methods)
+	case *tipe.Ellipsis:
+		o = newObject("Ellipsis", src.Pos{})
+		o.add("elem", e.tipe(t.Elem))
+	case *tipe.Array:
+		o = newObject("Array", src.Pos{})
+		if t.Len != 0 {
+			o.add("len", t.Len)
+		}
+		o.add("elem", e.tipe(t.Elem))
+		o.add("ellipsis", t.Ellipsis)
+	case *tipe.Slice:
+		o = newObject("Slice", src.Pos{})
+		o.add("elem", e.tipe(t.Elem))
+	case *tipe.Table:
+		o = newObject("Table", src.Pos{})
+		o.add("elem", e.tipe(t.Type))
+	case *tipe.Tuple:
+		return e.tuple(t)
+	case *tipe.Pointer:
+		o = newObject("Pointer", src.Pos{})
+		o.add("elem", e.tipe(t.Elem))
+	case *tipe.Chan:
+		o = newObject("Chan", src.Pos{})
+		if t.Direction < 0 || int(t.Direction) >= len(chanDirections) {
+			e.errorf("unknown channel direction %d", t.Direction)
+			break
+		}
+		o.add("dir", chanDirections[t.Direction])
+		o.add("elem", e.tipe(t.Elem))
+	case *tipe.Map:
+		o = newObject("Map", src.Pos{})
+		o.add("key", e.tipe(t.Key))
+		o.add("value", e.tipe(t.Value))
+	case *tipe.Package:
+		if t.GoPkg != nil {
+			e.errorf("cannot marshal Go package %s", t.Path)
+			return nil
+		}
+		o = newObject("Package", src.Pos{})
+		o.add("path", e.str(t.Path))
+		o.add("exports", e.typeMap(t.Exports))
+	case *tipe.Interface:
+		o = newObject("Interface", src.Pos{})
+		methods := make(map[string]tipe.Type, len(t.Methods))
+		for name, m := range t.Methods {
+			methods[name] = m
+		}
+		o.add("methods", e.typeMap(methods))
+	case *tipe.Alias:
+		o = newObject("Alias", src.Pos{})
+		o.add("name", e.str(t.Name))
+		o.add("tipe", e.tipe(t.Type))
+	case *tipe.Unresolved:
+		o = newObject("Unresolved", src.Pos{})
+		o.add("package", e.str(t.Package))
+		o.add("name", e.str(t.Name))
+	default:
+		e.errorf("cannot marshal type %T", t)
+		return nil
+	}
+	return o
+}
+
+func (e *encoder) tipes(list []tipe.Type) interface{} {
+	var res []interface{}
+	for _, t := range list {
+		res = append(res, e.tipe(t))
+	}
+	return res
+}
+
+func (e *encoder) tuple(t *tipe.Tuple) interface{} {
+	if t == nil {
+		return nil
+	}
+	o := newObject("Tuple", src.Pos{})
+	o.add("elems", e.tipes(t.Elems))
+	return o
+}
+
+// typeMap encodes m as an object with its keys in sorted order.
+func (e *encoder) typeMap(m map[string]tipe.Type) interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	o := &object{}
+	for _, name := range names {
+		*o = append(*o, field{e.str(name), e.tipe(m[name])})
+	}
+	return o
+}
+
+func (d *decoder) tipe(v interface{}) tipe.Type {
+	f, typ, ok := d.node(v)
+	if !ok {
+		return nil
+	}
+	switch typ {
+	case "Basic":
+		return tipe.Basic(d.str(f, "name"))
+	case "Builtin":
+		return tipe.Builtin(d.str(f, "name"))
+	case "Func":
+		t := &tipe.Func{
+			Spec:     tipe.Specialization{Num: tipe.Basic(d.str(f, "num"))},
+			Params:   d.tuple(f["params"]),
+			Results:  d.tuple(f["results"]),
+			Variadic: d.bool(f, "variadic"),
+			FreeVars: d.strs(f, "freeVars"),
+		}
+		for _, v := range d.list(f, "freeMdik") {
+			t.FreeMdik = append(t.FreeMdik, d.namedType(d.tipe(v)))
+		}
+		return t
+	case "Struct":
+		t := &tipe.Struct{
+			Spec: tipe.Specialization{Num: tipe.Basic(d.str(f, "num"))},
+		}
+		for _, v := range d.list(f, "fields") {
+			sf, ok := v.(map[string]interface{})
+			if !ok {
+				d.errorf("struct field is %s, want object", describe(v))
+				continue
+			}
+			t.Fields = append(t.Fields, tipe.StructField{
+				Name:     d.str(sf, "name"),
+				Type:     d.tipe(sf["tipe"]),
+				Tag:      tipe.StructTag(d.str(sf, "tag")),
+				Embedded: d.bool(sf, "embedded"),
+			})
+		}
+		return t
+	case "Named":
+		if _, isRef := f["ref"]; isRef {
+			id := d.int(f, "ref", 64)
+			t := d.named[id]
+			if t == nil {
+				d.errorf("reference to unknown named type %d", id)
+			}
+			return t
+		}
+		id := d.int(f, "id", 64)
+		if d.named[id] != nil {
+			d.errorf("named type %d defined twice", id)
+			return nil
+		}
+		// Record t before decoding its fields, which may refer to it.
+		t := new(tipe.Named)
+		d.named[id] = t
+		t.Spec = tipe.Specialization{Num: tipe.Basic(d.str(f, "num"))}
+		t.Type = d.tipe(f["tipe"])
+		t.PkgName = d.str(f, "pkgName")
+		t.PkgPath = d.str(f, "pkgPath")
+		t.Name = d.str(f, "name")
+		t.MethodNames = d.strs(f, "methodNames")
+		for _, v := range d.list(f, "methods") {
+			t.Methods = append(t.Methods, d.funcType(d.tipe(v)))
+		}
+		return t
+	case "Ellipsis":
+		return &tipe.Ellipsis{Elem: d.tipe(f["elem"])}
+	case "Array":
+		return &tipe.Array{
+			Len:      d.int(f, "len", 64),
+			Elem:     d.tipe(f["elem"]),
+			Ellipsis: d.bool(f, "ellipsis"),
+		}
+	case "Slice":
+		return &tipe.Slice{Elem: d.tipe(f["elem"])}
+	case "Table":
+		return &tipe.Table{Type: d.tipe(f["elem"])}
+	case "Tuple":
+		t := &tipe.Tuple{}
+		for _, v := range d.list(f, "elems") {
+			t.Elems = append(t.Elems, d.tipe(v))
+		}
+		return t
+	case "Pointer":
+		return &tipe.Pointer{Elem: d.tipe(f["elem"])}
+	case "Chan":
+		t := &tipe.Chan{Elem: d.tipe(f["elem"])}
+		switch dir := d.str(f, "dir"); dir {
+		case "":
+			t.Direction = tipe.ChanBoth
+		case "send":
+			t.Direction = tipe.ChanSend
+		case "recv":
+			t.Direction = tipe.ChanRecv
+		default:
+			d.errorf("unknown channel direction %q", dir)
+		}
+		return t
+	case "Map":
+		return &tipe.Map{
+			Key:   d.tipe(f["key"]),
+			Value: d.tipe(f["value"]),
+		}
+	case "Package":
+		return &tipe.Package{
+			Path:    d.str(f, "path"),
+			Exports: d.typeMap(f, "exports"),
+		}
+	case "Interface":
+		t := &tipe.Interface{}
+		if methods := d.typeMap(f, "methods"); methods != nil {
+			t.Methods = make(map[string]*tipe.Func, len(methods))
+			for name, m := range methods {
+				t.Methods[name] = d.funcType(m)
+			}
+		}
+		return t
+	case "Alias":
+		t := &tipe.Alias{
+			Name: d.str(f, "name"),
+			Type: d.tipe(f["tipe"]),
+		}
+		// Keep the predeclared aliases unique.
+		for _, pre := range []*tipe.Alias{tipe.Byte, tipe.Rune} {
+			if *t == *pre {
+				return pre
+			}
+		}
+		return t
+	case "Unresolved":
+		return &tipe.Unresolved{
+			Package: d.str(f, "package"),
+			Name:    d.str(f, "name"),
+		}
+	default:
+		d.errorf("unknown type %q", typ)
+		return nil
+	}
+}
+
+func (d *decoder) tipes(f fields, key string) []tipe.Type {
+	var res []tipe.Type
+	for _, v := range d.list(f, key) {
+		res = append(res, d.tipe(v))
+	}
+	return res
+}
+
+func (d *decoder) tuple(v interface{}) *tipe.Tuple {
+	t := d.tipe(v)
+	if t == nil {
+		return nil
+	}
+	tuple, ok := t.(*tipe.Tuple)
+	if !ok {
+		d.errorf("type is %T, want *tipe.Tuple", t)
+	}
+	return tuple
+}
+
+func (d *decoder) typeMap(f fields, key string) map[string]tipe.Type {
+	obj := d.object(f, key)
+	if obj == nil {
+		return nil
+	}
+	m := make(map[string]tipe.Type, len(obj))
+	for name, v := range obj {
+		m[name] = d.tipe(v)
+	}
+	return m
+}
+
+func (d *decoder) funcType(t tipe.Type) *tipe.Func {
+	fn, ok := t.(*tipe.Func)
+	if !ok && t != nil {
+		d.errorf("type is %T, want *tipe.Func", t)
+	}
+	return fn
+}
+
+func (d *decoder) namedType(t tipe.Type) *tipe.Named {
+	n, ok := t.(*tipe.Named)
+	if !ok && t != nil {
+		d.errorf("type is %T, want *tipe.Named", t)
+	}
+	return n
+}
+
+func (d *decoder) aliasType(t tipe.Type) *tipe.Alias {
+	a, ok := t.(*tipe.Alias)
+	if !ok && t != nil {
+		d.errorf("type is %T, want *tipe.Alias", t)
+	}
+	return a
+}
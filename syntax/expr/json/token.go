@@ -0,0 +1,104 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "neugram.io/ng/syntax/token"
+
+// tokenNames are the names of the token constants, which are used
+// for operators in place of their spelling.
+var tokenNames = [...]string{
+	token.Unknown:         "Unknown",
+	token.Comment:         "Comment",
+	token.Ident:           "Ident",
+	token.Int:             "Int",
+	token.Float:           "Float",
+	token.Imaginary:       "Imaginary",
+	token.String:          "String",
+	token.Rune:            "Rune",
+	token.Add:             "Add",
+	token.Sub:             "Sub",
+	token.Mul:             "Mul",
+	token.Div:             "Div",
+	token.Rem:             "Rem",
+	token.Pow:             "Pow",
+	token.Ref:             "Ref",
+	token.RefPow:          "RefPow",
+	token.LogicalAnd:      "LogicalAnd",
+	token.LogicalOr:       "LogicalOr",
+	token.Equal:           "Equal",
+	token.Less:            "Less",
+	token.Greater:         "Greater",
+	token.Assign:          "Assign",
+	token.Not:             "Not",
+	token.NotEqual:        "NotEqual",
+	token.LessEqual:       "LessEqual",
+	token.GreaterEqual:    "GreaterEqual",
+	token.Shell:           "Shell",
+	token.ShellWord:       "ShellWord",
+	token.ShellPipe:       "ShellPipe",
+	token.ShellNewline:    "ShellNewline",
+	token.GreaterAnd:      "GreaterAnd",
+	token.AndGreater:      "AndGreater",
+	token.TwoGreater:      "TwoGreater",
+	token.TwoLess:         "TwoLess",
+	token.ChanOp:          "ChanOp",
+	token.Ellipsis:        "Ellipsis",
+	token.Inc:             "Inc",
+	token.Dec:             "Dec",
+	token.AddAssign:       "AddAssign",
+	token.SubAssign:       "SubAssign",
+	token.MulAssign:       "MulAssign",
+	token.DivAssign:       "DivAssign",
+	token.RemAssign:       "RemAssign",
+	token.PowAssign:       "PowAssign",
+	token.Define:          "Define",
+	token.LeftParen:       "LeftParen",
+	token.LeftBracket:     "LeftBracket",
+	token.LeftBrace:       "LeftBrace",
+	token.LeftBraceTable:  "LeftBraceTable",
+	token.RightParen:      "RightParen",
+	token.RightBracket:    "RightBracket",
+	token.RightBrace:      "RightBrace",
+	token.RightBraceTable: "RightBraceTable",
+	token.Comma:           "Comma",
+	token.Period:          "Period",
+	token.Semicolon:       "Semicolon",
+	token.Colon:           "Colon",
+	token.Pipe:            "Pipe",
+	token.Package:         "Package",
+	token.Import:          "Import",
+	token.Func:            "Func",
+	token.Return:          "Return",
+	token.Defer:           "Defer",
+	token.Select:          "Select",
+	token.Switch:          "Switch",
+	token.Case:            "Case",
+	token.Default:         "Default",
+	token.Fallthrough:     "Fallthrough",
+	token.Const:           "Const",
+	token.Var:             "Var",
+	token.If:              "If",
+	token.Else:            "Else",
+	token.For:             "For",
+	token.Range:           "Range",
+	token.Continue:        "Continue",
+	token.Break:           "Break",
+	token.Goto:            "Goto",
+	token.Go:              "Go",
+	token.Chan:            "Chan",
+	token.Map:             "Map",
+	token.Struct:          "Struct",
+	token.Methodik:        "Methodik",
+	token.Interface:       "Interface",
+	token.Type:            "Type",
+}
+
+var tokensByName = make(map[string]token.Token, len(tokenNames))
+
+func init() {
+	for t, name := range tokenNames {
+		tokensByName[name] = token.Token(t)
+	}
+}
@@ -285,7 +285,7 @@ func pathsExpand(src []string, arg string, params Params) (res []string, err err
 
 // indexUnquoted returns the index of the first unquoted Unicode code
 // point r, or -1. A code point r is quoted if it is directly preceded
-// by a '\' or enclosed in "" or ''.
+// by a '\' or enclosed in double or single quotes.
 func indexUnquoted(s string, r rune) int {
 	prevSlash := false
 	inBlock := rune(-1)
@@ -312,7 +312,8 @@ func indexUnquoted(s string, r rune) int {
 	return -1
 }
 
-// indexParam returns the index of the first $ not quoted with '' or \, or -1.
+// indexParam returns the index of the first $ not quoted with single
+// quotes or \, or -1.
 func indexParam(s string) int {
 	prevSlash := false
 	inQuote := false
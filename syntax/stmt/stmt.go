@@ -31,7 +31,8 @@ type ImportSet struct {
 type TypeDecl struct {
 	Position src.Pos
 	Name     string
-	Type     *tipe.Named
+	Type     *tipe.Named // nil for an alias declaration
+	Alias    *tipe.Alias // type Name = T
 }
 
 type TypeDeclSet struct {
@@ -81,6 +82,7 @@ type Assign struct {
 
 type Block struct {
 	Position src.Pos
+	End      src.Pos // closing brace, or the token after a case body
 	Stmts    []Stmt
 }
 
@@ -249,3 +251,7 @@ func (s *Labeled) Pos() src.Pos       { return s.Position }
 func (s *Select) Pos() src.Pos        { return s.Position }
 func (s SelectCase) Pos() src.Pos     { return s.Position }
 func (s *Bad) Pos() src.Pos           { return s.Position }
+
+// FuncBody implements expr.Body, so a Block can be the body of an
+// expr.FuncLiteral.
+func (s *Block) FuncBody() {}
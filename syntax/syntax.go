@@ -11,7 +11,6 @@
 //	syntax/expr
 //	syntax/stmt
 //	syntax/tipe
-//
 package syntax
 
 import (
@@ -27,7 +26,20 @@ type Node interface {
 // File is the syntax tree of a Neugram file.
 type File struct {
 	Filename string
-	Stmts    []stmt.Stmt
+	Stmts    []stmt.Stmt    // top-level statements, including declarations
+	Imports  []*stmt.Import // imports at the top level of the file, also in Stmts
+	Comments []*Comment     // comments in the order they appear in the source
+
+	BlankLines []int32 // lines that are empty or only white space
 }
 
 func (f File) Pos() src.Pos { return src.Pos{Filename: f.Filename} }
+
+// A Comment is a // or /* */ comment. Comments are not part of the
+// syntax tree, they are recorded in the File alongside it.
+type Comment struct {
+	Position src.Pos
+	Text     string // comment text, including the // or /* */
+}
+
+func (c *Comment) Pos() src.Pos { return c.Position }
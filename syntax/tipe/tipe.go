@@ -13,6 +13,10 @@ import (
 	"sort"
 )
 
+// Type is a Neugram type.
+//
+// Basic types, such as Int64 or UntypedFloat, are values of Basic.
+// Composite types are pointers to the structs defined in this package.
 type Type interface {
 	tipe()
 }
@@ -127,6 +131,7 @@ type Alias struct {
 	Type Type
 }
 
+// Byte and Rune are the predeclared aliases for Uint8 and Int32.
 var (
 	Byte = &Alias{Name: "byte", Type: Uint8}
 	Rune = &Alias{Name: "rune", Type: Int32}
@@ -149,6 +154,9 @@ type Specialization struct {
 	Num Basic
 }
 
+// Basic is a predeclared boolean, numeric or string type.
+// The Untyped kinds are the types of constants that have not yet been
+// given a concrete type.
 type Basic string
 
 const (
@@ -252,6 +260,8 @@ func (t *Interface) tipe()  {}
 func (t *Alias) tipe()      {}
 func (t *Unresolved) tipe() {}
 
+// IsNumeric reports whether t is an integer, floating-point or
+// complex type, and so may be an operand of arithmetic operators.
 func IsNumeric(t Type) bool {
 	t = Unalias(t)
 	b, ok := Underlying(t).(Basic)
@@ -263,17 +273,64 @@ func IsNumeric(t Type) bool {
 		Int, Int8, Int16, Int32, Int64,
 		Uint, Uint8, Uint16, Uint32, Uint64,
 		Float32, Float64, Complex64, Complex128,
-		UntypedInteger, UntypedFloat, UntypedComplex:
+		UntypedInteger, UntypedRune, UntypedFloat, UntypedComplex:
 		return true
 	}
 	return false
 }
 
+// Default returns the type an untyped constant of type t takes when
+// it is used without further context, as in x := 1. Other types are
+// returned unchanged.
+func Default(t Type) Type {
+	b, ok := t.(Basic)
+	if !ok {
+		return t
+	}
+	switch b {
+	case UntypedBool:
+		return Bool
+	case UntypedString:
+		return String
+	case UntypedInteger:
+		return Int // Num
+	case UntypedFloat:
+		return Float64 // Num
+	case UntypedComplex:
+		return Complex128 // Num
+	case UntypedRune:
+		return Rune
+	}
+	return t
+}
+
 func IsUntypedNil(t Type) bool {
 	b, _ := Underlying(t).(Basic)
 	return b == UntypedNil
 }
 
+// IsComparable reports whether values of type t can be compared
+// with == and !=, and so whether t can be used as a map key.
+func IsComparable(t Type) bool {
+	switch t := Underlying(t).(type) {
+	case Basic:
+		return t != Invalid && t != UntypedNil
+	case *Chan, *Interface, *Pointer:
+		return true
+	case *Array:
+		return IsComparable(t.Elem)
+	case *Struct:
+		for _, sf := range t.Fields {
+			if !IsComparable(sf.Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 func UsesNum(t Type) bool {
 	return usesNum(t, make(map[Type]bool))
 }
@@ -439,6 +496,9 @@ func (eq *equaler) equal(x, y Type) bool {
 		if x == nil || y == nil {
 			return false
 		}
+		if x.Name != y.Name || x.PkgPath != y.PkgPath {
+			return false
+		}
 		if x.Spec != y.Spec {
 			return false
 		}
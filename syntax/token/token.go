@@ -160,8 +160,8 @@ var tokens = map[string]Token{
 	"-=":           SubAssign,
 	"*=":           MulAssign,
 	"/=":           DivAssign,
-	"RemAssign":    RemAssign,
-	"PowAssign":    PowAssign,
+	"%=":           RemAssign,
+	"^=":           PowAssign,
 	":=":           Define,
 	"(":            LeftParen,
 	"[":            LeftBracket,
@@ -169,6 +169,7 @@ var tokens = map[string]Token{
 	"{|":           LeftBraceTable,
 	")":            RightParen,
 	"]":            RightBracket,
+	"}":            RightBrace,
 	"|}":           RightBraceTable,
 	",":            Comma,
 	".":            Period,
@@ -0,0 +1,26 @@
+// Copyright 2018 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import "testing"
+
+var stringTests = []struct {
+	tok  Token
+	want string
+}{
+	{Add, "+"},
+	{RemAssign, "%="},
+	{PowAssign, "^="},
+	{RightBrace, "}"},
+	{Func, "func"},
+}
+
+func TestString(t *testing.T) {
+	for _, test := range stringTests {
+		if got := test.tok.String(); got != test.want {
+			t.Errorf("%d.String() = %q, want %q", int(test.tok), got, test.want)
+		}
+	}
+}
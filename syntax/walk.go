@@ -234,7 +234,7 @@ func (w *walker) walk(parent, node Node, fieldName string, iter *iterator) {
 
 	case *expr.TableLiteral:
 		w.walkSlice(node, "ColNames")
-		// TODO: handle rows
+		w.walkRows(node)
 
 	case *expr.Type:
 
@@ -303,3 +303,19 @@ func (w *walker) walkSlice(parent Node, fieldName string) {
 		w.iter.index++
 	}
 }
+
+// walkRows walks the cells of a table literal in row-major order.
+// The cursor index counts cells across all rows.
+func (w *walker) walkRows(node *expr.TableLiteral) {
+	oldIter := w.iter
+	defer func() { w.iter = oldIter }()
+
+	w.iter.index = 0
+
+	for _, row := range node.Rows {
+		for _, e := range row {
+			w.walk(node, e, "Rows", &w.iter)
+			w.iter.index++
+		}
+	}
+}
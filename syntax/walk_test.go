@@ -12,6 +12,7 @@ import (
 
 	"neugram.io/ng/parser"
 	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
 )
 
 func TestWalk(t *testing.T) {
@@ -67,3 +68,21 @@ func TestWalk(t *testing.T) {
 		})
 	}
 }
+
+func TestWalkTableRows(t *testing.T) {
+	s, err := parser.ParseStmt([]byte(`x := [|]int{{|"a", "b"|}, {1, y}, {z, 4}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	preFn := func(c *syntax.Cursor) bool {
+		if e, ok := c.Node.(*expr.Ident); ok && c.Name == "Rows" {
+			names = append(names, e.Name)
+		}
+		return true
+	}
+	syntax.Walk(s, preFn, nil)
+	if got, want := strings.Join(names, ","), "y,z"; got != want {
+		t.Errorf("idents in rows: %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package edit describes changes to the text of a file.
+package edit // import "neugram.io/ng/tools/edit"
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An Edit replaces Length bytes at byte offset Offset with New.
+type Edit struct {
+	Offset int
+	Length int
+	New    string
+}
+
+// Apply returns src with edits applied. The edits may be in any
+// order, but must not overlap.
+func Apply(src []byte, edits []Edit) ([]byte, error) {
+	sorted := append([]Edit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	var res []byte
+	off := 0
+	for _, e := range sorted {
+		if e.Offset < off || e.Length < 0 || e.Offset+e.Length > len(src) {
+			return nil, fmt.Errorf("bad edit at offset %d", e.Offset)
+		}
+		res = append(res, src[off:e.Offset]...)
+		res = append(res, e.New...)
+		off = e.Offset + e.Length
+	}
+	return append(res, src[off:]...), nil
+}
@@ -0,0 +1,903 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tools implements refactorings of Neugram programs.
+package tools // import "neugram.io/ng/tools"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"neugram.io/ng/format"
+	"neugram.io/ng/syntax"
+	"neugram.io/ng/syntax/expr"
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/syntax/stmt"
+	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
+	"neugram.io/ng/tools/edit"
+	"neugram.io/ng/typecheck"
+)
+
+// Rename renames the identifier at pos, a 1-based line and byte
+// column in filename, to newName.
+//
+// The identifier may refer to or declare a variable, constant,
+// function, method or struct field. Rename finds its declaration and
+// every reference to it in filename and allFiles, following the
+// exports of a file to the files that import it. The edits to make
+// are returned for each file that changes, keyed by the name given
+// for it, or by its absolute path for an imported file not in
+// allFiles.
+//
+// Every file must type check. Rename refuses to rename builtins,
+// types and packages, variables used as $-parameters of shell
+// commands, and to choose a name that would change what another
+// identifier refers to.
+func Rename(filename string, pos src.Pos, newName string, allFiles []string) (map[string][]edit.Edit, error) {
+	if !isIdent(newName) || newName == "_" {
+		return nil, fmt.Errorf("cannot rename to %q, not an identifier", newName)
+	}
+	r := &renamer{newName: newName, byAbs: make(map[string]*file)}
+	f, err := r.load(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range allFiles {
+		if _, err := r.load(name); err != nil {
+			return nil, err
+		}
+	}
+	t, err := r.resolve(f, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]edit.Edit)
+	if t.name == newName {
+		return res, nil
+	}
+	if t.kind == method || t.kind == field {
+		if hasMethod(t.typ, newName) || hasField(t.typ, newName) {
+			return nil, fmt.Errorf("cannot rename %s to %s, %s already has a field or method %s", t.name, newName, t.typ.Name, newName)
+		}
+	}
+	for _, g := range r.files {
+		offs, err := r.occurrences(g, t)
+		if err != nil {
+			return nil, err
+		}
+		if len(offs) == 0 {
+			continue
+		}
+		if g != t.file && !isExported(newName) {
+			return nil, fmt.Errorf("cannot rename %s to %s, it is used in %s", t.name, newName, g.name)
+		}
+		sort.Ints(offs)
+		var edits []edit.Edit
+		for i, off := range offs {
+			if i > 0 && off == offs[i-1] {
+				continue
+			}
+			edits = append(edits, edit.Edit{Offset: off, Length: len(t.name), New: newName})
+		}
+		res[g.name] = edits
+	}
+	return res, nil
+}
+
+type renamer struct {
+	newName string
+	files   []*file // in the order loaded
+	byAbs   map[string]*file
+}
+
+// A file is a type checked file of the program.
+type file struct {
+	name  string // as given to Rename
+	abs   string
+	src   []byte
+	lines []int // offset of the start of each line
+
+	checker   *typecheck.Checker
+	pkg       *typecheck.Package
+	idents    []*expr.Ident // in source order
+	selectors map[*expr.Ident]*expr.Selector
+	keys      map[*expr.Ident]*expr.CompLiteral // field names of composite literals
+	funcs     []*expr.FuncLiteral
+	blocks    []*stmt.Block
+	shells    []*expr.Shell
+}
+
+// load reads and type checks a file, if it is not already loaded.
+func (r *renamer) load(name string) (*file, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	if f := r.byAbs[abs]; f != nil {
+		return f, nil
+	}
+	source, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	checker := typecheck.New("")
+	pkg, err := checker.Check(abs)
+	if err != nil {
+		if err, hasPos := err.(typecheck.Error); hasPos && err.Pos.Line > 0 {
+			return nil, fmt.Errorf("%s:%d:%d: %s", name, err.Pos.Line, err.Pos.Column, err.Msg)
+		}
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	f := &file{
+		name:      name,
+		abs:       abs,
+		src:       source,
+		lines:     []int{0},
+		checker:   checker,
+		pkg:       pkg,
+		selectors: make(map[*expr.Ident]*expr.Selector),
+		keys:      make(map[*expr.Ident]*expr.CompLiteral),
+	}
+	for i, b := range source {
+		if b == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+	syntax.Walk(pkg.Syntax, func(c *syntax.Cursor) bool {
+		switch n := c.Node.(type) {
+		case *expr.Ident:
+			if n.Position.Line > 0 {
+				f.idents = append(f.idents, n)
+			}
+		case *expr.Selector:
+			f.selectors[n.Right] = n
+		case *expr.CompLiteral:
+			for _, key := range n.Keys {
+				if id, isIdent := key.(*expr.Ident); isIdent {
+					f.keys[id] = n
+				}
+			}
+		case *expr.FuncLiteral:
+			f.funcs = append(f.funcs, n)
+		case *stmt.Block:
+			f.blocks = append(f.blocks, n)
+		case *expr.Shell:
+			f.shells = append(f.shells, n)
+		}
+		return true
+	}, nil)
+	r.files = append(r.files, f)
+	r.byAbs[abs] = f
+	return f, nil
+}
+
+type targetKind int
+
+const (
+	local  targetKind = iota // a variable or constant of a function
+	global                   // a package-level variable, constant or function
+	method
+	field
+)
+
+// A target is what is being renamed.
+type target struct {
+	kind targetKind
+	name string
+	file *file          // the declaring file
+	obj  *typecheck.Obj // local or global, of file's type checker
+	typ  *tipe.Named    // method or field, of file's type checker
+}
+
+// resolve finds what the identifier at pos in f refers to or declares.
+func (r *renamer) resolve(f *file, pos src.Pos) (*target, error) {
+	if id := f.identAt(pos); id != nil {
+		if sel := f.selectors[id]; sel != nil {
+			return r.resolveSelector(f, sel)
+		}
+		if lit := f.keys[id]; lit != nil {
+			return r.member(f, f.checker.Type(lit), id.Name)
+		}
+		obj := f.checker.Ident(id)
+		if obj == nil {
+			return nil, fmt.Errorf("%s:%d:%d: cannot rename %s", f.name, pos.Line, pos.Column, id.Name)
+		}
+		return r.object(f, obj, id.Name)
+	}
+
+	// Most declarations are not identifiers in the syntax tree.
+	off, name := f.wordAt(pos)
+	if !isIdent(name) {
+		return nil, fmt.Errorf("%s:%d:%d: no identifier to rename", f.name, pos.Line, pos.Column)
+	}
+	for _, id := range f.idents {
+		obj := f.checker.Ident(id)
+		if obj == nil || obj.Name != name {
+			continue
+		}
+		if decl, found := f.declPos(obj, id); found && f.offset(decl) == off {
+			return r.object(f, obj, name)
+		}
+	}
+	if obj := f.pkg.GlobalNames[name]; obj != nil {
+		if decl, found := f.declPos(obj, nil); found && f.offset(decl) == off {
+			return r.object(f, obj, name)
+		}
+	}
+	for _, decl := range f.typeDecls() {
+		if f.memberOffset(decl, name) == off {
+			return r.member(f, f.declType(decl), name)
+		}
+	}
+	return nil, fmt.Errorf("%s:%d:%d: cannot rename %s", f.name, pos.Line, pos.Column, name)
+}
+
+// object returns the target for obj, declared in f and referred to by
+// name.
+func (r *renamer) object(f *file, obj *typecheck.Obj, name string) (*target, error) {
+	switch {
+	case typecheck.Universe.Objs[name] == obj:
+		return nil, fmt.Errorf("cannot rename builtin %s", name)
+	case obj.Kind == typecheck.ObjPkg:
+		return nil, fmt.Errorf("cannot rename package %s", name)
+	case obj.Kind == typecheck.ObjType:
+		return nil, fmt.Errorf("cannot rename type %s, renaming types is not supported", name)
+	}
+	t := &target{kind: local, name: name, file: f, obj: obj}
+	if f.pkg.GlobalNames[name] == obj {
+		t.kind = global
+	}
+	return t, nil
+}
+
+// resolveSelector returns the target of sel, a package member or a
+// field or method of a value.
+func (r *renamer) resolveSelector(f *file, sel *expr.Selector) (*target, error) {
+	name := sel.Right.Name
+	if pkg := f.pkgOf(sel.Left); pkg != nil {
+		filename := ngFilename(pkg.Path)
+		if filename == "" {
+			return nil, fmt.Errorf("cannot rename %s, it is declared in Go package %s", name, pkg.Path)
+		}
+		df, err := r.load(filename)
+		if err != nil {
+			return nil, err
+		}
+		obj := df.pkg.GlobalNames[name]
+		if obj == nil {
+			return nil, fmt.Errorf("%s is not declared in %s", name, df.name)
+		}
+		return r.object(df, obj, name)
+	}
+	return r.member(f, f.checker.Type(sel.Left), name)
+}
+
+// member returns the target for the field or method name of values of
+// type t, used in f.
+func (r *renamer) member(f *file, t tipe.Type, name string) (*target, error) {
+	if ptr, isPtr := t.(*tipe.Pointer); isPtr {
+		t = ptr.Elem
+	}
+	named, isNamed := t.(*tipe.Named)
+	if !isNamed {
+		return nil, fmt.Errorf("cannot rename %s, a member of %s", name, format.Type(t))
+	}
+	df := f
+	if named.PkgPath != "" {
+		filename := ngFilename(named.PkgPath)
+		if filename == "" {
+			return nil, fmt.Errorf("cannot rename %s, it is declared in Go package %s", name, named.PkgPath)
+		}
+		var err error
+		if df, err = r.load(filename); err != nil {
+			return nil, err
+		}
+		obj := df.pkg.GlobalNames[named.Name]
+		if obj == nil {
+			return nil, fmt.Errorf("%s is not declared in %s", named.Name, df.name)
+		}
+		if named, isNamed = obj.Type.(*tipe.Named); !isNamed {
+			return nil, fmt.Errorf("cannot rename %s, a member of %s", name, obj.Name)
+		}
+	}
+	if df.typeDecl(named) == nil {
+		return nil, fmt.Errorf("cannot rename %s, a member of %s", name, format.Type(named))
+	}
+	tgt := &target{name: name, file: df, typ: named}
+	switch {
+	case hasMethod(named, name):
+		tgt.kind = method
+	case hasField(named, name):
+		tgt.kind = field
+	default:
+		return nil, fmt.Errorf("cannot rename %s, not a field or method of %s", name, named.Name)
+	}
+	return tgt, nil
+}
+
+// occurrences returns the offsets in g of the declaration of t and
+// the references to it.
+func (r *renamer) occurrences(g *file, t *target) ([]int, error) {
+	var offs []int
+	switch t.kind {
+	case local, global:
+		for id, sel := range g.selectors {
+			if pkg := g.pkgOf(sel.Left); pkg != nil && id.Name == t.name && ngFilename(pkg.Path) == t.file.abs {
+				offs = append(offs, g.offset(id.Position))
+			}
+		}
+		if g != t.file {
+			return offs, nil
+		}
+		var refs []*expr.Ident
+		for _, id := range g.idents {
+			if g.selectors[id] == nil && g.keys[id] == nil && g.checker.Ident(id) == t.obj {
+				refs = append(refs, id)
+				offs = append(offs, g.offset(id.Position))
+			}
+		}
+		var first *expr.Ident
+		if len(refs) > 0 {
+			first = refs[0]
+		}
+		decl, found := g.declPos(t.obj, first)
+		if !found {
+			return nil, fmt.Errorf("cannot find the declaration of %s in %s", t.name, g.name)
+		}
+		offs = append(offs, g.offset(decl))
+		uses := []src.Pos{decl}
+		for _, id := range refs {
+			uses = append(uses, id.Position)
+		}
+		if err := g.checkScope(t, r.newName, decl, uses); err != nil {
+			return nil, err
+		}
+		// A $-parameter of a shell command is not an identifier, and
+		// may be in a quoted word that is not expanded.
+		scope := g.scope(t.obj, decl)
+		for _, sh := range g.shells {
+			if hasName(sh.FreeVars, t.name) && g.inBlock(scope, sh.Position) {
+				return nil, fmt.Errorf("cannot rename %s, it is used in a shell command at %s:%d:%d", t.name, g.name, sh.Position.Line, sh.Position.Column)
+			}
+		}
+
+	case method, field:
+		for id, sel := range g.selectors {
+			if id.Name == t.name && g.isType(g.checker.Type(sel.Left), t) {
+				offs = append(offs, g.offset(id.Position))
+			}
+		}
+		if t.kind == field {
+			for id, lit := range g.keys {
+				if id.Name == t.name && g.isType(g.checker.Type(lit), t) {
+					offs = append(offs, g.offset(id.Position))
+				}
+			}
+		}
+		if g == t.file {
+			off := g.memberOffset(g.typeDecl(t.typ), t.name)
+			if off < 0 {
+				return nil, fmt.Errorf("cannot find the declaration of %s in %s", t.name, g.name)
+			}
+			offs = append(offs, off)
+		}
+	}
+	return offs, nil
+}
+
+// checkScope reports an error if renaming t, declared at decl and
+// used at uses in g, to newName would change what an identifier
+// refers to.
+//
+// It is conservative: names are taken to be in scope in the whole of
+// their block, not only after their declaration.
+func (g *file) checkScope(t *target, newName string, decl src.Pos, uses []src.Pos) error {
+	conflict := func(pos src.Pos) error {
+		return fmt.Errorf("cannot rename %s to %s, it conflicts with %s at %s:%d:%d", t.name, newName, newName, g.name, pos.Line, pos.Column)
+	}
+	scope := g.scope(t.obj, decl)
+	for _, id := range g.idents {
+		if id.Name != newName || g.selectors[id] != nil || g.keys[id] != nil {
+			continue
+		}
+		obj := g.checker.Ident(id)
+		if obj == nil {
+			continue
+		}
+		// The identifier would refer to t.
+		if g.inBlock(scope, id.Position) {
+			return conflict(id.Position)
+		}
+		if typecheck.Universe.Objs[newName] == obj {
+			continue // t may shadow a builtin it is not in the scope of
+		}
+		// A use of t would refer to obj.
+		objDecl, found := g.declPos(obj, id)
+		if !found {
+			objDecl = id.Position
+		}
+		for _, use := range uses {
+			if g.inBlock(g.scope(obj, objDecl), use) {
+				return conflict(objDecl)
+			}
+		}
+	}
+	if obj := g.pkg.GlobalNames[newName]; obj != nil {
+		pos, _ := g.declPos(obj, nil)
+		return conflict(pos)
+	}
+	// Parameters that are not used are not identifiers.
+	for _, fn := range g.funcs {
+		body, isBlock := fn.Body.(*stmt.Block)
+		if !isBlock || !declares(fn, newName) {
+			continue
+		}
+		for _, use := range uses {
+			if g.inBlock(body, use) || use.Line == fn.Position.Line && before(fn.Position, use) && before(use, body.Position) {
+				return conflict(fn.Position)
+			}
+		}
+	}
+	return nil
+}
+
+// scope returns the block obj, declared at decl, is in scope in, or
+// nil for the whole file.
+func (g *file) scope(obj *typecheck.Obj, decl src.Pos) *stmt.Block {
+	if g.pkg.GlobalNames[obj.Name] == obj || obj.Kind == typecheck.ObjPkg {
+		return nil
+	}
+	for _, fn := range g.funcs {
+		body, isBlock := fn.Body.(*stmt.Block)
+		if isBlock && declares(fn, obj.Name) && before(fn.Position, decl) && before(decl, body.Position) {
+			return body
+		}
+	}
+	var inner *stmt.Block
+	for _, b := range g.blocks {
+		if !before(b.Position, decl) || !before(decl, b.End) {
+			continue
+		}
+		if inner == nil || before(inner.Position, b.Position) {
+			inner = b
+		}
+	}
+	return inner
+}
+
+// inBlock reports whether pos is in b, or b is nil.
+func (g *file) inBlock(b *stmt.Block, pos src.Pos) bool {
+	return b == nil || before(b.Position, pos) && before(pos, b.End)
+}
+
+// declPos finds where obj, referred to by the identifier ref, is
+// declared. The ref may be nil for a package-level object.
+//
+// The type checker records the statement or function literal that
+// declares most objects, the name is found in its first line.
+// Parameters and constants are found in the syntax tree.
+func (g *file) declPos(obj *typecheck.Obj, ref *expr.Ident) (src.Pos, bool) {
+	if decl, isNode := obj.Decl.(syntax.Node); isNode {
+		pos := decl.Pos()
+		if pos.Filename != g.pkg.Syntax.Filename || pos.Line < 1 || int(pos.Line) > len(g.lines) {
+			return src.Pos{}, false
+		}
+		for _, id := range g.idents {
+			if id.Position.Line == pos.Line && g.checker.Ident(id) == obj {
+				return id.Position, true
+			}
+		}
+		if col := findWord(g.line(int(pos.Line)), obj.Name, 0); col >= 0 {
+			pos.Column = int16(col + 1)
+			return pos, true
+		}
+		return src.Pos{}, false
+	}
+
+	// The innermost function with a parameter of the name.
+	for i := len(g.funcs) - 1; ref != nil && i >= 0; i-- {
+		fn := g.funcs[i]
+		body, isBlock := fn.Body.(*stmt.Block)
+		if !isBlock || !before(fn.Position, ref.Position) || !before(ref.Position, body.End) || !declares(fn, obj.Name) {
+			continue
+		}
+		line := g.line(int(fn.Position.Line))
+		from := int(fn.Position.Column) - 1 + len("func")
+		if fn.Name != "" {
+			from = findWord(line, fn.Name, from) + len(fn.Name)
+		}
+		if col := findWord(line, obj.Name, from); col >= 0 {
+			pos := fn.Position
+			pos.Column = int16(col + 1)
+			return pos, true
+		}
+	}
+
+	var pos src.Pos
+	switch obj.Kind {
+	case typecheck.ObjConst, typecheck.ObjPkg:
+		// The last declaration of the name before ref.
+		syntax.Walk(g.pkg.Syntax, func(c *syntax.Cursor) bool {
+			var names []string
+			switch s := c.Node.(type) {
+			case *stmt.Const:
+				names = s.NameList
+			case *stmt.Import:
+				if s.Name != "" {
+					names = []string{s.Name}
+				} else {
+					names = []string{path.Base(s.Path)}
+				}
+			}
+			for _, name := range names {
+				if p := c.Node.Pos(); name == obj.Name && (ref == nil || before(p, ref.Position)) {
+					pos = p
+				}
+			}
+			return true
+		}, nil)
+	}
+	if pos.Line > 0 {
+		if col := findWord(g.line(int(pos.Line)), obj.Name, int(pos.Column)-1); col >= 0 {
+			pos.Column = int16(col + 1)
+		}
+		return pos, true
+	}
+
+	// Variables of range and type switch statements are declared
+	// by their first identifier.
+	for _, id := range g.idents {
+		if g.checker.Ident(id) == obj {
+			return id.Position, true
+		}
+	}
+	return src.Pos{}, false
+}
+
+// declares reports whether name is a receiver, parameter or result
+// of fn.
+func declares(fn *expr.FuncLiteral, name string) bool {
+	return fn.ReceiverName == name || hasName(fn.ParamNames, name) || hasName(fn.ResultNames, name)
+}
+
+// hasName reports whether name is one of names.
+func hasName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgOf returns the package e names, or nil.
+func (g *file) pkgOf(e expr.Expr) *typecheck.Package {
+	id, isIdent := e.(*expr.Ident)
+	if !isIdent {
+		return nil
+	}
+	obj := g.checker.Ident(id)
+	if obj == nil || obj.Kind != typecheck.ObjPkg {
+		return nil
+	}
+	pkg, _ := obj.Decl.(*typecheck.Package)
+	return pkg
+}
+
+// isType reports whether t, a type in g, is the type of the method or
+// field target, or a pointer to it.
+func (g *file) isType(t tipe.Type, tgt *target) bool {
+	if ptr, isPtr := t.(*tipe.Pointer); isPtr {
+		t = ptr.Elem
+	}
+	named, isNamed := t.(*tipe.Named)
+	if !isNamed {
+		return false
+	}
+	if g == tgt.file {
+		return named == tgt.typ
+	}
+	return named.Name == tgt.typ.Name && named.PkgPath != "" && ngFilename(named.PkgPath) == tgt.file.abs
+}
+
+// typeDecls returns the package-level type declarations of g.
+func (g *file) typeDecls() []stmt.Stmt {
+	var decls []stmt.Stmt
+	for _, s := range g.pkg.Syntax.Stmts {
+		switch s := s.(type) {
+		case *stmt.TypeDecl:
+			if s.Type != nil {
+				decls = append(decls, s)
+			}
+		case *stmt.TypeDeclSet:
+			for _, d := range s.TypeDecls {
+				if d.Type != nil {
+					decls = append(decls, d)
+				}
+			}
+		case *stmt.MethodikDecl:
+			decls = append(decls, s)
+		}
+	}
+	return decls
+}
+
+func (g *file) declType(decl stmt.Stmt) *tipe.Named {
+	switch decl := decl.(type) {
+	case *stmt.TypeDecl:
+		return decl.Type
+	case *stmt.MethodikDecl:
+		return decl.Type
+	}
+	return nil
+}
+
+// typeDecl returns the package-level declaration of t in g, or nil.
+func (g *file) typeDecl(t *tipe.Named) stmt.Stmt {
+	for _, decl := range g.typeDecls() {
+		if g.declType(decl) == t {
+			return decl
+		}
+	}
+	return nil
+}
+
+// memberOffset returns the offset of the declaration of the method or
+// field name in decl, or -1.
+func (g *file) memberOffset(decl stmt.Stmt, name string) int {
+	t := g.declType(decl)
+	if t == nil {
+		return -1
+	}
+	if md, isMethodik := decl.(*stmt.MethodikDecl); isMethodik {
+		for _, fn := range md.Methods {
+			if fn.Name != name {
+				continue
+			}
+			// func (r) name(
+			line := g.line(int(fn.Position.Line))
+			from := int(fn.Position.Column) - 1
+			if from < 0 || from > len(line) {
+				return -1
+			}
+			rparen := strings.IndexByte(line[from:], ')')
+			if rparen < 0 {
+				return -1
+			}
+			if col := findWord(line, name, from+rparen); col >= 0 {
+				return g.lines[fn.Position.Line-1] + col
+			}
+			return -1
+		}
+	}
+	if !hasField(t, name) {
+		return -1
+	}
+	start := g.offset(decl.Pos())
+	i := findWord(string(g.src[start:]), "struct", 0)
+	if i < 0 {
+		return -1
+	}
+	return fieldOffset(g.src, start+i, name)
+}
+
+// fieldOffset returns the offset of the name of the field declared by
+// the struct type at off in src, or -1.
+func fieldOffset(src []byte, off int, name string) int {
+	depth := 0
+	start := false // at the start of a field name
+	for i := off; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"' || c == '`':
+			// A tag.
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if c == '"' && src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			i = j + 1
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '(' || c == '[':
+			depth++
+			start = depth == 1
+			i++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth <= 0 {
+				return -1
+			}
+			i++
+		case c == '\n' || c == ';' || c == ',':
+			start = start || depth == 1
+			i++
+		default:
+			r, size := utf8.DecodeRune(src[i:])
+			if !isIdentRune(r) {
+				i += size
+				continue
+			}
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRune(src[j:])
+				if !isIdentRune(r) {
+					break
+				}
+				j += size
+			}
+			if depth == 1 && start && string(src[i:j]) == name {
+				return i
+			}
+			if depth == 1 {
+				start = false
+			}
+			i = j
+		}
+	}
+	return -1
+}
+
+func hasMethod(t *tipe.Named, name string) bool {
+	for _, n := range t.MethodNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(t *tipe.Named, name string) bool {
+	s, isStruct := t.Type.(*tipe.Struct)
+	if !isStruct {
+		return false
+	}
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ngFilename returns the file of the Neugram package with the type
+// checker's path, or "" for a Go package.
+func ngFilename(pkgPath string) string {
+	if !strings.HasSuffix(pkgPath, "_ng") {
+		return ""
+	}
+	filename := strings.TrimSuffix(pkgPath, "_ng") + ".ng"
+	if strings.HasPrefix(filename, "rel/") {
+		filename = filename[len("rel"):]
+	}
+	if !filepath.IsAbs(filename) {
+		return ""
+	}
+	return filename
+}
+
+// line returns the text of the 1-based line n.
+func (g *file) line(n int) string {
+	if n < 1 || n > len(g.lines) {
+		return ""
+	}
+	end := len(g.src)
+	if n < len(g.lines) {
+		end = g.lines[n] - 1
+	}
+	return string(g.src[g.lines[n-1]:end])
+}
+
+// offset converts a position in g to a byte offset.
+func (g *file) offset(pos src.Pos) int {
+	if pos.Line < 1 || int(pos.Line) > len(g.lines) {
+		return -1
+	}
+	return g.lines[pos.Line-1] + int(pos.Column) - 1
+}
+
+// identAt returns the identifier at pos, including the position just
+// after it.
+func (g *file) identAt(pos src.Pos) *expr.Ident {
+	for _, id := range g.idents {
+		start := id.Position.Column
+		if id.Position.Line == pos.Line && start <= pos.Column && int(pos.Column) <= int(start)+len(id.Name) {
+			return id
+		}
+	}
+	return nil
+}
+
+// wordAt returns the offset and text of the word at pos, including
+// the position just after it.
+func (g *file) wordAt(pos src.Pos) (int, string) {
+	line := g.line(int(pos.Line))
+	col := int(pos.Column) - 1
+	if col < 0 || col > len(line) {
+		return -1, ""
+	}
+	start, end := col, col
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:start])
+		if !isIdentRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(line) {
+		r, size := utf8.DecodeRuneInString(line[end:])
+		if !isIdentRune(r) {
+			break
+		}
+		end += size
+	}
+	return g.lines[pos.Line-1] + start, line[start:end]
+}
+
+// before reports whether p is at or before q.
+func before(p, q src.Pos) bool {
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+	return p.Column <= q.Column
+}
+
+// findWord returns the offset of the first identifier name in s at
+// or after from, or -1.
+func findWord(s, name string, from int) int {
+	if from < 0 {
+		from = 0
+	}
+	for from <= len(s) {
+		i := strings.Index(s[from:], name)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(name)
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		startOK := i == 0 || !isIdentRune(r)
+		r, _ = utf8.DecodeRuneInString(s[end:])
+		endOK := end == len(s) || !isIdentRune(r)
+		if startOK && endOK {
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+func isIdent(s string) bool {
+	if _, isKeyword := token.Keywords[s]; isKeyword || s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isIdentRune(r) || i == 0 && unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
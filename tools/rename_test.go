@@ -0,0 +1,339 @@
+// Copyright 2017 The Neugram Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"neugram.io/ng/syntax/src"
+	"neugram.io/ng/tools/edit"
+)
+
+const mainFile = `import "./shapes.ng"
+
+func area(b shapes.Box) int {
+	return b.Width * b.Height
+}
+
+func grow(b shapes.Box, n int) shapes.Box {
+	b.Width += n
+	return b
+}
+
+x := 2
+b := shapes.Box{Width: x, Height: 3}
+print(area(grow(b, x)), b.Volume(), shapes.Double(x))
+`
+
+const shapesFile = `type Unit int
+
+func Double(x int) int {
+	y := x
+	return y + x
+}
+
+methodik Box struct {
+	Width  int
+	Height int ` + "`json:\"height\"`" + `
+} {
+	func (b) Volume() int { return b.Width * b.Height * Double(1) }
+	func (*b) Scale(n int) {
+		b.Width *= n
+		b.Height = b.Height * n
+	}
+}
+
+func twice(f func(int) int, x int) int {
+	return f(f(x))
+}
+
+big := Box{Width: Double(2), Height: 1}
+big.Scale(2)
+print(twice(Double, big.Volume()))
+`
+
+const localsFile = `func f(n int) int {
+	x := n
+	for i := 0; i < n; i++ {
+		x := x * 2
+		n += x
+	}
+	var y = x + 1
+	return x * y
+}
+
+func g(a int) int {
+	x := a + 1
+	return x
+}
+
+const limit = 10
+x := f(limit)
+print(x, g(3), limit)
+
+dir := "/tmp"
+$$ ls $dir $$
+`
+
+var renameTests = []struct {
+	name    string
+	file    string // of the identifier to rename
+	at      string // text starting with the identifier
+	newName string
+	want    map[string]string // the changed files, each with a line that changes
+}{
+	{
+		name:    "local",
+		file:    "locals.ng",
+		at:      "x := n",
+		newName: "v",
+		want: map[string]string{
+			"locals.ng": "	v := n\n		x := v * 2\n	var y = v + 1\n	return v * y\n",
+		},
+	},
+	{
+		name:    "variable declaration",
+		file:    "locals.ng",
+		at:      "y = x + 1",
+		newName: "z",
+		want: map[string]string{
+			"locals.ng": "	var z = x + 1\n	return x * z\n",
+		},
+	},
+	{
+		name:    "parameter",
+		file:    "locals.ng",
+		at:      "a int) int {",
+		newName: "n",
+		want: map[string]string{
+			"locals.ng": "func g(n int) int {\n	x := n + 1\n",
+		},
+	},
+	{
+		name:    "shadowed local",
+		file:    "locals.ng",
+		at:      "x := x * 2",
+		newName: "w",
+		want: map[string]string{
+			"locals.ng": "		w := x * 2\n		n += w\n",
+		},
+	},
+	{
+		name:    "global variable",
+		file:    "locals.ng",
+		at:      "x, g(3), limit)",
+		newName: "res",
+		want: map[string]string{
+			"locals.ng": "res := f(limit)\nprint(res, g(3), limit)\n",
+		},
+	},
+	{
+		name:    "constant",
+		file:    "locals.ng",
+		at:      "limit = 10",
+		newName: "max",
+		want: map[string]string{
+			"locals.ng": "const max = 10\nx := f(max)\nprint(x, g(3), max)\n",
+		},
+	},
+	{
+		name:    "function",
+		file:    "main.ng",
+		at:      "grow(b, x)), b.Volume(), shapes.Double(x))",
+		newName: "widen",
+		want: map[string]string{
+			"main.ng": "func widen(b shapes.Box, n int) shapes.Box {\nprint(area(widen(b, x)), b.Volume(), shapes.Double(x))\n",
+		},
+	},
+	{
+		name:    "function declaration",
+		file:    "shapes.ng",
+		at:      "twice(f func(int) int, x int) int {",
+		newName: "thrice",
+		want: map[string]string{
+			"shapes.ng": "func thrice(f func(int) int, x int) int {\nprint(thrice(Double, big.Volume()))\n",
+		},
+	},
+	{
+		name:    "method",
+		file:    "shapes.ng",
+		at:      "Volume() int {",
+		newName: "Size",
+		want: map[string]string{
+			"shapes.ng": "	func (b) Size() int { return b.Width * b.Height * Double(1) }\nprint(twice(Double, big.Size()))\n",
+			"main.ng":   "print(area(grow(b, x)), b.Size(), shapes.Double(x))\n",
+		},
+	},
+	{
+		name:    "pointer method",
+		file:    "shapes.ng",
+		at:      "Scale(2)",
+		newName: "Grow",
+		want: map[string]string{
+			"shapes.ng": "	func (*b) Grow(n int) {\nbig.Grow(2)\n",
+		},
+	},
+	{
+		name:    "field",
+		file:    "main.ng",
+		at:      "Width += n",
+		newName: "W",
+		want: map[string]string{
+			"main.ng":   "	return b.W * b.Height\n	b.W += n\nb := shapes.Box{W: x, Height: 3}\n",
+			"shapes.ng": "	W  int\n	func (b) Volume() int { return b.W * b.Height * Double(1) }\n		b.W *= n\nbig := Box{W: Double(2), Height: 1}\n",
+		},
+	},
+	{
+		name:    "field declaration",
+		file:    "shapes.ng",
+		at:      "Height int `json",
+		newName: "H",
+		want: map[string]string{
+			"main.ng":   "	return b.Width * b.H\nb := shapes.Box{Width: x, H: 3}\n",
+			"shapes.ng": "	H int `json:\"height\"`\n	func (b) Volume() int { return b.Width * b.H * Double(1) }\n		b.H = b.H * n\nbig := Box{Width: Double(2), H: 1}\n",
+		},
+	},
+	{
+		name:    "exported function",
+		file:    "main.ng",
+		at:      "Double(x))",
+		newName: "Twice",
+		want: map[string]string{
+			"main.ng":   "print(area(grow(b, x)), b.Volume(), shapes.Twice(x))\n",
+			"shapes.ng": "func Twice(x int) int {\n	func (b) Volume() int { return b.Width * b.Height * Twice(1) }\nbig := Box{Width: Twice(2), Height: 1}\nprint(twice(Twice, big.Volume()))\n",
+		},
+	},
+	{
+		name:    "same name",
+		file:    "shapes.ng",
+		at:      "y := x",
+		newName: "y",
+		want:    map[string]string{},
+	},
+}
+
+// writeFiles writes the test program to a temporary directory.
+func writeFiles(t *testing.T) (dir string, files []string) {
+	dir, err := ioutil.TempDir("", "ngtools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range map[string]string{
+		"main.ng":   mainFile,
+		"shapes.ng": shapesFile,
+		"locals.ng": localsFile,
+	} {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, filename)
+	}
+	sort.Strings(files)
+	return dir, files
+}
+
+// posOf returns the position of the first at in text.
+func posOf(t *testing.T, text, at string) src.Pos {
+	for i, line := range strings.Split(text, "\n") {
+		if col := strings.Index(line, at); col >= 0 {
+			return src.Pos{Line: int32(i + 1), Column: int16(col + 1)}
+		}
+	}
+	t.Fatalf("no %q in the file", at)
+	return src.Pos{}
+}
+
+// changedLines returns the lines of after that are not in before.
+func changedLines(before, after string) string {
+	old := strings.Split(before, "\n")
+	var res string
+	for i, line := range strings.Split(after, "\n") {
+		if i >= len(old) || old[i] != line {
+			res += line + "\n"
+		}
+	}
+	return res
+}
+
+func TestRename(t *testing.T) {
+	dir, files := writeFiles(t)
+	defer os.RemoveAll(dir)
+
+	for _, test := range renameTests {
+		filename := filepath.Join(dir, test.file)
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos := posOf(t, string(src), test.at)
+		edits, err := Rename(filename, pos, test.newName, files)
+		if err != nil {
+			t.Errorf("%s: Rename: %v", test.name, err)
+			continue
+		}
+		for _, filename := range files {
+			name := filepath.Base(filename)
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := edit.Apply(src, edits[filename])
+			if err != nil {
+				t.Errorf("%s: %s: %v", test.name, name, err)
+				continue
+			}
+			if got := changedLines(string(src), string(res)); got != test.want[name] {
+				t.Errorf("%s: %s changed lines:\n%s\nwant:\n%s", test.name, name, got, test.want[name])
+			}
+		}
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	dir, files := writeFiles(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file    string
+		at      string
+		newName string
+		err     string
+	}{
+		{"main.ng", "print(area(grow(b, x)), b.Volume(), shapes.Double(x))", "p", "builtin print"},
+		{"shapes.ng", "Unit int", "Size", "renaming types is not supported"},
+		{"main.ng", "shapes.Double(x))", "s", "cannot rename package shapes"},
+		{"locals.ng", "x := n", "n", "conflicts with n"},
+		{"locals.ng", "x := n", "y", "conflicts with y"},
+		{"locals.ng", "x := a + 1", "a", "conflicts with a"},
+		{"locals.ng", "limit = 10", "g", "conflicts with g"},
+		{"locals.ng", "limit = 10", "print", "conflicts with print"},
+		{"locals.ng", "n int) int {", "limit", "conflicts with limit"},
+		{"locals.ng", "dir :=", "path", "used in a shell command at"},
+		{"shapes.ng", "Double(x int) int {", "twice", "conflicts with twice"},
+		{"shapes.ng", "Double(x int) int {", "double", "used in"},
+		{"shapes.ng", "Width  int", "Height", "already has a field or method Height"},
+		{"shapes.ng", "Volume() int {", "Scale", "already has a field or method Scale"},
+		{"main.ng", "b := shapes.Box{Width: x, Height: 3}", "for", "not an identifier"},
+		{"main.ng", "2", "y", "no identifier"},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(dir, test.file)
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos := posOf(t, string(src), test.at)
+		_, err = Rename(filename, pos, test.newName, files)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Rename(%s, %q, %s) error = %v, want %q", test.file, test.at, test.newName, err, test.err)
+		}
+	}
+}
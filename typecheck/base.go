@@ -29,6 +29,7 @@ var universeObjs = map[string]*Obj{
 	"true":  {Kind: ObjConst, Type: tipe.UntypedBool, Decl: constant.MakeBool(true)},
 	"false": {Kind: ObjConst, Type: tipe.UntypedBool, Decl: constant.MakeBool(false)},
 	"nil":   {Kind: ObjVar, Type: tipe.UntypedNil},
+	"iota":  {Kind: ObjConst, Type: tipe.UntypedInteger},
 	"env":   {Kind: ObjVar, Type: &tipe.Map{Key: tipe.String, Value: tipe.String}},
 	"alias": {Kind: ObjVar, Type: &tipe.Map{Key: tipe.String, Value: tipe.String}},
 	"error": {
@@ -26,6 +26,7 @@ import (
 	"neugram.io/ng/syntax"
 	"neugram.io/ng/syntax/expr"
 	"neugram.io/ng/syntax/shell"
+	"neugram.io/ng/syntax/src"
 	"neugram.io/ng/syntax/stmt"
 	"neugram.io/ng/syntax/tipe"
 	"neugram.io/ng/syntax/token"
@@ -45,6 +46,9 @@ type Checker struct {
 	importWalk    []string // in-process pkgs, used to detect cycles
 	memory        *tipe.Memory
 	resolveWalked map[*tipe.Named]bool
+	iota          constant.Value // value of iota in the current const spec, or nil
+	inFunc        bool           // checking a function body
+	locals        []localVar     // variables declared in the current function body
 
 	cur    *Scope
 	curPkg *Package
@@ -103,6 +107,37 @@ func (c *Checker) Check(path string) (*Package, error) {
 	return pkg, nil
 }
 
+// CheckFile typechecks f, a parsed Neugram file, as the package the
+// Checker was created for.
+//
+// Unlike Check, it does not stop at the first statement with an
+// error. It returns the errors of every statement, those without a
+// source position given the position of their statement. It is for
+// tools that check a file as it is edited.
+func (c *Checker) CheckFile(f *syntax.File) (*Package, []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.curPkg.Syntax = f
+	var errs []error
+	for _, s := range f.Stmts {
+		c.errs = c.errs[:0]
+		c.stmt(s, nil, nil)
+		n := len(errs)
+		for _, err := range c.errs {
+			if _, hasPos := err.(Error); !hasPos {
+				err = Error{Pos: s.Pos(), Msg: err.Error()}
+			}
+			if len(errs) > n && errs[len(errs)-1] == err {
+				continue // the same error found twice
+			}
+			errs = append(errs, err)
+		}
+	}
+	c.errs = c.errs[:0]
+	return c.curPkg, errs
+}
+
 // Errs returns any errors encountered during type checking.
 func (c *Checker) Errs() []error {
 	if len(c.errs) == 0 {
@@ -147,11 +182,15 @@ func IsError(t tipe.Type) bool {
 func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe.Type {
 	switch s := s.(type) {
 	case *stmt.ConstSet:
-		for _, v := range s.Consts {
+		for i, v := range s.Consts {
+			c.iota = constant.MakeInt64(int64(i))
 			c.checkConst(v)
 		}
+		c.iota = nil
 		return nil
 	case *stmt.Const:
+		c.iota = constant.MakeInt64(0)
+		defer func() { c.iota = nil }()
 		return c.checkConst(s)
 	case *stmt.VarSet:
 		for _, v := range s.Vars {
@@ -216,7 +255,7 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 			for i, lhs := range s.Left {
 				p := partials[i]
 				if isUntyped(p.typ) {
-					c.constrainUntyped(&p, defaultType(p.typ))
+					c.constrainUntyped(&p, tipe.Default(p.typ))
 				}
 				name := lhs.(*expr.Ident).Name
 				if name == "_" {
@@ -233,16 +272,27 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 					Decl: s,
 				}
 				c.addObj(obj)
+				c.addLocal(obj, lhs.Pos())
 				c.idents[lhs.(*expr.Ident)] = obj
 			}
 		} else {
 			for i, lhs := range s.Left {
 				p := partials[i]
-				if ident, isIdent := lhs.(*expr.Ident); isIdent && ident.Name == "_" {
+				ident, isIdent := lhs.(*expr.Ident)
+				if isIdent && ident.Name == "_" {
 					// "_" takes value of any type and drops it.
 					continue
 				}
+				// Assigning to a variable does not use it.
+				var obj *Obj
+				if isIdent {
+					obj = c.cur.LookupRec(ident.Name)
+				}
+				used := obj != nil && obj.Used
 				lhsP := c.expr(lhs)
+				if obj != nil {
+					obj.Used = used
+				}
 				c.assign(&p, lhsP.typ)
 			}
 		}
@@ -341,6 +391,7 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 					Kind: ObjVar, Type: kt,
 				}
 				c.addObj(obj)
+				c.addLocal(obj, s.Key.Pos())
 				c.idents[s.Key.(*expr.Ident)] = obj
 				c.types[s.Key] = kt
 			}
@@ -350,6 +401,7 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 					Kind: ObjVar, Type: vt,
 				}
 				c.addObj(obj)
+				c.addLocal(obj, s.Val.Pos())
 				c.idents[s.Val.(*expr.Ident)] = obj
 				c.types[s.Val] = vt
 			}
@@ -373,6 +425,16 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 		return nil
 
 	case *stmt.TypeDecl:
+		if s.Alias != nil {
+			s.Alias.Type, _ = c.resolve(s.Alias.Type)
+			c.addObj(&Obj{
+				Name: s.Name,
+				Kind: ObjType,
+				Type: s.Alias,
+				Decl: s,
+			})
+			return nil
+		}
 		c.addObj(&Obj{
 			Name: s.Name,
 			Kind: ObjType,
@@ -537,9 +599,13 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 		if p.mode == modeInvalid {
 			return nil
 		}
-		cht, ok := p.typ.(*tipe.Chan)
+		cht, ok := tipe.Underlying(p.typ).(*tipe.Chan)
 		if !ok {
-			c.errorfmt("cannot send to non-channel type: %s", cht)
+			c.errorfmt("cannot send to non-channel type: %s", p.typ)
+			return nil
+		}
+		if cht.Direction == tipe.ChanRecv {
+			c.errorfmt("%s: cannot send to receive-only channel", s)
 			return nil
 		}
 		p = c.expr(s.Value)
@@ -674,10 +740,10 @@ func (c *Checker) stmt(s stmt.Stmt, retType *tipe.Tuple, retNames []string) tipe
 				}
 				set[typ] = struct{}{}
 				if !c.typeAssert(iface, typ) {
-					// TODO: explain why it can't implement the interface.
 					c.errorfmt(
-						"impossible type switch case: %s (type %s) cannot have dynamic type %s",
+						"impossible type switch case: %s (type %s) cannot have dynamic type %s%s",
 						format.Expr(p.expr), format.Type(iface), format.Type(typ),
+						c.notImplemented(iface, typ),
 					)
 				}
 			}
@@ -753,28 +819,11 @@ func (c *Checker) checkConst(s *stmt.Const) tipe.Type {
 		if p.mode == modeInvalid {
 			return nil
 		}
-		if tuple, isTuple := p.typ.(*tipe.Tuple); isTuple {
-			if len(s.Values) > 1 {
-				c.errorfmt("multiple value %s in single-value context", rhs)
-				return nil
-			}
-			for _, t := range tuple.Elems {
-				partials = append(partials, partial{
-					mode: modeConst,
-					typ:  t,
-				})
-			}
-			continue
+		if p.mode != modeConst {
+			c.errorfmt("const initializer %s is not a constant", rhs)
+			return nil
 		}
-		partials = append(partials, c.exprNoElide(rhs))
-	}
-	if len(s.Values) == 1 && len(s.NameList) == 2 && len(s.NameList) == len(partials)+1 {
-		partials = c.checkCommaOK(s.Values[0], partials)
-	}
-
-	if len(s.Values) == 1 && len(s.NameList) == len(partials)-1 && IsError(partials[len(partials)-1].typ) {
-		markElideError(s.Values[0])
-		partials = partials[:len(partials)-1]
+		partials = append(partials, p)
 	}
 
 	if len(s.NameList) != len(partials) {
@@ -812,7 +861,7 @@ func (c *Checker) checkConst(s *stmt.Const) tipe.Type {
 			if s.Type != nil && !c.assignable(s.Type, p.typ) {
 				switch len(s.NameList) {
 				case 1:
-					c.errorfmt("cannot use %v (type %v) as type %v in assignment", format.Expr(s.Values[i]), format.Type(p.typ), format.Type(s.Type))
+					c.errorfmt("cannot use %v (type %v) as type %v in assignment%s", format.Expr(s.Values[i]), format.Type(p.typ), format.Type(s.Type), c.notImplemented(s.Type, p.typ))
 				default:
 					c.errorfmt("cannot assign %v to %s (type %v) in multiple assignment", format.Type(p.typ), name, format.Type(s.Type))
 				}
@@ -897,7 +946,7 @@ func (c *Checker) checkVar(s *stmt.Var) tipe.Type {
 				if s.Type != nil {
 					c.constrainUntyped(&p, s.Type)
 				} else {
-					c.constrainUntyped(&p, defaultType(p.typ))
+					c.constrainUntyped(&p, tipe.Default(p.typ))
 				}
 			}
 			typ = p.typ
@@ -905,7 +954,7 @@ func (c *Checker) checkVar(s *stmt.Var) tipe.Type {
 			if s.Type != nil && !c.assignable(s.Type, p.typ) {
 				switch len(s.NameList) {
 				case 1:
-					c.errorfmt("cannot use %v (type %v) as type %v in assignment", format.Expr(s.Values[i]), format.Type(p.typ), format.Type(s.Type))
+					c.errorfmt("cannot use %v (type %v) as type %v in assignment%s", format.Expr(s.Values[i]), format.Type(p.typ), format.Type(s.Type), c.notImplemented(s.Type, p.typ))
 				default:
 					c.errorfmt("cannot assign %v to %s (type %v) in multiple assignment", format.Type(p.typ), name, format.Type(s.Type))
 				}
@@ -915,12 +964,14 @@ func (c *Checker) checkVar(s *stmt.Var) tipe.Type {
 		if s.Type != nil {
 			typ = s.Type
 		}
-		c.addObj(&Obj{
+		obj := &Obj{
 			Name: name,
 			Kind: ObjVar,
 			Type: typ,
 			Decl: s,
-		})
+		}
+		c.addObj(obj)
+		c.addLocal(obj, s.Position)
 	}
 	return nil
 }
@@ -994,6 +1045,7 @@ func (c *Checker) checkCommaOK(e expr.Expr, partials []partial) []partial {
 var goErrorID = gotypes.Universe.Lookup("error").Id()
 
 func (c *Checker) fromGoType(t gotypes.Type) (res tipe.Type) {
+	t = gotypes.Unalias(t) // any is interface{}
 	if res = c.goTypes[t]; res != nil {
 		return res
 	}
@@ -1193,9 +1245,10 @@ func (c *Checker) goPkg(path string) (*Package, error) {
 			Name: goobj.Name(), // TODO: use goobj.Id()?
 			Type: c.fromGoType(goobj.Type()),
 		}
-		switch goobj.(type) {
+		switch goobj := goobj.(type) {
 		case *gotypes.Const:
 			obj.Kind = ObjConst
+			obj.Decl = goobj.Val()
 		case *gotypes.Var:
 			obj.Kind = ObjVar
 		case *gotypes.TypeName:
@@ -1317,6 +1370,10 @@ func (c *Checker) parseFile(filename string, source []byte) error {
 }
 
 func (c *Checker) checkImport(s *stmt.Import) {
+	if s.Name == "." {
+		c.errorfmt("dot imports are not supported: %q", s.Path)
+		return
+	}
 	if strings.HasPrefix(s.Path, "/") {
 		c.errorfmt("imports do not support absolute paths: %q", s.Path)
 		return
@@ -1409,6 +1466,11 @@ func (c *Checker) resolve(t tipe.Type) (ret tipe.Type, resolved bool) {
 		var r1, r2 bool
 		t.Key, r1 = c.resolve(t.Key)
 		t.Value, r2 = c.resolve(t.Value)
+		_, unresolved := tipe.Underlying(t.Key).(*tipe.Unresolved)
+		if r1 && !unresolved && !tipe.IsComparable(t.Key) {
+			c.errorfmt("invalid map key type %s", format.Type(t.Key))
+			return t, false
+		}
 		return t, r1 && r2
 	case *tipe.Named:
 		if c.resolveWalked[t] {
@@ -1730,7 +1792,7 @@ func (c *Checker) exprBuiltinCall(e *expr.Call) partial {
 		p.typ = tipe.Int
 		if len(e.Args) != 1 {
 			p.mode = modeInvalid
-			c.errorfmt("%s takes exactly 1 argument, got %d", p.typ, len(e.Args))
+			c.errorfmt("len takes exactly 1 argument, got %d", len(e.Args))
 			return p
 		}
 		arg0 := c.expr(e.Args[0])
@@ -1750,12 +1812,12 @@ func (c *Checker) exprBuiltinCall(e *expr.Call) partial {
 		p.typ = tipe.Int
 		if len(e.Args) != 1 {
 			p.mode = modeInvalid
-			c.errorfmt("%s takes exactly 1 argument, got %d", p.typ, len(e.Args))
+			c.errorfmt("cap takes exactly 1 argument, got %d", len(e.Args))
 			return p
 		}
 		arg0 := c.expr(e.Args[0])
 		switch tipe.Underlying(arg0.typ).(type) {
-		case *tipe.Array, *tipe.Slice, *tipe.Map, *tipe.Chan:
+		case *tipe.Array, *tipe.Slice, *tipe.Chan:
 			return p
 		}
 		p.mode = modeInvalid
@@ -1787,13 +1849,19 @@ func (c *Checker) exprBuiltinCall(e *expr.Call) partial {
 		}
 
 		arg0 := c.exprType(e.Args[0])
-		if arg0 != nil {
-			switch t := arg0.(type) {
-			case *tipe.Slice, *tipe.Map, *tipe.Chan:
-				p.typ = t
+		p.typ = arg0
+		switch tipe.Underlying(arg0).(type) {
+		case *tipe.Slice:
+			if len(e.Args) == 1 {
+				p.mode = modeInvalid
+				c.errorfmt("missing len argument to make(%s)", arg0)
 			}
-		}
-		if p.typ == nil {
+		case *tipe.Map, *tipe.Chan:
+			if len(e.Args) == 3 {
+				p.mode = modeInvalid
+				c.errorfmt("too many arguments to make(%s)", arg0)
+			}
+		default:
 			p.mode = modeInvalid
 			c.errorfmt("make argument must be a slice, map, or channel")
 		}
@@ -1896,9 +1964,14 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 		return c.exprBuiltinCall(e)
 	}
 
+	funct, ok := tipe.Underlying(p.typ).(*tipe.Func)
+	if !ok {
+		c.errorfmt("cannot call non-function %s (type %s)", e.Func, p.typ)
+		p.mode = modeInvalid
+		return p
+	}
 	p.mode = modeVar
 	p.expr = e
-	funct := tipe.Underlying(p.typ).(*tipe.Func)
 	var params, results []tipe.Type
 	if funct.Params != nil {
 		params = funct.Params.Elems
@@ -1938,6 +2011,17 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 			c.errorfmt("cannot use ... with multi-valued function %s", funct)
 			return p
 		}
+		// The spread argument must be the variadic parameter.
+		if len(unpacked) > len(params) {
+			p.mode = modeInvalid
+			c.errorArgCount(e, funct, unpacked, "too many")
+			return p
+		}
+		if len(unpacked) < len(params) {
+			p.mode = modeInvalid
+			c.errorArgCount(e, funct, unpacked, "too few")
+			return p
+		}
 	}
 
 	// Type-check each argument against the called function
@@ -1958,7 +2042,7 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 				continue
 			}
 			p.mode = modeInvalid
-			c.errorfmt("too many arguments to function %s", funct)
+			c.errorArgCount(e, funct, unpacked, "too many")
 			return p
 		}
 
@@ -1974,7 +2058,11 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 			// We're using "x..." and are at the position of the
 			// variadic parameter. To allow for typechecking,
 			// change the Ellipsis type to a Slice type.
-			typ = &tipe.Slice{Elem: typ.(*tipe.Ellipsis).Elem}
+			// The variadic parameter of a Go function is
+			// already a slice.
+			if t, isEllipsis := typ.(*tipe.Ellipsis); isEllipsis {
+				typ = &tipe.Slice{Elem: t.Elem}
+			}
 		} else if !e.Ellipsis && funct.Variadic && i >= len(params)-1 {
 			// We're not using "x...", but we are at (or beyond)
 			// the position of the variadic parameter. That means that
@@ -1982,8 +2070,8 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 			// arguments we should typecheck against the underlying
 			// element type rather than the ellipsis type.
 
-			// TODO there seems to be some cases where we get a slice here
-			// rather than an ellipsis. It's not clear why that is.
+			// The variadic parameter of a Go function is a slice
+			// rather than an ellipsis.
 			switch t := typ.(type) {
 			case *tipe.Ellipsis:
 				typ = t.Elem
@@ -2004,11 +2092,18 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 		}
 
 		// Typecheck the argument against the declared type of the
-		// matching function parameter.
-		c.convert(&pi, typ)
-		if pi.mode == modeInvalid {
+		// matching function parameter. Untyped constants are
+		// converted, everything else must be assignable.
+		if isUntyped(pi.typ) || tipe.UsesNum(typ) {
+			c.convert(&pi, typ)
+			if pi.mode == modeInvalid {
+				p.mode = modeInvalid
+				c.errorfmt("cannot use type %s as type %s in argument %d to function", pi.typ, typ, i)
+				return p
+			}
+		} else if !c.assignable(typ, pi.typ) {
 			p.mode = modeInvalid
-			c.errorfmt("cannot use type %s as type %s in argument %d to function", pi.typ, typ, i)
+			c.errorfmt("cannot use %s (type %s) as type %s in argument to %s%s", pi.expr, pi.typ, typ, e.Func, c.notImplemented(typ, pi.typ))
 			return p
 		}
 	}
@@ -2022,13 +2117,27 @@ func (c *Checker) exprPartialCall(e *expr.Call) partial {
 	}
 	if numArgs < len(params) {
 		p.mode = modeInvalid
-		c.errorfmt("too few arguments in call to %s", funct)
+		c.errorArgCount(e, funct, unpacked, "too few")
 		return p
 	}
 
 	return p
 }
 
+// errorArgCount reports a call with too many or too few arguments,
+// naming the function and showing the argument types next to the
+// expected signature.
+func (c *Checker) errorArgCount(e *expr.Call, funct *tipe.Func, args []partial, problem string) {
+	have := make([]string, len(args))
+	for i, arg := range args {
+		have[i] = format.Type(arg.typ)
+	}
+	if e.Ellipsis && len(have) > 0 {
+		have[len(have)-1] += "..."
+	}
+	c.errorfmt("%s arguments in call to %s: have (%s), want %s", problem, e.Func, strings.Join(have, ", "), format.Type(funct))
+}
+
 func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 	defer func() {
 		if p.mode == modeConst {
@@ -2053,9 +2162,22 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 		obj := c.cur.LookupRec(e.Name)
 		if obj == nil {
 			p.mode = modeInvalid
-			c.errorfmt("undeclared identifier: %s", e.Name)
+			c.errorAt(e.Position, "undefined: %s", e.Name)
 			return p
 		}
+		if obj == Universe.Objs["iota"] {
+			if c.iota == nil {
+				p.mode = modeInvalid
+				c.errorfmt("cannot use iota outside constant declaration")
+				return p
+			}
+			p.mode = modeConst
+			p.typ = obj.Type
+			p.val = c.iota
+			c.idents[e] = obj
+			return p
+		}
+		obj.Used = true
 		// TODO: is a partial's mode just an ObjKind?
 		// not every partial has an Obj, but we could reuse the type.
 		switch obj.Kind {
@@ -2078,23 +2200,24 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 		case *big.Int:
 			p.mode = modeConst
 			p.typ = tipe.UntypedInteger
-			p.val = constant.MakeFromLiteral(v.String(), gotoken.INT, 0)
+			p.val = constant.Make(new(big.Int).Set(v))
 		case *big.Float:
 			p.mode = modeConst
 			p.typ = tipe.UntypedFloat
-			p.val = constant.MakeFromLiteral(v.String(), gotoken.FLOAT, 0)
+			p.val = floatConst(v)
 		case *bigcplx.Complex:
 			p.mode = modeConst
 			p.typ = tipe.UntypedComplex
-			p.val = constant.MakeFromLiteral(v.String(), gotoken.IMAG, 0)
+			im := constant.MakeImag(floatConst(v.Imag))
+			p.val = constant.BinaryOp(floatConst(v.Real), gotoken.ADD, im)
 		case string:
 			p.mode = modeConst
 			p.typ = tipe.UntypedString
-			p.val = constant.MakeFromLiteral(v, gotoken.STRING, 0)
+			p.val = constant.MakeString(v)
 		case rune:
 			p.mode = modeConst
 			p.typ = tipe.UntypedRune
-			p.val = constant.MakeFromLiteral(string(v), gotoken.CHAR, 0)
+			p.val = constant.MakeInt64(int64(v))
 		case bool:
 			p.mode = modeConst
 			p.typ = tipe.UntypedBool
@@ -2142,7 +2265,19 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 				}
 			}
 		}
-		c.stmt(e.Body.(*stmt.Block), e.Type.Results, retNames)
+		// Parameters are declared in the outermost scope of
+		// the function body, so the body cannot redeclare them.
+		inFunc, locals := c.inFunc, c.locals
+		c.inFunc, c.locals = true, nil
+		for _, s := range e.Body.(*stmt.Block).Stmts {
+			c.stmt(s, e.Type.Results, retNames)
+		}
+		for _, l := range c.locals {
+			if !l.obj.Used {
+				c.errorAt(l.pos, "%s declared and not used", l.obj.Name)
+			}
+		}
+		c.inFunc, c.locals = inFunc, locals
 		for _, pname := range e.ParamNames {
 			delete(c.cur.foundInParent, pname)
 		}
@@ -2332,7 +2467,7 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 			p.typ = t
 			return p
 		}
-		c.errorfmt("%s does not implement %s", t, leftTyp)
+		c.errorfmt("%s does not implement %s%s", t, leftTyp, c.notImplemented(leftTyp, t))
 		p.mode = modeInvalid
 		return p
 
@@ -2343,8 +2478,30 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 			p.mode = sub.mode
 			p.typ = sub.typ
 			p.val = sub.val
-			if e.Op == token.Sub && sub.val != nil {
-				p.val = constant.UnaryOp(gotoken.SUB, sub.val, 0)
+			if sub.mode == modeInvalid {
+				return p
+			}
+			switch e.Op {
+			case token.Not:
+				if !isBoolean(sub.typ) {
+					c.errorfmt("invalid operation: %s (operator ! not defined on %s)", e, sub.typ)
+					p.mode = modeInvalid
+					return p
+				}
+			case token.Sub, token.Add:
+				if !tipe.IsNumeric(sub.typ) {
+					c.errorfmt("invalid operation: %s (operator %s not defined on %s)", e, e.Op, sub.typ)
+					p.mode = modeInvalid
+					return p
+				}
+			}
+			if sub.mode == modeConst {
+				switch e.Op {
+				case token.Not:
+					p.val = constant.UnaryOp(gotoken.NOT, sub.val, 0)
+				case token.Sub:
+					p.val = constant.UnaryOp(gotoken.SUB, sub.val, 0)
+				}
 			}
 			return p
 		case token.Ref:
@@ -2374,12 +2531,17 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 				p.mode = modeInvalid
 				return p
 			}
-			t, ok := sub.typ.(*tipe.Chan)
+			t, ok := tipe.Underlying(sub.typ).(*tipe.Chan)
 			if !ok {
 				c.errorfmt("receive from non-chan type %s", sub.typ)
 				p.mode = modeInvalid
 				return p
 			}
+			if t.Direction == tipe.ChanSend {
+				c.errorfmt("%s: cannot receive from send-only channel", e)
+				p.mode = modeInvalid
+				return p
+			}
 			p.mode = modeVar
 			p.typ = t.Elem
 			return p
@@ -2398,8 +2560,15 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 		case token.TwoGreater, token.TwoLess:
 			// constraints are handled later
 		default:
-			c.constrainUntyped(&left, right.typ)
-			c.constrainUntyped(&right, left.typ)
+			// Constrain the lower ranked operand first, so that
+			// mixed untyped constants like 1.5 + 1 are promoted.
+			if untypedRank(left.typ) > untypedRank(right.typ) {
+				c.constrainUntyped(&right, left.typ)
+				c.constrainUntyped(&left, right.typ)
+			} else {
+				c.constrainUntyped(&left, right.typ)
+				c.constrainUntyped(&right, left.typ)
+			}
 		}
 		left.expr = e
 
@@ -2414,7 +2583,7 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 			}
 			switch e.Op {
 			case token.Equal, token.NotEqual:
-				if !isComparable(lt) {
+				if !tipe.IsComparable(lt) {
 					if canBeNil(lt) || canBeNil(rt) {
 						if ltOrig != tipe.UntypedNil && rtOrig != tipe.UntypedNil {
 							c.errorfmt("type %s only comparable to nil", lt)
@@ -2434,34 +2603,97 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 					return left
 				}
 			}
+			if left.mode == modeConst && right.mode == modeConst {
+				left.val = constant.MakeBool(constant.Compare(left.val, convGoOp(e.Op), right.val))
+				left.typ = tipe.UntypedBool
+				return left
+			}
+			left.mode = modeVar
+			left.val = nil
 			left.typ = tipe.Bool
 			return left
 		}
 
-		// TODO check for division by zero
+		switch e.Op {
+		case token.TwoLess, token.TwoGreater:
+			// checked below
+		default:
+			if !tipe.Equal(left.typ, right.typ) {
+				c.errorfmt("inoperable types %s and %s", left.typ, right.typ)
+				left.mode = modeInvalid
+				return left
+			}
+			if !opDefined(e.Op, left.typ) {
+				// The evaluator calls a method for an operator
+				// not defined on the type, as in m1 + m2 on a
+				// type with the method Add(m T) T.
+				if name := opMethods[e.Op]; name != "" {
+					if m := c.memory.Method(left.typ, name); m != nil {
+						if m.Params == nil || len(m.Params.Elems) != 1 || !c.assignable(m.Params.Elems[0], right.typ) ||
+							m.Results == nil || len(m.Results.Elems) != 1 {
+							c.errorfmt("invalid operation: %s (operator %s expects method %s(%s) T but type has %s)", e, e.Op, name, right.typ, m)
+							left.mode = modeInvalid
+							return left
+						}
+						left.mode = modeVar
+						left.val = nil
+						left.typ = m.Results.Elems[0]
+						return left
+					}
+				}
+				c.errorfmt("invalid operation: %s (operator %s not defined on %s)", e, e.Op, left.typ)
+				left.mode = modeInvalid
+				return left
+			}
+			if (e.Op == token.Div || e.Op == token.Rem) && right.mode == modeConst && constant.Sign(right.val) == 0 {
+				c.errorfmt("invalid operation: division by zero")
+				left.mode = modeInvalid
+				return left
+			}
+		}
+
 		if left.mode == modeConst && right.mode == modeConst {
 			switch e.Op {
-			case token.TwoLess, token.Greater:
+			case token.TwoLess, token.TwoGreater:
 				rhs, ok := big.NewInt(0).SetString(right.val.ExactString(), 0)
 				if !ok {
 					c.errorfmt("constant %s is not an integer", right.val.ExactString())
 					left.mode = modeInvalid
 					return left
 				}
-				left.val = constant.Shift(left.val, convGoOp(e.Op), uint(rhs.Uint64()))
+				lhs := constant.ToInt(left.val)
+				if lhs.Kind() != constant.Int {
+					c.errorfmt("invalid operation: %s (shift of type %s)", e, ltOrig)
+					left.mode = modeInvalid
+					return left
+				}
+				left.val = constant.Shift(lhs, convGoOp(e.Op), uint(rhs.Uint64()))
+			case token.Div:
+				op := gotoken.QUO
+				if isInteger(left.typ) {
+					op = gotoken.QUO_ASSIGN // integer division
+				}
+				left.val = constant.BinaryOp(left.val, op, right.val)
 			default:
 				left.val = constant.BinaryOp(left.val, convGoOp(e.Op), right.val)
 			}
-			// TODO check rounding
-			// TODO check for comparison, result is untyped bool
+			if basic, isBasic := tipe.Underlying(left.typ).(tipe.Basic); isBasic && isTyped(basic) {
+				if round(left.val, basic) == nil {
+					c.errorfmt("constant %s overflows %s", left.val, left.typ)
+					left.mode = modeInvalid
+					return left
+				}
+			}
 			return left
 		}
+		left.mode = modeVar
+		left.val = nil
 
 		switch e.Op {
 		case token.TwoLess, token.TwoGreater:
 			c.constrainUntyped(&left, right.typ)
 			// right operand must be an unsigned integer
-			switch typ := rtOrig.(type) {
+			switch typ := tipe.Underlying(rtOrig).(type) {
 			case tipe.Basic:
 				switch typ {
 				case tipe.Uint, tipe.Uint8, tipe.Uint16, tipe.Uint32, tipe.Uint64:
@@ -2481,20 +2713,20 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 
 				default:
 					c.errorfmt("invalid operation: %s (shift count type %v, must be unsigned integer)",
-						format.Expr(e), format.Type(typ),
+						format.Expr(e), format.Type(rtOrig),
 					)
 					right.mode = modeInvalid
 					return right
 				}
 			default:
 				c.errorfmt("invalid operation: %s (shift count type %v, must be unsigned integer)",
-					format.Expr(e), format.Type(typ),
+					format.Expr(e), format.Type(rtOrig),
 				)
 				right.mode = modeInvalid
 				return right
 			}
 			// left operand must be an integer
-			switch typ := ltOrig.(type) {
+			switch typ := tipe.Underlying(ltOrig).(type) {
 			case tipe.Basic:
 				switch typ {
 				case tipe.UntypedInteger,
@@ -2503,24 +2735,18 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 					// ok
 				default:
 					c.errorfmt("invalid operation: %s (shift of type %v)",
-						format.Expr(e), format.Type(typ),
+						format.Expr(e), format.Type(ltOrig),
 					)
 					left.mode = modeInvalid
 					return left
 				}
 			default:
 				c.errorfmt("invalid operation: %s (shift of type %v)",
-					format.Expr(e), format.Type(typ),
+					format.Expr(e), format.Type(ltOrig),
 				)
 				left.mode = modeInvalid
 				return left
 			}
-		default:
-			if !tipe.Equal(left.typ, right.typ) {
-				c.errorfmt("inoperable types %s and %s", left.typ, right.typ)
-				left.mode = modeInvalid
-				return left
-			}
 		}
 		return left
 	case *expr.Call:
@@ -2610,9 +2836,14 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 					if lt.GoPkg != nil {
 						s := lt.GoPkg.(*gotypes.Package).Scope()
 						obj := s.Lookup(name)
-						if _, isAType := obj.(*gotypes.TypeName); isAType {
+						switch obj := obj.(type) {
+						case *gotypes.TypeName:
 							p.mode = modeTypeExpr
 							return p
+						case *gotypes.Const:
+							p.mode = modeConst
+							p.val = obj.Val()
+							return p
 						}
 					}
 					p.mode = modeVar // TODO modeFunc?
@@ -2720,9 +2951,7 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 			}
 			return p
 		case *tipe.Table:
-			p.mode = modeInvalid
-			c.errorfmt("TODO table slicing support")
-			return p
+			return c.exprTableIndex(e, lt, left.typ)
 		default:
 			p.mode = modeInvalid
 			c.errorfmt("TODO index %T", lt)
@@ -2779,8 +3008,72 @@ func (c *Checker) exprPartial(e expr.Expr, hint typeHint) (p partial) {
 	panic(fmt.Sprintf("expr TODO: %s", format.Debug(e)))
 }
 
+// exprTableIndex checks the table index t[col, row]. Each index is
+// either a single integer or a range of integers; a single column may
+// also be named by a string. Two single indices select an element, one
+// range selects a slice of elements, and two ranges select a table.
+func (c *Checker) exprTableIndex(e *expr.Index, t *tipe.Table, typ tipe.Type) (p partial) {
+	p.expr = e
+	if len(e.Indicies) != 2 {
+		p.mode = modeInvalid
+		c.errorfmt("invalid table index %s (table %s needs a column and a row index)", e, typ)
+		return p
+	}
+	ranges := 0
+	for i, ind := range e.Indicies {
+		if s, isSlice := ind.(*expr.Slice); isSlice {
+			ranges++
+			if s.Max != nil {
+				p.mode = modeInvalid
+				c.errorfmt("invalid table index %s (3-index slice of table)", e)
+				return p
+			}
+			for _, bound := range []expr.Expr{s.Low, s.High} {
+				if bound == nil {
+					continue
+				}
+				if bp := c.tableIndex(bound, false); bp.mode == modeInvalid {
+					return bp
+				}
+			}
+			continue
+		}
+		if ip := c.tableIndex(ind, i == 0); ip.mode == modeInvalid {
+			return ip
+		}
+	}
+	p.mode = modeVar
+	switch ranges {
+	case 0:
+		p.typ = t.Type
+	case 1:
+		p.typ = &tipe.Slice{Elem: t.Type}
+	default:
+		p.typ = typ
+	}
+	return p
+}
+
+// tableIndex checks a single table index or range bound, which must be
+// an integer, or if isCol is set may be a column name.
+func (c *Checker) tableIndex(e expr.Expr, isCol bool) partial {
+	p := c.expr(e)
+	if p.mode == modeInvalid {
+		return p
+	}
+	switch {
+	case isInteger(p.typ):
+		c.assign(&p, tipe.Int)
+	case isCol && isString(p.typ):
+		c.assign(&p, tipe.String)
+	default:
+		p.mode = modeInvalid
+		c.errorfmt("invalid table index %s (type %s, must be integer)", e, p.typ)
+	}
+	return p
+}
+
 func (c *Checker) checkStructLiteral(e *expr.CompLiteral, t *tipe.Struct, p partial) partial {
-	structName := fmt.Sprintf("%s", e.Type)
 	elemsp := make([]partial, len(e.Values))
 	for i, elem := range e.Values {
 		elemsp[i] = c.expr(elem)
@@ -2794,7 +3087,7 @@ func (c *Checker) checkStructLiteral(e *expr.CompLiteral, t *tipe.Struct, p part
 			return p
 		}
 		if len(e.Values) != len(t.Fields) {
-			c.errorfmt("wrong number of elements, %d, when %s expects %d", len(e.Values), structName, len(t.Fields))
+			c.errorfmt("wrong number of elements, %d, when %s expects %d", len(e.Values), e.Type, len(t.Fields))
 			p.mode = modeInvalid
 			return p
 		}
@@ -2806,28 +3099,40 @@ func (c *Checker) checkStructLiteral(e *expr.CompLiteral, t *tipe.Struct, p part
 			}
 		}
 	} else {
-		namedp := make(map[string]partial)
-		for i, elemp := range elemsp {
+		// The keys of a struct literal are field names, which
+		// the parser cannot tell from map keys.
+		seen := make(map[string]bool)
+		for i := range elemsp {
 			ident, ok := e.Keys[i].(*expr.Ident)
 			if !ok {
 				c.errorfmt("invalid field name %s in struct initializer", e.Keys[i])
 				p.mode = modeInvalid
 				return p
 			}
-			namedp[ident.Name] = elemp
-		}
-		for _, sf := range t.Fields {
-			elemp, found := namedp[sf.Name]
-			if !found {
-				continue
+			var sf *tipe.StructField
+			for j := range t.Fields {
+				if t.Fields[j].Name == ident.Name {
+					sf = &t.Fields[j]
+					break
+				}
 			}
-			c.assign(&elemp, sf.Type)
-			if elemp.mode == modeInvalid {
+			if sf == nil {
+				c.errorAt(ident.Position, "unknown field %s in struct literal of type %s", ident.Name, e.Type)
+				p.mode = modeInvalid
+				return p
+			}
+			if seen[ident.Name] {
+				c.errorAt(ident.Position, "duplicate field name %s in struct literal", ident.Name)
+				p.mode = modeInvalid
+				return p
+			}
+			seen[ident.Name] = true
+			c.assign(&elemsp[i], sf.Type)
+			if elemsp[i].mode == modeInvalid {
 				p.mode = modeInvalid
 				return p
 			}
 		}
-		//panic("TODO: named CompLiteral")
 	}
 	if p.mode != modeInvalid {
 		p.expr = e
@@ -2990,7 +3295,9 @@ func (c *Checker) shell(cmd expr.Expr) {
 			c.errorfmt("%v", err)
 		}
 		for _, name := range params {
-			c.cur.LookupRec(name) // foundInParent
+			if obj := c.cur.LookupRec(name); obj != nil { // foundInParent
+				obj.Used = true
+			}
 		}
 	}
 }
@@ -3065,15 +3372,13 @@ func (c *Checker) assign(p *partial, t tipe.Type) {
 		return
 	}
 	if !tipe.Equal(p.typ, t) {
-		switch iface := tipe.Underlying(t).(type) {
-		case *tipe.Interface:
+		if iface, isIface := tipe.Underlying(t).(*tipe.Interface); isIface {
 			// make sure p.typ implements all methods of iface.
 			if c.typeAssert(iface, p.typ) {
 				return
 			}
-			// TODO: explain why p.typ does not implement t
 		}
-		c.errorfmt("cannot assign %s to %s", p.typ, t)
+		c.errorfmt("cannot assign %s to %s%s", p.typ, t, c.notImplemented(t, p.typ))
 		p.mode = modeInvalid
 	}
 }
@@ -3144,9 +3449,15 @@ func (c *Checker) assignable(dst, src tipe.Type) bool {
 		return true
 	}
 
+	// a named type can be assigned to its unnamed underlying type,
+	// and vice versa
+	if (!isNamed(dst) || !isNamed(src)) && tipe.Equal(tipe.Underlying(dst), tipe.Underlying(src)) {
+		return true
+	}
+
 	// bidirectional channels can be assigned to directional channels
-	if srcCh, ok := src.(*tipe.Chan); ok && srcCh.Direction == tipe.ChanBoth {
-		if dstCh, ok := dst.(*tipe.Chan); ok {
+	if srcCh, ok := tipe.Underlying(src).(*tipe.Chan); ok && srcCh.Direction == tipe.ChanBoth {
+		if dstCh, ok := tipe.Underlying(dst).(*tipe.Chan); ok && (!isNamed(dst) || !isNamed(src)) {
 			return tipe.Equal(srcCh.Elem, dstCh.Elem)
 		}
 	}
@@ -3154,6 +3465,16 @@ func (c *Checker) assignable(dst, src tipe.Type) bool {
 	return false
 }
 
+// isNamed reports whether t is a named type, either declared or
+// predeclared, as opposed to a type literal such as []int.
+func isNamed(t tipe.Type) bool {
+	switch t.(type) {
+	case *tipe.Named, tipe.Basic:
+		return true
+	}
+	return false
+}
+
 func isString(t tipe.Type) bool {
 	t = tipe.Underlying(t)
 	return t == tipe.String || t == tipe.UntypedString
@@ -3195,10 +3516,12 @@ func (c *Checker) constrainUntyped(p *partial, t tipe.Type) {
 	// catch invalid constraints
 	if isUntyped(t) {
 		switch {
-		case t == tipe.UntypedFloat && p.typ == tipe.UntypedInteger:
-			// promote untyped int to float
-		case t == tipe.UntypedComplex && (p.typ == tipe.UntypedInteger || p.typ == tipe.UntypedFloat):
-			// promote untyped int or float to complex
+		case t == tipe.UntypedRune && p.typ == tipe.UntypedInteger:
+			// promote untyped int to rune
+		case t == tipe.UntypedFloat && (p.typ == tipe.UntypedInteger || p.typ == tipe.UntypedRune):
+			// promote untyped int or rune to float
+		case t == tipe.UntypedComplex && (p.typ == tipe.UntypedInteger || p.typ == tipe.UntypedRune || p.typ == tipe.UntypedFloat):
+			// promote untyped int, rune or float to complex
 		case t == tipe.Num && (p.typ == tipe.UntypedInteger || p.typ == tipe.UntypedFloat):
 			// promote untyped int or float to num type parameter
 		case t != p.typ:
@@ -3209,10 +3532,14 @@ func (c *Checker) constrainUntyped(p *partial, t tipe.Type) {
 		case tipe.Basic:
 			switch p.mode {
 			case modeConst:
-				p.val = round(p.val, t)
+				val := p.val
+				p.val = round(val, t)
 				if p.val == nil {
-					c.errorfmt("cannot convert const %s to %s", p.typ, t)
-					// TODO more details about why
+					if val.Kind() == constant.Int && isInteger(t) {
+						c.errorfmt("constant %s overflows %s", val, t)
+					} else {
+						c.errorfmt("cannot convert const %s to %s", p.typ, t)
+					}
 				}
 			case modeVar:
 				panic(fmt.Sprintf("TODO coerce var to basic: t=%s, p.typ=%s", t, format.Type(p.typ)))
@@ -3261,26 +3588,54 @@ func (c *Checker) constrainExprType(e expr.Expr, t tipe.Type) {
 // type under iface can be a t, just that as far as we know statically
 // it might be.)
 func (c *Checker) typeAssert(iface *tipe.Interface, t tipe.Type) bool {
-	if len(iface.Methods) == 0 {
-		return true // interface{} might be anything
+	name, _ := c.missingMethod(iface, t)
+	return name == ""
+}
+
+// missingMethod returns the name of a method of iface that t does
+// not have, or has with a different signature, in which case wrongType
+// is true. It returns "" if t implements iface.
+func (c *Checker) missingMethod(iface *tipe.Interface, t tipe.Type) (name string, wrongType bool) {
+	names := make([]string, 0, len(iface.Methods))
+	for name := range iface.Methods {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	if tiface, tIsIface := tipe.Underlying(t).(*tipe.Interface); tIsIface {
-		for name, method := range iface.Methods {
-			if !tipe.Equal(tiface.Methods[name], method) {
-				return false
+	tiface, tIsIface := tipe.Underlying(t).(*tipe.Interface)
+	for _, name := range names {
+		var mt tipe.Type
+		if tIsIface {
+			if m := tiface.Methods[name]; m != nil {
+				mt = m
 			}
+		} else {
+			mt = findMember(t, name)
 		}
-	} else {
-		for name, method := range iface.Methods {
-			mt := findMember(t, name)
-			if !tipe.Equal(method, mt) {
-				return false
-			}
+		if !tipe.Equal(iface.Methods[name], mt) {
+			return name, mt != nil
 		}
 	}
+	return "", false
+}
 
-	return true
+// notImplemented explains why a value of type t cannot be used as
+// the interface type dst, in a form suitable for appending to an
+// error message. It returns "" if dst is not an interface.
+func (c *Checker) notImplemented(dst, t tipe.Type) string {
+	iface, isIface := tipe.Underlying(dst).(*tipe.Interface)
+	if !isIface {
+		return ""
+	}
+	name, wrongType := c.missingMethod(iface, t)
+	switch {
+	case name == "":
+		return ""
+	case wrongType:
+		return fmt.Sprintf(" (wrong type for %s method)", name)
+	default:
+		return fmt.Sprintf(" (missing %s method)", name)
+	}
 }
 
 // findMember finds the field or method with name in type t.
@@ -3319,6 +3674,19 @@ func findMember(t tipe.Type, name string) (mt tipe.Type) {
 }
 
 func (c *Checker) errorfmt(formatstr string, args ...interface{}) {
+	err := fmt.Errorf(formatstr, formatArgs(args)...)
+	c.errs = append(c.errs, err)
+}
+
+// errorAt is like errorfmt, for an error at a known source position.
+func (c *Checker) errorAt(pos src.Pos, formatstr string, args ...interface{}) {
+	err := Error{Pos: pos, Msg: fmt.Sprintf(formatstr, formatArgs(args)...)}
+	c.errs = append(c.errs, err)
+}
+
+// formatArgs replaces statements, types and expressions in args
+// with their printed form.
+func formatArgs(args []interface{}) []interface{} {
 	for i, arg := range args {
 		switch v := arg.(type) {
 		case stmt.Stmt:
@@ -3332,9 +3700,23 @@ func (c *Checker) errorfmt(formatstr string, args ...interface{}) {
 			args[i] = format.Expr(v)
 		}
 	}
+	return args
+}
 
-	err := fmt.Errorf(formatstr, args...)
-	c.errs = append(c.errs, err)
+// Error is a type checking error at a source position.
+type Error struct {
+	Pos src.Pos
+	Msg string
+}
+
+func (e Error) Error() string {
+	switch {
+	case e.Pos.Line == 0:
+		return e.Msg
+	case e.Pos.Filename == "":
+		return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
 }
 
 func (c *Checker) pushScope() {
@@ -3354,6 +3736,20 @@ func (c *Checker) Type(e expr.Expr) (t tipe.Type) {
 	return t
 }
 
+// floatConst returns the exact constant value of a float literal.
+func floatConst(f *big.Float) constant.Value {
+	r, _ := f.Rat(nil)
+	return constant.Make(r)
+}
+
+// Value reports the constant value of e, or nil if e is not constant.
+func (c *Checker) Value(e expr.Expr) constant.Value {
+	c.mu.Lock()
+	v := c.consts[e]
+	c.mu.Unlock()
+	return v
+}
+
 // Ident reports the object an identifier refers to.
 func (c *Checker) Ident(e *expr.Ident) *Obj {
 	c.mu.Lock()
@@ -3404,12 +3800,17 @@ func convGoOp(op token.Token) gotoken.Token {
 	case token.Mul:
 		return gotoken.MUL
 	case token.Div:
-		return gotoken.QUO // TODO: QUO_ASSIGN for int div
+		return gotoken.QUO
 	case token.Rem:
 		return gotoken.REM
 	case token.Pow:
-		panic("TODO token.Pow")
-		return gotoken.REM
+		return gotoken.XOR
+	case token.Ref:
+		return gotoken.AND
+	case token.Pipe:
+		return gotoken.OR
+	case token.RefPow:
+		return gotoken.AND_NOT
 	case token.LogicalAnd:
 		return gotoken.LAND
 	case token.LogicalOr:
@@ -3418,6 +3819,18 @@ func convGoOp(op token.Token) gotoken.Token {
 		return gotoken.SHL
 	case token.TwoGreater:
 		return gotoken.SHR
+	case token.Equal:
+		return gotoken.EQL
+	case token.NotEqual:
+		return gotoken.NEQ
+	case token.Less:
+		return gotoken.LSS
+	case token.LessEqual:
+		return gotoken.LEQ
+	case token.Greater:
+		return gotoken.GTR
+	case token.GreaterEqual:
+		return gotoken.GEQ
 	default:
 		panic(fmt.Sprintf("typecheck: bad op: %s", op))
 	}
@@ -3600,6 +4013,22 @@ func (c *Checker) Lookup(name string) *Obj {
 	return c.cur.LookupRec(name)
 }
 
+// localVar is a variable declared in a function body.
+// Like Go, such a variable must be used.
+type localVar struct {
+	obj *Obj
+	pos src.Pos
+}
+
+// addLocal records obj, declared at pos, as a local variable if
+// a function body is being checked. Package-level variables and
+// function parameters are not recorded, as they need not be used.
+func (c *Checker) addLocal(obj *Obj, pos src.Pos) {
+	if c.inFunc && obj.Name != "_" {
+		c.locals = append(c.locals, localVar{obj: obj, pos: pos})
+	}
+}
+
 func (c *Checker) addObj(obj *Obj) {
 	c.cur.Objs[obj.Name] = obj
 
@@ -3733,23 +4162,70 @@ func isUntyped(t tipe.Type) bool {
 	return false
 }
 
-func isComparable(t tipe.Type) bool {
-	switch t := tipe.Underlying(t).(type) {
-	case tipe.Basic:
-		return t != tipe.Invalid && t != tipe.UntypedNil
-	case *tipe.Chan, *tipe.Interface, *tipe.Pointer:
-		return true
-	case *tipe.Struct:
-		for _, sf := range t.Fields {
-			if !isComparable(sf.Type) {
-				return false
-			}
-		}
+// untypedRank orders the untyped numeric types by the direction of
+// promotion: integer, rune, float, complex. Other types rank zero.
+func untypedRank(t tipe.Type) int {
+	switch t {
+	case tipe.UntypedInteger:
+		return 1
+	case tipe.UntypedRune:
+		return 2
+	case tipe.UntypedFloat:
+		return 3
+	case tipe.UntypedComplex:
+		return 4
+	}
+	return 0
+}
+
+func isInteger(t tipe.Type) bool {
+	switch tipe.Underlying(t) {
+	case tipe.Num, tipe.Byte, tipe.Rune, tipe.Integer,
+		tipe.Int, tipe.Int8, tipe.Int16, tipe.Int32, tipe.Int64,
+		tipe.Uint, tipe.Uint8, tipe.Uint16, tipe.Uint32, tipe.Uint64,
+		tipe.UntypedInteger, tipe.UntypedRune:
 		return true
 	default:
 		return false
 	}
-	// TODO Array, Table
+}
+
+func isBoolean(t tipe.Type) bool {
+	t = tipe.Underlying(t)
+	return t == tipe.Bool || t == tipe.UntypedBool
+}
+
+// opMethods are the methods the evaluator calls to compute a binary
+// operator on a type it is not defined on.
+var opMethods = map[token.Token]string{
+	token.Add:    "Add",
+	token.Sub:    "Sub",
+	token.Mul:    "Mul",
+	token.Div:    "Div",
+	token.Rem:    "Rem",
+	token.Pow:    "Xor",
+	token.Ref:    "And",
+	token.Pipe:   "Or",
+	token.RefPow: "AndNot",
+}
+
+// opDefined reports whether the binary operator op, other than a
+// comparison or shift, is defined on operands of type t.
+//
+// As in Go, ^ is bitwise exclusive or, not exponentiation, so it
+// is only defined on integers.
+func opDefined(op token.Token, t tipe.Type) bool {
+	switch op {
+	case token.Add:
+		return tipe.IsNumeric(t) || isString(t)
+	case token.Sub, token.Mul, token.Div:
+		return tipe.IsNumeric(t)
+	case token.Rem, token.Pow, token.Ref, token.Pipe, token.RefPow:
+		return isInteger(t)
+	case token.LogicalAnd, token.LogicalOr:
+		return isBoolean(t)
+	}
+	return true
 }
 
 func isOrdered(t tipe.Type) bool {
@@ -3759,7 +4235,7 @@ func isOrdered(t tipe.Type) bool {
 		tipe.Uint, tipe.Uint8, tipe.Uint16, tipe.Uint32, tipe.Uint64,
 		tipe.Float32, tipe.Float64,
 		tipe.Complex64, tipe.Complex128,
-		tipe.UntypedInteger, tipe.UntypedFloat, tipe.UntypedComplex, tipe.UntypedString:
+		tipe.UntypedInteger, tipe.UntypedRune, tipe.UntypedFloat, tipe.UntypedComplex, tipe.UntypedString:
 		return true
 	default:
 		return false
@@ -3776,28 +4252,6 @@ func canBeNil(t tipe.Type) bool {
 	}
 }
 
-func defaultType(t tipe.Type) tipe.Type {
-	b, ok := t.(tipe.Basic)
-	if !ok {
-		return t
-	}
-	switch b {
-	case tipe.UntypedBool:
-		return tipe.Bool
-	case tipe.UntypedString:
-		return tipe.String
-	case tipe.UntypedInteger:
-		return tipe.Int // tipe.Num
-	case tipe.UntypedFloat:
-		return tipe.Float64 // tipe.Num
-	case tipe.UntypedComplex:
-		return tipe.Complex128 // tipe.Num
-	case tipe.UntypedRune:
-		return tipe.Rune
-	}
-	return t
-}
-
 // markElideError marks the expression to dynamically
 // elide errors at runtime. If the expression type does not
 // support eliding errors, it does nothing.
@@ -5,12 +5,19 @@
 package typecheck
 
 import (
+	"fmt"
+	"go/constant"
+	"math/big"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"neugram.io/ng/format"
 	"neugram.io/ng/parser"
+	"neugram.io/ng/syntax/expr"
 	"neugram.io/ng/syntax/stmt"
 	"neugram.io/ng/syntax/tipe"
+	"neugram.io/ng/syntax/token"
 )
 
 type identType struct {
@@ -100,6 +107,24 @@ var typeTests = []typeTest{
 		},
 		[]identType{{"a", &tipe.Table{tipe.Int64}}},
 	},
+	{
+		[]string{
+			`a := [|]int64{{|"Col1","Col2"|}, {1, 2}, {3, 4}}`,
+			`i := 1`,
+			`x := a[0, i]`,
+			`y := a["Col2", 1]`,
+			`col := a[0, :]`,
+			`row := a[:, 1]`,
+			`sub := a[1:, 0:i]`,
+		},
+		[]identType{
+			{"x", tipe.Int64},
+			{"y", tipe.Int64},
+			{"col", &tipe.Slice{Elem: tipe.Int64}},
+			{"row", &tipe.Slice{Elem: tipe.Int64}},
+			{"sub", &tipe.Table{Type: tipe.Int64}},
+		},
+	},
 	{
 		[]string{
 			`methodik A struct{ X int64 } {
@@ -225,3 +250,444 @@ func TestBasic(t *testing.T) {
 		}
 	}
 }
+
+var typeErrTests = []struct {
+	stmts []string
+	err   string
+}{
+	{
+		[]string{`x := 1`, `y := x + z`},
+		"1:10: undefined: z",
+	},
+	{
+		[]string{`f := func() { print(undef) }`},
+		"undefined: undef",
+	},
+	{
+		[]string{`a := [|]int64{{1, 2}}`, `x := a[0]`},
+		"invalid table index a[0] (table [|]int64 needs a column and a row index)",
+	},
+	{
+		[]string{`a := [|]int64{{1, 2}}`, `x := a[0, 1.5]`},
+		"invalid table index 1.5 (type untyped float, must be integer)",
+	},
+	{
+		[]string{`a := [|]int64{{1, 2}}`, `x := a[0, "Col1"]`},
+		`invalid table index "Col1" (type untyped string, must be integer)`,
+	},
+	{
+		[]string{`a := [|]int64{{1, 2}}`, `x := a["a":, 0]`},
+		`invalid table index "a" (type untyped string, must be integer)`,
+	},
+	{
+		[]string{`a := [|]int64{{1, 2}}`, `x := a[0:1:2, 0]`},
+		"invalid table index a[0:1:2, 0] (3-index slice of table)",
+	},
+	{
+		[]string{`const c int8 = 1 << 7`},
+		"constant 128 overflows int8",
+	},
+	{
+		[]string{`x := 1`, `const c = x + 1`},
+		"const initializer x + 1 is not a constant",
+	},
+	{
+		[]string{`x := 1 % (3 - 3)`},
+		"invalid operation: division by zero",
+	},
+}
+
+var constTests = []struct {
+	stmt string
+	want string // exact value of c
+}{
+	{`const c = 1 + 2*3`, "7"},
+	{`const c = 7 / 2`, "3"},
+	{`const c = 7.0 / 2`, "7/2"},
+	{`const c = -5 % 3`, "-2"},
+	{`const c = 1 << 70 >> 68`, "4"},
+	{`const c = 6 &^ 3`, "4"},
+	{`const c = 6 ^ 3`, "5"},
+	{`const c = 'a' + 1`, "98"},
+	{`const c = "a" + "b"`, `"ab"`},
+	{`const c = 1 < 2 && !(2 <= 1)`, "true"},
+	{`const c = "a" == "b"`, "false"},
+	{`const c int8 = -(1 << 7)`, "-128"},
+}
+
+func TestConstants(t *testing.T) {
+	for _, test := range constTests {
+		s, err := parser.ParseStmt([]byte(test.stmt))
+		if err != nil {
+			t.Fatalf("parser.ParseStmt(%q): %v", test.stmt, err)
+		}
+		c := New("")
+		c.Add(s)
+		if errs := c.Errs(); len(errs) > 0 {
+			t.Errorf("%q: %v", test.stmt, errs[0])
+			continue
+		}
+		v, ok := c.cur.Objs["c"].Decl.(constant.Value)
+		if !ok {
+			t.Errorf("%q: c has no constant value", test.stmt)
+			continue
+		}
+		if got := v.ExactString(); got != test.want {
+			t.Errorf("%q: c = %s, want %s", test.stmt, got, test.want)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	for _, test := range typeErrTests {
+		c := New("")
+		var err error
+		for _, str := range test.stmts {
+			s, perr := parser.ParseStmt([]byte(str))
+			if perr != nil {
+				t.Fatalf("parser.ParseStmt(%q): %v", str, perr)
+			}
+			c.Add(s)
+			if errs := c.Errs(); len(errs) > 0 {
+				err = errs[0]
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%q: no error, want %q", test.stmts, test.err)
+		} else if got := err.Error(); !strings.Contains(got, test.err) {
+			t.Errorf("%q: error %q, want %q", test.stmts, got, test.err)
+		}
+	}
+}
+
+var (
+	namedInts  = &tipe.Named{Name: "Ints", Type: &tipe.Slice{Elem: tipe.Int}}
+	namedInts2 = &tipe.Named{Name: "Ints2", Type: &tipe.Slice{Elem: tipe.Int}}
+	celsius    = &tipe.Named{Name: "Celsius", Type: tipe.Float64}
+	stringer   = &tipe.Interface{Methods: map[string]*tipe.Func{
+		"String": {Params: &tipe.Tuple{}, Results: &tipe.Tuple{Elems: []tipe.Type{tipe.String}}},
+	}}
+	namedStringer = &tipe.Named{
+		Name:        "S",
+		Type:        &tipe.Struct{},
+		MethodNames: []string{"String"},
+		Methods:     []*tipe.Func{stringer.Methods["String"]},
+	}
+)
+
+var assignableTests = []struct {
+	dst, src                tipe.Type
+	assignable, convertible bool
+}{
+	{tipe.Int, tipe.Int, true, true},
+	{tipe.Int, tipe.Int64, false, true},
+	{tipe.Byte, tipe.Uint8, true, true},
+	{tipe.String, tipe.UntypedString, true, true},
+	{tipe.Bool, tipe.UntypedBool, true, true},
+	{&tipe.Slice{Elem: tipe.Int}, tipe.UntypedNil, true, true},
+	{tipe.Int, tipe.UntypedNil, false, false},
+	{namedInts, &tipe.Slice{Elem: tipe.Int}, true, true},
+	{&tipe.Slice{Elem: tipe.Int}, namedInts, true, true},
+	{namedInts, namedInts2, false, true},
+	{celsius, tipe.Float64, false, true},
+	{tipe.Float64, celsius, false, true},
+	{&tipe.Slice{Elem: tipe.Int}, &tipe.Slice{Elem: tipe.Int64}, false, false},
+	{&tipe.Slice{Elem: tipe.Uint8}, tipe.String, false, true},
+	{tipe.String, &tipe.Slice{Elem: tipe.Uint8}, false, true},
+	{&tipe.Interface{}, tipe.Int, true, true},
+	{stringer, namedStringer, true, true},
+	{stringer, tipe.Int, false, false},
+	{&tipe.Chan{Direction: tipe.ChanRecv, Elem: tipe.Int}, &tipe.Chan{Elem: tipe.Int}, true, true},
+	{&tipe.Chan{Elem: tipe.Int}, &tipe.Chan{Direction: tipe.ChanRecv, Elem: tipe.Int}, false, false},
+}
+
+func TestAssignable(t *testing.T) {
+	c := New("")
+	for _, test := range assignableTests {
+		dst, src := format.Type(test.dst), format.Type(test.src)
+		if got := c.assignable(test.dst, test.src); got != test.assignable {
+			t.Errorf("assignable(%s, %s) = %v, want %v", dst, src, got, test.assignable)
+		}
+		if got := c.convertible(test.dst, test.src); got != test.convertible {
+			t.Errorf("convertible(%s, %s) = %v, want %v", dst, src, got, test.convertible)
+		}
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	src := `x := 1
+y := x + "a"
+z := undefined
+w := x
+`
+	f, err := parser.New("f.ng").Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("")
+	pkg, errs := c.CheckFile(f)
+	if len(errs) != 2 {
+		t.Fatalf("CheckFile: %d errors, want 2: %v", len(errs), errs)
+	}
+	for i, line := range []int32{2, 3} {
+		if pos := errs[i].(Error).Pos; pos.Line != line {
+			t.Errorf("error %d: %v at line %d, want line %d", i, errs[i], pos.Line, line)
+		}
+	}
+	if obj := pkg.GlobalNames["w"]; obj == nil || obj.Type != tipe.Int {
+		t.Errorf("w is %v, want an int after the errors", obj)
+	}
+}
+
+// exprGen generates random expression trees over a set of
+// variables, for property testing the type checker.
+type exprGen struct {
+	r    *rand.Rand
+	vars []genVar
+}
+
+type genVar struct {
+	name string
+	typ  tipe.Type
+}
+
+// genTypes are the types of the generated variables, in turn.
+// A bool is made by a comparison when there is no bool variable.
+var genTypes = []tipe.Type{tipe.Int, tipe.Float64, tipe.String, tipe.Bool}
+
+func newExprGen(seed int64, names []string) *exprGen {
+	g := &exprGen{r: rand.New(rand.NewSource(seed))}
+	for i, name := range names {
+		g.vars = append(g.vars, genVar{name: name, typ: genTypes[i%len(genTypes)]})
+	}
+	return g
+}
+
+// decls returns the statements declaring the variables.
+func (g *exprGen) decls() []string {
+	var res []string
+	for _, v := range g.vars {
+		res = append(res, fmt.Sprintf("%s := %s(%s)", v.name, format.Type(v.typ), format.Expr(g.literal(v.typ))))
+	}
+	return res
+}
+
+func (g *exprGen) variable(typ tipe.Type) expr.Expr {
+	var names []string
+	for _, v := range g.vars {
+		if v.typ == typ {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		if typ == tipe.Bool {
+			return &expr.Binary{Op: token.Less, Left: g.variable(tipe.Int), Right: g.variable(tipe.Int)}
+		}
+		panic(fmt.Sprintf("exprGen: no variable of type %s", format.Type(typ)))
+	}
+	return &expr.Ident{Name: names[g.r.Intn(len(names))]}
+}
+
+func (g *exprGen) literal(typ tipe.Type) expr.Expr {
+	switch typ {
+	case tipe.Int:
+		return &expr.BasicLiteral{Value: big.NewInt(g.r.Int63n(9) + 1)}
+	case tipe.Float64:
+		return &expr.BasicLiteral{Value: big.NewFloat(float64(g.r.Intn(8)+1) / 4)}
+	case tipe.String:
+		return &expr.BasicLiteral{Value: []string{"", "s", "日本"}[g.r.Intn(3)]}
+	default:
+		return &expr.Ident{Name: []string{"true", "false"}[g.r.Intn(2)]}
+	}
+}
+
+// expr returns an expression of type typ. If constOK is false, the
+// expression is not constant, so it cannot overflow or be a constant
+// division by zero. Only leaves are constant.
+func (g *exprGen) expr(depth int, typ tipe.Type, constOK bool) expr.Expr {
+	if depth <= 0 || g.r.Intn(4) == 0 {
+		if constOK && g.r.Intn(3) == 0 {
+			return g.literal(typ)
+		}
+		return g.variable(typ)
+	}
+	switch typ {
+	case tipe.Int, tipe.Float64:
+		switch g.r.Intn(6) {
+		case 0:
+			return &expr.Unary{Op: token.Sub, Expr: g.expr(depth-1, typ, false)}
+		case 1:
+			if typ == tipe.Int {
+				return &expr.Call{Func: &expr.Ident{Name: "len"}, Args: []expr.Expr{g.expr(depth-1, tipe.String, false)}}
+			}
+			return &expr.Call{Func: &expr.Ident{Name: "float64"}, Args: []expr.Expr{g.expr(depth-1, tipe.Int, false)}}
+		}
+		ops := []token.Token{token.Add, token.Sub, token.Mul, token.Div}
+		if typ == tipe.Int {
+			ops = append(ops, token.Rem)
+		}
+		op := ops[g.r.Intn(len(ops))]
+		return &expr.Binary{
+			Op:    op,
+			Left:  g.expr(depth-1, typ, false),
+			Right: g.expr(depth-1, typ, op != token.Div && op != token.Rem),
+		}
+	case tipe.String:
+		return &expr.Binary{Op: token.Add, Left: g.expr(depth-1, typ, false), Right: g.expr(depth-1, typ, true)}
+	default:
+		switch g.r.Intn(3) {
+		case 0:
+			return &expr.Unary{Op: token.Not, Expr: g.expr(depth-1, tipe.Bool, false)}
+		case 1:
+			op := []token.Token{token.LogicalAnd, token.LogicalOr}[g.r.Intn(2)]
+			return &expr.Binary{Op: op, Left: g.expr(depth-1, tipe.Bool, false), Right: g.expr(depth-1, tipe.Bool, true)}
+		}
+		operand := genTypes[g.r.Intn(len(genTypes))]
+		ops := []token.Token{token.Equal, token.NotEqual}
+		if operand != tipe.Bool {
+			ops = append(ops, token.Less, token.LessEqual, token.Greater, token.GreaterEqual)
+		}
+		return &expr.Binary{
+			Op:    ops[g.r.Intn(len(ops))],
+			Left:  g.expr(depth-1, operand, false),
+			Right: g.expr(depth-1, operand, true),
+		}
+	}
+}
+
+// badExpr returns an expression that would be of type typ, but for
+// one type error somewhere in it.
+func (g *exprGen) badExpr(depth int, typ tipe.Type) expr.Expr {
+	if depth > 0 && g.r.Intn(3) > 0 {
+		// A good expression around a bad one.
+		switch typ {
+		case tipe.Bool:
+			return &expr.Unary{Op: token.Not, Expr: g.badExpr(depth-1, typ)}
+		default:
+			return &expr.Binary{Op: token.Add, Left: g.badExpr(depth-1, typ), Right: g.expr(depth-1, typ, true)}
+		}
+	}
+	other := genTypes[g.r.Intn(len(genTypes))]
+	for other == typ {
+		other = genTypes[g.r.Intn(len(genTypes))]
+	}
+	switch g.r.Intn(4) {
+	case 0:
+		return &expr.Ident{Name: "undefinedVar"}
+	case 1:
+		// An operand of the wrong type.
+		if typ == tipe.Bool {
+			return &expr.Unary{Op: token.Not, Expr: g.expr(depth, other, false)}
+		}
+		return &expr.Unary{Op: token.Sub, Expr: g.expr(depth, tipe.String, false)}
+	case 2:
+		return &expr.Call{Func: &expr.Ident{Name: "len"}, Args: []expr.Expr{g.expr(depth, tipe.Int, false)}}
+	default:
+		// Mismatched operands.
+		return &expr.Binary{Op: token.Equal, Left: g.expr(depth, typ, false), Right: g.expr(depth, other, false)}
+	}
+}
+
+// checkExpr type checks e after the declarations of g's variables.
+func (g *exprGen) checkExpr(t *testing.T, e expr.Expr) (c *Checker, errs []error) {
+	c = New("")
+	defer func() {
+		if x := recover(); x != nil {
+			t.Fatalf("%s: type checker panic: %v", format.Expr(e), x)
+		}
+	}()
+	for _, src := range g.decls() {
+		s, err := parser.ParseStmt([]byte(src))
+		if err != nil {
+			t.Fatalf("parser.ParseStmt(%q): %v", src, err)
+		}
+		c.Add(s)
+		if errs := c.Errs(); len(errs) > 0 {
+			t.Fatalf("%s: %v", src, errs[0])
+		}
+	}
+	c.Add(&stmt.Simple{Expr: &expr.Call{Func: &expr.Ident{Name: "print"}, Args: []expr.Expr{e}}})
+	return c, c.Errs()
+}
+
+// checkTypes reports an expression in e without a type, or with a
+// type inconsistent with its operator.
+func checkTypes(t *testing.T, c *Checker, top, e expr.Expr) tipe.Type {
+	typ := c.types[e]
+	if typ == nil {
+		t.Errorf("%s: %s has no type", format.Expr(top), format.Expr(e))
+		return nil
+	}
+	is := func(want ...tipe.Type) {
+		for _, w := range want {
+			if tipe.Equal(typ, w) {
+				return
+			}
+		}
+		t.Errorf("%s: %s has type %s, want %s", format.Expr(top), format.Expr(e), format.Type(typ), format.Type(want[0]))
+	}
+	switch e := e.(type) {
+	case *expr.Binary:
+		left := checkTypes(t, c, top, e.Left)
+		checkTypes(t, c, top, e.Right)
+		switch e.Op {
+		case token.Equal, token.NotEqual, token.Less, token.LessEqual, token.Greater, token.GreaterEqual,
+			token.LogicalAnd, token.LogicalOr:
+			is(tipe.Bool, tipe.UntypedBool)
+		default:
+			is(left)
+		}
+	case *expr.Unary:
+		x := checkTypes(t, c, top, e.Expr)
+		if e.Op == token.Not {
+			is(tipe.Bool, tipe.UntypedBool)
+		} else {
+			is(x)
+		}
+	case *expr.Call:
+		for _, arg := range e.Args {
+			checkTypes(t, c, top, arg)
+		}
+		switch e.Func.(*expr.Ident).Name {
+		case "len":
+			is(tipe.Int)
+		case "float64":
+			is(tipe.Float64)
+		}
+	}
+	return typ
+}
+
+// The random expressions are at most exprGenDepth deep, over
+// variables an int a, a float64 b and a string c.
+var (
+	exprGenDepth = 5
+	exprGenNames = []string{"a", "b", "c"}
+)
+
+func TestExprRandom(t *testing.T) {
+	g := newExprGen(1, exprGenNames)
+	for i := 0; i < 1000; i++ {
+		typ := genTypes[g.r.Intn(len(genTypes))]
+		e := g.expr(exprGenDepth, typ, false)
+		c, errs := g.checkExpr(t, e)
+		if len(errs) > 0 {
+			t.Errorf("%s: %v", format.Expr(e), errs[0])
+			continue
+		}
+		if got := checkTypes(t, c, e, e); got != nil && !tipe.Equal(got, typ) {
+			t.Errorf("%s: type %s, want %s", format.Expr(e), format.Type(got), format.Type(typ))
+		}
+	}
+}
+
+func TestBadExprRandom(t *testing.T) {
+	g := newExprGen(1, exprGenNames)
+	for i := 0; i < 1000; i++ {
+		e := g.badExpr(exprGenDepth, genTypes[g.r.Intn(len(genTypes))])
+		if _, errs := g.checkExpr(t, e); len(errs) == 0 {
+			t.Errorf("%s: no type error", format.Expr(e))
+		}
+	}
+}